@@ -2,22 +2,25 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
+	"github.com/task-underground/backend/internal/domain"
 	"github.com/task-underground/backend/internal/handler"
 	"github.com/task-underground/backend/internal/middleware"
 	"github.com/task-underground/backend/internal/repository"
 	"github.com/task-underground/backend/internal/service"
+	"github.com/task-underground/backend/internal/storage"
 	"github.com/task-underground/backend/internal/websocket"
-	"golang.org/x/time/rate"
 )
 
 func main() {
@@ -43,18 +46,108 @@ func main() {
 	claimRepo := repository.NewClaimRepository(db)
 	chatRepo := repository.NewChatRepository(db)
 	escrowRepo := repository.NewEscrowRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	deviceRepo := repository.NewDeviceRepository(db)
+	reputationRepo := repository.NewReputationRepository(db)
+	reviewRepo := repository.NewReviewRepository(db)
+	blockRepo := repository.NewBlockRepository(db)
+	moderationRepo := repository.NewModerationRepository(db)
+	adminRepo := repository.NewAdminRepository(db)
+	sybilRepo := repository.NewSybilRepository(db)
+	fraudRepo := repository.NewFraudRepository(db)
+	privacyRepo := repository.NewPrivacyRepository(db)
+	keyRepo := repository.NewKeyRepository(db)
 
 	// Services
-	userSvc := service.NewUserService(userRepo)
+	secret := serverSecret()
+	powDifficulty, _ := strconv.Atoi(os.Getenv("SYBIL_POW_DIFFICULTY"))
+	accountsPerIP, _ := strconv.Atoi(os.Getenv("SYBIL_ACCOUNTS_PER_IP"))
+	probationHours, _ := strconv.Atoi(os.Getenv("SYBIL_PROBATION_HOURS"))
+	probationMaxClaims, _ := strconv.Atoi(os.Getenv("SYBIL_PROBATION_MAX_CLAIMS"))
+	probationMaxReward, _ := strconv.ParseFloat(os.Getenv("SYBIL_PROBATION_MAX_REWARD"), 64)
+	sybilSvc := service.NewSybilService(sybilRepo, userRepo, claimRepo, service.SybilConfig{
+		Secret:             secret,
+		PowDifficulty:      powDifficulty,
+		AccountsPerIP:      accountsPerIP,
+		ProbationPeriod:    time.Duration(probationHours) * time.Hour,
+		ProbationMaxClaims: probationMaxClaims,
+		ProbationMaxReward: probationMaxReward,
+	})
+	aliasSvc := service.NewAliasService(userRepo, taskRepo, claimRepo, secret)
+	userSvc := service.NewUserService(userRepo, taskRepo, reviewRepo, aliasSvc)
 	escrowSvc := service.NewEscrowService(escrowRepo, taskRepo)
-	taskSvc := service.NewTaskService(taskRepo, claimRepo, escrowSvc)
-	chatSvc := service.NewChatService(chatRepo)
-	claimSvc := service.NewClaimService(claimRepo, taskRepo, chatRepo, escrowSvc, userRepo)
+	trustSvc := service.NewTrustService(userRepo)
+	reputationSvc := service.NewReputationService(reputationRepo)
+	fraudFlagScore, _ := strconv.Atoi(os.Getenv("FRAUD_FLAG_SCORE"))
+	fraudRepeatedPairs, _ := strconv.Atoi(os.Getenv("FRAUD_REPEATED_PAIRS"))
+	fraudSvc := service.NewFraudService(fraudRepo, service.FraudConfig{
+		Secret:        secret,
+		RepeatedPairs: fraudRepeatedPairs,
+		FlagScore:     fraudFlagScore,
+	})
+	taskSvc := service.NewTaskService(taskRepo, claimRepo, escrowSvc, blockRepo, service.TaskGates{trustSvc, fraudSvc})
+	claimSvc := service.NewClaimService(claimRepo, taskRepo, chatRepo, escrowSvc, userRepo, reputationSvc, blockRepo, service.ClaimGates{sybilSvc, trustSvc, fraudSvc}, fraudSvc)
+	reviewSvc := service.NewReviewService(reviewRepo, claimRepo, taskRepo)
+	blockSvc := service.NewBlockService(blockRepo, taskRepo, aliasSvc)
+	arbitrationSvc := service.NewArbitrationService(claimRepo, taskRepo, blockRepo, trustSvc, claimSvc)
 
 	// WebSocket Hub
-	wsHub := websocket.NewHub()
+	wsHub := websocket.NewHub(blockSvc)
 	go wsHub.Run()
 
+	// Sessions, whose revocation also closes their WebSocket connections
+	authSvc := service.NewAuthService(userRepo, deviceRepo, sessionRepo, service.AuthConfig{
+		Secret:      secret,
+		Gate:        sybilSvc,
+		Connections: wsHub,
+	})
+	deviceSvc := service.NewDeviceService(deviceRepo, userRepo, authSvc)
+
+	// Attachment files
+	attachmentDir := os.Getenv("ATTACHMENT_DIR")
+	if attachmentDir == "" {
+		attachmentDir = "./data/attachments"
+	}
+	blobs, err := storage.NewLocalBlobStore(attachmentDir)
+	if err != nil {
+		log.Fatalf("Failed to open attachment storage: %v", err)
+	}
+	attachmentMaxBytes, _ := strconv.ParseInt(os.Getenv("ATTACHMENT_MAX_BYTES"), 10, 64)
+	if attachmentMaxBytes <= 0 {
+		attachmentMaxBytes = 10 << 20
+	}
+	attachmentsPerMessage, _ := strconv.Atoi(os.Getenv("ATTACHMENTS_PER_MESSAGE"))
+	if attachmentsPerMessage <= 0 {
+		attachmentsPerMessage = 4
+	}
+
+	messageEditMinutes, _ := strconv.Atoi(os.Getenv("MESSAGE_EDIT_WINDOW_MINUTES"))
+	messageRetentionDays, _ := strconv.Atoi(os.Getenv("MESSAGE_RETENTION_DAYS"))
+	chatSvc := service.NewChatService(chatRepo, taskRepo, claimRepo, blockRepo, keyRepo, aliasSvc, wsHub, service.ChatConfig{
+		EditWindow:        time.Duration(messageEditMinutes) * time.Minute,
+		Blobs:             blobs,
+		MaxAttachmentSize: attachmentMaxBytes,
+		MaxAttachments:    attachmentsPerMessage,
+		Retention:         time.Duration(messageRetentionDays) * 24 * time.Hour,
+	})
+	keySvc := service.NewKeyService(keyRepo, chatRepo, aliasSvc)
+
+	// Moderation
+	suspensionSvc := service.NewSuspensionService(userRepo, authSvc, taskSvc, claimSvc, wsHub)
+	hideThreshold, _ := strconv.Atoi(os.Getenv("REPORT_HIDE_THRESHOLD"))
+	moderationSvc := service.NewModerationService(moderationRepo, taskRepo, claimRepo, chatRepo, userRepo, taskSvc, suspensionSvc, aliasSvc, service.ModerationConfig{
+		HideThreshold: hideThreshold,
+	})
+
+	// Privacy
+	privacySvc := service.NewPrivacyService(privacyRepo, userRepo, deviceRepo, authSvc, claimSvc, wsHub, blobs)
+
+	// Admin
+	adminSvc := service.NewAdminService(adminRepo, moderationRepo, taskSvc, claimSvc, escrowSvc)
+	if err := adminSvc.Bootstrap(context.Background(), os.Getenv("ADMIN_API_KEY")); err != nil {
+		log.Fatalf("Failed to set up bootstrap admin: %v", err)
+	}
+
 	// Background job for auto-cancelling expired tasks
 	go func() {
 		ticker := time.NewTicker(1 * time.Minute)
@@ -63,17 +156,75 @@ func main() {
 			if err := taskSvc.AutoCancelExpiredTasks(context.Background()); err != nil {
 				log.Printf("Error auto-cancelling tasks: %v", err)
 			}
+			if err := claimSvc.ExpireAbandonedClaims(context.Background()); err != nil {
+				log.Printf("Error expiring abandoned claims: %v", err)
+			}
+		}
+	}()
+
+	// Background job for rebuilding reputation so decay keeps scores current
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+		for {
+			if n, err := reputationSvc.RecomputeAll(context.Background()); err != nil {
+				log.Printf("Error recomputing reputation: %v", err)
+			} else {
+				log.Printf("Recomputed reputation for %d users", n)
+			}
+			<-ticker.C
+		}
+	}()
+
+	// Background job for deleting disappeared messages, those past retention and chats both sides deleted
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			result, err := chatSvc.PurgeMessages(context.Background())
+			if err != nil {
+				log.Printf("Error purging messages: %v", err)
+			}
+			if result.Chats > 0 || result.Expired > 0 || result.Retention > 0 {
+				log.Printf("Purged %d deleted chats, %d expired messages and %d past retention, with %d attachment files", result.Chats, result.Expired, result.Retention, result.Attachments)
+			}
+		}
+	}()
+
+	// Rate limit store; postgres shares the counters between instances
+	var rateLimitStore middleware.RateLimitStore
+	if os.Getenv("RATE_LIMIT_STORE") == "postgres" {
+		rateLimitStore = middleware.NewPostgresRateLimitStore(repository.NewRateLimitRepository(db))
+	} else {
+		rateLimitStore = middleware.NewMemoryRateLimitStore()
+	}
+	rateLimiter := middleware.NewRateLimiter(rateLimitStore, middleware.DefaultIPRateLimit, middleware.DefaultRouteRateLimits)
+
+	// Background job for forgetting idle rate limit keys
+	go func() {
+		ticker := time.NewTicker(1 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := rateLimitStore.Sweep(context.Background(), 10*time.Minute); err != nil {
+				log.Printf("Error sweeping rate limits: %v", err)
+			}
 		}
 	}()
 
 	// Router
 	r := gin.Default()
 
+	// Only the proxies in TRUSTED_PROXIES may set the client address, which
+	// the rate limits and fraud checks key on
+	if err := r.SetTrustedProxies(middleware.TrustedProxies(os.Getenv("TRUSTED_PROXIES"))); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	// CORS middleware
 	r.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Device-ID, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
 
 		if c.Request.Method == "OPTIONS" {
@@ -84,16 +235,11 @@ func main() {
 		c.Next()
 	})
 
-	// Rate limiting
-	limiter := rate.NewLimiter(rate.Every(time.Second), 10)
-	r.Use(func(c *gin.Context) {
-		if !limiter.Allow() {
-			c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
-			c.Abort()
-			return
-		}
-		c.Next()
-	})
+	// Client address and user agent for the fraud checks
+	r.Use(middleware.ClientInfo())
+
+	// Rate limiting, per client address here and per user on the API routes
+	r.Use(rateLimiter.ByIP())
 
 	// Health check
 	r.GET("/health", func(c *gin.Context) {
@@ -101,17 +247,63 @@ func main() {
 	})
 
 	// WebSocket
-	wsHandler := websocket.NewWSHandler(wsHub, userSvc)
-	r.GET("/ws", middleware.AuthMiddleware(userSvc), wsHandler.HandleWebSocket)
+	wsMaxMessageSize, _ := strconv.ParseInt(os.Getenv("WS_MAX_MESSAGE_SIZE"), 10, 64)
+	wsHandler := websocket.NewWSHandler(wsHub, userSvc, chatSvc, aliasSvc, rateLimiter, websocket.WSConfig{
+		MaxMessageSize: wsMaxMessageSize,
+	})
+	r.GET("/ws", middleware.WebSocketAuthMiddleware(authSvc), wsHandler.HandleWebSocket)
+
+	// Auth routes (unauthenticated)
+	authHandler := handler.NewAuthHandler(authSvc, sybilSvc)
+	authRoutes := r.Group("/api/v1/auth")
+	authRoutes.POST("/handshake", authHandler.Handshake)
+	authRoutes.POST("/refresh", authHandler.Refresh)
+	authRoutes.POST("/recover", authHandler.Recover)
+	authRoutes.POST("/pair", authHandler.Pair)
 
 	// API routes
 	api := r.Group("/api/v1")
-	api.Use(middleware.AuthMiddleware(userSvc))
+	api.Use(middleware.AuthMiddleware(authSvc), rateLimiter.ByUser())
+
+	api.POST("/auth/logout", authHandler.Logout)
+
+	// User routes
+	userHandler := handler.NewUserHandler(userSvc)
+	api.GET("/me", userHandler.GetMe)
+	api.GET("/tasks/:tid/participants/:alias", userHandler.GetParticipantProfile)
+
+	// Data export and account erasure
+	privacyHandler := handler.NewPrivacyHandler(privacySvc)
+	api.GET("/me/export", privacyHandler.Export)
+	api.DELETE("/me", privacyHandler.Erase)
+
+	// Block routes
+	blockHandler := handler.NewBlockHandler(blockSvc)
+	api.POST("/tasks/:tid/participants/:alias/block", blockHandler.Block)
+	api.GET("/me/blocks", blockHandler.ListBlocks)
+	api.DELETE("/me/blocks/:id", blockHandler.Unblock)
+
+	// Device and recovery routes
+	deviceHandler := handler.NewDeviceHandler(deviceSvc)
+	api.GET("/me/devices", deviceHandler.ListDevices)
+	api.DELETE("/me/devices/:id", deviceHandler.RevokeDevice)
+	api.POST("/me/devices/pairing", deviceHandler.CreatePairingCode)
+	api.POST("/me/recovery", deviceHandler.SetupRecovery)
+	api.DELETE("/me/recovery", deviceHandler.DisableRecovery)
+
+	// Key directory routes for end-to-end encrypted chat
+	keyHandler := handler.NewKeyHandler(keySvc)
+	api.GET("/chats/:id/keys/mine", keyHandler.GetKeyStatus)
+	api.PUT("/chats/:id/keys/mine", keyHandler.PublishKeys)
+	api.POST("/chats/:id/keys", keyHandler.TakeChatKeys)
 
 	// Handlers
-	taskHandler := handler.NewTaskHandler(taskSvc)
-	claimHandler := handler.NewClaimHandler(claimSvc)
-	chatHandler := handler.NewChatHandler(chatSvc, taskSvc, claimSvc)
+	taskHandler := handler.NewTaskHandler(taskSvc, aliasSvc)
+	claimHandler := handler.NewClaimHandler(claimSvc, aliasSvc)
+	chatHandler := handler.NewChatHandler(chatSvc, taskSvc, claimSvc, aliasSvc)
+	reviewHandler := handler.NewReviewHandler(reviewSvc)
+	moderationHandler := handler.NewModerationHandler(moderationSvc)
+	arbitrationHandler := handler.NewArbitrationHandler(arbitrationSvc, aliasSvc)
 
 	// Task routes
 	api.POST("/tasks", taskHandler.CreateTask)
@@ -123,19 +315,73 @@ func main() {
 	api.GET("/tasks/:tid/claims", claimHandler.GetClaimsByTask)
 	api.GET("/claims/:id", claimHandler.GetClaim)
 	api.POST("/claims/:id/submit", claimHandler.SubmitCompletion)
+	api.POST("/claims/:id/accept", claimHandler.AcceptApplication)
 	api.POST("/claims/:id/approve", claimHandler.ApproveClaim)
 	api.POST("/claims/:id/reject", claimHandler.RejectClaim)
+	api.POST("/claims/:id/withdraw", claimHandler.WithdrawClaim)
+	api.POST("/claims/:id/dispute", claimHandler.DisputeClaim)
+
+	// Arbitration routes for users whose trust tier carries the duty
+	api.GET("/disputes", arbitrationHandler.ListDisputes)
+	api.POST("/disputes/:id/resolve", arbitrationHandler.ResolveDispute)
+
+	// Review routes
+	api.GET("/claims/:id/reviews", reviewHandler.GetReviews)
+	api.POST("/claims/:id/reviews", reviewHandler.SubmitReview)
 
 	// Chat routes
 	api.GET("/tasks/:tid/chats", chatHandler.GetChats)
 	api.POST("/tasks/:tid/chats", chatHandler.GetOrCreateChat)
+	api.GET("/chats", chatHandler.GetInbox)
 	api.DELETE("/chats/:id", chatHandler.DeleteChat)
-	api.POST("/chats/:id/messages", chatHandler.SendMessage)
+	api.PUT("/chats/:id/timer", chatHandler.SetTimer)
+	// Room for every attachment at full size plus the rest of the form
+	messageUploadLimit := int64(attachmentsPerMessage)*attachmentMaxBytes + 1<<20
+	api.POST("/chats/:id/messages", middleware.LimitBody(messageUploadLimit), chatHandler.SendMessage)
 	api.GET("/chats/:id/messages", chatHandler.GetMessages)
+	api.PATCH("/chats/:id/messages/:mid", chatHandler.EditMessage)
+	api.DELETE("/chats/:id/messages/:mid", chatHandler.UnsendMessage)
+	api.GET("/chats/:id/attachments/:aid", chatHandler.GetAttachment)
+	api.POST("/chats/:id/read", chatHandler.MarkRead)
+	api.GET("/me/unread", chatHandler.GetUnreadCount)
+
+	// Report routes
+	api.POST("/reports", moderationHandler.CreateReport)
 
 	// Task routes (continued)
 	api.GET("/task/:id", taskHandler.GetTask)
 
+	// Admin routes, each gated by the permission its role must grant
+	adminHandler := handler.NewAdminHandler(adminSvc)
+	sybilHandler := handler.NewSybilHandler(sybilSvc)
+	fraudHandler := handler.NewFraudHandler(fraudSvc, claimSvc)
+	admin := r.Group("/admin/v1")
+	admin.Use(middleware.AdminMiddleware(adminSvc))
+
+	moderate := middleware.RequirePermission(domain.PermModerate)
+	admin.GET("/reports", moderate, moderationHandler.GetQueue)
+	admin.GET("/reports/:type/:id", moderate, moderationHandler.GetReports)
+	admin.GET("/messages/:id", moderate, moderationHandler.GetMessage)
+	admin.POST("/moderation/actions", moderate, moderationHandler.TakeAction)
+	admin.POST("/users/:id/suspend", moderate, moderationHandler.SuspendUser)
+	admin.POST("/users/:id/unsuspend", moderate, moderationHandler.UnsuspendUser)
+	admin.GET("/fraud/flags", moderate, fraudHandler.GetFlags)
+	admin.POST("/fraud/flags/:id/review", moderate, fraudHandler.ReviewFlag)
+
+	admin.GET("/users", middleware.RequirePermission(domain.PermViewUsers), adminHandler.SearchUsers)
+	admin.GET("/sybil/clusters", middleware.RequirePermission(domain.PermViewUsers), sybilHandler.GetClusters)
+	admin.GET("/tasks", middleware.RequirePermission(domain.PermViewTasks), adminHandler.SearchTasks)
+	admin.GET("/claims", middleware.RequirePermission(domain.PermViewTasks), adminHandler.SearchClaims)
+	admin.GET("/tasks/:id/escrow", middleware.RequirePermission(domain.PermViewEscrow), adminHandler.GetEscrowTransactions)
+	admin.POST("/tasks/:id/cancel", middleware.RequirePermission(domain.PermCancelTasks), adminHandler.CancelTask)
+	admin.POST("/claims/:id/resolve", middleware.RequirePermission(domain.PermResolveDisputes), adminHandler.ResolveDispute)
+	admin.GET("/stats", middleware.RequirePermission(domain.PermViewStats), adminHandler.GetStats)
+
+	manageAdmins := middleware.RequirePermission(domain.PermManageAdmins)
+	admin.GET("/admins", manageAdmins, adminHandler.ListAdmins)
+	admin.POST("/admins", manageAdmins, adminHandler.CreateAdmin)
+	admin.DELETE("/admins/:id", manageAdmins, adminHandler.RevokeAdmin)
+
 	// Server
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -171,3 +417,19 @@ func main() {
 
 	log.Println("Server exited")
 }
+
+// serverSecret returns the key used to sign access tokens and derive task
+// aliases. Without AUTH_TOKEN_SECRET a random key is used, so tokens and
+// aliases do not survive a restart.
+func serverSecret() []byte {
+	if secret := os.Getenv("AUTH_TOKEN_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+
+	log.Println("AUTH_TOKEN_SECRET not set, using a random signing key")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("Failed to generate auth secret: %v", err)
+	}
+	return secret
+}
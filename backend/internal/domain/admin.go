@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AdminRole string
+
+const (
+	AdminRoleAdmin      AdminRole = "admin"
+	AdminRoleModerator  AdminRole = "moderator"
+	AdminRoleArbitrator AdminRole = "arbitrator"
+	AdminRoleFinance    AdminRole = "finance"
+)
+
+type AdminPermission string
+
+const (
+	PermModerate        AdminPermission = "moderate"
+	PermViewUsers       AdminPermission = "users.read"
+	PermViewTasks       AdminPermission = "tasks.read"
+	PermCancelTasks     AdminPermission = "tasks.cancel"
+	PermResolveDisputes AdminPermission = "disputes.resolve"
+	PermViewEscrow      AdminPermission = "escrow.read"
+	PermViewStats       AdminPermission = "stats.read"
+	PermManageAdmins    AdminPermission = "admins.manage"
+)
+
+// RolePermissions maps each admin role to what it may do. AdminRoleAdmin may
+// do everything. PermViewTasks covers claims as well as tasks.
+var RolePermissions = map[AdminRole][]AdminPermission{
+	AdminRoleModerator:  {PermModerate, PermViewUsers, PermViewTasks, PermViewStats},
+	AdminRoleArbitrator: {PermResolveDisputes, PermViewTasks, PermViewStats},
+	AdminRoleFinance:    {PermViewEscrow, PermCancelTasks, PermViewTasks, PermViewStats},
+}
+
+func (r AdminRole) IsValid() bool {
+	_, ok := RolePermissions[r]
+	return ok || r == AdminRoleAdmin
+}
+
+// Admin is a staff account. Admins authenticate with an API key of their
+// own, never with a device ID.
+type Admin struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	Role      AdminRole  `json:"role"`
+	KeyHash   string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (a *Admin) Can(permission AdminPermission) bool {
+	if a.Role == AdminRoleAdmin {
+		return true
+	}
+	for _, p := range RolePermissions[a.Role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+type UserSearch struct {
+	ID            *uuid.UUID
+	DeviceID      string
+	SuspendedOnly bool
+	Limit         int
+	Offset        int
+}
+
+type TaskSearch struct {
+	OwnerID *uuid.UUID
+	Status  TaskStatus
+	Query   string
+	Limit   int
+	Offset  int
+}
+
+type ClaimSearch struct {
+	TaskID    *uuid.UUID
+	ClaimerID *uuid.UUID
+	Status    ClaimStatus
+	Limit     int
+	Offset    int
+}
+
+// SystemStats is a point-in-time overview for the admin dashboard.
+type SystemStats struct {
+	Users          int                 `json:"users"`
+	SuspendedUsers int                 `json:"suspended_users"`
+	Tasks          map[TaskStatus]int  `json:"tasks"`
+	Claims         map[ClaimStatus]int `json:"claims"`
+	OpenReports    int                 `json:"open_reports"`
+	EscrowLocked   float64             `json:"escrow_locked"`
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AttachmentKind string
+
+const (
+	AttachmentImage    AttachmentKind = "image"
+	AttachmentDocument AttachmentKind = "document"
+)
+
+// AttachmentTypes are the file types that may be sent in chat, by MIME type
+// as sniffed from the content.
+var AttachmentTypes = map[string]AttachmentKind{
+	"image/jpeg":      AttachmentImage,
+	"image/png":       AttachmentImage,
+	"image/gif":       AttachmentImage,
+	"application/pdf": AttachmentDocument,
+	"text/plain":      AttachmentDocument,
+}
+
+// Attachment is a file sent with a message. Width and Height are set for
+// images.
+type Attachment struct {
+	ID         uuid.UUID      `json:"id"`
+	MessageID  uuid.UUID      `json:"message_id"`
+	ChatID     uuid.UUID      `json:"-"`
+	Kind       AttachmentKind `json:"kind"`
+	MimeType   string         `json:"mime_type"`
+	Size       int64          `json:"size"`
+	Width      *int           `json:"width,omitempty"`
+	Height     *int           `json:"height,omitempty"`
+	Filename   string         `json:"filename,omitempty"`
+	StorageKey string         `json:"-"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
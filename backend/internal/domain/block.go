@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Block stops two users from interacting in either direction. The blocked
+// user is only ever shown by the alias they had on the task where the block
+// was made.
+type Block struct {
+	ID        uuid.UUID  `json:"id"`
+	BlockerID uuid.UUID  `json:"-"`
+	BlockedID uuid.UUID  `json:"-"`
+	TaskID    *uuid.UUID `json:"task_id,omitempty"`
+	Alias     string     `json:"alias,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
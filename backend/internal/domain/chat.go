@@ -1,6 +1,9 @@
 package domain
 
 import (
+	"encoding/base64"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,29 +12,171 @@ import (
 type Chat struct {
 	ID                    uuid.UUID `json:"id"`
 	TaskID                uuid.UUID `json:"task_id"`
-	ParticipantID         uuid.UUID `json:"participant_id"`
-	OtherParticipantID    uuid.UUID `json:"other_participant_id"`
-	DeletedByParticipant  bool      `json:"deleted_by_participant"`
-	DeletedByOther        bool      `json:"deleted_by_other"`
+	ParticipantID         uuid.UUID `json:"-"`
+	OtherParticipantID    uuid.UUID `json:"-"`
+	DeletedByParticipant  bool      `json:"-"`
+	DeletedByOther        bool      `json:"-"`
+	// When each side last deleted the chat; their history starts there.
+	ParticipantClearedAt  *time.Time `json:"-"`
+	OtherClearedAt        *time.Time `json:"-"`
+	// MessageTTL is the chat's disappearing-messages timer in seconds; new
+	// messages expire that long after they are sent. Zero is off.
+	MessageTTL            int       `json:"message_ttl"`
 	CreatedAt             time.Time `json:"created_at"`
 	UpdatedAt             time.Time `json:"updated_at"`
+	// Read state from the viewer's side, filled in for chat listings:
+	// messages from the other participant the viewer has not read, and
+	// the last message the other participant has read.
+	UnreadCount           int        `json:"unread_count"`
+	CounterpartReadID     *uuid.UUID `json:"counterpart_last_read_id,omitempty"`
+	// Filled in for the inbox: the task's title, the latest message,
+	// shortened for a preview, and when the chat was last active.
+	TaskTitle             string     `json:"task_title,omitempty"`
+	LastMessage           *Message   `json:"-"`
+	ActiveAt              time.Time  `json:"-"`
 }
 
-func (c *Chat) IsDeleted() bool {
-	return c.DeletedByParticipant || c.DeletedByOther
+// IsDeletedBy reports whether userID has deleted the chat on their side.
+func (c *Chat) IsDeletedBy(userID uuid.UUID) bool {
+	return (c.ParticipantID == userID && c.DeletedByParticipant) ||
+		(c.OtherParticipantID == userID && c.DeletedByOther)
 }
 
+// IsVisibleTo reports whether userID takes part in the chat and has not
+// deleted it. Deleting only hides the chat from the side that did it.
 func (c *Chat) IsVisibleTo(userID uuid.UUID) bool {
-	if c.IsDeleted() {
+	if c.ParticipantID != userID && c.OtherParticipantID != userID {
 		return false
 	}
-	return c.ParticipantID == userID || c.OtherParticipantID == userID
+	return !c.IsDeletedBy(userID)
 }
 
+// HistoryStart is when userID last deleted the chat, or nil if they never
+// have. Messages sent before then are gone for them, even after they
+// rejoin it.
+func (c *Chat) HistoryStart(userID uuid.UUID) *time.Time {
+	if c.ParticipantID == userID {
+		return c.ParticipantClearedAt
+	}
+	return c.OtherClearedAt
+}
+
+// ShowsMessage reports whether message is part of userID's history.
+func (c *Chat) ShowsMessage(userID uuid.UUID, message *Message) bool {
+	start := c.HistoryStart(userID)
+	return start == nil || message.CreatedAt.After(*start)
+}
+
+// MessageExpiry is when a message sent to the chat at sentAt expires, or
+// nil if the timer is off.
+func (c *Chat) MessageExpiry(sentAt time.Time) *time.Time {
+	if c.MessageTTL <= 0 {
+		return nil
+	}
+	expiresAt := sentAt.Add(time.Duration(c.MessageTTL) * time.Second)
+	return &expiresAt
+}
+
+// MessageTTLs are the disappearing-messages timers a chat can be set to:
+// an hour, a day and a week.
+var MessageTTLs = []int{60 * 60, 24 * 60 * 60, 7 * 24 * 60 * 60}
+
+// IsValidMessageTTL reports whether seconds is one of MessageTTLs or zero,
+// which turns the timer off.
+func IsValidMessageTTL(seconds int) bool {
+	if seconds == 0 {
+		return true
+	}
+	for _, ttl := range MessageTTLs {
+		if seconds == ttl {
+			return true
+		}
+	}
+	return false
+}
+
+// Counterpart returns the other participant from userID's point of view.
+func (c *Chat) Counterpart(userID uuid.UUID) uuid.UUID {
+	if c.ParticipantID == userID {
+		return c.OtherParticipantID
+	}
+	return c.ParticipantID
+}
+
+// InboxCursor is a position in a user's inbox, which runs from the most
+// recently active chat back. Clients only see it as an opaque string.
+type InboxCursor struct {
+	ActiveAt time.Time
+	ChatID   uuid.UUID
+}
+
+func (c InboxCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.ActiveAt.UTC().Format(time.RFC3339Nano) + "|" + c.ChatID.String()))
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// ParseInboxCursor reads a cursor made by InboxCursor.String.
+func ParseInboxCursor(s string) (InboxCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return InboxCursor{}, errInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return InboxCursor{}, errInvalidCursor
+	}
+	activeAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return InboxCursor{}, errInvalidCursor
+	}
+	chatID, err := uuid.Parse(parts[1])
+	if err != nil {
+		return InboxCursor{}, errInvalidCursor
+	}
+	return InboxCursor{ActiveAt: activeAt, ChatID: chatID}, nil
+}
+
+// A message that was unsent (DeletedAt) or hidden by moderation (HiddenAt)
+// stays in the chat's history as a tombstone without content.
 type Message struct {
+	ID        uuid.UUID  `json:"id"`
+	ChatID    uuid.UUID  `json:"chat_id"`
+	SenderID  uuid.UUID  `json:"-"`
+	Content   string     `json:"content"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	HiddenAt  *time.Time `json:"hidden_at,omitempty"`
+	// ExpiresAt is set on messages sent while the chat's timer was on.
+	// Once it passes the message is gone for both participants.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Attachments are left out of tombstones.
+	Attachments []*Attachment `json:"attachments,omitempty"`
+	// End-to-end encrypted messages have no Content. Ciphertext is sealed
+	// with a key of its own, of which the viewer gets the Envelopes for
+	// their devices; Encrypted stays set where the ciphertext is left out,
+	// as in inbox previews.
+	Encrypted  bool        `json:"encrypted,omitempty"`
+	Ciphertext []byte      `json:"ciphertext,omitempty"`
+	Envelopes  []*Envelope `json:"envelopes,omitempty"`
+}
+
+// IsTombstone reports whether the message was unsent or hidden.
+func (m *Message) IsTombstone() bool {
+	return m.DeletedAt != nil || m.HiddenAt != nil
+}
+
+// IsExpired reports whether the message has disappeared by now.
+func (m *Message) IsExpired(now time.Time) bool {
+	return m.ExpiresAt != nil && !now.Before(*m.ExpiresAt)
+}
+
+// MessageEdit is an earlier version of an edited message, kept for
+// moderators. WrittenAt is when that version was sent or last edited.
+type MessageEdit struct {
 	ID        uuid.UUID `json:"id"`
-	ChatID    uuid.UUID `json:"chat_id"`
-	SenderID  uuid.UUID `json:"sender_id"`
+	MessageID uuid.UUID `json:"message_id"`
 	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	WrittenAt time.Time `json:"written_at"`
 }
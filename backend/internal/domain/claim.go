@@ -13,16 +13,22 @@ const (
 	ClaimStatusApproved ClaimStatus = "approved"
 	ClaimStatusRejected ClaimStatus = "rejected"
 	ClaimStatusCancelled ClaimStatus = "cancelled"
+	ClaimStatusDisputed ClaimStatus = "disputed"
+	ClaimStatusApplied  ClaimStatus = "applied"
 )
 
 type Claim struct {
 	ID              uuid.UUID  `json:"id"`
 	TaskID          uuid.UUID  `json:"task_id"`
-	ClaimerID       uuid.UUID  `json:"claimer_id"`
+	ClaimerID       uuid.UUID  `json:"-"`
 	Status          ClaimStatus `json:"status"`
 	SubmittedAt     *time.Time `json:"submitted_at,omitempty"`
 	CompletionText  string     `json:"completion_text,omitempty"`
 	CompletionImageURL string  `json:"completion_image_url,omitempty"`
+	DisputeReason   string     `json:"dispute_reason,omitempty"`
+	DisputedAt      *time.Time `json:"disputed_at,omitempty"`
+	DecidedAt       *time.Time `json:"decided_at,omitempty"`
+	HiddenAt        *time.Time `json:"hidden_at,omitempty"`
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
 }
@@ -30,3 +36,31 @@ type Claim struct {
 func (c *Claim) IsSubmitted() bool {
 	return c.SubmittedAt != nil && c.CompletionText != ""
 }
+
+// Redact strips a hidden submission's content before it is shown.
+func (c *Claim) Redact() {
+	if c.HiddenAt != nil {
+		c.CompletionText = ""
+		c.CompletionImageURL = ""
+	}
+}
+
+type ArbitrationDecision string
+
+const (
+	ArbitrationApprove ArbitrationDecision = "approve"
+	ArbitrationReject  ArbitrationDecision = "reject"
+)
+
+// Arbitration records the outcome of a disputed rejection. It was decided
+// either by the admin in ArbitratorID or by the user in UserArbitratorID.
+type Arbitration struct {
+	ID               uuid.UUID           `json:"id"`
+	TaskID           uuid.UUID           `json:"task_id"`
+	ClaimID          uuid.UUID           `json:"claim_id"`
+	ArbitratorID     *uuid.UUID          `json:"-"`
+	UserArbitratorID *uuid.UUID          `json:"-"`
+	Decision         ArbitrationDecision `json:"decision"`
+	Reason           string              `json:"reason,omitempty"`
+	CreatedAt        time.Time           `json:"created_at"`
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Device is one installation linked to a user. DeviceID is the raw identifier
+// the app presents at handshake and is never returned to clients.
+type Device struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"-"`
+	DeviceID   string     `json:"-"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Current    bool       `json:"current"`
+}
+
+func (d *Device) IsRevoked() bool {
+	return d.RevokedAt != nil
+}
+
+// PairingCode links a new device to an existing account. Code is only
+// available when the pairing is created.
+type PairingCode struct {
+	Code      string    `json:"code"`
+	QRPayload string    `json:"qr_payload"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
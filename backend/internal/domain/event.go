@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventProtocolVersion is the version of the WebSocket protocol. Clients
+// put it in every command and receive it in every event.
+const EventProtocolVersion = 1
+
+// EventType names a frame sent to the client. Names are snake_case, like
+// the command types clients send.
+type EventType string
+
+const (
+	// EventChatMessage carries a MessageView of a new message, presented
+	// for the recipient.
+	EventChatMessage EventType = "chat_message"
+	// EventMessageEdited carries a MessageView of an edited message,
+	// presented for the recipient.
+	EventMessageEdited EventType = "message_edited"
+	// EventMessageDeleted tells the recipient a message was unsent and is
+	// now a tombstone. The payload is a ChatActivity.
+	EventMessageDeleted EventType = "message_deleted"
+	// EventTyping tells the other participant, on connections subscribed
+	// to the chat, that someone is typing. The payload is a ChatActivity.
+	EventTyping EventType = "typing"
+	// EventMessageRead tells the other participant, on all their devices,
+	// the last message that has been read. The payload is a ChatActivity.
+	EventMessageRead EventType = "message_read"
+	// EventChatTimer tells the other participant, on all their devices,
+	// that the chat's disappearing-messages timer was changed. The payload
+	// is a ChatTimer.
+	EventChatTimer EventType = "chat_timer"
+	// EventAck answers a command. ID is the command's request ID and the
+	// payload depends on the command.
+	EventAck EventType = "ack"
+	// EventError answers a command that failed, or a frame that could not
+	// be read as a command.
+	EventError EventType = "error"
+)
+
+// Event is the envelope of every frame sent to the client:
+//
+//	{"v": 1, "type": "chat_message", "payload": {...}, "sent_at": "2024-05-01T12:00:00Z"}
+//	{"v": 1, "type": "ack", "id": "42", "payload": {...}, "sent_at": "..."}
+//	{"v": 1, "type": "error", "id": "42", "error": {"code": "forbidden", "message": "..."}, "sent_at": "..."}
+//
+// The payload depends on the type and is always shaped for the recipient,
+// with aliases rather than user IDs. Events queued while a connection is
+// busy are sent together in one frame, separated by newlines.
+//
+// From names the user who caused the event, if any, so it is never
+// delivered to someone they have a block with.
+type Event struct {
+	Version int           `json:"v"`
+	Type    EventType     `json:"type"`
+	ID      string        `json:"id,omitempty"`
+	Payload interface{}   `json:"payload,omitempty"`
+	Error   *CommandError `json:"error,omitempty"`
+	SentAt  time.Time     `json:"sent_at"`
+	From    uuid.UUID     `json:"-"`
+}
+
+// CommandError explains why a command failed. RetryAfter is set, in
+// seconds, when the command was rate limited.
+type CommandError struct {
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	RetryAfter int    `json:"retry_after,omitempty"`
+}
+
+// ChatActivity is the payload of EventTyping, EventMessageRead and
+// EventMessageDeleted.
+type ChatActivity struct {
+	ChatID    uuid.UUID  `json:"chat_id"`
+	MessageID *uuid.UUID `json:"message_id,omitempty"`
+}
+
+// ChatTimer is the payload of EventChatTimer. MessageTTL is in seconds and
+// zero when the timer was turned off.
+type ChatTimer struct {
+	ChatID     uuid.UUID `json:"chat_id"`
+	MessageTTL int       `json:"message_ttl"`
+}
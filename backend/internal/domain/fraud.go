@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClientInfo describes where a request came from, for the fraud checks.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// FingerprintKind tells apart the two ways users are matched: by network
+// alone, or by network and client software together, which is close to
+// being the same physical device.
+type FingerprintKind string
+
+const (
+	FingerprintNetwork FingerprintKind = "network"
+	FingerprintDevice  FingerprintKind = "device"
+)
+
+// FraudSignal is one suspicious pattern found on an approved claim.
+type FraudSignal string
+
+const (
+	FraudSignalSharedDevice    FraudSignal = "shared_device"
+	FraudSignalSharedNetwork   FraudSignal = "shared_network"
+	FraudSignalRepeatedPair    FraudSignal = "repeated_pair"
+	FraudSignalInstantApproval FraudSignal = "instant_approval"
+	FraudSignalCircularPayment FraudSignal = "circular_payment"
+)
+
+// FraudSignalWeights is how much each signal adds to a claim's score.
+var FraudSignalWeights = map[FraudSignal]int{
+	FraudSignalSharedDevice:    60,
+	FraudSignalSharedNetwork:   40,
+	FraudSignalCircularPayment: 40,
+	FraudSignalRepeatedPair:    30,
+	FraudSignalInstantApproval: 20,
+}
+
+type FraudFlagStatus string
+
+const (
+	FraudFlagOpen      FraudFlagStatus = "open"
+	FraudFlagCleared   FraudFlagStatus = "cleared"
+	FraudFlagConfirmed FraudFlagStatus = "confirmed"
+)
+
+// FraudFlag holds an approved claim for review. The reward stays in escrow
+// and neither side gets reputation for it unless the flag is cleared.
+type FraudFlag struct {
+	ClaimID    uuid.UUID       `json:"claim_id"`
+	TaskID     uuid.UUID       `json:"task_id"`
+	OwnerID    uuid.UUID       `json:"owner_id"`
+	ClaimerID  uuid.UUID       `json:"claimer_id"`
+	Score      int             `json:"score"`
+	Signals    []FraudSignal   `json:"signals"`
+	Status     FraudFlagStatus `json:"status"`
+	ReviewedBy string          `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time      `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+)
+
+// Prekey is a public key a device has published, under the ID the device
+// gave it.
+type Prekey struct {
+	KeyID     int    `json:"key_id"`
+	PublicKey []byte `json:"public_key"`
+}
+
+// SignedPrekey is a prekey signed with the device's identity key.
+type SignedPrekey struct {
+	Prekey
+	Signature []byte `json:"signature"`
+}
+
+// KeyBundle is what another device needs to start an encrypted session with
+// DeviceID in one chat. Devices publish separate keys for every chat, and
+// clients only ever see DeviceID as the device's alias in that chat, so
+// nothing in a bundle links chats on different tasks. The server only ever
+// holds public keys and does not check the signature; clients verify it
+// against the identity key. OneTimePrekey is set while the device has any
+// left, and each is handed out only once.
+type KeyBundle struct {
+	DeviceID      uuid.UUID    `json:"device_id"`
+	UserID        uuid.UUID    `json:"-"`
+	IdentityKey   []byte       `json:"identity_key"`
+	SignedPrekey  SignedPrekey `json:"signed_prekey"`
+	OneTimePrekey *Prekey      `json:"one_time_prekey,omitempty"`
+	// Mine marks the viewer's own other devices, which need a copy of
+	// every message they send as well.
+	Mine bool `json:"mine"`
+}
+
+// KeyStatus tells a device what the directory holds for it in one chat, so
+// it knows when to top up its one-time prekeys. DeviceID is the alias the
+// device goes by in the chat, under which its envelopes arrive.
+type KeyStatus struct {
+	DeviceID       uuid.UUID `json:"device_id"`
+	Published      bool      `json:"published"`
+	OneTimePrekeys int       `json:"one_time_prekeys"`
+}
+
+// Envelope is an encrypted message's key, sealed for one device. Clients
+// address devices by their alias in the chat.
+type Envelope struct {
+	MessageID uuid.UUID `json:"-"`
+	DeviceID  uuid.UUID `json:"device_id"`
+	Payload   []byte    `json:"payload"`
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReportTargetType string
+
+const (
+	ReportTargetTask    ReportTargetType = "task"
+	ReportTargetClaim   ReportTargetType = "claim"
+	ReportTargetMessage ReportTargetType = "message"
+	ReportTargetUser    ReportTargetType = "user"
+)
+
+type ReportCategory string
+
+const (
+	ReportCategoryScam          ReportCategory = "scam"
+	ReportCategoryIllegal       ReportCategory = "illegal"
+	ReportCategoryHarassment    ReportCategory = "harassment"
+	ReportCategorySpam          ReportCategory = "spam"
+	ReportCategoryInappropriate ReportCategory = "inappropriate"
+	ReportCategoryOther         ReportCategory = "other"
+)
+
+func (c ReportCategory) IsValid() bool {
+	switch c {
+	case ReportCategoryScam, ReportCategoryIllegal, ReportCategoryHarassment,
+		ReportCategorySpam, ReportCategoryInappropriate, ReportCategoryOther:
+		return true
+	}
+	return false
+}
+
+type ReportStatus string
+
+const (
+	ReportStatusOpen      ReportStatus = "open"
+	ReportStatusActioned  ReportStatus = "actioned"
+	ReportStatusDismissed ReportStatus = "dismissed"
+)
+
+const MaxReportNoteLength = 1000
+
+// Report is one user's complaint about a piece of content or a user. The
+// reported user's ID is never echoed back to the reporter.
+type Report struct {
+	ID         uuid.UUID        `json:"id"`
+	ReporterID *uuid.UUID       `json:"-"`
+	TargetType ReportTargetType `json:"target_type"`
+	TargetID   uuid.UUID        `json:"-"`
+	Category   ReportCategory   `json:"category"`
+	Note       string           `json:"note,omitempty"`
+	// DisclosedContent is what the reporter decrypted of an encrypted
+	// message, checked against its ciphertext.
+	DisclosedContent string       `json:"disclosed_content,omitempty"`
+	Status           ReportStatus `json:"status"`
+	CreatedAt        time.Time    `json:"created_at"`
+	ResolvedAt       *time.Time   `json:"resolved_at,omitempty"`
+}
+
+// ModerationQueueItem aggregates the reports against one target.
+type ModerationQueueItem struct {
+	TargetType      ReportTargetType `json:"target_type"`
+	TargetID        uuid.UUID        `json:"target_id"`
+	ReportCount     int              `json:"report_count"`
+	Categories      []string         `json:"categories"`
+	FirstReportedAt time.Time        `json:"first_reported_at"`
+	LastReportedAt  time.Time        `json:"last_reported_at"`
+	Hidden          bool             `json:"hidden"`
+}
+
+type ModerationActionType string
+
+const (
+	// ModerationHide hides the content; a hidden task is also cancelled and
+	// its escrow refunded.
+	ModerationHide ModerationActionType = "hide"
+	// ModerationSuspendUser suspends the user, or the author of the content.
+	ModerationSuspendUser ModerationActionType = "suspend_user"
+	// ModerationUnsuspendUser lifts a suspension.
+	ModerationUnsuspendUser ModerationActionType = "unsuspend_user"
+	// ModerationDismiss closes the reports and restores auto-hidden content.
+	ModerationDismiss ModerationActionType = "dismiss"
+	// ModerationForceCancel records an admin cancelling a task and refunding
+	// its escrow.
+	ModerationForceCancel ModerationActionType = "force_cancel"
+)
+
+type ModerationAction struct {
+	ID         uuid.UUID            `json:"id"`
+	TargetType ReportTargetType     `json:"target_type"`
+	TargetID   uuid.UUID            `json:"target_id"`
+	Action     ModerationActionType `json:"action"`
+	Reason     string               `json:"reason,omitempty"`
+	Moderator  string               `json:"moderator"`
+	CreatedAt  time.Time            `json:"created_at"`
+}
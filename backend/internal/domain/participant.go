@@ -0,0 +1,64 @@
+package domain
+
+// Participant is how another user appears in API payloads: a pseudonym that
+// is stable within one task and unlinkable across tasks, plus a coarse
+// reputation band. Real user IDs never leave the server.
+type Participant struct {
+	Alias          string `json:"alias"`
+	IsSelf         bool   `json:"is_self"`
+	ReputationBand string `json:"reputation_band"`
+}
+
+const (
+	ReputationBandLow         = "low"
+	ReputationBandNew         = "new"
+	ReputationBandRising      = "rising"
+	ReputationBandEstablished = "established"
+	ReputationBandTrusted     = "trusted"
+	ReputationBandVeteran     = "veteran"
+)
+
+func ReputationBand(reputation int) string {
+	switch {
+	case reputation < 0:
+		return ReputationBandLow
+	case reputation == 0:
+		return ReputationBandNew
+	case reputation < 5:
+		return ReputationBandRising
+	case reputation < 20:
+		return ReputationBandEstablished
+	case reputation < 50:
+		return ReputationBandTrusted
+	default:
+		return ReputationBandVeteran
+	}
+}
+
+type TaskView struct {
+	*Task
+	Owner Participant `json:"owner"`
+}
+
+type ClaimView struct {
+	*Claim
+	Claimer Participant `json:"claimer"`
+}
+
+// DisputeView is a disputed claim as shown to an arbitrator, next to the
+// task it was made on.
+type DisputeView struct {
+	Task  *TaskView  `json:"task"`
+	Claim *ClaimView `json:"claim"`
+}
+
+type ChatView struct {
+	*Chat
+	Counterpart Participant  `json:"counterpart"`
+	LastMessage *MessageView `json:"last_message,omitempty"`
+}
+
+type MessageView struct {
+	*Message
+	Sender Participant `json:"sender"`
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErasedContent replaces text written by a user who erased their account.
+const ErasedContent = "[deleted]"
+
+// DataExport is everything stored about one user, as handed to them by the
+// data export.
+type DataExport struct {
+	ExportedAt         time.Time            `json:"exported_at"`
+	User               *User                `json:"user"`
+	Devices            []*Device            `json:"devices"`
+	Tasks              []*Task              `json:"tasks"`
+	Claims             []*Claim             `json:"claims"`
+	Chats              []*ChatExport        `json:"chats"`
+	EscrowTransactions []*EscrowTransaction `json:"escrow_transactions"`
+	Reviews            []*Review            `json:"reviews"`
+}
+
+// ChatExport is one of the user's chats with its full history. Mine marks the
+// messages the user sent.
+type ChatExport struct {
+	*Chat
+	Messages []*ExportedMessage `json:"messages"`
+}
+
+type ExportedMessage struct {
+	ID        uuid.UUID `json:"id"`
+	Content   string    `json:"content"`
+	Mine      bool      `json:"mine"`
+	CreatedAt time.Time `json:"created_at"`
+}
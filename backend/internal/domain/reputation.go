@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReputationRole string
+
+const (
+	ReputationRoleWorker ReputationRole = "worker"
+	ReputationRolePoster ReputationRole = "poster"
+)
+
+type ReputationEventKind string
+
+const (
+	// Worker events
+	ReputationClaimApproved       ReputationEventKind = "claim_approved"
+	ReputationClaimRejected       ReputationEventKind = "claim_rejected"
+	ReputationClaimWithdrawn      ReputationEventKind = "claim_withdrawn"
+	ReputationClaimAbandoned      ReputationEventKind = "claim_abandoned"
+	ReputationRejectionOverturned ReputationEventKind = "rejection_overturned"
+
+	// Poster events
+	ReputationTaskCompleted        ReputationEventKind = "task_completed"
+	ReputationUnjustifiedRejection ReputationEventKind = "unjustified_rejection"
+
+	// Either side
+	ReputationDisputeLost ReputationEventKind = "dispute_lost"
+)
+
+// ReputationEvent is one append-only fact that feeds a user's scores. Scores
+// are always derived from the full event history, so the weighting can
+// change without losing information.
+type ReputationEvent struct {
+	ID           uuid.UUID           `json:"id"`
+	UserID       uuid.UUID           `json:"user_id"`
+	Role         ReputationRole      `json:"role"`
+	Kind         ReputationEventKind `json:"kind"`
+	TaskID       *uuid.UUID          `json:"task_id,omitempty"`
+	ClaimID      *uuid.UUID          `json:"claim_id,omitempty"`
+	RewardAmount float64             `json:"reward_amount"`
+	CreatedAt    time.Time           `json:"created_at"`
+}
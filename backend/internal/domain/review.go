@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReviewWindow is how long both sides have to review each other after a
+// claim is decided. Reviews are revealed when it closes even if only one
+// side submitted.
+const ReviewWindow = 7 * 24 * time.Hour
+
+const MaxReviewTags = 5
+const MaxReviewCommentLength = 280
+
+// reviewTags is the fixed vocabulary reviewers can pick from. Free-form tags
+// would make reviews easy to sign and so link across tasks.
+var reviewTags = map[string]bool{
+	"clear_instructions": true,
+	"fair":               true,
+	"responsive":         true,
+	"fast_review":        true,
+	"quality_work":       true,
+	"on_time":            true,
+	"friendly":           true,
+	"unclear":            true,
+	"unresponsive":       true,
+	"slow":               true,
+	"low_quality":        true,
+}
+
+func IsValidReviewTag(tag string) bool {
+	return reviewTags[tag]
+}
+
+type Review struct {
+	ID           uuid.UUID      `json:"id"`
+	ClaimID      uuid.UUID      `json:"claim_id"`
+	TaskID       uuid.UUID      `json:"task_id"`
+	ReviewerID   uuid.UUID      `json:"-"`
+	RevieweeID   uuid.UUID      `json:"-"`
+	ReviewerRole ReputationRole `json:"reviewer_role"`
+	Rating       int            `json:"rating"`
+	Tags         []string       `json:"tags"`
+	Comment      string         `json:"comment,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+// ReviewThread is one side's view of the reviews on a claim. The other
+// side's review stays hidden until Revealed.
+type ReviewThread struct {
+	ClaimID              uuid.UUID `json:"claim_id"`
+	WindowClosesAt       time.Time `json:"window_closes_at"`
+	Revealed             bool      `json:"revealed"`
+	CounterpartSubmitted bool      `json:"counterpart_submitted"`
+	Mine                 *Review   `json:"mine"`
+	Theirs               *Review   `json:"theirs"`
+}
+
+// RatingSummary aggregates the revealed reviews a user received in one role.
+type RatingSummary struct {
+	Average *float64 `json:"average"`
+	Count   int      `json:"count"`
+}
+
+// minPublicRatings is how many reviews a user needs before a rating is shown
+// to other participants; below it a single review would identify its author.
+const minPublicRatings = 3
+
+// Coarse returns the average rounded to the nearest half star, or nil when
+// there are too few reviews to show.
+func (r RatingSummary) Coarse() *float64 {
+	if r.Average == nil || r.Count < minPublicRatings {
+		return nil
+	}
+	rounded := math.Round(*r.Average*2) / 2
+	return &rounded
+}
+
+type Ratings struct {
+	AsWorker RatingSummary `json:"as_worker"`
+	AsPoster RatingSummary `json:"as_poster"`
+}
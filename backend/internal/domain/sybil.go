@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountProof is what an unknown device presents on handshake to have an
+// account created for it: a solved proof-of-work challenge and the address
+// the request came from.
+type AccountProof struct {
+	Challenge string
+	Nonce     string
+	IP        string
+}
+
+// AccountChallenge asks the client to find a nonce such that
+// SHA-256(challenge + nonce) starts with Difficulty zero bits.
+type AccountChallenge struct {
+	Challenge  string    `json:"challenge"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// SybilCluster is a group of young accounts that all claimed tasks from the
+// same owner. Networks counts the distinct addresses they were created
+// from; a cluster created from one or two networks is the strongest signal.
+type SybilCluster struct {
+	OwnerID    uuid.UUID   `json:"owner_id"`
+	ClaimerIDs []uuid.UUID `json:"claimer_ids"`
+	Claims     int         `json:"claims"`
+	Networks   int         `json:"networks"`
+}
@@ -14,27 +14,30 @@ const (
 	TaskStatusCompleted TaskStatus = "completed"
 	TaskStatusCancelled TaskStatus = "cancelled"
 	TaskStatusDisputed  TaskStatus = "disputed"
+	TaskStatusHeld      TaskStatus = "held"
 )
 
 type Task struct {
-	ID            uuid.UUID  `json:"id"`
-	OwnerID       uuid.UUID  `json:"owner_id"`
-	Title         string     `json:"title"`
-	Description   string     `json:"description"`
-	RewardAmount  float64    `json:"reward_amount"`
-	MaxClaimants  int        `json:"max_claimants"`
-	ClaimDeadline time.Time  `json:"claim_deadline"`
-	OwnerDeadline time.Time  `json:"owner_deadline"`
-	Status        TaskStatus `json:"status"`
-	EscrowLocked  bool       `json:"escrow_locked"`
-	CreatedAt     time.Time  `json:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at"`
+	ID              uuid.UUID  `json:"id"`
+	OwnerID         uuid.UUID  `json:"-"`
+	Title           string     `json:"title"`
+	Description     string     `json:"description"`
+	RewardAmount    float64    `json:"reward_amount"`
+	MaxClaimants    int        `json:"max_claimants"`
+	ClaimDeadline   time.Time  `json:"claim_deadline"`
+	OwnerDeadline   time.Time  `json:"owner_deadline"`
+	Status          TaskStatus `json:"status"`
+	EscrowLocked    bool       `json:"escrow_locked"`
+	ApplicationMode bool       `json:"application_mode"`
+	HiddenAt        *time.Time `json:"hidden_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 func (t *Task) CanBeClaimed() bool {
 	now := time.Now()
 	return (t.Status == TaskStatusOpen || t.Status == TaskStatusClaimed) &&
-		now.Before(t.ClaimDeadline)
+		now.Before(t.ClaimDeadline) && t.HiddenAt == nil
 }
 
 func (t *Task) ShouldAutoCancel() bool {
@@ -0,0 +1,103 @@
+package domain
+
+import "time"
+
+// TrustTier is a level of privileges earned through reputation and history.
+type TrustTier int
+
+const (
+	TrustTierNew TrustTier = iota
+	TrustTierMember
+	TrustTierTrusted
+	TrustTierVeteran
+)
+
+// TrustPrivileges are what a tier unlocks. MaxTaskReward caps the reward of
+// a task the user posts or claims; ApplicationTasks lets them post tasks in
+// application mode; Arbitration lets them decide disputes they have no part
+// in.
+type TrustPrivileges struct {
+	MaxTaskReward    float64 `json:"max_task_reward"`
+	MaxActiveClaims  int     `json:"max_active_claims"`
+	ApplicationTasks bool    `json:"application_tasks"`
+	Arbitration      bool    `json:"arbitration"`
+}
+
+// TrustRequirement is what a user needs to reach a tier. Completed counts
+// approved claims plus completed tasks; MinApprovalRate only applies once
+// some claims have been decided.
+type TrustRequirement struct {
+	MinAccountDays  int     `json:"min_account_days"`
+	MinReputation   int     `json:"min_reputation"`
+	MinCompleted    int     `json:"min_completed"`
+	MinApprovalRate float64 `json:"min_approval_rate"`
+}
+
+type TrustTierInfo struct {
+	Tier        TrustTier
+	Requirement TrustRequirement
+	Privileges  TrustPrivileges
+}
+
+// TrustTiers lists every tier from lowest to highest.
+var TrustTiers = []TrustTierInfo{
+	{
+		Tier:       TrustTierNew,
+		Privileges: TrustPrivileges{MaxTaskReward: 25, MaxActiveClaims: 2},
+	},
+	{
+		Tier:        TrustTierMember,
+		Requirement: TrustRequirement{MinAccountDays: 7, MinReputation: 1, MinCompleted: 3},
+		Privileges:  TrustPrivileges{MaxTaskReward: 100, MaxActiveClaims: 5},
+	},
+	{
+		Tier:        TrustTierTrusted,
+		Requirement: TrustRequirement{MinAccountDays: 30, MinReputation: 20, MinCompleted: 15, MinApprovalRate: 0.8},
+		Privileges:  TrustPrivileges{MaxTaskReward: 500, MaxActiveClaims: 10, ApplicationTasks: true},
+	},
+	{
+		Tier:        TrustTierVeteran,
+		Requirement: TrustRequirement{MinAccountDays: 90, MinReputation: 50, MinCompleted: 50, MinApprovalRate: 0.9},
+		Privileges:  TrustPrivileges{MaxTaskReward: 2000, MaxActiveClaims: 20, ApplicationTasks: true, Arbitration: true},
+	},
+}
+
+// Met reports whether a user with the given history meets the requirement.
+func (r TrustRequirement) Met(user *User, stats *UserStats, now time.Time) bool {
+	if now.Sub(user.CreatedAt) < time.Duration(r.MinAccountDays)*24*time.Hour {
+		return false
+	}
+	if user.Reputation < r.MinReputation {
+		return false
+	}
+	if stats.ClaimsApproved+stats.TasksCompleted < r.MinCompleted {
+		return false
+	}
+	if rate := stats.ApprovalRate(); rate != nil && *rate < r.MinApprovalRate {
+		return false
+	}
+	return true
+}
+
+// NextTrustTier returns the tier above tier, or nil at the top.
+func NextTrustTier(tier TrustTier) *TrustTierInfo {
+	next := int(tier) + 1
+	if next >= len(TrustTiers) {
+		return nil
+	}
+	return &TrustTiers[next]
+}
+
+// ComputeTrustTier returns the highest tier whose requirement the user
+// meets. Suspended users are held at the lowest tier.
+func ComputeTrustTier(user *User, stats *UserStats, now time.Time) TrustTierInfo {
+	if user.IsSuspended(now) {
+		return TrustTiers[0]
+	}
+	for i := len(TrustTiers) - 1; i > 0; i-- {
+		if TrustTiers[i].Requirement.Met(user, stats, now) {
+			return TrustTiers[i]
+		}
+	}
+	return TrustTiers[0]
+}
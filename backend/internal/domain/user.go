@@ -1,16 +1,113 @@
 package domain
 
 import (
+	"math"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type User struct {
-	ID          uuid.UUID `json:"id"`
-	DeviceID    string    `json:"device_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	Reputation  int       `json:"reputation"`
-	TotalEarned float64   `json:"total_earned"`
-	TotalSpent  float64   `json:"total_spent"`
+	ID               uuid.UUID  `json:"id"`
+	DeviceID         string     `json:"-"` // signs the account in, so it is never sent out
+	CreatedAt        time.Time  `json:"created_at"`
+	Reputation       int        `json:"reputation"`
+	WorkerScore      float64    `json:"worker_score"`
+	PosterScore      float64    `json:"poster_score"`
+	TotalEarned      float64    `json:"total_earned"`
+	TotalSpent       float64    `json:"total_spent"`
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty"`
+	SuspendedUntil   *time.Time `json:"suspended_until,omitempty"`
+	SuspensionReason string     `json:"suspension_reason,omitempty"`
+	ErasedAt         *time.Time `json:"-"`
+}
+
+// IsSuspended reports whether the user is suspended at now. A suspension
+// without an end is permanent.
+func (u *User) IsSuspended(now time.Time) bool {
+	if u.SuspendedAt == nil {
+		return false
+	}
+	return u.SuspendedUntil == nil || now.Before(*u.SuspendedUntil)
+}
+
+// UserStats are activity counts derived from a user's tasks and claims.
+type UserStats struct {
+	TasksPosted    int `json:"tasks_posted"`
+	TasksCompleted int `json:"tasks_completed"`
+	TasksCancelled int `json:"tasks_cancelled"`
+	ClaimsTotal    int `json:"claims_total"`
+	ClaimsApproved int `json:"claims_approved"`
+	ClaimsRejected int `json:"claims_rejected"`
+	ActiveClaims   int `json:"active_claims"`
+}
+
+// ApprovalRate is the share of decided claims that were approved, or nil
+// when none have been decided yet.
+func (s *UserStats) ApprovalRate() *float64 {
+	decided := s.ClaimsApproved + s.ClaimsRejected
+	if decided == 0 {
+		return nil
+	}
+	rate := float64(s.ClaimsApproved) / float64(decided)
+	return &rate
+}
+
+// Profile is the private view a user gets of their own account.
+type Profile struct {
+	CreatedAt      time.Time       `json:"created_at"`
+	Reputation     int             `json:"reputation"`
+	ReputationBand string          `json:"reputation_band"`
+	WorkerScore    float64         `json:"worker_score"`
+	PosterScore    float64         `json:"poster_score"`
+	TotalEarned    float64         `json:"total_earned"`
+	TotalSpent     float64         `json:"total_spent"`
+	Stats          UserStats       `json:"stats"`
+	ApprovalRate   *float64        `json:"approval_rate"`
+	Ratings        Ratings         `json:"ratings"`
+	TrustTier      TrustTier       `json:"trust_tier"`
+	Privileges     TrustPrivileges `json:"privileges"`
+	// NextTier is what it takes to reach the next tier, if there is one
+	NextTier *TrustRequirement `json:"next_tier,omitempty"`
+}
+
+// PublicProfile is what other participants may see. Every figure is coarse
+// enough that it cannot be used to link the same person across tasks.
+type PublicProfile struct {
+	Participant
+	CompletionRate   *float64 `json:"completion_rate"`
+	Rating           *float64 `json:"rating"`
+	AccountAgeBucket string   `json:"account_age_bucket"`
+}
+
+const (
+	AccountAgeUnderWeek     = "under_1_week"
+	AccountAgeUnderMonth    = "1_to_4_weeks"
+	AccountAgeUnderHalfYear = "1_to_6_months"
+	AccountAgeOverHalfYear  = "over_6_months"
+)
+
+func AccountAgeBucket(createdAt, now time.Time) string {
+	age := now.Sub(createdAt)
+	switch {
+	case age < 7*24*time.Hour:
+		return AccountAgeUnderWeek
+	case age < 28*24*time.Hour:
+		return AccountAgeUnderMonth
+	case age < 182*24*time.Hour:
+		return AccountAgeUnderHalfYear
+	default:
+		return AccountAgeOverHalfYear
+	}
+}
+
+// CompletionRate is the approval rate rounded to the nearest 10% so it does
+// not act as a fingerprint.
+func (s *UserStats) CompletionRate() *float64 {
+	rate := s.ApprovalRate()
+	if rate == nil {
+		return nil
+	}
+	rounded := math.Round(*rate*10) / 10
+	return &rounded
 }
@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/middleware"
+	"github.com/task-underground/backend/internal/service"
+)
+
+type AdminHandler struct {
+	adminSvc service.AdminService
+}
+
+func NewAdminHandler(adminSvc service.AdminService) *AdminHandler {
+	return &AdminHandler{adminSvc: adminSvc}
+}
+
+// adminTaskView and adminClaimView expose the owner and claimer, which the
+// public JSON deliberately leaves out.
+type adminTaskView struct {
+	*domain.Task
+	OwnerID string `json:"owner_id"`
+}
+
+type adminClaimView struct {
+	*domain.Claim
+	ClaimerID string `json:"claimer_id"`
+}
+
+type CreateAdminRequest struct {
+	Name string `json:"name" binding:"required"`
+	Role string `json:"role" binding:"required"`
+}
+
+func (h *AdminHandler) CreateAdmin(c *gin.Context) {
+	var req CreateAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	admin, key, err := h.adminSvc.CreateAdmin(c.Request.Context(), req.Name, domain.AdminRole(req.Role))
+	if err != nil {
+		if err == service.ErrInvalidAdmin {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"admin": admin, "api_key": key})
+}
+
+func (h *AdminHandler) ListAdmins(c *gin.Context) {
+	admins, err := h.adminSvc.ListAdmins(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"admins": admins})
+}
+
+func (h *AdminHandler) RevokeAdmin(c *gin.Context) {
+	adminID := c.Param("id")
+
+	err := h.adminSvc.RevokeAdmin(c.Request.Context(), parseUUID(adminID))
+	if err != nil {
+		if err == service.ErrAdminNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "admin revoked"})
+}
+
+func (h *AdminHandler) SearchUsers(c *gin.Context) {
+	id, ok := queryUUID(c, "id")
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	users, err := h.adminSvc.SearchUsers(c.Request.Context(), domain.UserSearch{
+		ID:            id,
+		DeviceID:      c.Query("device_id"),
+		SuspendedOnly: c.Query("suspended") == "true",
+		Limit:         limit,
+		Offset:        offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+func (h *AdminHandler) SearchTasks(c *gin.Context) {
+	ownerID, ok := queryUUID(c, "owner_id")
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	tasks, err := h.adminSvc.SearchTasks(c.Request.Context(), domain.TaskSearch{
+		OwnerID: ownerID,
+		Status:  domain.TaskStatus(c.Query("status")),
+		Query:   c.Query("q"),
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	views := make([]adminTaskView, 0, len(tasks))
+	for _, task := range tasks {
+		views = append(views, adminTaskView{Task: task, OwnerID: task.OwnerID.String()})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tasks": views})
+}
+
+func (h *AdminHandler) SearchClaims(c *gin.Context) {
+	taskID, ok := queryUUID(c, "task_id")
+	if !ok {
+		return
+	}
+	claimerID, ok := queryUUID(c, "claimer_id")
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	claims, err := h.adminSvc.SearchClaims(c.Request.Context(), domain.ClaimSearch{
+		TaskID:    taskID,
+		ClaimerID: claimerID,
+		Status:    domain.ClaimStatus(c.Query("status")),
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	views := make([]adminClaimView, 0, len(claims))
+	for _, claim := range claims {
+		views = append(views, adminClaimView{Claim: claim, ClaimerID: claim.ClaimerID.String()})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"claims": views})
+}
+
+func (h *AdminHandler) GetEscrowTransactions(c *gin.Context) {
+	taskID := c.Param("id")
+
+	transactions, err := h.adminSvc.GetEscrowTransactions(c.Request.Context(), parseUUID(taskID))
+	if err != nil {
+		if err == service.ErrTaskNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if transactions == nil {
+		transactions = []*domain.EscrowTransaction{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transactions": transactions})
+}
+
+type AdminReasonRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+func (h *AdminHandler) CancelTask(c *gin.Context) {
+	taskID := c.Param("id")
+
+	var req AdminReasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	action, err := h.adminSvc.ForceCancelTask(c.Request.Context(), middleware.GetAdmin(c), parseUUID(taskID), req.Reason)
+	if err != nil {
+		if err == service.ErrAdminReasonMissing {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrTaskNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrTaskAlreadyClosed {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, action)
+}
+
+type ResolveDisputeRequest struct {
+	Decision string `json:"decision" binding:"required"`
+	Reason   string `json:"reason" binding:"required"`
+}
+
+func (h *AdminHandler) ResolveDispute(c *gin.Context) {
+	claimID := c.Param("id")
+
+	var req ResolveDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.adminSvc.ResolveDispute(c.Request.Context(), middleware.GetAdmin(c), parseUUID(claimID), domain.ArbitrationDecision(req.Decision), req.Reason)
+	if err != nil {
+		if err == service.ErrAdminReasonMissing || err == service.ErrInvalidDecision {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrClaimNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrInvalidClaimState {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "dispute resolved"})
+}
+
+func (h *AdminHandler) GetStats(c *gin.Context) {
+	stats, err := h.adminSvc.GetStats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// queryUUID reads an optional UUID query parameter. It answers 400 and
+// returns false when the value is present but malformed.
+func queryUUID(c *gin.Context, key string) (*uuid.UUID, bool) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + key})
+		return nil, false
+	}
+	return &id, true
+}
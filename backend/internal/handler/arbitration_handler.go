@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/middleware"
+	"github.com/task-underground/backend/internal/service"
+)
+
+type ArbitrationHandler struct {
+	arbitrationSvc service.ArbitrationService
+	aliasSvc       service.AliasService
+}
+
+func NewArbitrationHandler(arbitrationSvc service.ArbitrationService, aliasSvc service.AliasService) *ArbitrationHandler {
+	return &ArbitrationHandler{
+		arbitrationSvc: arbitrationSvc,
+		aliasSvc:       aliasSvc,
+	}
+}
+
+func (h *ArbitrationHandler) ListDisputes(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	disputes, err := h.arbitrationSvc.ListDisputes(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		if _, ok := err.(*service.TierLimitError); ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	views := make([]*domain.DisputeView, 0, len(disputes))
+	for _, dispute := range disputes {
+		tasks, err := h.aliasSvc.PresentTasks(c.Request.Context(), []*domain.Task{dispute.Task}, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		claims, err := h.aliasSvc.PresentClaims(c.Request.Context(), []*domain.Claim{dispute.Claim}, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		views = append(views, &domain.DisputeView{Task: tasks[0], Claim: claims[0]})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"disputes": views})
+}
+
+func (h *ArbitrationHandler) ResolveDispute(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	claimID := c.Param("id")
+
+	var req ResolveDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.arbitrationSvc.ResolveDispute(c.Request.Context(), userID, parseUUID(claimID), domain.ArbitrationDecision(req.Decision), req.Reason)
+	if err != nil {
+		if err == service.ErrArbitrationReasonRequired || err == service.ErrInvalidDecision {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if _, ok := err.(*service.TierLimitError); ok || err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrClaimNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrInvalidClaimState {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "dispute resolved"})
+}
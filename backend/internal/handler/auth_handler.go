@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/middleware"
+	"github.com/task-underground/backend/internal/service"
+)
+
+type AuthHandler struct {
+	authSvc  service.AuthService
+	sybilSvc service.SybilService
+}
+
+func NewAuthHandler(authSvc service.AuthService, sybilSvc service.SybilService) *AuthHandler {
+	return &AuthHandler{
+		authSvc:  authSvc,
+		sybilSvc: sybilSvc,
+	}
+}
+
+type HandshakeRequest struct {
+	DeviceID   string `json:"device_id" binding:"required"`
+	DeviceName string `json:"device_name" binding:"max=100"`
+	// PowChallenge and PowNonce are only needed when the device is new
+	PowChallenge string `json:"pow_challenge"`
+	PowNonce     string `json:"pow_nonce"`
+}
+
+func (h *AuthHandler) Handshake(c *gin.Context) {
+	var req HandshakeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.authSvc.Handshake(c.Request.Context(), req.DeviceID, req.DeviceName, domain.AccountProof{
+		Challenge: req.PowChallenge,
+		Nonce:     req.PowNonce,
+		IP:        c.ClientIP(),
+	})
+	if err != nil {
+		if err == service.ErrDeviceRevoked || err == service.ErrUserSuspended {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrProofOfWorkRequired {
+			// Hand out a fresh challenge so the client can solve it and retry
+			challenge, err := h.sybilSvc.Challenge(req.DeviceID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to authenticate"})
+				return
+			}
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": service.ErrProofOfWorkRequired.Error(), "challenge": challenge})
+			return
+		}
+		if err == service.ErrTooManyAccounts {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to authenticate"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+type RecoverRequest struct {
+	RecoverySecret string `json:"recovery_secret" binding:"required"`
+	DeviceID       string `json:"device_id" binding:"required"`
+	DeviceName     string `json:"device_name" binding:"max=100"`
+}
+
+func (h *AuthHandler) Recover(c *gin.Context) {
+	var req RecoverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.authSvc.Recover(c.Request.Context(), req.RecoverySecret, req.DeviceID, req.DeviceName)
+	if err != nil {
+		if err == service.ErrInvalidRecovery {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrUserSuspended {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+type PairRequest struct {
+	Code       string `json:"code" binding:"required"`
+	DeviceID   string `json:"device_id" binding:"required"`
+	DeviceName string `json:"device_name" binding:"max=100"`
+}
+
+func (h *AuthHandler) Pair(c *gin.Context) {
+	var req PairRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.authSvc.Pair(c.Request.Context(), req.Code, req.DeviceID, req.DeviceName)
+	if err != nil {
+		if err == service.ErrInvalidPairingCode {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrUserSuspended {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.authSvc.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if err == service.ErrInvalidRefreshToken {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrUserSuspended {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	sessionID := middleware.GetSessionID(c)
+
+	err := h.authSvc.Logout(c.Request.Context(), sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-underground/backend/internal/middleware"
+	"github.com/task-underground/backend/internal/service"
+)
+
+type BlockHandler struct {
+	blockSvc service.BlockService
+}
+
+func NewBlockHandler(blockSvc service.BlockService) *BlockHandler {
+	return &BlockHandler{blockSvc: blockSvc}
+}
+
+func (h *BlockHandler) Block(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	taskID := c.Param("tid")
+	alias := c.Param("alias")
+
+	block, err := h.blockSvc.Block(c.Request.Context(), userID, parseUUID(taskID), alias)
+	if err != nil {
+		if err == service.ErrTaskNotFound || err == service.ErrParticipantNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrCannotBlockSelf {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, block)
+}
+
+func (h *BlockHandler) ListBlocks(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	blocks, err := h.blockSvc.ListBlocks(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"blocks": blocks})
+}
+
+func (h *BlockHandler) Unblock(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	blockID := c.Param("id")
+
+	err := h.blockSvc.Unblock(c.Request.Context(), userID, parseUUID(blockID))
+	if err != nil {
+		if err == service.ErrBlockNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user unblocked"})
+}
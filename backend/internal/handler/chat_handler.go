@@ -1,11 +1,15 @@
 package handler
 
 import (
+	"errors"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
 	"github.com/task-underground/backend/internal/middleware"
 	"github.com/task-underground/backend/internal/service"
 )
@@ -14,13 +18,15 @@ type ChatHandler struct {
 	chatSvc  service.ChatService
 	taskSvc  service.TaskService
 	claimSvc service.ClaimService
+	aliasSvc service.AliasService
 }
 
-func NewChatHandler(chatSvc service.ChatService, taskSvc service.TaskService, claimSvc service.ClaimService) *ChatHandler {
+func NewChatHandler(chatSvc service.ChatService, taskSvc service.TaskService, claimSvc service.ClaimService, aliasSvc service.AliasService) *ChatHandler {
 	return &ChatHandler{
 		chatSvc:  chatSvc,
 		taskSvc:  taskSvc,
 		claimSvc: claimSvc,
+		aliasSvc: aliasSvc,
 	}
 }
 
@@ -29,46 +35,75 @@ func (h *ChatHandler) GetChats(c *gin.Context) {
 	taskID := c.Param("tid")
 
 	chats, err := h.chatSvc.GetChatsByTaskID(c.Request.Context(), parseUUID(taskID), userID)
+	if err != nil {
+		if err == service.ErrTaskNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		respondChatError(c, err)
+		return
+	}
+
+	views, err := h.aliasSvc.PresentChats(c.Request.Context(), chats, userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"chats": chats})
+	c.JSON(http.StatusOK, gin.H{"chats": views})
 }
 
-func (h *ChatHandler) GetOrCreateChat(c *gin.Context) {
+// GetInbox lists the user's chats across all tasks. Pages follow
+// next_cursor until it is empty.
+func (h *ChatHandler) GetInbox(c *gin.Context) {
 	userID := middleware.GetUserID(c)
-	taskID := parseUUID(c.Param("tid"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	chats, next, err := h.chatSvc.GetInbox(c.Request.Context(), userID, c.Query("cursor"), limit)
+	if err != nil {
+		if err == service.ErrInvalidCursor {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	// Get task to find owner
-	task, err := h.taskSvc.GetTask(c.Request.Context(), taskID)
+	views, err := h.aliasSvc.PresentChats(c.Request.Context(), chats, userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	var otherUserID uuid.UUID
-	if userID == task.OwnerID {
-		// User is owner, need to get claimer ID from query
-		claimerIDStr := c.Query("claimer_id")
-		if claimerIDStr == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "claimer_id required when owner requests chat"})
+	c.JSON(http.StatusOK, gin.H{"chats": views, "next_cursor": next})
+}
+
+func (h *ChatHandler) GetOrCreateChat(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	taskID := c.Param("tid")
+
+	// Owners address a claimer by claim, never by user ID
+	chat, err := h.chatSvc.GetOrCreateChat(c.Request.Context(), parseUUID(taskID), userID, parseUUID(c.Query("claim_id")))
+	if err != nil {
+		if err == service.ErrTaskNotFound || err == service.ErrClaimNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
-		otherUserID = parseUUID(claimerIDStr)
-	} else {
-		// User is claimer, other is owner
-		otherUserID = task.OwnerID
+		if err == service.ErrUnauthorized || err == service.ErrChatUnavailable {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	chat, err := h.chatSvc.GetOrCreateChat(c.Request.Context(), taskID, userID, otherUserID)
+	views, err := h.aliasSvc.PresentChats(c.Request.Context(), []*domain.Chat{chat}, userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, chat)
+	c.JSON(http.StatusOK, views[0])
 }
 
 func (h *ChatHandler) DeleteChat(c *gin.Context) {
@@ -77,50 +112,346 @@ func (h *ChatHandler) DeleteChat(c *gin.Context) {
 
 	err := h.chatSvc.DeleteChat(c.Request.Context(), parseUUID(chatID), userID)
 	if err != nil {
-		if err == service.ErrChatNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondChatError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "chat deleted"})
+}
+
+type SetTimerRequest struct {
+	MessageTTL *int `json:"message_ttl" binding:"required"`
+}
+
+// SetTimer sets the chat's disappearing-messages timer, in seconds; zero
+// turns it off.
+func (h *ChatHandler) SetTimer(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	chatID := c.Param("id")
+
+	var req SetTimerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	chat, err := h.chatSvc.SetTimer(c.Request.Context(), parseUUID(chatID), userID, *req.MessageTTL)
+	if err != nil {
+		if err == service.ErrInvalidTimer || err == service.ErrChatDeleted {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrChatUnavailable {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
+		respondChatError(c, err)
+		return
+	}
+
+	views, err := h.aliasSvc.PresentChats(c.Request.Context(), []*domain.Chat{chat}, userID)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "chat deleted"})
+	c.JSON(http.StatusOK, views[0])
 }
 
+// SendMessageRequest carries either content or, for an end-to-end
+// encrypted message, the ciphertext and its envelopes, base64 encoded.
 type SendMessageRequest struct {
-	Content string `json:"content" binding:"required"`
+	Content    string             `json:"content"`
+	Ciphertext []byte             `json:"ciphertext"`
+	Envelopes  []*domain.Envelope `json:"envelopes"`
 }
 
+// SendMessage takes a JSON body, or a multipart form with a content field
+// and attachments files when sending attachments.
 func (h *ChatHandler) SendMessage(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	chatID := c.Param("id")
 
-	var req SendMessageRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	var content string
+	var uploads []service.AttachmentUpload
+	var sealed *SendMessageRequest
+	if c.ContentType() == "multipart/form-data" {
+		form, err := c.MultipartForm()
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body is too large"})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer form.RemoveAll()
+
+		content = c.PostForm("content")
+		for _, header := range form.File["attachments"] {
+			file, err := header.Open()
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			defer file.Close()
+			uploads = append(uploads, service.AttachmentUpload{Filename: header.Filename, Content: file})
+		}
+	} else {
+		var req SendMessageRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		content = req.Content
+		if req.Ciphertext != nil {
+			sealed = &req
+		}
+	}
+
+	var message *domain.Message
+	var err error
+	if sealed != nil {
+		message, err = h.chatSvc.SendEncryptedMessage(c.Request.Context(), parseUUID(chatID), userID, middleware.GetDeviceID(c), sealed.Ciphertext, sealed.Envelopes)
+	} else {
+		message, err = h.chatSvc.SendMessage(c.Request.Context(), parseUUID(chatID), userID, content, uploads)
+	}
+	if err != nil {
+		if err == service.ErrUnauthorized || err == service.ErrChatUnavailable {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		// The sender should fetch the chat's keys again, or send in the clear
+		// only while the other side has no keys
+		if err == service.ErrEnvelopeMismatch || err == service.ErrNoRecipientKeys || err == service.ErrPlaintextRefused {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrAttachmentTooLarge {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrAttachmentType {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	message, err := h.chatSvc.SendMessage(c.Request.Context(), parseUUID(chatID), userID, req.Content)
+	chat, err := h.chatSvc.GetChat(c.Request.Context(), message.ChatID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	views, err := h.aliasSvc.PresentMessages(c.Request.Context(), chat, []*domain.Message{message}, userID)
 	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, views[0])
+}
+
+type EditMessageRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+func (h *ChatHandler) EditMessage(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req EditMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, message)
+	message, err := h.chatSvc.EditMessage(c.Request.Context(), parseUUID(c.Param("id")), parseUUID(c.Param("mid")), userID, req.Content)
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+
+	h.respondMessage(c, message, userID)
+}
+
+// UnsendMessage removes a message for both participants. The response is
+// the tombstone left in its place.
+func (h *ChatHandler) UnsendMessage(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	message, err := h.chatSvc.UnsendMessage(c.Request.Context(), parseUUID(c.Param("id")), parseUUID(c.Param("mid")), userID)
+	if err != nil {
+		respondMessageError(c, err)
+		return
+	}
+
+	h.respondMessage(c, message, userID)
+}
+
+func (h *ChatHandler) respondMessage(c *gin.Context, message *domain.Message, userID uuid.UUID) {
+	chat, err := h.chatSvc.GetChat(c.Request.Context(), message.ChatID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	views, err := h.aliasSvc.PresentMessages(c.Request.Context(), chat, []*domain.Message{message}, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, views[0])
+}
+
+// GetAttachment downloads an attachment. Images are shown inline; anything
+// else is offered as a download.
+func (h *ChatHandler) GetAttachment(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	attachment, file, err := h.chatSvc.OpenAttachment(c.Request.Context(), parseUUID(c.Param("id")), parseUUID(c.Param("aid")), userID)
+	if err != nil {
+		if err == service.ErrAttachmentNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		respondChatError(c, err)
+		return
+	}
+	defer file.Close()
+
+	disposition := "attachment"
+	if attachment.Kind == domain.AttachmentImage {
+		disposition = "inline"
+	}
+	contentType := attachment.MimeType
+	if strings.HasPrefix(contentType, "text/") {
+		contentType += "; charset=utf-8"
+	}
+
+	if attachment.Filename != "" {
+		disposition = mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename})
+	}
+
+	c.DataFromReader(http.StatusOK, attachment.Size, contentType, file, map[string]string{
+		"Content-Disposition":    disposition,
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, max-age=86400",
+	})
+}
+
+func respondMessageError(c *gin.Context, err error) {
+	if err == service.ErrMessageNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err == service.ErrChatUnavailable {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err == service.ErrMessageDeleted || err == service.ErrEditWindowOver || err == service.ErrMessageEncrypted || err == service.ErrPlaintextRefused || err == service.ErrChatDeleted {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err == service.ErrMessageEmpty {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	respondChatError(c, err)
 }
 
 func (h *ChatHandler) GetMessages(c *gin.Context) {
+	userID := middleware.GetUserID(c)
 	chatID := c.Param("id")
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	messages, err := h.chatSvc.GetMessages(c.Request.Context(), parseUUID(chatID), limit, offset)
+	chat, err := h.chatSvc.GetChat(c.Request.Context(), parseUUID(chatID), userID)
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	messages, err := h.chatSvc.GetMessages(c.Request.Context(), chat.ID, userID, limit, offset)
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	views, err := h.aliasSvc.PresentMessages(c.Request.Context(), chat, messages, userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"messages": messages})
+	c.JSON(http.StatusOK, gin.H{"messages": views})
+}
+
+type MarkReadRequest struct {
+	MessageID string `json:"message_id"`
+}
+
+// MarkRead records how far the user has read a chat. Without a message_id
+// the whole chat is read.
+func (h *ChatHandler) MarkRead(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	chatID := c.Param("id")
+
+	var req MarkReadRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var messageID uuid.UUID
+	if req.MessageID != "" {
+		var err error
+		if messageID, err = uuid.Parse(req.MessageID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid message_id"})
+			return
+		}
+	}
+
+	err := h.chatSvc.MarkRead(c.Request.Context(), parseUUID(chatID), userID, messageID)
+	if err != nil {
+		if err == service.ErrMessageNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		respondChatError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "chat read"})
+}
+
+// GetUnreadCount returns the unread messages across all of the user's
+// chats, for the app icon badge.
+func (h *ChatHandler) GetUnreadCount(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	unread, err := h.chatSvc.UnreadCount(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread": unread})
+}
+
+func respondChatError(c *gin.Context, err error) {
+	if err == service.ErrChatNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err == service.ErrUnauthorized {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 }
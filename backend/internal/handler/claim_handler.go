@@ -4,16 +4,31 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/task-underground/backend/internal/domain"
 	"github.com/task-underground/backend/internal/middleware"
 	"github.com/task-underground/backend/internal/service"
 )
 
 type ClaimHandler struct {
 	claimSvc service.ClaimService
+	aliasSvc service.AliasService
 }
 
-func NewClaimHandler(claimSvc service.ClaimService) *ClaimHandler {
-	return &ClaimHandler{claimSvc: claimSvc}
+func NewClaimHandler(claimSvc service.ClaimService, aliasSvc service.AliasService) *ClaimHandler {
+	return &ClaimHandler{
+		claimSvc: claimSvc,
+		aliasSvc: aliasSvc,
+	}
+}
+
+func (h *ClaimHandler) respondClaim(c *gin.Context, status int, claim *domain.Claim) {
+	views, err := h.aliasSvc.PresentClaims(c.Request.Context(), []*domain.Claim{claim}, middleware.GetUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(status, views[0])
 }
 
 func (h *ClaimHandler) ClaimTask(c *gin.Context) {
@@ -22,7 +37,11 @@ func (h *ClaimHandler) ClaimTask(c *gin.Context) {
 
 	claim, err := h.claimSvc.ClaimTask(c.Request.Context(), parseUUID(taskID), userID)
 	if err != nil {
-		if err == service.ErrTaskNotFound || err == service.ErrTaskNotClaimable || err == service.ErrClaimLimitReached || err == service.ErrAlreadyClaimed {
+		if _, ok := err.(*service.TierLimitError); ok || err == service.ErrCannotClaimOwnTask {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrTaskNotFound || err == service.ErrTaskNotClaimable || err == service.ErrClaimLimitReached || err == service.ErrAlreadyClaimed || err == service.ErrProbationClaimLimit || err == service.ErrProbationRewardLimit {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
@@ -30,35 +49,51 @@ func (h *ClaimHandler) ClaimTask(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, claim)
+	h.respondClaim(c, http.StatusCreated, claim)
 }
 
 func (h *ClaimHandler) GetClaim(c *gin.Context) {
+	userID := middleware.GetUserID(c)
 	claimID := c.Param("id")
 
-	claim, err := h.claimSvc.GetClaim(c.Request.Context(), parseUUID(claimID))
+	claim, err := h.claimSvc.GetClaim(c.Request.Context(), parseUUID(claimID), userID)
 	if err != nil {
 		if err == service.ErrClaimNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, claim)
+	h.respondClaim(c, http.StatusOK, claim)
 }
 
 func (h *ClaimHandler) GetClaimsByTask(c *gin.Context) {
+	userID := middleware.GetUserID(c)
 	taskID := c.Param("tid")
 
-	claims, err := h.claimSvc.GetClaimsByTaskID(c.Request.Context(), parseUUID(taskID))
+	claims, err := h.claimSvc.GetClaimsByTaskID(c.Request.Context(), parseUUID(taskID), userID)
+	if err != nil {
+		if err == service.ErrTaskNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	views, err := h.aliasSvc.PresentClaims(c.Request.Context(), claims, userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"claims": claims})
+	c.JSON(http.StatusOK, gin.H{"claims": views})
 }
 
 type SubmitCompletionRequest struct {
@@ -78,15 +113,49 @@ func (h *ClaimHandler) SubmitCompletion(c *gin.Context) {
 
 	claim, err := h.claimSvc.SubmitCompletion(c.Request.Context(), parseUUID(claimID), userID, req.Text, req.ImageURL)
 	if err != nil {
-		if err == service.ErrClaimNotFound || err == service.ErrUnauthorized {
+		if err == service.ErrClaimNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrInvalidClaimState {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respondClaim(c, http.StatusOK, claim)
+}
+
+// AcceptApplication takes an applicant on an application-mode task.
+func (h *ClaimHandler) AcceptApplication(c *gin.Context) {
+	ownerID := middleware.GetUserID(c)
+	claimID := c.Param("id")
+
+	claim, err := h.claimSvc.AcceptApplication(c.Request.Context(), parseUUID(claimID), ownerID)
+	if err != nil {
+		if err == service.ErrClaimNotFound || err == service.ErrTaskNotClaimable || err == service.ErrClaimLimitReached {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrInvalidClaimState {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, claim)
+	h.respondClaim(c, http.StatusOK, claim)
 }
 
 func (h *ClaimHandler) ApproveClaim(c *gin.Context) {
@@ -95,10 +164,18 @@ func (h *ClaimHandler) ApproveClaim(c *gin.Context) {
 
 	err := h.claimSvc.ApproveClaim(c.Request.Context(), parseUUID(claimID), ownerID)
 	if err != nil {
-		if err == service.ErrClaimNotFound || err == service.ErrUnauthorized {
+		if err == service.ErrClaimNotFound {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrInvalidClaimState {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -112,13 +189,81 @@ func (h *ClaimHandler) RejectClaim(c *gin.Context) {
 
 	err := h.claimSvc.RejectClaim(c.Request.Context(), parseUUID(claimID), ownerID)
 	if err != nil {
-		if err == service.ErrClaimNotFound || err == service.ErrUnauthorized {
+		if err == service.ErrClaimNotFound {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrInvalidClaimState {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "claim rejected"})
 }
+
+func (h *ClaimHandler) WithdrawClaim(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	claimID := c.Param("id")
+
+	err := h.claimSvc.WithdrawClaim(c.Request.Context(), parseUUID(claimID), userID)
+	if err != nil {
+		if err == service.ErrClaimNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrInvalidClaimState {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "claim withdrawn"})
+}
+
+type DisputeClaimRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+func (h *ClaimHandler) DisputeClaim(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	claimID := c.Param("id")
+
+	var req DisputeClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claim, err := h.claimSvc.DisputeClaim(c.Request.Context(), parseUUID(claimID), userID, req.Reason)
+	if err != nil {
+		if err == service.ErrClaimNotFound || err == service.ErrDisputeReasonRequired {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrInvalidClaimState {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.respondClaim(c, http.StatusOK, claim)
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-underground/backend/internal/middleware"
+	"github.com/task-underground/backend/internal/service"
+)
+
+type DeviceHandler struct {
+	deviceSvc service.DeviceService
+}
+
+func NewDeviceHandler(deviceSvc service.DeviceService) *DeviceHandler {
+	return &DeviceHandler{deviceSvc: deviceSvc}
+}
+
+func (h *DeviceHandler) ListDevices(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	devices, err := h.deviceSvc.ListDevices(c.Request.Context(), userID, middleware.GetDeviceID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"devices": devices})
+}
+
+func (h *DeviceHandler) RevokeDevice(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	deviceID := c.Param("id")
+
+	err := h.deviceSvc.RevokeDevice(c.Request.Context(), userID, parseUUID(deviceID))
+	if err != nil {
+		if err == service.ErrDeviceNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "device revoked"})
+}
+
+func (h *DeviceHandler) CreatePairingCode(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	code, err := h.deviceSvc.CreatePairingCode(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, code)
+}
+
+func (h *DeviceHandler) SetupRecovery(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	secret, err := h.deviceSvc.SetupRecovery(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"recovery_secret": secret})
+}
+
+func (h *DeviceHandler) DisableRecovery(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	err := h.deviceSvc.DisableRecovery(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "recovery disabled"})
+}
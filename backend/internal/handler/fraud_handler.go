@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/middleware"
+	"github.com/task-underground/backend/internal/service"
+)
+
+type FraudHandler struct {
+	fraudSvc service.FraudService
+	claimSvc service.ClaimService
+}
+
+func NewFraudHandler(fraudSvc service.FraudService, claimSvc service.ClaimService) *FraudHandler {
+	return &FraudHandler{fraudSvc: fraudSvc, claimSvc: claimSvc}
+}
+
+func (h *FraudHandler) GetFlags(c *gin.Context) {
+	status := c.DefaultQuery("status", string(domain.FraudFlagOpen))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	flags, err := h.fraudSvc.ListFlags(c.Request.Context(), domain.FraudFlagStatus(status), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if flags == nil {
+		flags = []*domain.FraudFlag{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"flags": flags})
+}
+
+type ReviewFraudFlagRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// ReviewFlag clears or confirms a flagged claim, paying out or refunding its
+// held reward.
+func (h *FraudHandler) ReviewFlag(c *gin.Context) {
+	claimID := c.Param("id")
+
+	var req ReviewFraudFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.claimSvc.SettleFlaggedClaim(c.Request.Context(), parseUUID(claimID), domain.FraudFlagStatus(req.Status), middleware.GetModerator(c))
+	if err != nil {
+		if err == service.ErrInvalidFraudReview {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrFraudFlagNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrFraudFlagReviewed {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "flag reviewed"})
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/middleware"
+	"github.com/task-underground/backend/internal/service"
+)
+
+type KeyHandler struct {
+	keySvc service.KeyService
+}
+
+func NewKeyHandler(keySvc service.KeyService) *KeyHandler {
+	return &KeyHandler{keySvc: keySvc}
+}
+
+// Keys are base64 in JSON.
+type PublishKeysRequest struct {
+	IdentityKey    []byte              `json:"identity_key" binding:"required"`
+	SignedPrekey   domain.SignedPrekey `json:"signed_prekey" binding:"required"`
+	OneTimePrekeys []domain.Prekey     `json:"one_time_prekeys"`
+}
+
+// PublishKeys publishes the keys the device making the request uses in the
+// chat.
+func (h *KeyHandler) PublishKeys(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	chatID := c.Param("id")
+
+	var req PublishKeysRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := h.keySvc.PublishKeys(c.Request.Context(), parseUUID(chatID), userID, middleware.GetDeviceID(c), service.KeyUpload{
+		IdentityKey:    req.IdentityKey,
+		SignedPrekey:   req.SignedPrekey,
+		OneTimePrekeys: req.OneTimePrekeys,
+	})
+	if err != nil {
+		if err == service.ErrInvalidKeys || err == service.ErrTooManyPrekeys {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		respondChatError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+func (h *KeyHandler) GetKeyStatus(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	chatID := c.Param("id")
+
+	status, err := h.keySvc.GetKeyStatus(c.Request.Context(), parseUUID(chatID), userID, middleware.GetDeviceID(c))
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// TakeChatKeys returns the bundles of the devices a message to the chat must
+// be sealed for. Each call uses up one-time prekeys, which is why it is a
+// POST, so clients should keep the sessions they start rather than taking
+// keys for every message.
+func (h *KeyHandler) TakeChatKeys(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	chatID := c.Param("id")
+
+	bundles, err := h.keySvc.TakeChatKeys(c.Request.Context(), parseUUID(chatID), userID, middleware.GetDeviceID(c))
+	if err != nil {
+		respondChatError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"devices": bundles})
+}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/middleware"
+	"github.com/task-underground/backend/internal/service"
+)
+
+type ModerationHandler struct {
+	moderationSvc service.ModerationService
+}
+
+func NewModerationHandler(moderationSvc service.ModerationService) *ModerationHandler {
+	return &ModerationHandler{moderationSvc: moderationSvc}
+}
+
+type CreateReportRequest struct {
+	TargetType string `json:"target_type" binding:"required"`
+	TargetID   string `json:"target_id"`
+	TaskID     string `json:"task_id"`
+	Alias      string `json:"alias"`
+	Category   string `json:"category" binding:"required"`
+	Note       string `json:"note"`
+	// MessageKey, base64, discloses an encrypted message's content
+	MessageKey []byte `json:"message_key"`
+}
+
+func (h *ModerationHandler) CreateReport(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req CreateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.moderationSvc.Report(c.Request.Context(), userID, service.ReportInput{
+		TargetType: domain.ReportTargetType(req.TargetType),
+		TargetID:   parseUUID(req.TargetID),
+		TaskID:     parseUUID(req.TaskID),
+		Alias:      req.Alias,
+		Category:   domain.ReportCategory(req.Category),
+		Note:       req.Note,
+		MessageKey: req.MessageKey,
+	})
+	if err != nil {
+		if err == service.ErrInvalidReport || err == service.ErrReportNoteTooLong || err == service.ErrCannotReportSelf || err == service.ErrInvalidDisclosure {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrReportTargetNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, report)
+}
+
+func (h *ModerationHandler) GetQueue(c *gin.Context) {
+	status := c.DefaultQuery("status", string(domain.ReportStatusOpen))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	items, err := h.moderationSvc.GetQueue(c.Request.Context(), domain.ReportStatus(status), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+// reportView exposes the target to moderators, which the public Report JSON
+// deliberately leaves out.
+type reportView struct {
+	*domain.Report
+	TargetID string `json:"target_id"`
+}
+
+func (h *ModerationHandler) GetReports(c *gin.Context) {
+	targetType := c.Param("type")
+	targetID := c.Param("id")
+
+	reports, err := h.moderationSvc.GetReports(c.Request.Context(), domain.ReportTargetType(targetType), parseUUID(targetID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	views := make([]reportView, 0, len(reports))
+	for _, report := range reports {
+		views = append(views, reportView{Report: report, TargetID: report.TargetID.String()})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reports": views})
+}
+
+// GetMessage shows a message with its full content and edit history.
+func (h *ModerationHandler) GetMessage(c *gin.Context) {
+	message, edits, err := h.moderationSvc.GetMessage(c.Request.Context(), parseUUID(c.Param("id")))
+	if err != nil {
+		if err == service.ErrReportTargetNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if edits == nil {
+		edits = []*domain.MessageEdit{}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "edits": edits})
+}
+
+type ModerationActionRequest struct {
+	TargetType   string `json:"target_type" binding:"required"`
+	TargetID     string `json:"target_id" binding:"required"`
+	Action       string `json:"action" binding:"required"`
+	Reason       string `json:"reason"`
+	SuspendUntil string `json:"suspend_until"`
+}
+
+func (h *ModerationHandler) TakeAction(c *gin.Context) {
+	var req ModerationActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	input := service.ModerationActionInput{
+		TargetType: domain.ReportTargetType(req.TargetType),
+		TargetID:   parseUUID(req.TargetID),
+		Action:     domain.ModerationActionType(req.Action),
+		Reason:     req.Reason,
+		Moderator:  middleware.GetModerator(c),
+	}
+	if req.SuspendUntil != "" {
+		until, err := parseTime(req.SuspendUntil)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid suspend_until format"})
+			return
+		}
+		input.SuspendUntil = &until
+	}
+
+	action, err := h.moderationSvc.TakeAction(c.Request.Context(), input)
+	if err != nil {
+		if err == service.ErrInvalidModerationAction || err == service.ErrSuspensionReasonRequired || err == service.ErrInvalidSuspension {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrReportTargetNotFound || err == service.ErrTaskNotFound || err == service.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, action)
+}
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason" binding:"required"`
+	Until  string `json:"until"`
+}
+
+// SuspendUser suspends a user directly; leaving out until makes it
+// permanent.
+func (h *ModerationHandler) SuspendUser(c *gin.Context) {
+	userID := c.Param("id")
+
+	var req SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	input := service.ModerationActionInput{
+		TargetType: domain.ReportTargetUser,
+		TargetID:   parseUUID(userID),
+		Action:     domain.ModerationSuspendUser,
+		Reason:     req.Reason,
+		Moderator:  middleware.GetModerator(c),
+	}
+	if req.Until != "" {
+		until, err := parseTime(req.Until)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid until format"})
+			return
+		}
+		input.SuspendUntil = &until
+	}
+
+	h.takeUserAction(c, input)
+}
+
+type UnsuspendUserRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+func (h *ModerationHandler) UnsuspendUser(c *gin.Context) {
+	userID := c.Param("id")
+
+	var req UnsuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.takeUserAction(c, service.ModerationActionInput{
+		TargetType: domain.ReportTargetUser,
+		TargetID:   parseUUID(userID),
+		Action:     domain.ModerationUnsuspendUser,
+		Reason:     req.Reason,
+		Moderator:  middleware.GetModerator(c),
+	})
+}
+
+func (h *ModerationHandler) takeUserAction(c *gin.Context, input service.ModerationActionInput) {
+	action, err := h.moderationSvc.TakeAction(c.Request.Context(), input)
+	if err != nil {
+		if err == service.ErrSuspensionReasonRequired || err == service.ErrInvalidSuspension {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrReportTargetNotFound || err == service.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, action)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-underground/backend/internal/middleware"
+	"github.com/task-underground/backend/internal/service"
+)
+
+type PrivacyHandler struct {
+	privacySvc service.PrivacyService
+}
+
+func NewPrivacyHandler(privacySvc service.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{privacySvc: privacySvc}
+}
+
+func (h *PrivacyHandler) Export(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	export, err := h.privacySvc.Export(c.Request.Context(), userID)
+	if err != nil {
+		if err == service.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("task-underground-export-%s.json", export.ExportedAt.Format("2006-01-02"))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.JSON(http.StatusOK, export)
+}
+
+func (h *PrivacyHandler) Erase(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	err := h.privacySvc.Erase(c.Request.Context(), userID)
+	if err != nil {
+		if err == service.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrErasureEscrowLocked || err == service.ErrErasureDisputeOpen {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account erased"})
+}
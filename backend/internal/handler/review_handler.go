@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-underground/backend/internal/middleware"
+	"github.com/task-underground/backend/internal/service"
+)
+
+type ReviewHandler struct {
+	reviewSvc service.ReviewService
+}
+
+func NewReviewHandler(reviewSvc service.ReviewService) *ReviewHandler {
+	return &ReviewHandler{reviewSvc: reviewSvc}
+}
+
+type SubmitReviewRequest struct {
+	Rating  int      `json:"rating" binding:"required"`
+	Tags    []string `json:"tags"`
+	Comment string   `json:"comment"`
+}
+
+func (h *ReviewHandler) SubmitReview(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	claimID := c.Param("id")
+
+	var req SubmitReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	thread, err := h.reviewSvc.SubmitReview(c.Request.Context(), parseUUID(claimID), userID, service.ReviewInput{
+		Rating:  req.Rating,
+		Tags:    req.Tags,
+		Comment: req.Comment,
+	})
+	if err != nil {
+		if err == service.ErrInvalidRating || err == service.ErrInvalidReviewTag || err == service.ErrTooManyReviewTags || err == service.ErrReviewCommentLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrClaimNotFound || err == service.ErrTaskNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrClaimNotDecided || err == service.ErrReviewWindowClosed || err == service.ErrAlreadyReviewed {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, thread)
+}
+
+func (h *ReviewHandler) GetReviews(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	claimID := c.Param("id")
+
+	thread, err := h.reviewSvc.GetReviews(c.Request.Context(), parseUUID(claimID), userID)
+	if err != nil {
+		if err == service.ErrClaimNotFound || err == service.ErrTaskNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrUnauthorized {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err == service.ErrClaimNotDecided {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, thread)
+}
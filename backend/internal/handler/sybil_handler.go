@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/service"
+)
+
+type SybilHandler struct {
+	sybilSvc service.SybilService
+}
+
+func NewSybilHandler(sybilSvc service.SybilService) *SybilHandler {
+	return &SybilHandler{sybilSvc: sybilSvc}
+}
+
+func (h *SybilHandler) GetClusters(c *gin.Context) {
+	clusters, err := h.sybilSvc.FindClusters(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if clusters == nil {
+		clusters = []*domain.SybilCluster{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"clusters": clusters})
+}
@@ -5,25 +5,31 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/task-underground/backend/internal/domain"
 	"github.com/task-underground/backend/internal/middleware"
 	"github.com/task-underground/backend/internal/service"
 )
 
 type TaskHandler struct {
-	taskSvc service.TaskService
+	taskSvc  service.TaskService
+	aliasSvc service.AliasService
 }
 
-func NewTaskHandler(taskSvc service.TaskService) *TaskHandler {
-	return &TaskHandler{taskSvc: taskSvc}
+func NewTaskHandler(taskSvc service.TaskService, aliasSvc service.AliasService) *TaskHandler {
+	return &TaskHandler{
+		taskSvc:  taskSvc,
+		aliasSvc: aliasSvc,
+	}
 }
 
 type CreateTaskRequest struct {
-	Title         string  `json:"title" binding:"required"`
-	Description   string  `json:"description" binding:"required"`
-	RewardAmount  float64 `json:"reward_amount" binding:"required,gt=0"`
-	MaxClaimants  int     `json:"max_claimants" binding:"required,gt=0"`
-	ClaimDeadline string  `json:"claim_deadline" binding:"required"`
-	OwnerDeadline string  `json:"owner_deadline" binding:"required"`
+	Title           string  `json:"title" binding:"required"`
+	Description     string  `json:"description" binding:"required"`
+	RewardAmount    float64 `json:"reward_amount" binding:"required,gt=0"`
+	MaxClaimants    int     `json:"max_claimants" binding:"required,gt=0"`
+	ClaimDeadline   string  `json:"claim_deadline" binding:"required"`
+	OwnerDeadline   string  `json:"owner_deadline" binding:"required"`
+	ApplicationMode bool    `json:"application_mode"`
 }
 
 func (h *TaskHandler) CreateTask(c *gin.Context) {
@@ -48,31 +54,43 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 	}
 
 	svcReq := service.CreateTaskRequest{
-		Title:         req.Title,
-		Description:   req.Description,
-		RewardAmount:  req.RewardAmount,
-		MaxClaimants:  req.MaxClaimants,
-		ClaimDeadline: claimDeadline,
-		OwnerDeadline: ownerDeadline,
+		Title:           req.Title,
+		Description:     req.Description,
+		RewardAmount:    req.RewardAmount,
+		MaxClaimants:    req.MaxClaimants,
+		ClaimDeadline:   claimDeadline,
+		OwnerDeadline:   ownerDeadline,
+		ApplicationMode: req.ApplicationMode,
 	}
 
 	task, err := h.taskSvc.CreateTask(c.Request.Context(), userID, svcReq)
 	if err != nil {
+		if _, ok := err.(*service.TierLimitError); ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, task)
+	views, err := h.aliasSvc.PresentTasks(c.Request.Context(), []*domain.Task{task}, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, views[0])
 }
 
 func (h *TaskHandler) GetTask(c *gin.Context) {
+	userID := middleware.GetUserID(c)
 	taskID := c.Param("id")
 	if taskID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "task id required"})
 		return
 	}
 
-	task, err := h.taskSvc.GetTask(c.Request.Context(), parseUUID(taskID))
+	task, err := h.taskSvc.GetTask(c.Request.Context(), parseUUID(taskID), userID)
 	if err != nil {
 		if err == service.ErrTaskNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -82,20 +100,33 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, task)
+	views, err := h.aliasSvc.PresentTasks(c.Request.Context(), []*domain.Task{task}, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, views[0])
 }
 
 func (h *TaskHandler) GetOpenTasks(c *gin.Context) {
+	userID := middleware.GetUserID(c)
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
-	tasks, err := h.taskSvc.GetOpenTasks(c.Request.Context(), limit, offset)
+	tasks, err := h.taskSvc.GetOpenTasks(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	views, err := h.aliasSvc.PresentTasks(c.Request.Context(), tasks, userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"tasks": tasks})
+	c.JSON(http.StatusOK, gin.H{"tasks": views})
 }
 
 func (h *TaskHandler) GetUserTasks(c *gin.Context) {
@@ -109,5 +140,11 @@ func (h *TaskHandler) GetUserTasks(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"tasks": tasks})
+	views, err := h.aliasSvc.PresentTasks(c.Request.Context(), tasks, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tasks": views})
 }
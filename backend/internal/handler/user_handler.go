@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-underground/backend/internal/middleware"
+	"github.com/task-underground/backend/internal/service"
+)
+
+type UserHandler struct {
+	userSvc service.UserService
+}
+
+func NewUserHandler(userSvc service.UserService) *UserHandler {
+	return &UserHandler{userSvc: userSvc}
+}
+
+func (h *UserHandler) GetMe(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	profile, err := h.userSvc.GetProfile(c.Request.Context(), userID)
+	if err != nil {
+		if err == service.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *UserHandler) GetParticipantProfile(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	taskID := c.Param("tid")
+	alias := c.Param("alias")
+
+	profile, err := h.userSvc.GetPublicProfile(c.Request.Context(), parseUUID(taskID), alias, userID)
+	if err != nil {
+		if err == service.ErrTaskNotFound || err == service.ErrParticipantNotFound || err == service.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
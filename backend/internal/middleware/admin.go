@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/service"
+)
+
+const (
+	AdminKey     = "admin"
+	ModeratorKey = "moderator"
+
+	// AdminKeyHeader carries an admin's own API key. Device credentials are
+	// never accepted on the admin endpoints.
+	AdminKeyHeader = "X-Admin-Key"
+)
+
+// AdminMiddleware authenticates an admin by API key. Which endpoints the
+// admin may then use is decided by RequirePermission.
+func AdminMiddleware(adminSvc service.AdminService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		admin, err := adminSvc.Authenticate(c.Request.Context(), c.GetHeader(AdminKeyHeader))
+		if err != nil {
+			if err == service.ErrInvalidAdminKey {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "admin key required"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			c.Abort()
+			return
+		}
+
+		c.Set(AdminKey, admin)
+		c.Set(ModeratorKey, admin.Name)
+		c.Next()
+	}
+}
+
+// RequirePermission refuses admins whose role does not grant permission.
+func RequirePermission(permission domain.AdminPermission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		admin := GetAdmin(c)
+		if admin == nil || !admin.Can(permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func GetAdmin(c *gin.Context) *domain.Admin {
+	admin, exists := c.Get(AdminKey)
+	if !exists {
+		return nil
+	}
+	return admin.(*domain.Admin)
+}
+
+func GetModerator(c *gin.Context) string {
+	return c.GetString(ModeratorKey)
+}
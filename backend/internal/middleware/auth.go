@@ -2,34 +2,103 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/task-underground/backend/internal/service"
 )
 
-const UserIDKey = "user_id"
+const (
+	UserIDKey    = "user_id"
+	SessionIDKey = "session_id"
+	DeviceIDKey  = "device_id"
+	ExpiresAtKey = "token_expires_at"
 
-func AuthMiddleware(userSvc service.UserService) gin.HandlerFunc {
+	// WebSocketTokenProtocol is the subprotocol a browser offers alongside the
+	// access token, since it cannot set an Authorization header on upgrade.
+	WebSocketTokenProtocol = "bearer"
+)
+
+// AuthMiddleware validates the bearer access token statelessly.
+func AuthMiddleware(authSvc service.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		deviceID := c.GetHeader("X-Device-ID")
-		if deviceID == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "X-Device-ID header required"})
-			c.Abort()
-			return
+		authenticate(c, authSvc, bearerToken(c))
+	}
+}
+
+// WebSocketAuthMiddleware also accepts the access token from the
+// Sec-WebSocket-Protocol header. It is never read from the URL, which ends
+// up in access logs.
+func WebSocketAuthMiddleware(authSvc service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := bearerToken(c)
+		if token == "" {
+			token = subprotocolToken(c)
 		}
+		authenticate(c, authSvc, token)
+	}
+}
+
+func authenticate(c *gin.Context, authSvc service.AuthService, token string) {
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access token required"})
+		c.Abort()
+		return
+	}
+
+	claims, err := authSvc.ValidateAccessToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Abort()
+		return
+	}
 
-		user, err := userSvc.GetOrCreateUser(c.Request.Context(), deviceID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to authenticate"})
-			c.Abort()
-			return
+	// Suspended users keep failing here even if a token outlives the
+	// revocation of their sessions
+	err = authSvc.CheckUser(c.Request.Context(), claims.UserID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == service.ErrUserSuspended {
+			status = http.StatusForbidden
 		}
+		if err == service.ErrInvalidToken {
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		c.Abort()
+		return
+	}
+
+	c.Set(UserIDKey, claims.UserID)
+	c.Set(SessionIDKey, claims.SessionID)
+	c.Set(DeviceIDKey, claims.DeviceID)
+	c.Set(ExpiresAtKey, time.Unix(claims.ExpiresAt, 0))
+	c.Next()
+}
+
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
 
-		c.Set(UserIDKey, user.ID)
-		c.Set("user", user)
-		c.Next()
+func subprotocolToken(c *gin.Context) string {
+	var protocols []string
+	for _, header := range c.Request.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(header, ",") {
+			protocols = append(protocols, strings.TrimSpace(p))
+		}
 	}
+	for i, p := range protocols {
+		if p == WebSocketTokenProtocol && i+1 < len(protocols) {
+			return protocols[i+1]
+		}
+	}
+	return ""
 }
 
 func GetUserID(c *gin.Context) uuid.UUID {
@@ -39,3 +108,28 @@ func GetUserID(c *gin.Context) uuid.UUID {
 	}
 	return userID.(uuid.UUID)
 }
+
+func GetSessionID(c *gin.Context) uuid.UUID {
+	sessionID, exists := c.Get(SessionIDKey)
+	if !exists {
+		return uuid.Nil
+	}
+	return sessionID.(uuid.UUID)
+}
+
+func GetDeviceID(c *gin.Context) uuid.UUID {
+	deviceID, exists := c.Get(DeviceIDKey)
+	if !exists {
+		return uuid.Nil
+	}
+	return deviceID.(uuid.UUID)
+}
+
+// GetExpiresAt returns when the request's access token expires.
+func GetExpiresAt(c *gin.Context) time.Time {
+	expiresAt, exists := c.Get(ExpiresAtKey)
+	if !exists {
+		return time.Time{}
+	}
+	return expiresAt.(time.Time)
+}
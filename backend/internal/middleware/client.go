@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/service"
+)
+
+// TrustedProxies parses a comma-separated list of proxy addresses or CIDR
+// ranges for gin's SetTrustedProxies. Only requests arriving from one of
+// them may set the client address with X-Forwarded-For; an empty list
+// trusts no proxy, so the client address is always the peer's.
+func TrustedProxies(list string) []string {
+	var proxies []string
+	for _, proxy := range strings.Split(list, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// ClientInfo puts the client address and user agent on the request context,
+// where the fraud checks read them.
+func ClientInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := service.WithClientInfo(c.Request.Context(), domain.ClientInfo{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
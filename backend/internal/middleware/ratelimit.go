@@ -0,0 +1,254 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/repository"
+	"golang.org/x/time/rate"
+)
+
+// RouteClass groups routes that share a rate limit budget.
+type RouteClass string
+
+const (
+	RouteClassRead       RouteClass = "read"
+	RouteClassWrite      RouteClass = "write"
+	RouteClassTaskCreate RouteClass = "task_create"
+	RouteClassClaim      RouteClass = "claim"
+	RouteClassMessage    RouteClass = "message"
+	RouteClassKeys       RouteClass = "keys"
+)
+
+// RateLimit allows Requests requests per Window.
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// DefaultIPRateLimit caps everything coming from one address, signed in or
+// not, so unauthenticated routes are covered too.
+var DefaultIPRateLimit = RateLimit{Requests: 600, Window: time.Minute}
+
+// DefaultRouteRateLimits are the per-user budgets for each route class.
+var DefaultRouteRateLimits = map[RouteClass]RateLimit{
+	RouteClassRead:       {Requests: 120, Window: time.Minute},
+	RouteClassWrite:      {Requests: 60, Window: time.Minute},
+	RouteClassTaskCreate: {Requests: 10, Window: time.Minute},
+	RouteClassClaim:      {Requests: 30, Window: time.Minute},
+	RouteClassMessage:    {Requests: 60, Window: time.Minute},
+	RouteClassKeys:       {Requests: 10, Window: time.Minute},
+}
+
+// RateLimitResult is the outcome of counting one request. Reset is how long
+// until the key has its full budget again, or, when the request was refused,
+// until the next request will be allowed.
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Duration
+}
+
+// RateLimitStore keeps the counters behind the limits. Sweep forgets keys
+// that have not been used for idle.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+	Sweep(ctx context.Context, idle time.Duration) error
+}
+
+type RateLimiter struct {
+	store       RateLimitStore
+	ipLimit     RateLimit
+	routeLimits map[RouteClass]RateLimit
+}
+
+func NewRateLimiter(store RateLimitStore, ipLimit RateLimit, routeLimits map[RouteClass]RateLimit) *RateLimiter {
+	return &RateLimiter{
+		store:       store,
+		ipLimit:     ipLimit,
+		routeLimits: routeLimits,
+	}
+}
+
+// ByIP limits requests per client address.
+func (l *RateLimiter) ByIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l.limit(c, "ip:"+c.ClientIP(), l.ipLimit)
+	}
+}
+
+// ByUser limits requests per user and route class. It must run after
+// AuthMiddleware; the user's devices share one budget.
+func (l *RateLimiter) ByUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		class := ClassifyRoute(c.Request.Method, c.FullPath())
+		limit, ok := l.routeLimits[class]
+		if !ok {
+			c.Next()
+			return
+		}
+		l.limit(c, userRateLimitKey(GetUserID(c), class), limit)
+	}
+}
+
+// TakeUser counts one request by userID against the budget of class, for
+// requests that do not come in over an HTTP route, such as WebSocket
+// commands. They share the budget with the routes of the same class.
+// Classes without a limit are always allowed.
+func (l *RateLimiter) TakeUser(ctx context.Context, userID uuid.UUID, class RouteClass) (RateLimitResult, error) {
+	limit, ok := l.routeLimits[class]
+	if !ok {
+		return RateLimitResult{Allowed: true}, nil
+	}
+	return l.store.Take(ctx, userRateLimitKey(userID, class), limit)
+}
+
+func userRateLimitKey(userID uuid.UUID, class RouteClass) string {
+	return "user:" + userID.String() + ":" + string(class)
+}
+
+func (l *RateLimiter) limit(c *gin.Context, key string, limit RateLimit) {
+	result, err := l.store.Take(c.Request.Context(), key, limit)
+	if err != nil {
+		// A broken store should not take the API down with it
+		log.Printf("Error checking rate limit for %s: %v", key, err)
+		c.Next()
+		return
+	}
+
+	reset := strconv.Itoa(int(math.Ceil(result.Reset.Seconds())))
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", reset)
+
+	if !result.Allowed {
+		c.Header("Retry-After", reset)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// ClassifyRoute maps a method and route pattern to its route class.
+func ClassifyRoute(method, path string) RouteClass {
+	if method == http.MethodGet || method == http.MethodHead {
+		return RouteClassRead
+	}
+	switch {
+	case method == http.MethodPost && path == "/api/v1/tasks":
+		return RouteClassTaskCreate
+	case path == "/api/v1/chats/:id/messages":
+		return RouteClassMessage
+	case path == "/api/v1/chats/:id/keys":
+		// Each request uses up one-time prekeys of the other side
+		return RouteClassKeys
+	case path == "/api/v1/tasks/:tid/claims" || strings.HasPrefix(path, "/api/v1/claims/"):
+		return RouteClassClaim
+	}
+	return RouteClassWrite
+}
+
+// memoryRateLimitStore keeps a token bucket per key in this process.
+type memoryRateLimitStore struct {
+	mu       sync.Mutex
+	limiters map[string]*memoryLimiter
+}
+
+type memoryLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func NewMemoryRateLimitStore() RateLimitStore {
+	return &memoryRateLimitStore{limiters: make(map[string]*memoryLimiter)}
+}
+
+func (s *memoryRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	now := time.Now()
+	interval := limit.Window / time.Duration(limit.Requests)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.limiters[key]
+	if !ok {
+		entry = &memoryLimiter{limiter: rate.NewLimiter(rate.Every(interval), limit.Requests)}
+		s.limiters[key] = entry
+	}
+	entry.lastSeen = now
+
+	allowed := entry.limiter.AllowN(now, 1)
+	tokens := entry.limiter.TokensAt(now)
+
+	result := RateLimitResult{
+		Allowed:   allowed,
+		Limit:     limit.Requests,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+	}
+	if allowed {
+		result.Reset = time.Duration((float64(limit.Requests) - tokens) * float64(interval))
+	} else {
+		result.Reset = time.Duration((1 - tokens) * float64(interval))
+	}
+	return result, nil
+}
+
+func (s *memoryRateLimitStore) Sweep(ctx context.Context, idle time.Duration) error {
+	cutoff := time.Now().Add(-idle)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, entry := range s.limiters {
+		if entry.lastSeen.Before(cutoff) {
+			delete(s.limiters, key)
+		}
+	}
+	return nil
+}
+
+// postgresRateLimitStore counts requests in fixed windows shared by every
+// instance using the same database.
+type postgresRateLimitStore struct {
+	repo repository.RateLimitRepository
+}
+
+func NewPostgresRateLimitStore(repo repository.RateLimitRepository) RateLimitStore {
+	return &postgresRateLimitStore{repo: repo}
+}
+
+func (s *postgresRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	now := time.Now()
+	windowStart := now.Truncate(limit.Window)
+
+	hits, err := s.repo.Hit(ctx, key, windowStart)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	remaining := limit.Requests - hits
+	if remaining < 0 {
+		remaining = 0
+	}
+	return RateLimitResult{
+		Allowed:   hits <= limit.Requests,
+		Limit:     limit.Requests,
+		Remaining: remaining,
+		Reset:     windowStart.Add(limit.Window).Sub(now),
+	}, nil
+}
+
+func (s *postgresRateLimitStore) Sweep(ctx context.Context, idle time.Duration) error {
+	_, err := s.repo.DeleteBefore(ctx, time.Now().Add(-idle))
+	return err
+}
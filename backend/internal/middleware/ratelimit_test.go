@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyRoute(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		class  RouteClass
+	}{
+		{http.MethodGet, "/api/v1/tasks", RouteClassRead},
+		{http.MethodGet, "/api/v1/chats/:id/messages", RouteClassRead},
+		{http.MethodPost, "/api/v1/tasks", RouteClassTaskCreate},
+		{http.MethodPost, "/api/v1/tasks/:tid/claims", RouteClassClaim},
+		{http.MethodPost, "/api/v1/claims/:id/submit", RouteClassClaim},
+		{http.MethodPost, "/api/v1/chats/:id/messages", RouteClassMessage},
+		{http.MethodPost, "/api/v1/chats/:id/keys", RouteClassKeys},
+		{http.MethodDelete, "/api/v1/chats/:id", RouteClassWrite},
+		{http.MethodPost, "/api/v1/reports", RouteClassWrite},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.class, ClassifyRoute(tt.method, tt.path), "%s %s", tt.method, tt.path)
+	}
+}
+
+func TestRateLimiterKeysByUserAndRouteClass(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := NewMemoryRateLimitStore()
+	limiter := NewRateLimiter(store, RateLimit{Requests: 100, Window: time.Minute}, map[RouteClass]RateLimit{
+		RouteClassRead:       {Requests: 5, Window: time.Minute},
+		RouteClassTaskCreate: {Requests: 1, Window: time.Minute},
+	})
+
+	r := gin.New()
+	api := r.Group("/api/v1")
+	api.Use(limiter.ByIP(), func(c *gin.Context) {
+		c.Set(UserIDKey, uuid.MustParse(c.GetHeader("X-User")))
+	}, limiter.ByUser())
+	api.POST("/tasks", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	api.GET("/tasks", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(method, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/tasks", nil)
+		req.Header.Set("X-User", user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	alice := uuid.New().String()
+	bob := uuid.New().String()
+
+	w := do(http.MethodPost, alice)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	// The second task creation is refused with a retry hint
+	w = do(http.MethodPost, alice)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	// Reads have their own budget and other users are unaffected
+	w = do(http.MethodGet, alice)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "4", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, bob).Code)
+
+	// Idle keys are forgotten
+	assert.NoError(t, store.Sweep(context.Background(), 0))
+	assert.Equal(t, http.StatusCreated, do(http.MethodPost, alice).Code)
+}
+
+func TestRateLimiterIgnoresForwardedForFromUntrustedClients(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := NewRateLimiter(NewMemoryRateLimitStore(), RateLimit{Requests: 1, Window: time.Minute}, nil)
+
+	newRouter := func(trusted string) *gin.Engine {
+		r := gin.New()
+		assert.NoError(t, r.SetTrustedProxies(TrustedProxies(trusted)))
+		r.Use(limiter.ByIP())
+		r.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+		return r
+	}
+	do := func(r *gin.Engine, remoteAddr, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// With no trusted proxies a made-up X-Forwarded-For does not buy a
+	// fresh budget
+	r := newRouter("")
+	assert.Equal(t, http.StatusOK, do(r, "192.0.2.1:1234", "198.51.100.1"))
+	assert.Equal(t, http.StatusTooManyRequests, do(r, "192.0.2.1:1234", "198.51.100.2"))
+
+	// Behind a trusted proxy each forwarded client has its own
+	r = newRouter("10.0.0.0/8, 10.1.2.3")
+	assert.Equal(t, http.StatusOK, do(r, "10.1.2.3:1234", "203.0.113.1"))
+	assert.Equal(t, http.StatusOK, do(r, "10.1.2.3:1234", "203.0.113.2"))
+	assert.Equal(t, http.StatusTooManyRequests, do(r, "10.1.2.3:1234", "203.0.113.2"))
+}
@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type AdminRepository interface {
+	Create(ctx context.Context, admin *domain.Admin) error
+	UpsertByName(ctx context.Context, admin *domain.Admin) error
+	GetByKeyHash(ctx context.Context, keyHash string) (*domain.Admin, error)
+	List(ctx context.Context) ([]*domain.Admin, error)
+	Revoke(ctx context.Context, id uuid.UUID) error
+	SearchUsers(ctx context.Context, search domain.UserSearch) ([]*domain.User, error)
+	SearchTasks(ctx context.Context, search domain.TaskSearch) ([]*domain.Task, error)
+	SearchClaims(ctx context.Context, search domain.ClaimSearch) ([]*domain.Claim, error)
+	GetStats(ctx context.Context) (*domain.SystemStats, error)
+}
+
+type adminRepository struct {
+	db *sql.DB
+}
+
+func NewAdminRepository(db *sql.DB) AdminRepository {
+	return &adminRepository{db: db}
+}
+
+const adminColumns = `id, name, role, key_hash, created_at, revoked_at`
+
+func scanAdmin(row interface{ Scan(...interface{}) error }) (*domain.Admin, error) {
+	admin := &domain.Admin{}
+	var revokedAt sql.NullTime
+	err := row.Scan(
+		&admin.ID,
+		&admin.Name,
+		&admin.Role,
+		&admin.KeyHash,
+		&admin.CreatedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		admin.RevokedAt = &revokedAt.Time
+	}
+	return admin, nil
+}
+
+func (r *adminRepository) Create(ctx context.Context, admin *domain.Admin) error {
+	query := `
+		INSERT INTO admins (id, name, role, key_hash)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+
+	return r.db.QueryRowContext(ctx, query, admin.ID, admin.Name, admin.Role, admin.KeyHash).Scan(&admin.CreatedAt)
+}
+
+// UpsertByName creates the admin or, if the name is taken, replaces its role
+// and key and lifts any revocation.
+func (r *adminRepository) UpsertByName(ctx context.Context, admin *domain.Admin) error {
+	query := `
+		INSERT INTO admins (id, name, role, key_hash)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name)
+		DO UPDATE SET role = EXCLUDED.role, key_hash = EXCLUDED.key_hash, revoked_at = NULL
+		RETURNING id, created_at
+	`
+
+	return r.db.QueryRowContext(ctx, query, admin.ID, admin.Name, admin.Role, admin.KeyHash).Scan(&admin.ID, &admin.CreatedAt)
+}
+
+func (r *adminRepository) GetByKeyHash(ctx context.Context, keyHash string) (*domain.Admin, error) {
+	query := `SELECT ` + adminColumns + ` FROM admins WHERE key_hash = $1 AND revoked_at IS NULL`
+
+	return scanAdmin(r.db.QueryRowContext(ctx, query, keyHash))
+}
+
+func (r *adminRepository) List(ctx context.Context) ([]*domain.Admin, error) {
+	query := `SELECT ` + adminColumns + ` FROM admins ORDER BY created_at ASC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var admins []*domain.Admin
+	for rows.Next() {
+		admin, err := scanAdmin(rows)
+		if err != nil {
+			return nil, err
+		}
+		admins = append(admins, admin)
+	}
+	return admins, rows.Err()
+}
+
+func (r *adminRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE admins SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// filter collects WHERE conditions and their positional arguments.
+type filter struct {
+	conditions []string
+	args       []interface{}
+}
+
+func (f *filter) add(condition string, arg interface{}) {
+	f.args = append(f.args, arg)
+	f.conditions = append(f.conditions, fmt.Sprintf(condition, len(f.args)))
+}
+
+// clause renders the WHERE clause followed by LIMIT and OFFSET.
+func (f *filter) clause(limit, offset int) string {
+	where := ""
+	if len(f.conditions) > 0 {
+		where = "WHERE " + strings.Join(f.conditions, " AND ")
+	}
+	f.args = append(f.args, limit, offset)
+	return fmt.Sprintf("%s ORDER BY created_at DESC LIMIT $%d OFFSET $%d", where, len(f.args)-1, len(f.args))
+}
+
+func (r *adminRepository) SearchUsers(ctx context.Context, search domain.UserSearch) ([]*domain.User, error) {
+	var f filter
+	if search.ID != nil {
+		f.add("id = $%d", *search.ID)
+	}
+	if search.DeviceID != "" {
+		f.add("(device_id = $%[1]d OR id IN (SELECT user_id FROM user_devices WHERE device_id = $%[1]d))", search.DeviceID)
+	}
+	if search.SuspendedOnly {
+		f.conditions = append(f.conditions, "suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > NOW())")
+	}
+	query := `SELECT ` + userColumns + ` FROM users ` + f.clause(search.Limit, search.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, f.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (r *adminRepository) SearchTasks(ctx context.Context, search domain.TaskSearch) ([]*domain.Task, error) {
+	var f filter
+	if search.OwnerID != nil {
+		f.add("owner_id = $%d", *search.OwnerID)
+	}
+	if search.Status != "" {
+		f.add("status = $%d", search.Status)
+	}
+	if search.Query != "" {
+		f.add("(title ILIKE $%[1]d OR description ILIKE $%[1]d)", "%"+search.Query+"%")
+	}
+	query := `SELECT ` + taskColumns + ` FROM tasks ` + f.clause(search.Limit, search.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, f.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []*domain.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+func (r *adminRepository) SearchClaims(ctx context.Context, search domain.ClaimSearch) ([]*domain.Claim, error) {
+	var f filter
+	if search.TaskID != nil {
+		f.add("task_id = $%d", *search.TaskID)
+	}
+	if search.ClaimerID != nil {
+		f.add("claimer_id = $%d", *search.ClaimerID)
+	}
+	if search.Status != "" {
+		f.add("status = $%d", search.Status)
+	}
+	query := `SELECT ` + claimColumns + ` FROM claims ` + f.clause(search.Limit, search.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, f.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claims []*domain.Claim
+	for rows.Next() {
+		claim, err := scanClaim(rows)
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, claim)
+	}
+	return claims, rows.Err()
+}
+
+func (r *adminRepository) GetStats(ctx context.Context) (*domain.SystemStats, error) {
+	stats := &domain.SystemStats{
+		Tasks:  make(map[domain.TaskStatus]int),
+		Claims: make(map[domain.ClaimStatus]int),
+	}
+
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM users),
+			(SELECT COUNT(*) FROM users WHERE suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > NOW())),
+			(SELECT COUNT(*) FROM reports WHERE status = 'open'),
+			(SELECT COALESCE(SUM(reward_amount), 0) FROM tasks WHERE escrow_locked)
+	`
+	err := r.db.QueryRowContext(ctx, query).Scan(&stats.Users, &stats.SuspendedUsers, &stats.OpenReports, &stats.EscrowLocked)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM tasks GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status domain.TaskStatus
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		stats.Tasks[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM claims GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status domain.ClaimStatus
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		stats.Claims[status] = count
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type BlockRepository interface {
+	Create(ctx context.Context, block *domain.Block) error
+	GetByBlockerID(ctx context.Context, blockerID uuid.UUID) ([]*domain.Block, error)
+	Delete(ctx context.Context, id, blockerID uuid.UUID) (bool, error)
+	IsBlocked(ctx context.Context, userID, otherUserID uuid.UUID) (bool, error)
+}
+
+type blockRepository struct {
+	db *sql.DB
+}
+
+func NewBlockRepository(db *sql.DB) BlockRepository {
+	return &blockRepository{db: db}
+}
+
+// Create inserts a block, or returns the existing one for the same pair.
+func (r *blockRepository) Create(ctx context.Context, block *domain.Block) error {
+	query := `
+		INSERT INTO user_blocks (id, blocker_id, blocked_id, task_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (blocker_id, blocked_id) DO UPDATE SET blocker_id = user_blocks.blocker_id
+		RETURNING id, task_id, created_at
+	`
+
+	var taskID uuid.NullUUID
+	err := r.db.QueryRowContext(ctx, query,
+		block.ID,
+		block.BlockerID,
+		block.BlockedID,
+		block.TaskID,
+	).Scan(&block.ID, &taskID, &block.CreatedAt)
+	if err != nil {
+		return err
+	}
+	block.TaskID = nil
+	if taskID.Valid {
+		block.TaskID = &taskID.UUID
+	}
+	return nil
+}
+
+func (r *blockRepository) GetByBlockerID(ctx context.Context, blockerID uuid.UUID) ([]*domain.Block, error) {
+	query := `
+		SELECT id, blocker_id, blocked_id, task_id, created_at
+		FROM user_blocks
+		WHERE blocker_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, blockerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var blocks []*domain.Block
+	for rows.Next() {
+		block := &domain.Block{}
+		var taskID uuid.NullUUID
+		err := rows.Scan(
+			&block.ID,
+			&block.BlockerID,
+			&block.BlockedID,
+			&taskID,
+			&block.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if taskID.Valid {
+			block.TaskID = &taskID.UUID
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, rows.Err()
+}
+
+func (r *blockRepository) Delete(ctx context.Context, id, blockerID uuid.UUID) (bool, error) {
+	query := `DELETE FROM user_blocks WHERE id = $1 AND blocker_id = $2`
+	result, err := r.db.ExecContext(ctx, query, id, blockerID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// IsBlocked reports whether either user has blocked the other.
+func (r *blockRepository) IsBlocked(ctx context.Context, userID, otherUserID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM user_blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+		)
+	`
+	var blocked bool
+	err := r.db.QueryRowContext(ctx, query, userID, otherUserID).Scan(&blocked)
+	return blocked, err
+}
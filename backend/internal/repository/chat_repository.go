@@ -3,8 +3,10 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/task-underground/backend/internal/domain"
 )
 
@@ -12,9 +14,25 @@ type ChatRepository interface {
 	GetOrCreate(ctx context.Context, taskID, participantID, otherParticipantID uuid.UUID) (*domain.Chat, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Chat, error)
 	GetByTaskIDAndUserID(ctx context.Context, taskID, userID uuid.UUID) ([]*domain.Chat, error)
-	DeleteForUser(ctx context.Context, chatID, userID uuid.UUID) error
+	ListInbox(ctx context.Context, userID uuid.UUID, after *domain.InboxCursor, limit int) ([]*domain.Chat, error)
+	DeleteForUser(ctx context.Context, chatID, userID uuid.UUID) (bool, error)
+	PurgeChat(ctx context.Context, chatID uuid.UUID) (bool, []string, error)
+	PurgeDeletedChats(ctx context.Context, limit int) (int, []string, error)
 	CreateMessage(ctx context.Context, message *domain.Message) error
-	GetMessagesByChatID(ctx context.Context, chatID uuid.UUID, limit, offset int) ([]*domain.Message, error)
+	GetMessagesByChatID(ctx context.Context, chatID uuid.UUID, since *time.Time, limit, offset int) ([]*domain.Message, error)
+	GetMessageByID(ctx context.Context, id uuid.UUID) (*domain.Message, error)
+	GetLastMessage(ctx context.Context, chatID, userID uuid.UUID) (*domain.Message, error)
+	EditMessage(ctx context.Context, message *domain.Message) error
+	UnsendMessage(ctx context.Context, message *domain.Message) error
+	GetMessageEdits(ctx context.Context, messageID uuid.UUID) ([]*domain.MessageEdit, error)
+	GetAttachment(ctx context.Context, id uuid.UUID) (*domain.Attachment, error)
+	GetAttachments(ctx context.Context, messageIDs []uuid.UUID) ([]*domain.Attachment, error)
+	GetEnvelopes(ctx context.Context, messageIDs []uuid.UUID, userID uuid.UUID) ([]*domain.Envelope, error)
+	MarkRead(ctx context.Context, chatID, userID, messageID uuid.UUID) (bool, error)
+	CountUnread(ctx context.Context, userID uuid.UUID) (int, error)
+	SetMessageTTL(ctx context.Context, chatID uuid.UUID, seconds int) error
+	PurgeExpiredMessages(ctx context.Context, limit int) (int, []string, error)
+	PurgeEndedTaskMessages(ctx context.Context, endedBefore time.Time, limit int) (int, []string, error)
 }
 
 type chatRepository struct {
@@ -25,37 +43,154 @@ func NewChatRepository(db *sql.DB) ChatRepository {
 	return &chatRepository{db: db}
 }
 
-func (r *chatRepository) GetOrCreate(ctx context.Context, taskID, participantID, otherParticipantID uuid.UUID) (*domain.Chat, error) {
-	// Try to find existing chat
-	query := `
-		SELECT id, task_id, participant_id, other_participant_id, deleted_by_participant, deleted_by_other, created_at, updated_at
-		FROM chats
-		WHERE task_id = $1 AND participant_id = $2 AND other_participant_id = $3
-	`
-	
+const chatColumns = `id, task_id, participant_id, other_participant_id, deleted_by_participant, deleted_by_other, participant_cleared_at, other_cleared_at, message_ttl_seconds, created_at, updated_at`
+
+func scanChat(row interface{ Scan(...interface{}) error }) (*domain.Chat, error) {
 	chat := &domain.Chat{}
-	err := r.db.QueryRowContext(ctx, query, taskID, participantID, otherParticipantID).Scan(
+	var participantCleared, otherCleared sql.NullTime
+	err := row.Scan(
 		&chat.ID,
 		&chat.TaskID,
 		&chat.ParticipantID,
 		&chat.OtherParticipantID,
 		&chat.DeletedByParticipant,
 		&chat.DeletedByOther,
+		&participantCleared,
+		&otherCleared,
+		&chat.MessageTTL,
 		&chat.CreatedAt,
 		&chat.UpdatedAt,
 	)
+	if err != nil {
+		return nil, err
+	}
+	setClearedAt(chat, participantCleared, otherCleared)
+	return chat, nil
+}
+
+func setClearedAt(chat *domain.Chat, participantCleared, otherCleared sql.NullTime) {
+	if participantCleared.Valid {
+		chat.ParticipantClearedAt = &participantCleared.Time
+	}
+	if otherCleared.Valid {
+		chat.OtherClearedAt = &otherCleared.Time
+	}
+}
+
+// historyStart is when the user in param last deleted chat c, the start of
+// their history.
+func historyStart(param string) string {
+	return `COALESCE(CASE WHEN c.participant_id = ` + param + ` THEN c.participant_cleared_at ELSE c.other_cleared_at END, '-infinity')`
+}
+
+const messageColumns = `id, chat_id, sender_id, content, created_at, edited_at, deleted_at, hidden_at, expires_at, ciphertext`
+
+func scanMessage(row interface{ Scan(...interface{}) error }) (*domain.Message, error) {
+	message := &domain.Message{}
+	var editedAt, deletedAt, hiddenAt, expiresAt sql.NullTime
+	err := row.Scan(
+		&message.ID,
+		&message.ChatID,
+		&message.SenderID,
+		&message.Content,
+		&message.CreatedAt,
+		&editedAt,
+		&deletedAt,
+		&hiddenAt,
+		&expiresAt,
+		&message.Ciphertext,
+	)
+	if err != nil {
+		return nil, err
+	}
+	message.Encrypted = message.Ciphertext != nil
+	if editedAt.Valid {
+		message.EditedAt = &editedAt.Time
+	}
+	if deletedAt.Valid {
+		message.DeletedAt = &deletedAt.Time
+	}
+	if hiddenAt.Valid {
+		message.HiddenAt = &hiddenAt.Time
+	}
+	if expiresAt.Valid {
+		message.ExpiresAt = &expiresAt.Time
+	}
+	return message, nil
+}
+
+// chatReadColumns follows chatColumns in queries over chats c read by the
+// user $2: their unread count and the counterpart's last read message.
+var chatReadColumns = `,
+		(SELECT COUNT(*) FROM messages m
+		 WHERE m.chat_id = c.id AND m.sender_id <> $2 AND m.hidden_at IS NULL AND m.deleted_at IS NULL
+		 AND (m.expires_at IS NULL OR m.expires_at > NOW())
+		 AND m.created_at > ` + historyStart("$2") + `
+		 AND m.created_at > COALESCE((SELECT read_at FROM chat_reads WHERE chat_id = c.id AND user_id = $2), '-infinity')),
+		(SELECT message_id FROM chat_reads WHERE chat_id = c.id AND user_id <> $2)`
+
+func scanChatWithReads(row interface{ Scan(...interface{}) error }) (*domain.Chat, error) {
+	chat := &domain.Chat{}
+	var counterpartRead uuid.NullUUID
+	var participantCleared, otherCleared sql.NullTime
+	err := row.Scan(
+		&chat.ID,
+		&chat.TaskID,
+		&chat.ParticipantID,
+		&chat.OtherParticipantID,
+		&chat.DeletedByParticipant,
+		&chat.DeletedByOther,
+		&participantCleared,
+		&otherCleared,
+		&chat.MessageTTL,
+		&chat.CreatedAt,
+		&chat.UpdatedAt,
+		&chat.UnreadCount,
+		&counterpartRead,
+	)
+	if err != nil {
+		return nil, err
+	}
+	setClearedAt(chat, participantCleared, otherCleared)
+	if counterpartRead.Valid {
+		chat.CounterpartReadID = &counterpartRead.UUID
+	}
+	return chat, nil
+}
+
+// GetOrCreate returns the chat between the two users on the task, whichever
+// of them opened it, or creates it. If participantID had deleted it, it
+// comes back for them with their history starting where they deleted it.
+func (r *chatRepository) GetOrCreate(ctx context.Context, taskID, participantID, otherParticipantID uuid.UUID) (*domain.Chat, error) {
+	// Try to find existing chat
+	query := `
+		SELECT ` + chatColumns + `
+		FROM chats
+		WHERE task_id = $1
+		AND ((participant_id = $2 AND other_participant_id = $3) OR (participant_id = $3 AND other_participant_id = $2))
+		ORDER BY created_at ASC
+		LIMIT 1
+	`
+	
+	chat, err := scanChat(r.db.QueryRowContext(ctx, query, taskID, participantID, otherParticipantID))
 	
 	if err == nil {
-		// Reset deletion flags if chat exists
-		if chat.DeletedByParticipant || chat.DeletedByOther {
+		// Rejoin only on participantID's side
+		if chat.IsDeletedBy(participantID) {
 			updateQuery := `
-				UPDATE chats 
-				SET deleted_by_participant = FALSE, deleted_by_other = FALSE
+				UPDATE chats
+				SET deleted_by_participant = CASE WHEN participant_id = $2 THEN FALSE ELSE deleted_by_participant END,
+				    deleted_by_other = CASE WHEN other_participant_id = $2 THEN FALSE ELSE deleted_by_other END
 				WHERE id = $1
 			`
-			r.db.ExecContext(ctx, updateQuery, chat.ID)
-			chat.DeletedByParticipant = false
-			chat.DeletedByOther = false
+			if _, err := r.db.ExecContext(ctx, updateQuery, chat.ID, participantID); err != nil {
+				return nil, err
+			}
+			if chat.ParticipantID == participantID {
+				chat.DeletedByParticipant = false
+			} else {
+				chat.DeletedByOther = false
+			}
 		}
 		return chat, nil
 	}
@@ -65,10 +200,12 @@ func (r *chatRepository) GetOrCreate(ctx context.Context, taskID, participantID,
 	}
 	
 	// Create new chat
-	chat.ID = uuid.New()
-	chat.TaskID = taskID
-	chat.ParticipantID = participantID
-	chat.OtherParticipantID = otherParticipantID
+	chat = &domain.Chat{
+		ID:                 uuid.New(),
+		TaskID:             taskID,
+		ParticipantID:      participantID,
+		OtherParticipantID: otherParticipantID,
+	}
 	
 	insertQuery := `
 		INSERT INTO chats (id, task_id, participant_id, other_participant_id)
@@ -88,32 +225,18 @@ func (r *chatRepository) GetOrCreate(ctx context.Context, taskID, participantID,
 
 func (r *chatRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Chat, error) {
 	query := `
-		SELECT id, task_id, participant_id, other_participant_id, deleted_by_participant, deleted_by_other, created_at, updated_at
+		SELECT ` + chatColumns + `
 		FROM chats
 		WHERE id = $1
 	`
 	
-	chat := &domain.Chat{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&chat.ID,
-		&chat.TaskID,
-		&chat.ParticipantID,
-		&chat.OtherParticipantID,
-		&chat.DeletedByParticipant,
-		&chat.DeletedByOther,
-		&chat.CreatedAt,
-		&chat.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return chat, nil
+	return scanChat(r.db.QueryRowContext(ctx, query, id))
 }
 
 func (r *chatRepository) GetByTaskIDAndUserID(ctx context.Context, taskID, userID uuid.UUID) ([]*domain.Chat, error) {
 	query := `
-		SELECT id, task_id, participant_id, other_participant_id, deleted_by_participant, deleted_by_other, created_at, updated_at
-		FROM chats
+		SELECT ` + chatColumns + chatReadColumns + `
+		FROM chats c
 		WHERE task_id = $1 AND (participant_id = $2 OR other_participant_id = $2)
 		AND NOT (deleted_by_participant = TRUE AND participant_id = $2)
 		AND NOT (deleted_by_other = TRUE AND other_participant_id = $2)
@@ -126,9 +249,67 @@ func (r *chatRepository) GetByTaskIDAndUserID(ctx context.Context, taskID, userI
 	}
 	defer rows.Close()
 	
+	var chats []*domain.Chat
+	for rows.Next() {
+		chat, err := scanChatWithReads(rows)
+		if err != nil {
+			return nil, err
+		}
+		chats = append(chats, chat)
+	}
+	return chats, rows.Err()
+}
+
+// inboxPreviewLength is how much of the latest message the inbox shows.
+const inboxPreviewLength = 140
+
+// ListInbox returns the chats userID still lists across all tasks, most
+// recently active first, starting after the cursor if there is one.
+func (r *chatRepository) ListInbox(ctx context.Context, userID uuid.UUID, after *domain.InboxCursor, limit int) ([]*domain.Chat, error) {
+	query := `
+		SELECT * FROM (
+			SELECT c.id, c.task_id, c.participant_id, c.other_participant_id, c.deleted_by_participant, c.deleted_by_other,
+				c.participant_cleared_at, c.other_cleared_at, c.message_ttl_seconds, c.created_at, c.updated_at` + chatReadColumns + `,
+				t.title, lm.id AS last_id, lm.sender_id AS last_sender_id, lm.content AS last_content, lm.created_at AS last_created_at,
+				lm.encrypted AS last_encrypted,
+				COALESCE(lm.created_at, c.created_at) AS active_at
+			FROM chats c
+			JOIN tasks t ON t.id = c.task_id
+			LEFT JOIN LATERAL (
+				SELECT id, sender_id, LEFT(content, $3) AS content, created_at, ciphertext IS NOT NULL AS encrypted
+				FROM messages
+				WHERE chat_id = c.id AND hidden_at IS NULL AND deleted_at IS NULL
+				AND (expires_at IS NULL OR expires_at > NOW())
+				AND created_at > ` + historyStart("$2") + `
+				ORDER BY created_at DESC
+				LIMIT 1
+			) lm ON TRUE
+			WHERE (c.participant_id = $2 OR c.other_participant_id = $2)
+			AND NOT (c.deleted_by_participant = TRUE AND c.participant_id = $2)
+			AND NOT (c.deleted_by_other = TRUE AND c.other_participant_id = $2)
+		) inbox
+	`
+	args := []interface{}{limit, userID, inboxPreviewLength}
+	if after != nil {
+		query += ` WHERE (active_at, id) < ($4, $5)`
+		args = append(args, after.ActiveAt, after.ChatID)
+	}
+	query += ` ORDER BY active_at DESC, id DESC LIMIT $1`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
 	var chats []*domain.Chat
 	for rows.Next() {
 		chat := &domain.Chat{}
+		var counterpartRead, messageID, senderID uuid.NullUUID
+		var content sql.NullString
+		var sentAt sql.NullTime
+		var encrypted sql.NullBool
+		var participantCleared, otherCleared sql.NullTime
 		err := rows.Scan(
 			&chat.ID,
 			&chat.TaskID,
@@ -136,70 +317,238 @@ func (r *chatRepository) GetByTaskIDAndUserID(ctx context.Context, taskID, userI
 			&chat.OtherParticipantID,
 			&chat.DeletedByParticipant,
 			&chat.DeletedByOther,
+			&participantCleared,
+			&otherCleared,
+			&chat.MessageTTL,
 			&chat.CreatedAt,
 			&chat.UpdatedAt,
+			&chat.UnreadCount,
+			&counterpartRead,
+			&chat.TaskTitle,
+			&messageID,
+			&senderID,
+			&content,
+			&sentAt,
+			&encrypted,
+			&chat.ActiveAt,
 		)
 		if err != nil {
 			return nil, err
 		}
+		setClearedAt(chat, participantCleared, otherCleared)
+		if counterpartRead.Valid {
+			chat.CounterpartReadID = &counterpartRead.UUID
+		}
+		if messageID.Valid {
+			chat.LastMessage = &domain.Message{
+				ID:        messageID.UUID,
+				ChatID:    chat.ID,
+				SenderID:  senderID.UUID,
+				Content:   content.String,
+				CreatedAt: sentAt.Time,
+				Encrypted: encrypted.Bool,
+			}
+		}
 		chats = append(chats, chat)
 	}
 	return chats, rows.Err()
 }
 
-func (r *chatRepository) DeleteForUser(ctx context.Context, chatID, userID uuid.UUID) error {
+// DeleteForUser hides the chat from userID and starts their history over
+// from now. It reports whether both participants have now deleted it.
+func (r *chatRepository) DeleteForUser(ctx context.Context, chatID, userID uuid.UUID) (bool, error) {
 	query := `
 		UPDATE chats
 		SET deleted_by_participant = CASE WHEN participant_id = $2 THEN TRUE ELSE deleted_by_participant END,
-		    deleted_by_other = CASE WHEN other_participant_id = $2 THEN TRUE ELSE deleted_by_other END
+		    participant_cleared_at = CASE WHEN participant_id = $2 THEN NOW() ELSE participant_cleared_at END,
+		    deleted_by_other = CASE WHEN other_participant_id = $2 THEN TRUE ELSE deleted_by_other END,
+		    other_cleared_at = CASE WHEN other_participant_id = $2 THEN NOW() ELSE other_cleared_at END
 		WHERE id = $1
+		RETURNING deleted_by_participant AND deleted_by_other
 	`
-	_, err := r.db.ExecContext(ctx, query, chatID, userID)
-	return err
+	var both bool
+	err := r.db.QueryRowContext(ctx, query, chatID, userID).Scan(&both)
+	return both, err
 }
 
+// CreateMessage stores a message together with its attachments and, if it
+// is encrypted, its envelopes.
 func (r *chatRepository) CreateMessage(ctx context.Context, message *domain.Message) error {
-	query := `
-		INSERT INTO messages (id, chat_id, sender_id, content)
-		VALUES ($1, $2, $3, $4)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO messages (id, chat_id, sender_id, content, ciphertext, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING created_at
-	`
-	
-	err := r.db.QueryRowContext(ctx, query,
+	`,
 		message.ID,
 		message.ChatID,
 		message.SenderID,
 		message.Content,
+		message.Ciphertext,
+		message.ExpiresAt,
 	).Scan(&message.CreatedAt)
-	
-	return err
+	if err != nil {
+		return err
+	}
+
+	for _, envelope := range message.Envelopes {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO message_envelopes (message_id, device_id, payload)
+			VALUES ($1, $2, $3)
+		`, message.ID, envelope.DeviceID, envelope.Payload)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, attachment := range message.Attachments {
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO message_attachments (id, message_id, chat_id, kind, mime_type, size_bytes, width, height, filename, storage_key)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			RETURNING created_at
+		`,
+			attachment.ID,
+			message.ID,
+			message.ChatID,
+			attachment.Kind,
+			attachment.MimeType,
+			attachment.Size,
+			attachment.Width,
+			attachment.Height,
+			attachment.Filename,
+			attachment.StorageKey,
+		).Scan(&attachment.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
-func (r *chatRepository) GetMessagesByChatID(ctx context.Context, chatID uuid.UUID, limit, offset int) ([]*domain.Message, error) {
+const attachmentColumns = `id, message_id, chat_id, kind, mime_type, size_bytes, width, height, filename, storage_key, created_at`
+
+func scanAttachment(row interface{ Scan(...interface{}) error }) (*domain.Attachment, error) {
+	attachment := &domain.Attachment{}
+	var width, height sql.NullInt64
+	err := row.Scan(
+		&attachment.ID,
+		&attachment.MessageID,
+		&attachment.ChatID,
+		&attachment.Kind,
+		&attachment.MimeType,
+		&attachment.Size,
+		&width,
+		&height,
+		&attachment.Filename,
+		&attachment.StorageKey,
+		&attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if width.Valid && height.Valid {
+		w, h := int(width.Int64), int(height.Int64)
+		attachment.Width, attachment.Height = &w, &h
+	}
+	return attachment, nil
+}
+
+func (r *chatRepository) GetAttachment(ctx context.Context, id uuid.UUID) (*domain.Attachment, error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM message_attachments
+		WHERE id = $1
+	`
+
+	return scanAttachment(r.db.QueryRowContext(ctx, query, id))
+}
+
+// GetAttachments returns the attachments of the given messages in the
+// order they were sent.
+func (r *chatRepository) GetAttachments(ctx context.Context, messageIDs []uuid.UUID) ([]*domain.Attachment, error) {
 	query := `
-		SELECT id, chat_id, sender_id, content, created_at
+		SELECT ` + attachmentColumns + `
+		FROM message_attachments
+		WHERE message_id = ANY($1::uuid[])
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(uuidStrings(messageIDs)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []*domain.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, rows.Err()
+}
+
+// GetEnvelopes returns the envelopes of the given messages that are sealed
+// for userID's devices.
+func (r *chatRepository) GetEnvelopes(ctx context.Context, messageIDs []uuid.UUID, userID uuid.UUID) ([]*domain.Envelope, error) {
+	query := `
+		SELECT e.message_id, e.device_id, e.payload
+		FROM message_envelopes e
+		JOIN user_devices d ON d.id = e.device_id
+		WHERE e.message_id = ANY($1::uuid[]) AND d.user_id = $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(uuidStrings(messageIDs)), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var envelopes []*domain.Envelope
+	for rows.Next() {
+		envelope := &domain.Envelope{}
+		if err := rows.Scan(&envelope.MessageID, &envelope.DeviceID, &envelope.Payload); err != nil {
+			return nil, err
+		}
+		envelopes = append(envelopes, envelope)
+	}
+	return envelopes, rows.Err()
+}
+
+// GetMessagesByChatID returns a page of a chat's history, oldest first.
+// Unsent and hidden messages come back as tombstones with their content
+// and ciphertext blanked; expired ones, and with since those sent until
+// then, are left out.
+func (r *chatRepository) GetMessagesByChatID(ctx context.Context, chatID uuid.UUID, since *time.Time, limit, offset int) ([]*domain.Message, error) {
+	query := `
+		SELECT id, chat_id, sender_id,
+			CASE WHEN deleted_at IS NULL AND hidden_at IS NULL THEN content ELSE '' END,
+			created_at, edited_at, deleted_at, hidden_at, expires_at,
+			CASE WHEN deleted_at IS NULL AND hidden_at IS NULL THEN ciphertext END
 		FROM messages
-		WHERE chat_id = $1
+		WHERE chat_id = $1 AND (expires_at IS NULL OR expires_at > NOW())
+		AND ($4::timestamptz IS NULL OR created_at > $4)
 		ORDER BY created_at ASC
 		LIMIT $2 OFFSET $3
 	`
-	
-	rows, err := r.db.QueryContext(ctx, query, chatID, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, chatID, limit, offset, since)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	
+
 	var messages []*domain.Message
 	for rows.Next() {
-		message := &domain.Message{}
-		err := rows.Scan(
-			&message.ID,
-			&message.ChatID,
-			&message.SenderID,
-			&message.Content,
-			&message.CreatedAt,
-		)
+		message, err := scanMessage(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -207,3 +556,284 @@ func (r *chatRepository) GetMessagesByChatID(ctx context.Context, chatID uuid.UU
 	}
 	return messages, rows.Err()
 }
+
+func (r *chatRepository) GetMessageByID(ctx context.Context, id uuid.UUID) (*domain.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE id = $1
+	`
+
+	return scanMessage(r.db.QueryRowContext(ctx, query, id))
+}
+
+// GetLastMessage returns the latest message of the chat in userID's
+// history, which starts when they last deleted the chat.
+func (r *chatRepository) GetLastMessage(ctx context.Context, chatID, userID uuid.UUID) (*domain.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE chat_id = $1 AND hidden_at IS NULL AND deleted_at IS NULL
+		AND (expires_at IS NULL OR expires_at > NOW())
+		AND created_at > (SELECT ` + historyStart("$2") + ` FROM chats c WHERE c.id = $1)
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	return scanMessage(r.db.QueryRowContext(ctx, query, chatID, userID))
+}
+
+// EditMessage replaces the message's content with message.Content, keeping
+// the previous version in the edit history.
+func (r *chatRepository) EditMessage(ctx context.Context, message *domain.Message) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO message_edits (id, message_id, content, written_at)
+		SELECT $2, id, content, COALESCE(edited_at, created_at)
+		FROM messages
+		WHERE id = $1
+	`, message.ID, uuid.New())
+	if err != nil {
+		return err
+	}
+
+	var editedAt time.Time
+	err = tx.QueryRowContext(ctx, `
+		UPDATE messages
+		SET content = $2, edited_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING edited_at
+	`, message.ID, message.Content).Scan(&editedAt)
+	if err != nil {
+		return err
+	}
+	message.EditedAt = &editedAt
+
+	return tx.Commit()
+}
+
+// UnsendMessage turns the message into a tombstone. Its content stays in
+// the database for moderators.
+func (r *chatRepository) UnsendMessage(ctx context.Context, message *domain.Message) error {
+	query := `
+		UPDATE messages
+		SET deleted_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING deleted_at
+	`
+
+	var deletedAt time.Time
+	if err := r.db.QueryRowContext(ctx, query, message.ID).Scan(&deletedAt); err != nil {
+		return err
+	}
+	message.DeletedAt = &deletedAt
+	return nil
+}
+
+// GetMessageEdits returns the earlier versions of a message, oldest first.
+func (r *chatRepository) GetMessageEdits(ctx context.Context, messageID uuid.UUID) ([]*domain.MessageEdit, error) {
+	query := `
+		SELECT id, message_id, content, written_at
+		FROM message_edits
+		WHERE message_id = $1
+		ORDER BY written_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edits []*domain.MessageEdit
+	for rows.Next() {
+		edit := &domain.MessageEdit{}
+		if err := rows.Scan(&edit.ID, &edit.MessageID, &edit.Content, &edit.WrittenAt); err != nil {
+			return nil, err
+		}
+		edits = append(edits, edit)
+	}
+	return edits, rows.Err()
+}
+
+// MarkRead moves userID's read position in the chat up to messageID. It
+// never moves backwards; the result says whether it moved.
+func (r *chatRepository) MarkRead(ctx context.Context, chatID, userID, messageID uuid.UUID) (bool, error) {
+	query := `
+		INSERT INTO chat_reads (chat_id, user_id, message_id, read_at)
+		SELECT chat_id, $2, id, created_at
+		FROM messages
+		WHERE id = $3 AND chat_id = $1
+		ON CONFLICT (chat_id, user_id) DO UPDATE
+		SET message_id = EXCLUDED.message_id, read_at = EXCLUDED.read_at, updated_at = NOW()
+		WHERE chat_reads.read_at < EXCLUDED.read_at
+	`
+	result, err := r.db.ExecContext(ctx, query, chatID, userID, messageID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// CountUnread counts the messages userID has not read across every chat
+// they still list.
+func (r *chatRepository) CountUnread(ctx context.Context, userID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM messages m
+		JOIN chats c ON c.id = m.chat_id
+		LEFT JOIN chat_reads cr ON cr.chat_id = c.id AND cr.user_id = $1
+		WHERE (c.participant_id = $1 OR c.other_participant_id = $1)
+		AND NOT (c.deleted_by_participant = TRUE AND c.participant_id = $1)
+		AND NOT (c.deleted_by_other = TRUE AND c.other_participant_id = $1)
+		AND m.sender_id <> $1 AND m.hidden_at IS NULL AND m.deleted_at IS NULL
+		AND (m.expires_at IS NULL OR m.expires_at > NOW())
+		AND m.created_at > ` + historyStart("$1") + `
+		AND m.created_at > COALESCE(cr.read_at, '-infinity')
+	`
+	var count int
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&count)
+	return count, err
+}
+
+// SetMessageTTL sets the chat's disappearing-messages timer. Messages
+// already sent keep the expiry they were sent with.
+func (r *chatRepository) SetMessageTTL(ctx context.Context, chatID uuid.UUID, seconds int) error {
+	query := `UPDATE chats SET message_ttl_seconds = $2 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, chatID, seconds)
+	return err
+}
+
+// disputeOpen holds for a chat c whose claim is in dispute, since the
+// arbitrator may still need its messages as evidence. Queries using it pass
+// the disputed status as $1.
+const disputeOpen = `EXISTS (
+			SELECT 1 FROM claims cl
+			WHERE cl.task_id = c.task_id AND cl.claimer_id IN (c.participant_id, c.other_participant_id) AND cl.status = $1
+		)`
+
+// purgeSpared keeps the messages m of chats in dispute.
+const purgeSpared = `
+		AND NOT EXISTS (SELECT 1 FROM chats c WHERE c.id = m.chat_id AND ` + disputeOpen + `)`
+
+// PurgeExpiredMessages deletes up to limit messages whose disappearing
+// timer has run out. It returns how many it deleted and where their
+// attachment files are stored, for the caller to delete.
+func (r *chatRepository) PurgeExpiredMessages(ctx context.Context, limit int) (int, []string, error) {
+	query := `
+		SELECT m.id FROM messages m
+		WHERE m.expires_at <= NOW()` + purgeSpared + `
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+	return r.purge(ctx, "messages", "message_id", query, domain.ClaimStatusDisputed, limit)
+}
+
+// PurgeEndedTaskMessages deletes up to limit messages of tasks that were
+// completed or cancelled before endedBefore, like PurgeExpiredMessages.
+func (r *chatRepository) PurgeEndedTaskMessages(ctx context.Context, endedBefore time.Time, limit int) (int, []string, error) {
+	query := `
+		SELECT m.id FROM messages m
+		JOIN chats ch ON ch.id = m.chat_id
+		JOIN tasks t ON t.id = ch.task_id
+		WHERE t.status IN ($3, $4) AND t.updated_at < $5` + purgeSpared + `
+		LIMIT $2
+		FOR UPDATE OF m SKIP LOCKED
+	`
+	return r.purge(ctx, "messages", "message_id", query, domain.ClaimStatusDisputed, limit,
+		domain.TaskStatusCompleted, domain.TaskStatusCancelled, endedBefore)
+}
+
+// PurgeChat deletes a chat both participants have deleted, with all its
+// messages, unless it is in dispute. It reports whether it did and where
+// the chat's attachment files are stored.
+func (r *chatRepository) PurgeChat(ctx context.Context, chatID uuid.UUID) (bool, []string, error) {
+	query := `
+		SELECT c.id FROM chats c
+		WHERE c.id = $2 AND c.deleted_by_participant AND c.deleted_by_other
+		AND NOT ` + disputeOpen + `
+		FOR UPDATE SKIP LOCKED
+	`
+	n, keys, err := r.purge(ctx, "chats", "chat_id", query, domain.ClaimStatusDisputed, chatID)
+	return n > 0, keys, err
+}
+
+// PurgeDeletedChats deletes up to limit chats that PurgeChat spared while
+// they were in dispute and no longer are.
+func (r *chatRepository) PurgeDeletedChats(ctx context.Context, limit int) (int, []string, error) {
+	query := `
+		SELECT c.id FROM chats c
+		WHERE c.deleted_by_participant AND c.deleted_by_other
+		AND NOT ` + disputeOpen + `
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+	return r.purge(ctx, "chats", "chat_id", query, domain.ClaimStatusDisputed, limit)
+}
+
+// purge deletes the rows of table, messages or chats, that selectQuery picks
+// in one transaction, and everything that hangs off them. Besides how many
+// it deleted it returns the storage keys of the attachments that went with
+// them, found through keyColumn, for the caller to delete.
+func (r *chatRepository) purge(ctx context.Context, table, keyColumn, selectQuery string, args ...interface{}) (int, []string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, selectQuery, args...)
+	if err != nil {
+		return 0, nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, nil, err
+		}
+		ids = append(ids, id.String())
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+	if len(ids) == 0 {
+		return 0, nil, nil
+	}
+
+	rows, err = tx.QueryContext(ctx, `SELECT storage_key FROM message_attachments WHERE `+keyColumn+` = ANY($1::uuid[])`, pq.Array(ids))
+	if err != nil {
+		return 0, nil, err
+	}
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			rows.Close()
+			return 0, nil, err
+		}
+		keys = append(keys, key)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE id = ANY($1::uuid[])`, pq.Array(ids))
+	if err != nil {
+		return 0, nil, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, nil, err
+	}
+	return int(n), keys, tx.Commit()
+}
@@ -16,6 +16,11 @@ type ClaimRepository interface {
 	CountByTaskID(ctx context.Context, taskID uuid.UUID) (int, error)
 	UpdateStatus(ctx context.Context, id uuid.UUID, status domain.ClaimStatus) error
 	SubmitCompletion(ctx context.Context, id uuid.UUID, text, imageURL string) error
+	OpenDispute(ctx context.Context, id uuid.UUID, reason string) error
+	CreateArbitration(ctx context.Context, arbitration *domain.Arbitration) error
+	GetAbandoned(ctx context.Context) ([]*domain.Claim, error)
+	GetPendingByClaimerID(ctx context.Context, claimerID uuid.UUID) ([]*domain.Claim, error)
+	GetOpenDisputes(ctx context.Context, arbitratorID uuid.UUID, limit, offset int) ([]*domain.Claim, error)
 }
 
 type claimRepository struct {
@@ -26,6 +31,48 @@ func NewClaimRepository(db *sql.DB) ClaimRepository {
 	return &claimRepository{db: db}
 }
 
+const claimColumns = `id, task_id, claimer_id, status, submitted_at, completion_text, completion_image_url, dispute_reason, disputed_at, decided_at, hidden_at, created_at, updated_at`
+
+func scanClaim(row interface{ Scan(...interface{}) error }) (*domain.Claim, error) {
+	claim := &domain.Claim{}
+	var submittedAt, disputedAt, decidedAt, hiddenAt sql.NullTime
+	var completionText, completionImageURL, disputeReason sql.NullString
+	err := row.Scan(
+		&claim.ID,
+		&claim.TaskID,
+		&claim.ClaimerID,
+		&claim.Status,
+		&submittedAt,
+		&completionText,
+		&completionImageURL,
+		&disputeReason,
+		&disputedAt,
+		&decidedAt,
+		&hiddenAt,
+		&claim.CreatedAt,
+		&claim.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if submittedAt.Valid {
+		claim.SubmittedAt = &submittedAt.Time
+	}
+	if disputedAt.Valid {
+		claim.DisputedAt = &disputedAt.Time
+	}
+	if decidedAt.Valid {
+		claim.DecidedAt = &decidedAt.Time
+	}
+	if hiddenAt.Valid {
+		claim.HiddenAt = &hiddenAt.Time
+	}
+	claim.CompletionText = completionText.String
+	claim.CompletionImageURL = completionImageURL.String
+	claim.DisputeReason = disputeReason.String
+	return claim, nil
+}
+
 func (r *claimRepository) Create(ctx context.Context, claim *domain.Claim) error {
 	query := `
 		INSERT INTO claims (id, task_id, claimer_id, status)
@@ -45,36 +92,17 @@ func (r *claimRepository) Create(ctx context.Context, claim *domain.Claim) error
 
 func (r *claimRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Claim, error) {
 	query := `
-		SELECT id, task_id, claimer_id, status, submitted_at, completion_text, completion_image_url, created_at, updated_at
+		SELECT ` + claimColumns + `
 		FROM claims
 		WHERE id = $1
 	`
 	
-	claim := &domain.Claim{}
-	var submittedAt sql.NullTime
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&claim.ID,
-		&claim.TaskID,
-		&claim.ClaimerID,
-		&claim.Status,
-		&submittedAt,
-		&claim.CompletionText,
-		&claim.CompletionImageURL,
-		&claim.CreatedAt,
-		&claim.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-	if submittedAt.Valid {
-		claim.SubmittedAt = &submittedAt.Time
-	}
-	return claim, nil
+	return scanClaim(r.db.QueryRowContext(ctx, query, id))
 }
 
 func (r *claimRepository) GetByTaskID(ctx context.Context, taskID uuid.UUID) ([]*domain.Claim, error) {
 	query := `
-		SELECT id, task_id, claimer_id, status, submitted_at, completion_text, completion_image_url, created_at, updated_at
+		SELECT ` + claimColumns + `
 		FROM claims
 		WHERE task_id = $1
 		ORDER BY created_at ASC
@@ -88,25 +116,10 @@ func (r *claimRepository) GetByTaskID(ctx context.Context, taskID uuid.UUID) ([]
 	
 	var claims []*domain.Claim
 	for rows.Next() {
-		claim := &domain.Claim{}
-		var submittedAt sql.NullTime
-		err := rows.Scan(
-			&claim.ID,
-			&claim.TaskID,
-			&claim.ClaimerID,
-			&claim.Status,
-			&submittedAt,
-			&claim.CompletionText,
-			&claim.CompletionImageURL,
-			&claim.CreatedAt,
-			&claim.UpdatedAt,
-		)
+		claim, err := scanClaim(rows)
 		if err != nil {
 			return nil, err
 		}
-		if submittedAt.Valid {
-			claim.SubmittedAt = &submittedAt.Time
-		}
 		claims = append(claims, claim)
 	}
 	return claims, rows.Err()
@@ -114,42 +127,28 @@ func (r *claimRepository) GetByTaskID(ctx context.Context, taskID uuid.UUID) ([]
 
 func (r *claimRepository) GetByTaskIDAndClaimerID(ctx context.Context, taskID, claimerID uuid.UUID) (*domain.Claim, error) {
 	query := `
-		SELECT id, task_id, claimer_id, status, submitted_at, completion_text, completion_image_url, created_at, updated_at
+		SELECT ` + claimColumns + `
 		FROM claims
 		WHERE task_id = $1 AND claimer_id = $2
 	`
 	
-	claim := &domain.Claim{}
-	var submittedAt sql.NullTime
-	err := r.db.QueryRowContext(ctx, query, taskID, claimerID).Scan(
-		&claim.ID,
-		&claim.TaskID,
-		&claim.ClaimerID,
-		&claim.Status,
-		&submittedAt,
-		&claim.CompletionText,
-		&claim.CompletionImageURL,
-		&claim.CreatedAt,
-		&claim.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-	if submittedAt.Valid {
-		claim.SubmittedAt = &submittedAt.Time
-	}
-	return claim, nil
+	return scanClaim(r.db.QueryRowContext(ctx, query, taskID, claimerID))
 }
 
 func (r *claimRepository) CountByTaskID(ctx context.Context, taskID uuid.UUID) (int, error) {
-	query := `SELECT COUNT(*) FROM claims WHERE task_id = $1 AND status != 'cancelled'`
+	query := `SELECT COUNT(*) FROM claims WHERE task_id = $1 AND status NOT IN ('cancelled', 'applied')`
 	var count int
 	err := r.db.QueryRowContext(ctx, query, taskID).Scan(&count)
 	return count, err
 }
 
 func (r *claimRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status domain.ClaimStatus) error {
-	query := `UPDATE claims SET status = $1 WHERE id = $2`
+	query := `
+		UPDATE claims
+		SET status = $1::text,
+			decided_at = CASE WHEN $1::text IN ('approved', 'rejected') THEN NOW() ELSE decided_at END
+		WHERE id = $2
+	`
 	_, err := r.db.ExecContext(ctx, query, status, id)
 	return err
 }
@@ -163,3 +162,119 @@ func (r *claimRepository) SubmitCompletion(ctx context.Context, id uuid.UUID, te
 	_, err := r.db.ExecContext(ctx, query, text, imageURL, id)
 	return err
 }
+
+func (r *claimRepository) OpenDispute(ctx context.Context, id uuid.UUID, reason string) error {
+	query := `
+		UPDATE claims
+		SET status = 'disputed', dispute_reason = $1, disputed_at = NOW()
+		WHERE id = $2 AND status = 'rejected'
+	`
+	_, err := r.db.ExecContext(ctx, query, reason, id)
+	return err
+}
+
+func (r *claimRepository) CreateArbitration(ctx context.Context, arbitration *domain.Arbitration) error {
+	query := `
+		INSERT INTO arbitrations (id, task_id, claim_id, admin_arbitrator_id, arbitrator_id, decision, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		arbitration.ID,
+		arbitration.TaskID,
+		arbitration.ClaimID,
+		arbitration.ArbitratorID,
+		arbitration.UserArbitratorID,
+		arbitration.Decision,
+		arbitration.Reason,
+	).Scan(&arbitration.CreatedAt)
+}
+
+// GetAbandoned returns pending claims that were never submitted before the
+// task's owner deadline passed, and applications nobody accepted before the
+// claim deadline.
+func (r *claimRepository) GetAbandoned(ctx context.Context) ([]*domain.Claim, error) {
+	query := `
+		SELECT c.id, c.task_id, c.claimer_id, c.status, c.submitted_at, c.completion_text, c.completion_image_url, c.dispute_reason, c.disputed_at, c.decided_at, c.hidden_at, c.created_at, c.updated_at
+		FROM claims c
+		JOIN tasks t ON t.id = c.task_id
+		WHERE (c.status = 'pending' AND c.submitted_at IS NULL AND t.owner_deadline <= NOW())
+			OR (c.status = 'applied' AND t.claim_deadline <= NOW())
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claims []*domain.Claim
+	for rows.Next() {
+		claim, err := scanClaim(rows)
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, claim)
+	}
+	return claims, rows.Err()
+}
+
+func (r *claimRepository) GetPendingByClaimerID(ctx context.Context, claimerID uuid.UUID) ([]*domain.Claim, error) {
+	query := `SELECT ` + claimColumns + ` FROM claims WHERE claimer_id = $1 AND status IN ('pending', 'applied')`
+
+	rows, err := r.db.QueryContext(ctx, query, claimerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claims []*domain.Claim
+	for rows.Next() {
+		claim, err := scanClaim(rows)
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, claim)
+	}
+	return claims, rows.Err()
+}
+
+// GetOpenDisputes lists disputed claims oldest first, leaving out those the
+// arbitrator has a stake in: their own tasks, tasks they have claimed, and
+// parties they have blocked or been blocked by.
+func (r *claimRepository) GetOpenDisputes(ctx context.Context, arbitratorID uuid.UUID, limit, offset int) ([]*domain.Claim, error) {
+	query := `
+		SELECT c.id, c.task_id, c.claimer_id, c.status, c.submitted_at, c.completion_text, c.completion_image_url, c.dispute_reason, c.disputed_at, c.decided_at, c.hidden_at, c.created_at, c.updated_at
+		FROM claims c
+		JOIN tasks t ON t.id = c.task_id
+		WHERE c.status = 'disputed' AND t.owner_id <> $1
+		  AND NOT EXISTS (
+			SELECT 1 FROM claims own
+			WHERE own.task_id = c.task_id AND own.claimer_id = $1
+		  )
+		  AND NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.blocker_id = $1 AND b.blocked_id IN (t.owner_id, c.claimer_id))
+			   OR (b.blocked_id = $1 AND b.blocker_id IN (t.owner_id, c.claimer_id))
+		  )
+		ORDER BY c.disputed_at
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, arbitratorID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claims []*domain.Claim
+	for rows.Next() {
+		claim, err := scanClaim(rows)
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, claim)
+	}
+	return claims, rows.Err()
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type DeviceRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Device, error)
+	GetByDeviceID(ctx context.Context, deviceID string) (*domain.Device, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Device, error)
+	GetOrCreate(ctx context.Context, userID uuid.UUID, deviceID, name string) (*domain.Device, error)
+	Link(ctx context.Context, userID uuid.UUID, deviceID, name string) (*domain.Device, error)
+	Touch(ctx context.Context, id uuid.UUID) error
+	Revoke(ctx context.Context, id uuid.UUID) error
+	CreatePairing(ctx context.Context, userID uuid.UUID, codeHash string, expiresAt time.Time) error
+	ConsumePairing(ctx context.Context, codeHash string) (uuid.UUID, error)
+}
+
+type deviceRepository struct {
+	db *sql.DB
+}
+
+func NewDeviceRepository(db *sql.DB) DeviceRepository {
+	return &deviceRepository{db: db}
+}
+
+const deviceColumns = `id, user_id, device_id, name, created_at, last_seen_at, revoked_at`
+
+func scanDevice(row interface{ Scan(...interface{}) error }) (*domain.Device, error) {
+	device := &domain.Device{}
+	var revokedAt sql.NullTime
+	err := row.Scan(
+		&device.ID,
+		&device.UserID,
+		&device.DeviceID,
+		&device.Name,
+		&device.CreatedAt,
+		&device.LastSeenAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		device.RevokedAt = &revokedAt.Time
+	}
+	return device, nil
+}
+
+func (r *deviceRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Device, error) {
+	query := `SELECT ` + deviceColumns + ` FROM user_devices WHERE id = $1`
+	return scanDevice(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *deviceRepository) GetByDeviceID(ctx context.Context, deviceID string) (*domain.Device, error) {
+	query := `SELECT ` + deviceColumns + ` FROM user_devices WHERE device_id = $1`
+	return scanDevice(r.db.QueryRowContext(ctx, query, deviceID))
+}
+
+func (r *deviceRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Device, error) {
+	query := `SELECT ` + deviceColumns + ` FROM user_devices WHERE user_id = $1 ORDER BY created_at ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []*domain.Device
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+	return devices, rows.Err()
+}
+
+func (r *deviceRepository) GetOrCreate(ctx context.Context, userID uuid.UUID, deviceID, name string) (*domain.Device, error) {
+	query := `
+		INSERT INTO user_devices (user_id, device_id, name)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (device_id) DO UPDATE SET device_id = user_devices.device_id
+		RETURNING ` + deviceColumns
+
+	return scanDevice(r.db.QueryRowContext(ctx, query, userID, deviceID, name))
+}
+
+// Link attaches a device to a user, moving it off any account it was
+// previously registered to and clearing an earlier revocation.
+func (r *deviceRepository) Link(ctx context.Context, userID uuid.UUID, deviceID, name string) (*domain.Device, error) {
+	query := `
+		INSERT INTO user_devices (user_id, device_id, name)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (device_id) DO UPDATE
+		SET user_id = EXCLUDED.user_id, name = EXCLUDED.name, revoked_at = NULL, last_seen_at = NOW()
+		RETURNING ` + deviceColumns
+
+	return scanDevice(r.db.QueryRowContext(ctx, query, userID, deviceID, name))
+}
+
+func (r *deviceRepository) Touch(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE user_devices SET last_seen_at = NOW() WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *deviceRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE user_devices SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *deviceRepository) CreatePairing(ctx context.Context, userID uuid.UUID, codeHash string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO device_pairings (user_id, code_hash, expires_at)
+		VALUES ($1, $2, $3)
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, codeHash, expiresAt)
+	return err
+}
+
+// ConsumePairing marks an unexpired pairing code as used and returns the user
+// it belongs to. Returns sql.ErrNoRows for unknown, expired or used codes.
+func (r *deviceRepository) ConsumePairing(ctx context.Context, codeHash string) (uuid.UUID, error) {
+	query := `
+		UPDATE device_pairings SET used_at = NOW()
+		WHERE code_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`
+
+	var userID uuid.UUID
+	err := r.db.QueryRowContext(ctx, query, codeHash).Scan(&userID)
+	return userID, err
+}
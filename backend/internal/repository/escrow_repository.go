@@ -22,6 +22,30 @@ func NewEscrowRepository(db *sql.DB) EscrowRepository {
 	return &escrowRepository{db: db}
 }
 
+const escrowTransactionColumns = `id, task_id, user_id, amount, transaction_type, status, created_at, completed_at`
+
+func scanEscrowTransaction(row interface{ Scan(...interface{}) error }) (*domain.EscrowTransaction, error) {
+	tx := &domain.EscrowTransaction{}
+	var completedAt sql.NullTime
+	err := row.Scan(
+		&tx.ID,
+		&tx.TaskID,
+		&tx.UserID,
+		&tx.Amount,
+		&tx.TransactionType,
+		&tx.Status,
+		&tx.CreatedAt,
+		&completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		tx.CompletedAt = &completedAt.Time
+	}
+	return tx, nil
+}
+
 func (r *escrowRepository) CreateTransaction(ctx context.Context, tx *domain.EscrowTransaction) error {
 	query := `
 		INSERT INTO escrow_transactions (id, task_id, user_id, amount, transaction_type, status)
@@ -43,7 +67,7 @@ func (r *escrowRepository) CreateTransaction(ctx context.Context, tx *domain.Esc
 
 func (r *escrowRepository) GetTransactionsByTaskID(ctx context.Context, taskID uuid.UUID) ([]*domain.EscrowTransaction, error) {
 	query := `
-		SELECT id, task_id, user_id, amount, transaction_type, status, created_at, completed_at
+		SELECT ` + escrowTransactionColumns + `
 		FROM escrow_transactions
 		WHERE task_id = $1
 		ORDER BY created_at ASC
@@ -57,24 +81,10 @@ func (r *escrowRepository) GetTransactionsByTaskID(ctx context.Context, taskID u
 	
 	var transactions []*domain.EscrowTransaction
 	for rows.Next() {
-		tx := &domain.EscrowTransaction{}
-		var completedAt sql.NullTime
-		err := rows.Scan(
-			&tx.ID,
-			&tx.TaskID,
-			&tx.UserID,
-			&tx.Amount,
-			&tx.TransactionType,
-			&tx.Status,
-			&tx.CreatedAt,
-			&completedAt,
-		)
+		tx, err := scanEscrowTransaction(rows)
 		if err != nil {
 			return nil, err
 		}
-		if completedAt.Valid {
-			tx.CompletedAt = &completedAt.Time
-		}
 		transactions = append(transactions, tx)
 	}
 	return transactions, rows.Err()
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type FraudRepository interface {
+	RecordFingerprint(ctx context.Context, userID uuid.UUID, kind domain.FingerprintKind, hash string) error
+	SharesFingerprint(ctx context.Context, userA, userB uuid.UUID, kind domain.FingerprintKind, since time.Time) (bool, error)
+	CountApprovedBetween(ctx context.Context, ownerID, claimerID uuid.UUID, since time.Time, excludeClaimID uuid.UUID) (int, error)
+	CreateFlag(ctx context.Context, flag *domain.FraudFlag) error
+	GetFlag(ctx context.Context, claimID uuid.UUID) (*domain.FraudFlag, error)
+	ListFlags(ctx context.Context, status domain.FraudFlagStatus, limit, offset int) ([]*domain.FraudFlag, error)
+	UpdateFlagStatus(ctx context.Context, claimID uuid.UUID, status domain.FraudFlagStatus, reviewedBy string) error
+}
+
+type fraudRepository struct {
+	db *sql.DB
+}
+
+func NewFraudRepository(db *sql.DB) FraudRepository {
+	return &fraudRepository{db: db}
+}
+
+const fraudFlagColumns = `f.claim_id, c.task_id, t.owner_id, c.claimer_id, f.score, f.signals, f.status, f.reviewed_by, f.reviewed_at, f.created_at`
+
+func scanFraudFlag(row interface{ Scan(...interface{}) error }) (*domain.FraudFlag, error) {
+	flag := &domain.FraudFlag{}
+	var signals []string
+	var reviewedBy sql.NullString
+	err := row.Scan(
+		&flag.ClaimID, &flag.TaskID, &flag.OwnerID, &flag.ClaimerID, &flag.Score,
+		pq.Array(&signals), &flag.Status, &reviewedBy, &flag.ReviewedAt, &flag.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, signal := range signals {
+		flag.Signals = append(flag.Signals, domain.FraudSignal(signal))
+	}
+	flag.ReviewedBy = reviewedBy.String
+	return flag, nil
+}
+
+func (r *fraudRepository) RecordFingerprint(ctx context.Context, userID uuid.UUID, kind domain.FingerprintKind, hash string) error {
+	query := `
+		INSERT INTO user_fingerprints (user_id, kind, hash)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, kind, hash) DO UPDATE SET last_seen_at = NOW()
+	`
+
+	_, err := r.db.ExecContext(ctx, query, userID, kind, hash)
+	return err
+}
+
+// SharesFingerprint reports whether both users were seen with the same
+// fingerprint of the given kind since the given time. Networks also match
+// on the address each account was created from.
+func (r *fraudRepository) SharesFingerprint(ctx context.Context, userA, userB uuid.UUID, kind domain.FingerprintKind, since time.Time) (bool, error) {
+	query := `
+		WITH seen AS (
+			SELECT user_id, hash FROM user_fingerprints
+			WHERE kind = $3 AND last_seen_at >= $4 AND user_id IN ($1, $2)
+			UNION
+			SELECT user_id, ip_hash FROM account_creations
+			WHERE $3 = 'network' AND user_id IN ($1, $2)
+		)
+		SELECT EXISTS (
+			SELECT 1 FROM seen a JOIN seen b ON a.hash = b.hash
+			WHERE a.user_id = $1 AND b.user_id = $2
+		)
+	`
+
+	var shared bool
+	err := r.db.QueryRowContext(ctx, query, userA, userB, kind, since).Scan(&shared)
+	return shared, err
+}
+
+// CountApprovedBetween counts the claimer's approved claims on the owner's
+// tasks decided since the given time, leaving out excludeClaimID.
+func (r *fraudRepository) CountApprovedBetween(ctx context.Context, ownerID, claimerID uuid.UUID, since time.Time, excludeClaimID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM claims c
+		JOIN tasks t ON t.id = c.task_id
+		WHERE t.owner_id = $1 AND c.claimer_id = $2 AND c.status = 'approved'
+			AND c.decided_at >= $3 AND c.id <> $4
+	`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, ownerID, claimerID, since, excludeClaimID).Scan(&count)
+	return count, err
+}
+
+func (r *fraudRepository) CreateFlag(ctx context.Context, flag *domain.FraudFlag) error {
+	signals := make([]string, len(flag.Signals))
+	for i, signal := range flag.Signals {
+		signals[i] = string(signal)
+	}
+
+	query := `
+		INSERT INTO fraud_flags (claim_id, score, signals, status)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (claim_id) DO NOTHING
+		RETURNING created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, flag.ClaimID, flag.Score, pq.Array(signals), flag.Status).Scan(&flag.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+func (r *fraudRepository) GetFlag(ctx context.Context, claimID uuid.UUID) (*domain.FraudFlag, error) {
+	query := `
+		SELECT ` + fraudFlagColumns + `
+		FROM fraud_flags f
+		JOIN claims c ON c.id = f.claim_id
+		JOIN tasks t ON t.id = c.task_id
+		WHERE f.claim_id = $1
+	`
+
+	return scanFraudFlag(r.db.QueryRowContext(ctx, query, claimID))
+}
+
+func (r *fraudRepository) ListFlags(ctx context.Context, status domain.FraudFlagStatus, limit, offset int) ([]*domain.FraudFlag, error) {
+	query := `
+		SELECT ` + fraudFlagColumns + `
+		FROM fraud_flags f
+		JOIN claims c ON c.id = f.claim_id
+		JOIN tasks t ON t.id = c.task_id
+		WHERE f.status = $1
+		ORDER BY f.score DESC, f.created_at ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flags []*domain.FraudFlag
+	for rows.Next() {
+		flag, err := scanFraudFlag(rows)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, flag)
+	}
+	return flags, rows.Err()
+}
+
+func (r *fraudRepository) UpdateFlagStatus(ctx context.Context, claimID uuid.UUID, status domain.FraudFlagStatus, reviewedBy string) error {
+	query := `
+		UPDATE fraud_flags
+		SET status = $2, reviewed_by = $3, reviewed_at = NOW()
+		WHERE claim_id = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, claimID, status, reviewedBy)
+	return err
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+// KeyRepository keeps the keys devices publish, separately for each chat.
+type KeyRepository interface {
+	Publish(ctx context.Context, chatID uuid.UUID, bundle *domain.KeyBundle, prekeys []domain.Prekey) error
+	GetStatus(ctx context.Context, chatID, deviceID uuid.UUID) (*domain.KeyStatus, error)
+	TakeBundles(ctx context.Context, chatID uuid.UUID, userIDs []uuid.UUID, excludeDeviceID uuid.UUID) ([]*domain.KeyBundle, error)
+	GetKeyedDevices(ctx context.Context, chatID uuid.UUID, userIDs []uuid.UUID) (map[uuid.UUID]uuid.UUID, error)
+}
+
+type keyRepository struct {
+	db *sql.DB
+}
+
+func NewKeyRepository(db *sql.DB) KeyRepository {
+	return &keyRepository{db: db}
+}
+
+// Publish stores a device's identity key and signed prekey for the chat and
+// adds to its one-time prekeys there. A new identity key means the app was
+// reinstalled or reset, so the one-time prekeys made for the old one are
+// dropped.
+func (r *keyRepository) Publish(ctx context.Context, chatID uuid.UUID, bundle *domain.KeyBundle, prekeys []domain.Prekey) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM device_prekeys p
+		USING device_keys k
+		WHERE p.device_id = k.device_id AND p.chat_id = k.chat_id
+		AND k.device_id = $1 AND k.chat_id = $2 AND k.identity_key <> $3
+	`, bundle.DeviceID, chatID, bundle.IdentityKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO device_keys (device_id, chat_id, identity_key, signed_prekey_id, signed_prekey, signed_prekey_signature)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (device_id, chat_id) DO UPDATE
+		SET identity_key = EXCLUDED.identity_key,
+		    signed_prekey_id = EXCLUDED.signed_prekey_id,
+		    signed_prekey = EXCLUDED.signed_prekey,
+		    signed_prekey_signature = EXCLUDED.signed_prekey_signature,
+		    updated_at = NOW()
+	`,
+		bundle.DeviceID,
+		chatID,
+		bundle.IdentityKey,
+		bundle.SignedPrekey.KeyID,
+		bundle.SignedPrekey.PublicKey,
+		bundle.SignedPrekey.Signature,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, prekey := range prekeys {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO device_prekeys (device_id, chat_id, key_id, public_key)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (device_id, chat_id, key_id) DO NOTHING
+		`, bundle.DeviceID, chatID, prekey.KeyID, prekey.PublicKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *keyRepository) GetStatus(ctx context.Context, chatID, deviceID uuid.UUID) (*domain.KeyStatus, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM device_keys WHERE device_id = $1 AND chat_id = $2),
+			(SELECT COUNT(*) FROM device_prekeys WHERE device_id = $1 AND chat_id = $2)
+	`
+
+	status := &domain.KeyStatus{}
+	err := r.db.QueryRowContext(ctx, query, deviceID, chatID).Scan(&status.Published, &status.OneTimePrekeys)
+	return status, err
+}
+
+// TakeBundles returns the chat's key bundles of the users' devices that are
+// not revoked, except excludeDeviceID, each with one of its one-time prekeys,
+// which is used up by handing it out. A device without one-time prekeys
+// left still comes back, with only its signed prekey.
+func (r *keyRepository) TakeBundles(ctx context.Context, chatID uuid.UUID, userIDs []uuid.UUID, excludeDeviceID uuid.UUID) ([]*domain.KeyBundle, error) {
+	// Two concurrent takers may both pick a device's lowest key; only one
+	// of them deletes it and the other goes without
+	query := `
+		WITH taken AS (
+			DELETE FROM device_prekeys
+			WHERE chat_id = $3 AND (device_id, key_id) IN (
+				SELECT device_id, MIN(key_id) FROM device_prekeys
+				WHERE chat_id = $3 AND device_id IN (
+					SELECT id FROM user_devices
+					WHERE user_id = ANY($1::uuid[]) AND revoked_at IS NULL AND id <> $2
+				)
+				GROUP BY device_id
+			)
+			RETURNING device_id, key_id, public_key
+		)
+		SELECT k.device_id, d.user_id, k.identity_key, k.signed_prekey_id, k.signed_prekey, k.signed_prekey_signature,
+			t.key_id, t.public_key
+		FROM device_keys k
+		JOIN user_devices d ON d.id = k.device_id
+		LEFT JOIN taken t ON t.device_id = k.device_id
+		WHERE k.chat_id = $3 AND d.user_id = ANY($1::uuid[]) AND d.revoked_at IS NULL AND k.device_id <> $2
+		ORDER BY d.created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(uuidStrings(userIDs)), excludeDeviceID, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bundles []*domain.KeyBundle
+	for rows.Next() {
+		bundle := &domain.KeyBundle{}
+		var prekeyID sql.NullInt64
+		var prekey []byte
+		err := rows.Scan(
+			&bundle.DeviceID,
+			&bundle.UserID,
+			&bundle.IdentityKey,
+			&bundle.SignedPrekey.KeyID,
+			&bundle.SignedPrekey.PublicKey,
+			&bundle.SignedPrekey.Signature,
+			&prekeyID,
+			&prekey,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if prekeyID.Valid {
+			bundle.OneTimePrekey = &domain.Prekey{KeyID: int(prekeyID.Int64), PublicKey: prekey}
+		}
+		bundles = append(bundles, bundle)
+	}
+	return bundles, rows.Err()
+}
+
+// GetKeyedDevices maps the users' devices that are not revoked and have
+// published keys for the chat to the user each belongs to.
+func (r *keyRepository) GetKeyedDevices(ctx context.Context, chatID uuid.UUID, userIDs []uuid.UUID) (map[uuid.UUID]uuid.UUID, error) {
+	query := `
+		SELECT d.id, d.user_id
+		FROM user_devices d
+		JOIN device_keys k ON k.device_id = d.id
+		WHERE k.chat_id = $2 AND d.user_id = ANY($1::uuid[]) AND d.revoked_at IS NULL
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(uuidStrings(userIDs)), chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := make(map[uuid.UUID]uuid.UUID)
+	for rows.Next() {
+		var deviceID, userID uuid.UUID
+		if err := rows.Scan(&deviceID, &userID); err != nil {
+			return nil, err
+		}
+		devices[deviceID] = userID
+	}
+	return devices, rows.Err()
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	strs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strs = append(strs, id.String())
+	}
+	return strs
+}
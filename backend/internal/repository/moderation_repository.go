@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type ModerationRepository interface {
+	CreateReport(ctx context.Context, report *domain.Report) error
+	CountOpenReports(ctx context.Context, targetType domain.ReportTargetType, targetID uuid.UUID) (int, error)
+	GetReportsByTarget(ctx context.Context, targetType domain.ReportTargetType, targetID uuid.UUID) ([]*domain.Report, error)
+	GetQueue(ctx context.Context, status domain.ReportStatus, limit, offset int) ([]*domain.ModerationQueueItem, error)
+	ResolveReports(ctx context.Context, targetType domain.ReportTargetType, targetID uuid.UUID, status domain.ReportStatus) error
+	SetHidden(ctx context.Context, targetType domain.ReportTargetType, targetID uuid.UUID, hidden bool) error
+	CreateAction(ctx context.Context, action *domain.ModerationAction) error
+}
+
+type moderationRepository struct {
+	db *sql.DB
+}
+
+func NewModerationRepository(db *sql.DB) ModerationRepository {
+	return &moderationRepository{db: db}
+}
+
+// hideableTables maps the target types that can be hidden to their table.
+var hideableTables = map[domain.ReportTargetType]string{
+	domain.ReportTargetTask:    "tasks",
+	domain.ReportTargetClaim:   "claims",
+	domain.ReportTargetMessage: "messages",
+}
+
+// CreateReport inserts a report. A repeat report by the same reporter on the
+// same target updates the category and note instead of counting twice.
+func (r *moderationRepository) CreateReport(ctx context.Context, report *domain.Report) error {
+	query := `
+		INSERT INTO reports (id, reporter_id, target_type, target_id, category, note, disclosed_content)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+		ON CONFLICT (reporter_id, target_type, target_id)
+		DO UPDATE SET category = EXCLUDED.category, note = EXCLUDED.note,
+			disclosed_content = COALESCE(EXCLUDED.disclosed_content, reports.disclosed_content)
+		RETURNING id, status, created_at, COALESCE(disclosed_content, '')
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		report.ID,
+		report.ReporterID,
+		report.TargetType,
+		report.TargetID,
+		report.Category,
+		report.Note,
+		report.DisclosedContent,
+	).Scan(&report.ID, &report.Status, &report.CreatedAt, &report.DisclosedContent)
+}
+
+func (r *moderationRepository) CountOpenReports(ctx context.Context, targetType domain.ReportTargetType, targetID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM reports WHERE target_type = $1 AND target_id = $2 AND status = 'open'`
+	var count int
+	err := r.db.QueryRowContext(ctx, query, targetType, targetID).Scan(&count)
+	return count, err
+}
+
+func (r *moderationRepository) GetReportsByTarget(ctx context.Context, targetType domain.ReportTargetType, targetID uuid.UUID) ([]*domain.Report, error) {
+	query := `
+		SELECT id, reporter_id, target_type, target_id, category, note, COALESCE(disclosed_content, ''), status, created_at, resolved_at
+		FROM reports
+		WHERE target_type = $1 AND target_id = $2
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []*domain.Report
+	for rows.Next() {
+		report := &domain.Report{}
+		var reporterID uuid.NullUUID
+		var resolvedAt sql.NullTime
+		err := rows.Scan(
+			&report.ID,
+			&reporterID,
+			&report.TargetType,
+			&report.TargetID,
+			&report.Category,
+			&report.Note,
+			&report.DisclosedContent,
+			&report.Status,
+			&report.CreatedAt,
+			&resolvedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if reporterID.Valid {
+			report.ReporterID = &reporterID.UUID
+		}
+		if resolvedAt.Valid {
+			report.ResolvedAt = &resolvedAt.Time
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
+
+// GetQueue groups reports with the given status by target, most reported
+// first.
+func (r *moderationRepository) GetQueue(ctx context.Context, status domain.ReportStatus, limit, offset int) ([]*domain.ModerationQueueItem, error) {
+	query := `
+		SELECT
+			r.target_type,
+			r.target_id,
+			COUNT(*),
+			array_agg(DISTINCT r.category),
+			MIN(r.created_at),
+			MAX(r.created_at),
+			COALESCE(CASE r.target_type
+				WHEN 'task' THEN (SELECT hidden_at IS NOT NULL FROM tasks WHERE id = r.target_id)
+				WHEN 'claim' THEN (SELECT hidden_at IS NOT NULL FROM claims WHERE id = r.target_id)
+				WHEN 'message' THEN (SELECT hidden_at IS NOT NULL FROM messages WHERE id = r.target_id)
+			END, FALSE)
+		FROM reports r
+		WHERE r.status = $1
+		GROUP BY r.target_type, r.target_id
+		ORDER BY COUNT(*) DESC, MIN(r.created_at) ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*domain.ModerationQueueItem
+	for rows.Next() {
+		item := &domain.ModerationQueueItem{}
+		err := rows.Scan(
+			&item.TargetType,
+			&item.TargetID,
+			&item.ReportCount,
+			pq.Array(&item.Categories),
+			&item.FirstReportedAt,
+			&item.LastReportedAt,
+			&item.Hidden,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *moderationRepository) ResolveReports(ctx context.Context, targetType domain.ReportTargetType, targetID uuid.UUID, status domain.ReportStatus) error {
+	query := `
+		UPDATE reports
+		SET status = $1, resolved_at = NOW()
+		WHERE target_type = $2 AND target_id = $3 AND status = 'open'
+	`
+	_, err := r.db.ExecContext(ctx, query, status, targetType, targetID)
+	return err
+}
+
+// SetHidden hides or restores a task, claim or message. Other target types
+// are ignored.
+func (r *moderationRepository) SetHidden(ctx context.Context, targetType domain.ReportTargetType, targetID uuid.UUID, hidden bool) error {
+	table, ok := hideableTables[targetType]
+	if !ok {
+		return nil
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET hidden_at = NULL WHERE id = $1`, table)
+	if hidden {
+		query = fmt.Sprintf(`UPDATE %s SET hidden_at = COALESCE(hidden_at, NOW()) WHERE id = $1`, table)
+	}
+	_, err := r.db.ExecContext(ctx, query, targetID)
+	return err
+}
+
+func (r *moderationRepository) CreateAction(ctx context.Context, action *domain.ModerationAction) error {
+	query := `
+		INSERT INTO moderation_actions (id, target_type, target_id, action, reason, moderator)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		action.ID,
+		action.TargetType,
+		action.TargetID,
+		action.Action,
+		action.Reason,
+		action.Moderator,
+	).Scan(&action.CreatedAt)
+}
@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+// PrivacyRepository gathers everything tied to one user for the data export
+// and scrubs it again on erasure.
+type PrivacyRepository interface {
+	GetTasks(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error)
+	GetClaims(ctx context.Context, userID uuid.UUID) ([]*domain.Claim, error)
+	GetChats(ctx context.Context, userID uuid.UUID) ([]*domain.Chat, error)
+	GetMessages(ctx context.Context, userID uuid.UUID) ([]*domain.Message, error)
+	GetEscrowTransactions(ctx context.Context, userID uuid.UUID) ([]*domain.EscrowTransaction, error)
+	GetReviews(ctx context.Context, userID uuid.UUID) ([]*domain.Review, error)
+	CountLockedEscrow(ctx context.Context, userID uuid.UUID) (int, error)
+	CountOpenDisputes(ctx context.Context, userID uuid.UUID) (int, error)
+	GetAttachmentKeys(ctx context.Context, userID uuid.UUID) ([]string, error)
+	Erase(ctx context.Context, userID uuid.UUID) error
+}
+
+type privacyRepository struct {
+	db *sql.DB
+}
+
+func NewPrivacyRepository(db *sql.DB) PrivacyRepository {
+	return &privacyRepository{db: db}
+}
+
+func (r *privacyRepository) GetTasks(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks
+		WHERE owner_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []*domain.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+func (r *privacyRepository) GetClaims(ctx context.Context, userID uuid.UUID) ([]*domain.Claim, error) {
+	query := `
+		SELECT ` + claimColumns + `
+		FROM claims
+		WHERE claimer_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claims []*domain.Claim
+	for rows.Next() {
+		claim, err := scanClaim(rows)
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, claim)
+	}
+	return claims, rows.Err()
+}
+
+func (r *privacyRepository) GetChats(ctx context.Context, userID uuid.UUID) ([]*domain.Chat, error) {
+	query := `
+		SELECT ` + chatColumns + `
+		FROM chats
+		WHERE participant_id = $1 OR other_participant_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chats []*domain.Chat
+	for rows.Next() {
+		chat, err := scanChat(rows)
+		if err != nil {
+			return nil, err
+		}
+		chats = append(chats, chat)
+	}
+	return chats, rows.Err()
+}
+
+// GetMessages returns the messages in the user's chats, from both sides, so
+// the export shows whole conversations. Messages the other side unsent are
+// left blank; expired and hidden ones, and those from before the user last
+// deleted the chat, are left out, as they are in the app.
+func (r *privacyRepository) GetMessages(ctx context.Context, userID uuid.UUID) ([]*domain.Message, error) {
+	query := `
+		SELECT m.id, m.chat_id, m.sender_id,
+			CASE WHEN m.deleted_at IS NOT NULL AND m.sender_id <> $1 THEN '' ELSE m.content END,
+			m.created_at
+		FROM messages m
+		JOIN chats c ON c.id = m.chat_id
+		WHERE (c.participant_id = $1 OR c.other_participant_id = $1)
+		AND m.hidden_at IS NULL
+		AND (m.expires_at IS NULL OR m.expires_at > NOW())
+		AND m.created_at > ` + historyStart("$1") + `
+		ORDER BY m.created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*domain.Message
+	for rows.Next() {
+		message := &domain.Message{}
+		err := rows.Scan(
+			&message.ID,
+			&message.ChatID,
+			&message.SenderID,
+			&message.Content,
+			&message.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
+
+// GetEscrowTransactions returns the transactions made to or from the user.
+// Releases to a claimer on the user's tasks are left out, since they carry
+// the claimer's ID.
+func (r *privacyRepository) GetEscrowTransactions(ctx context.Context, userID uuid.UUID) ([]*domain.EscrowTransaction, error) {
+	query := `
+		SELECT ` + escrowTransactionColumns + `
+		FROM escrow_transactions
+		WHERE user_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []*domain.EscrowTransaction
+	for rows.Next() {
+		tx, err := scanEscrowTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, tx)
+	}
+	return transactions, rows.Err()
+}
+
+func (r *privacyRepository) GetReviews(ctx context.Context, userID uuid.UUID) ([]*domain.Review, error) {
+	query := `
+		SELECT ` + reviewColumns + `
+		FROM reviews
+		WHERE reviewer_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []*domain.Review
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+func (r *privacyRepository) CountLockedEscrow(ctx context.Context, userID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM tasks WHERE owner_id = $1 AND escrow_locked = TRUE`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&count)
+	return count, err
+}
+
+// CountOpenDisputes counts disputed claims the user is part of, either as the
+// claimer or as the task owner.
+func (r *privacyRepository) CountOpenDisputes(ctx context.Context, userID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM claims c
+		JOIN tasks t ON t.id = c.task_id
+		WHERE c.status = $2 AND (c.claimer_id = $1 OR t.owner_id = $1)
+	`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, userID, domain.ClaimStatusDisputed).Scan(&count)
+	return count, err
+}
+
+// GetAttachmentKeys returns where the files the user sent in chats are
+// stored, so they can be deleted along with the account.
+func (r *privacyRepository) GetAttachmentKeys(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	query := `
+		SELECT a.storage_key
+		FROM message_attachments a
+		JOIN messages m ON m.id = a.message_id
+		WHERE m.sender_id = $1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Erase scrubs the user's personal content in one transaction. Rows other
+// people depend on (tasks, claims, chats, escrow transactions) stay in place
+// with their text redacted, including what reports disclosed of the user's
+// messages; devices, sessions, blocks and reputation history are deleted
+// outright.
+func (r *privacyRepository) Erase(ctx context.Context, userID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{`UPDATE users
+		SET device_id = 'erased:' || id::text, recovery_secret_hash = NULL, suspension_reason = NULL,
+			reputation = 0, worker_score = 0, poster_score = 0, erased_at = NOW()
+		WHERE id = $1`, nil},
+		{`DELETE FROM user_devices WHERE user_id = $1`, nil},
+		{`DELETE FROM device_pairings WHERE user_id = $1`, nil},
+		{`UPDATE tasks SET title = $2, description = '', updated_at = NOW() WHERE owner_id = $1`, []interface{}{domain.ErasedContent}},
+		{`UPDATE claims
+		SET completion_text = CASE WHEN completion_text IS NULL THEN NULL ELSE $2 END,
+			completion_image_url = NULL, dispute_reason = NULL, updated_at = NOW()
+		WHERE claimer_id = $1`, []interface{}{domain.ErasedContent}},
+		{`DELETE FROM message_edits WHERE message_id IN (SELECT id FROM messages WHERE sender_id = $1)`, nil},
+		{`DELETE FROM message_attachments WHERE message_id IN (SELECT id FROM messages WHERE sender_id = $1)`, nil},
+		{`DELETE FROM message_envelopes WHERE message_id IN (SELECT id FROM messages WHERE sender_id = $1)`, nil},
+		{`UPDATE reports SET disclosed_content = NULL
+		WHERE target_type = 'message' AND target_id IN (SELECT id FROM messages WHERE sender_id = $1)`, nil},
+		{`UPDATE messages SET content = $2, ciphertext = NULL WHERE sender_id = $1`, []interface{}{domain.ErasedContent}},
+		{`UPDATE reviews SET comment = '', tags = '{}' WHERE reviewer_id = $1`, nil},
+		{`UPDATE reports SET reporter_id = NULL, note = '' WHERE reporter_id = $1`, nil},
+		{`DELETE FROM user_blocks WHERE blocker_id = $1 OR blocked_id = $1`, nil},
+		{`DELETE FROM reputation_events WHERE user_id = $1`, nil},
+		{`DELETE FROM account_creations WHERE user_id = $1`, nil},
+		{`DELETE FROM user_fingerprints WHERE user_id = $1`, nil},
+		{`DELETE FROM chat_reads WHERE user_id = $1`, nil},
+	}
+
+	for _, statement := range statements {
+		args := append([]interface{}{userID}, statement.args...)
+		if _, err := tx.ExecContext(ctx, statement.query, args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type RateLimitRepository interface {
+	Hit(ctx context.Context, key string, windowStart time.Time) (int, error)
+	DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type rateLimitRepository struct {
+	db *sql.DB
+}
+
+func NewRateLimitRepository(db *sql.DB) RateLimitRepository {
+	return &rateLimitRepository{db: db}
+}
+
+// Hit counts one request against key in the window starting at windowStart
+// and returns the number of requests in that window so far. A counter left
+// over from an earlier window starts again from one.
+func (r *rateLimitRepository) Hit(ctx context.Context, key string, windowStart time.Time) (int, error) {
+	query := `
+		INSERT INTO rate_limits (key, window_start, hits)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (key) DO UPDATE SET
+			hits = CASE WHEN rate_limits.window_start = EXCLUDED.window_start THEN rate_limits.hits + 1 ELSE 1 END,
+			window_start = EXCLUDED.window_start
+		RETURNING hits
+	`
+
+	var hits int
+	err := r.db.QueryRowContext(ctx, query, key, windowStart).Scan(&hits)
+	return hits, err
+}
+
+func (r *rateLimitRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `DELETE FROM rate_limits WHERE window_start < $1`
+
+	result, err := r.db.ExecContext(ctx, query, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type ReputationRepository interface {
+	CreateEvent(ctx context.Context, event *domain.ReputationEvent) error
+	GetEventsByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.ReputationEvent, error)
+	GetUserIDsWithEvents(ctx context.Context) ([]uuid.UUID, error)
+	UpdateScores(ctx context.Context, userID uuid.UUID, workerScore, posterScore float64, reputation int) error
+}
+
+type reputationRepository struct {
+	db *sql.DB
+}
+
+func NewReputationRepository(db *sql.DB) ReputationRepository {
+	return &reputationRepository{db: db}
+}
+
+func (r *reputationRepository) CreateEvent(ctx context.Context, event *domain.ReputationEvent) error {
+	query := `
+		INSERT INTO reputation_events (id, user_id, role, kind, task_id, claim_id, reward_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		event.ID,
+		event.UserID,
+		event.Role,
+		event.Kind,
+		event.TaskID,
+		event.ClaimID,
+		event.RewardAmount,
+	).Scan(&event.CreatedAt)
+}
+
+func (r *reputationRepository) GetEventsByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.ReputationEvent, error) {
+	query := `
+		SELECT id, user_id, role, kind, task_id, claim_id, reward_amount, created_at
+		FROM reputation_events
+		WHERE user_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*domain.ReputationEvent
+	for rows.Next() {
+		event := &domain.ReputationEvent{}
+		var taskID, claimID uuid.NullUUID
+		err := rows.Scan(
+			&event.ID,
+			&event.UserID,
+			&event.Role,
+			&event.Kind,
+			&taskID,
+			&claimID,
+			&event.RewardAmount,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if taskID.Valid {
+			event.TaskID = &taskID.UUID
+		}
+		if claimID.Valid {
+			event.ClaimID = &claimID.UUID
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (r *reputationRepository) GetUserIDsWithEvents(ctx context.Context) ([]uuid.UUID, error) {
+	query := `SELECT DISTINCT user_id FROM reputation_events`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *reputationRepository) UpdateScores(ctx context.Context, userID uuid.UUID, workerScore, posterScore float64, reputation int) error {
+	query := `UPDATE users SET worker_score = $1, poster_score = $2, reputation = $3 WHERE id = $4`
+	_, err := r.db.ExecContext(ctx, query, workerScore, posterScore, reputation, userID)
+	return err
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type ReviewRepository interface {
+	Create(ctx context.Context, review *domain.Review) error
+	GetByClaimID(ctx context.Context, claimID uuid.UUID) ([]*domain.Review, error)
+	GetRatings(ctx context.Context, userID uuid.UUID, decidedBefore time.Time) (*domain.Ratings, error)
+}
+
+type reviewRepository struct {
+	db *sql.DB
+}
+
+func NewReviewRepository(db *sql.DB) ReviewRepository {
+	return &reviewRepository{db: db}
+}
+
+const reviewColumns = `id, claim_id, task_id, reviewer_id, reviewee_id, reviewer_role, rating, tags, comment, created_at`
+
+func scanReview(row interface{ Scan(...interface{}) error }) (*domain.Review, error) {
+	review := &domain.Review{}
+	err := row.Scan(
+		&review.ID,
+		&review.ClaimID,
+		&review.TaskID,
+		&review.ReviewerID,
+		&review.RevieweeID,
+		&review.ReviewerRole,
+		&review.Rating,
+		pq.Array(&review.Tags),
+		&review.Comment,
+		&review.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (r *reviewRepository) Create(ctx context.Context, review *domain.Review) error {
+	query := `
+		INSERT INTO reviews (id, claim_id, task_id, reviewer_id, reviewee_id, reviewer_role, rating, tags, comment)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING created_at
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		review.ID,
+		review.ClaimID,
+		review.TaskID,
+		review.ReviewerID,
+		review.RevieweeID,
+		review.ReviewerRole,
+		review.Rating,
+		pq.Array(review.Tags),
+		review.Comment,
+	).Scan(&review.CreatedAt)
+}
+
+func (r *reviewRepository) GetByClaimID(ctx context.Context, claimID uuid.UUID) ([]*domain.Review, error) {
+	query := `
+		SELECT ` + reviewColumns + `
+		FROM reviews
+		WHERE claim_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, claimID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []*domain.Review
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+// GetRatings averages the revealed reviews a user received. A review counts
+// once the other side has reviewed too, or once its claim was decided before
+// decidedBefore (the review window has closed).
+func (r *reviewRepository) GetRatings(ctx context.Context, userID uuid.UUID, decidedBefore time.Time) (*domain.Ratings, error) {
+	query := `
+		SELECT r.reviewer_role, AVG(r.rating)::float8, COUNT(*)
+		FROM reviews r
+		JOIN claims c ON c.id = r.claim_id
+		WHERE r.reviewee_id = $1
+		  AND (
+			c.decided_at <= $2
+			OR EXISTS (SELECT 1 FROM reviews o WHERE o.claim_id = r.claim_id AND o.reviewer_id = r.reviewee_id)
+		  )
+		GROUP BY r.reviewer_role
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, decidedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ratings := &domain.Ratings{}
+	for rows.Next() {
+		var role domain.ReputationRole
+		var average float64
+		var count int
+		if err := rows.Scan(&role, &average, &count); err != nil {
+			return nil, err
+		}
+		// Reviews written by posters rate the user as a worker and vice versa
+		summary := domain.RatingSummary{Average: &average, Count: count}
+		if role == domain.ReputationRolePoster {
+			ratings.AsWorker = summary
+		} else {
+			ratings.AsPoster = summary
+		}
+	}
+	return ratings, rows.Err()
+}
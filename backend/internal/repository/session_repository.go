@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *domain.Session) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Session, error)
+	GetByRefreshTokenHash(ctx context.Context, hash string) (*domain.Session, error)
+	GetByPreviousRefreshTokenHash(ctx context.Context, hash string) (*domain.Session, error)
+	Rotate(ctx context.Context, id uuid.UUID, oldHash, newHash string, expiresAt time.Time) (bool, error)
+	Revoke(ctx context.Context, id uuid.UUID) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+	RevokeAllForDevice(ctx context.Context, deviceID uuid.UUID) ([]uuid.UUID, error)
+}
+
+type sessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+const sessionColumns = `id, user_id, device_id, refresh_token_hash, expires_at, revoked_at, created_at, last_used_at`
+
+func scanSession(row interface{ Scan(...interface{}) error }) (*domain.Session, error) {
+	session := &domain.Session{}
+	var revokedAt sql.NullTime
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.DeviceID,
+		&session.RefreshTokenHash,
+		&session.ExpiresAt,
+		&revokedAt,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+	return session, nil
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, device_id, refresh_token_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at, last_used_at
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		session.ID,
+		session.UserID,
+		session.DeviceID,
+		session.RefreshTokenHash,
+		session.ExpiresAt,
+	).Scan(&session.CreatedAt, &session.LastUsedAt)
+}
+
+func (r *sessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = $1`
+	return scanSession(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *sessionRepository) GetByRefreshTokenHash(ctx context.Context, hash string) (*domain.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE refresh_token_hash = $1`
+	return scanSession(r.db.QueryRowContext(ctx, query, hash))
+}
+
+func (r *sessionRepository) GetByPreviousRefreshTokenHash(ctx context.Context, hash string) (*domain.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE previous_refresh_hash = $1`
+	return scanSession(r.db.QueryRowContext(ctx, query, hash))
+}
+
+// Rotate swaps the refresh token of an active session. It reports false when
+// the session was revoked or rotated concurrently.
+func (r *sessionRepository) Rotate(ctx context.Context, id uuid.UUID, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	query := `
+		UPDATE sessions
+		SET refresh_token_hash = $1, previous_refresh_hash = $2, expires_at = $3, last_used_at = NOW()
+		WHERE id = $4 AND refresh_token_hash = $2 AND revoked_at IS NULL
+	`
+	result, err := r.db.ExecContext(ctx, query, newHash, oldHash, expiresAt, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *sessionRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		UPDATE sessions SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+		RETURNING id
+	`
+	return r.revokeReturning(ctx, query, userID)
+}
+
+func (r *sessionRepository) RevokeAllForDevice(ctx context.Context, deviceID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		UPDATE sessions SET revoked_at = NOW()
+		WHERE device_id = $1 AND revoked_at IS NULL
+		RETURNING id
+	`
+	return r.revokeReturning(ctx, query, deviceID)
+}
+
+func (r *sessionRepository) revokeReturning(ctx context.Context, query string, arg uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.db.QueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type SybilRepository interface {
+	AdmitAccountCreation(ctx context.Context, id uuid.UUID, ipHash string, since time.Time, limit int) (bool, error)
+	RecordAccountCreation(ctx context.Context, id, userID uuid.UUID) error
+	FindClusters(ctx context.Context, since time.Time, maxAccountAge time.Duration, minAccounts int) ([]*domain.SybilCluster, error)
+}
+
+type sybilRepository struct {
+	db *sql.DB
+}
+
+func NewSybilRepository(db *sql.DB) SybilRepository {
+	return &sybilRepository{db: db}
+}
+
+// AdmitAccountCreation records an account creation from ipHash as id,
+// unless limit of them have been recorded since the given time; a limit of
+// zero or less admits every creation. It reports whether it did. Admissions from one address are serialized, so parallel
+// handshakes cannot all pass the count.
+func (r *sybilRepository) AdmitAccountCreation(ctx context.Context, id uuid.UUID, ipHash string, since time.Time, limit int) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('account_creations|' || $1))`, ipHash); err != nil {
+		return false, err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO account_creations (id, ip_hash)
+		SELECT $1, $2
+		WHERE $4 <= 0 OR (SELECT COUNT(*) FROM account_creations WHERE ip_hash = $2 AND created_at >= $3) < $4
+	`, id, ipHash, since, limit)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, tx.Commit()
+}
+
+// RecordAccountCreation ties the admitted creation id to the account made.
+func (r *sybilRepository) RecordAccountCreation(ctx context.Context, id, userID uuid.UUID) error {
+	query := `UPDATE account_creations SET user_id = $2 WHERE id = $1 AND user_id IS NULL`
+
+	_, err := r.db.ExecContext(ctx, query, id, userID)
+	return err
+}
+
+// FindClusters groups claims made since the given time by accounts younger
+// than maxAccountAge at the time of the claim, per task owner, and returns
+// the owners whose tasks drew at least minAccounts such accounts.
+func (r *sybilRepository) FindClusters(ctx context.Context, since time.Time, maxAccountAge time.Duration, minAccounts int) ([]*domain.SybilCluster, error) {
+	query := `
+		SELECT t.owner_id, array_agg(DISTINCT c.claimer_id::text), COUNT(*), COUNT(DISTINCT ac.ip_hash)
+		FROM claims c
+		JOIN tasks t ON t.id = c.task_id
+		JOIN users u ON u.id = c.claimer_id
+		LEFT JOIN account_creations ac ON ac.user_id = c.claimer_id
+		WHERE c.created_at >= $1 AND c.created_at - u.created_at < make_interval(secs => $2)
+		GROUP BY t.owner_id
+		HAVING COUNT(DISTINCT c.claimer_id) >= $3
+		ORDER BY COUNT(DISTINCT c.claimer_id) DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, since, maxAccountAge.Seconds(), minAccounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clusters []*domain.SybilCluster
+	for rows.Next() {
+		cluster := &domain.SybilCluster{}
+		var claimerIDs []string
+		err := rows.Scan(&cluster.OwnerID, pq.Array(&claimerIDs), &cluster.Claims, &cluster.Networks)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range claimerIDs {
+			claimerID, err := uuid.Parse(id)
+			if err != nil {
+				return nil, err
+			}
+			cluster.ClaimerIDs = append(cluster.ClaimerIDs, claimerID)
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters, rows.Err()
+}
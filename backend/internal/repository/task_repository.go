@@ -12,11 +12,12 @@ type TaskRepository interface {
 	Create(ctx context.Context, task *domain.Task) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Task, error)
 	GetByOwnerID(ctx context.Context, ownerID uuid.UUID, limit, offset int) ([]*domain.Task, error)
-	GetOpenTasks(ctx context.Context, limit, offset int) ([]*domain.Task, error)
+	GetOpenTasks(ctx context.Context, viewerID uuid.UUID, limit, offset int) ([]*domain.Task, error)
 	UpdateStatus(ctx context.Context, id uuid.UUID, status domain.TaskStatus) error
 	SetEscrowLocked(ctx context.Context, id uuid.UUID, locked bool) error
 	GetTasksPastClaimDeadline(ctx context.Context) ([]*domain.Task, error)
 	GetTasksPastOwnerDeadline(ctx context.Context) ([]*domain.Task, error)
+	GetActiveByOwnerID(ctx context.Context, ownerID uuid.UUID) ([]*domain.Task, error)
 }
 
 type taskRepository struct {
@@ -27,10 +28,40 @@ func NewTaskRepository(db *sql.DB) TaskRepository {
 	return &taskRepository{db: db}
 }
 
+const taskColumns = `id, owner_id, title, description, reward_amount, max_claimants, claim_deadline, owner_deadline, status, escrow_locked, application_mode, hidden_at, created_at, updated_at`
+
+func scanTask(row interface{ Scan(...interface{}) error }) (*domain.Task, error) {
+	task := &domain.Task{}
+	var hiddenAt sql.NullTime
+	err := row.Scan(
+		&task.ID,
+		&task.OwnerID,
+		&task.Title,
+		&task.Description,
+		&task.RewardAmount,
+		&task.MaxClaimants,
+		&task.ClaimDeadline,
+		&task.OwnerDeadline,
+		&task.Status,
+		&task.EscrowLocked,
+		&task.ApplicationMode,
+		&hiddenAt,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if hiddenAt.Valid {
+		task.HiddenAt = &hiddenAt.Time
+	}
+	return task, nil
+}
+
 func (r *taskRepository) Create(ctx context.Context, task *domain.Task) error {
 	query := `
-		INSERT INTO tasks (id, owner_id, title, description, reward_amount, max_claimants, claim_deadline, owner_deadline, status, escrow_locked)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO tasks (id, owner_id, title, description, reward_amount, max_claimants, claim_deadline, owner_deadline, status, escrow_locked, application_mode)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING created_at, updated_at
 	`
 	
@@ -45,6 +76,7 @@ func (r *taskRepository) Create(ctx context.Context, task *domain.Task) error {
 		task.OwnerDeadline,
 		task.Status,
 		task.EscrowLocked,
+		task.ApplicationMode,
 	).Scan(&task.CreatedAt, &task.UpdatedAt)
 	
 	return err
@@ -52,35 +84,17 @@ func (r *taskRepository) Create(ctx context.Context, task *domain.Task) error {
 
 func (r *taskRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Task, error) {
 	query := `
-		SELECT id, owner_id, title, description, reward_amount, max_claimants, claim_deadline, owner_deadline, status, escrow_locked, created_at, updated_at
+		SELECT ` + taskColumns + `
 		FROM tasks
 		WHERE id = $1
 	`
 	
-	task := &domain.Task{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&task.ID,
-		&task.OwnerID,
-		&task.Title,
-		&task.Description,
-		&task.RewardAmount,
-		&task.MaxClaimants,
-		&task.ClaimDeadline,
-		&task.OwnerDeadline,
-		&task.Status,
-		&task.EscrowLocked,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return task, nil
+	return scanTask(r.db.QueryRowContext(ctx, query, id))
 }
 
 func (r *taskRepository) GetByOwnerID(ctx context.Context, ownerID uuid.UUID, limit, offset int) ([]*domain.Task, error) {
 	query := `
-		SELECT id, owner_id, title, description, reward_amount, max_claimants, claim_deadline, owner_deadline, status, escrow_locked, created_at, updated_at
+		SELECT ` + taskColumns + `
 		FROM tasks
 		WHERE owner_id = $1
 		ORDER BY created_at DESC
@@ -95,21 +109,7 @@ func (r *taskRepository) GetByOwnerID(ctx context.Context, ownerID uuid.UUID, li
 	
 	var tasks []*domain.Task
 	for rows.Next() {
-		task := &domain.Task{}
-		err := rows.Scan(
-			&task.ID,
-			&task.OwnerID,
-			&task.Title,
-			&task.Description,
-			&task.RewardAmount,
-			&task.MaxClaimants,
-			&task.ClaimDeadline,
-			&task.OwnerDeadline,
-			&task.Status,
-			&task.EscrowLocked,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		)
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -118,16 +118,23 @@ func (r *taskRepository) GetByOwnerID(ctx context.Context, ownerID uuid.UUID, li
 	return tasks, rows.Err()
 }
 
-func (r *taskRepository) GetOpenTasks(ctx context.Context, limit, offset int) ([]*domain.Task, error) {
+// GetOpenTasks lists claimable tasks, leaving out tasks whose owner and the
+// viewer have blocked each other.
+func (r *taskRepository) GetOpenTasks(ctx context.Context, viewerID uuid.UUID, limit, offset int) ([]*domain.Task, error) {
 	query := `
-		SELECT id, owner_id, title, description, reward_amount, max_claimants, claim_deadline, owner_deadline, status, escrow_locked, created_at, updated_at
+		SELECT ` + taskColumns + `
 		FROM tasks
-		WHERE status = 'open' AND claim_deadline > NOW()
+		WHERE status = 'open' AND claim_deadline > NOW() AND hidden_at IS NULL
+		  AND NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.blocker_id = tasks.owner_id AND b.blocked_id = $1)
+			   OR (b.blocker_id = $1 AND b.blocked_id = tasks.owner_id)
+		  )
 		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
+		LIMIT $2 OFFSET $3
 	`
 	
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, viewerID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -135,21 +142,7 @@ func (r *taskRepository) GetOpenTasks(ctx context.Context, limit, offset int) ([
 	
 	var tasks []*domain.Task
 	for rows.Next() {
-		task := &domain.Task{}
-		err := rows.Scan(
-			&task.ID,
-			&task.OwnerID,
-			&task.Title,
-			&task.Description,
-			&task.RewardAmount,
-			&task.MaxClaimants,
-			&task.ClaimDeadline,
-			&task.OwnerDeadline,
-			&task.Status,
-			&task.EscrowLocked,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		)
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -172,7 +165,7 @@ func (r *taskRepository) SetEscrowLocked(ctx context.Context, id uuid.UUID, lock
 
 func (r *taskRepository) GetTasksPastClaimDeadline(ctx context.Context) ([]*domain.Task, error) {
 	query := `
-		SELECT id, owner_id, title, description, reward_amount, max_claimants, claim_deadline, owner_deadline, status, escrow_locked, created_at, updated_at
+		SELECT ` + taskColumns + `
 		FROM tasks
 		WHERE status = 'open' AND claim_deadline <= NOW()
 	`
@@ -185,21 +178,7 @@ func (r *taskRepository) GetTasksPastClaimDeadline(ctx context.Context) ([]*doma
 	
 	var tasks []*domain.Task
 	for rows.Next() {
-		task := &domain.Task{}
-		err := rows.Scan(
-			&task.ID,
-			&task.OwnerID,
-			&task.Title,
-			&task.Description,
-			&task.RewardAmount,
-			&task.MaxClaimants,
-			&task.ClaimDeadline,
-			&task.OwnerDeadline,
-			&task.Status,
-			&task.EscrowLocked,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		)
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -210,7 +189,7 @@ func (r *taskRepository) GetTasksPastClaimDeadline(ctx context.Context) ([]*doma
 
 func (r *taskRepository) GetTasksPastOwnerDeadline(ctx context.Context) ([]*domain.Task, error) {
 	query := `
-		SELECT id, owner_id, title, description, reward_amount, max_claimants, claim_deadline, owner_deadline, status, escrow_locked, created_at, updated_at
+		SELECT ` + taskColumns + `
 		FROM tasks
 		WHERE status IN ('claimed', 'open') AND owner_deadline <= NOW()
 	`
@@ -223,21 +202,33 @@ func (r *taskRepository) GetTasksPastOwnerDeadline(ctx context.Context) ([]*doma
 	
 	var tasks []*domain.Task
 	for rows.Next() {
-		task := &domain.Task{}
-		err := rows.Scan(
-			&task.ID,
-			&task.OwnerID,
-			&task.Title,
-			&task.Description,
-			&task.RewardAmount,
-			&task.MaxClaimants,
-			&task.ClaimDeadline,
-			&task.OwnerDeadline,
-			&task.Status,
-			&task.EscrowLocked,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		)
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// GetActiveByOwnerID returns the owner's tasks that are not yet completed or
+// cancelled.
+func (r *taskRepository) GetActiveByOwnerID(ctx context.Context, ownerID uuid.UUID) ([]*domain.Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks
+		WHERE owner_id = $1 AND status NOT IN ('completed', 'cancelled')
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []*domain.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/task-underground/backend/internal/domain"
@@ -11,9 +12,13 @@ import (
 type UserRepository interface {
 	GetOrCreateByDeviceID(ctx context.Context, deviceID string) (*domain.User, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
-	UpdateReputation(ctx context.Context, id uuid.UUID, delta int) error
 	UpdateEarnings(ctx context.Context, id uuid.UUID, amount float64) error
 	UpdateSpending(ctx context.Context, id uuid.UUID, amount float64) error
+	GetByRecoverySecretHash(ctx context.Context, hash string) (*domain.User, error)
+	SetRecoverySecretHash(ctx context.Context, id uuid.UUID, hash *string) error
+	GetStats(ctx context.Context, id uuid.UUID) (*domain.UserStats, error)
+	Suspend(ctx context.Context, id uuid.UUID, until *time.Time, reason string) error
+	Unsuspend(ctx context.Context, id uuid.UUID) error
 }
 
 type userRepository struct {
@@ -24,55 +29,58 @@ func NewUserRepository(db *sql.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) GetOrCreateByDeviceID(ctx context.Context, deviceID string) (*domain.User, error) {
-	query := `
-		INSERT INTO users (device_id)
-		VALUES ($1)
-		ON CONFLICT (device_id) DO UPDATE SET device_id = users.device_id
-		RETURNING id, device_id, created_at, reputation, total_earned, total_spent
-	`
-	
+const userColumns = `id, device_id, created_at, reputation, worker_score, poster_score, total_earned, total_spent, suspended_at, suspended_until, COALESCE(suspension_reason, ''), erased_at`
+
+func scanUser(row interface{ Scan(...interface{}) error }) (*domain.User, error) {
 	user := &domain.User{}
-	err := r.db.QueryRowContext(ctx, query, deviceID).Scan(
+	var suspendedAt, suspendedUntil, erasedAt sql.NullTime
+	err := row.Scan(
 		&user.ID,
 		&user.DeviceID,
 		&user.CreatedAt,
 		&user.Reputation,
+		&user.WorkerScore,
+		&user.PosterScore,
 		&user.TotalEarned,
 		&user.TotalSpent,
+		&suspendedAt,
+		&suspendedUntil,
+		&user.SuspensionReason,
+		&erasedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if suspendedAt.Valid {
+		user.SuspendedAt = &suspendedAt.Time
+	}
+	if suspendedUntil.Valid {
+		user.SuspendedUntil = &suspendedUntil.Time
+	}
+	if erasedAt.Valid {
+		user.ErasedAt = &erasedAt.Time
+	}
 	return user, nil
 }
 
+func (r *userRepository) GetOrCreateByDeviceID(ctx context.Context, deviceID string) (*domain.User, error) {
+	query := `
+		INSERT INTO users (device_id)
+		VALUES ($1)
+		ON CONFLICT (device_id) DO UPDATE SET device_id = users.device_id
+		RETURNING ` + userColumns
+
+	return scanUser(r.db.QueryRowContext(ctx, query, deviceID))
+}
+
 func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
 	query := `
-		SELECT id, device_id, created_at, reputation, total_earned, total_spent
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE id = $1
 	`
-	
-	user := &domain.User{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&user.ID,
-		&user.DeviceID,
-		&user.CreatedAt,
-		&user.Reputation,
-		&user.TotalEarned,
-		&user.TotalSpent,
-	)
-	if err != nil {
-		return nil, err
-	}
-	return user, nil
-}
 
-func (r *userRepository) UpdateReputation(ctx context.Context, id uuid.UUID, delta int) error {
-	query := `UPDATE users SET reputation = reputation + $1 WHERE id = $2`
-	_, err := r.db.ExecContext(ctx, query, delta, id)
-	return err
+	return scanUser(r.db.QueryRowContext(ctx, query, id))
 }
 
 func (r *userRepository) UpdateEarnings(ctx context.Context, id uuid.UUID, amount float64) error {
@@ -86,3 +94,69 @@ func (r *userRepository) UpdateSpending(ctx context.Context, id uuid.UUID, amoun
 	_, err := r.db.ExecContext(ctx, query, amount, id)
 	return err
 }
+
+func (r *userRepository) GetByRecoverySecretHash(ctx context.Context, hash string) (*domain.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE recovery_secret_hash = $1
+	`
+
+	return scanUser(r.db.QueryRowContext(ctx, query, hash))
+}
+
+func (r *userRepository) SetRecoverySecretHash(ctx context.Context, id uuid.UUID, hash *string) error {
+	query := `UPDATE users SET recovery_secret_hash = $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, hash, id)
+	return err
+}
+
+func (r *userRepository) GetStats(ctx context.Context, id uuid.UUID) (*domain.UserStats, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM tasks WHERE owner_id = $1),
+			(SELECT COUNT(*) FROM tasks WHERE owner_id = $1 AND status = 'completed'),
+			(SELECT COUNT(*) FROM tasks WHERE owner_id = $1 AND status = 'cancelled'),
+			(SELECT COUNT(*) FROM claims WHERE claimer_id = $1),
+			(SELECT COUNT(*) FROM claims WHERE claimer_id = $1 AND status = 'approved'),
+			(SELECT COUNT(*) FROM claims WHERE claimer_id = $1 AND status = 'rejected'),
+			(SELECT COUNT(*) FROM claims WHERE claimer_id = $1 AND status IN ('pending', 'applied'))
+	`
+
+	stats := &domain.UserStats{}
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&stats.TasksPosted,
+		&stats.TasksCompleted,
+		&stats.TasksCancelled,
+		&stats.ClaimsTotal,
+		&stats.ClaimsApproved,
+		&stats.ClaimsRejected,
+		&stats.ActiveClaims,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Suspend marks a user suspended until the given time, or permanently when
+// until is nil.
+func (r *userRepository) Suspend(ctx context.Context, id uuid.UUID, until *time.Time, reason string) error {
+	query := `
+		UPDATE users
+		SET suspended_at = NOW(), suspended_until = $1, suspension_reason = $2
+		WHERE id = $3
+	`
+	_, err := r.db.ExecContext(ctx, query, until, reason, id)
+	return err
+}
+
+func (r *userRepository) Unsuspend(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE users
+		SET suspended_at = NULL, suspended_until = NULL, suspension_reason = NULL
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
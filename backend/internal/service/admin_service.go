@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/repository"
+)
+
+var (
+	ErrInvalidAdminKey    = errors.New("invalid admin key")
+	ErrInvalidAdmin       = errors.New("admin name and a valid role are required")
+	ErrAdminNotFound      = errors.New("admin not found")
+	ErrAdminReasonMissing = errors.New("reason is required")
+	ErrTaskAlreadyClosed  = errors.New("task is already completed or cancelled")
+)
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+
+	// BootstrapAdminName is the admin account whose key comes from the
+	// server configuration rather than the admins API.
+	BootstrapAdminName = "bootstrap"
+)
+
+type AdminService interface {
+	Bootstrap(ctx context.Context, key string) error
+	Authenticate(ctx context.Context, key string) (*domain.Admin, error)
+	CreateAdmin(ctx context.Context, name string, role domain.AdminRole) (*domain.Admin, string, error)
+	ListAdmins(ctx context.Context) ([]*domain.Admin, error)
+	RevokeAdmin(ctx context.Context, id uuid.UUID) error
+	SearchUsers(ctx context.Context, search domain.UserSearch) ([]*domain.User, error)
+	SearchTasks(ctx context.Context, search domain.TaskSearch) ([]*domain.Task, error)
+	SearchClaims(ctx context.Context, search domain.ClaimSearch) ([]*domain.Claim, error)
+	GetEscrowTransactions(ctx context.Context, taskID uuid.UUID) ([]*domain.EscrowTransaction, error)
+	ForceCancelTask(ctx context.Context, admin *domain.Admin, taskID uuid.UUID, reason string) (*domain.ModerationAction, error)
+	ResolveDispute(ctx context.Context, admin *domain.Admin, claimID uuid.UUID, decision domain.ArbitrationDecision, reason string) error
+	GetStats(ctx context.Context) (*domain.SystemStats, error)
+}
+
+type adminService struct {
+	adminRepo      repository.AdminRepository
+	moderationRepo repository.ModerationRepository
+	taskSvc        TaskService
+	claimSvc       ClaimService
+	escrowSvc      EscrowService
+}
+
+func NewAdminService(
+	adminRepo repository.AdminRepository,
+	moderationRepo repository.ModerationRepository,
+	taskSvc TaskService,
+	claimSvc ClaimService,
+	escrowSvc EscrowService,
+) AdminService {
+	return &adminService{
+		adminRepo:      adminRepo,
+		moderationRepo: moderationRepo,
+		taskSvc:        taskSvc,
+		claimSvc:       claimSvc,
+		escrowSvc:      escrowSvc,
+	}
+}
+
+// Bootstrap makes key the credential of a full admin account so the first
+// staff accounts can be created. An empty key leaves the accounts alone.
+func (s *adminService) Bootstrap(ctx context.Context, key string) error {
+	if key == "" {
+		return nil
+	}
+
+	return s.adminRepo.UpsertByName(ctx, &domain.Admin{
+		ID:      uuid.New(),
+		Name:    BootstrapAdminName,
+		Role:    domain.AdminRoleAdmin,
+		KeyHash: hashToken(key),
+	})
+}
+
+func (s *adminService) Authenticate(ctx context.Context, key string) (*domain.Admin, error) {
+	if key == "" {
+		return nil, ErrInvalidAdminKey
+	}
+
+	admin, err := s.adminRepo.GetByKeyHash(ctx, hashToken(key))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidAdminKey
+		}
+		return nil, err
+	}
+	return admin, nil
+}
+
+// CreateAdmin adds a staff account and returns its API key, which is only
+// ever shown this once.
+func (s *adminService) CreateAdmin(ctx context.Context, name string, role domain.AdminRole) (*domain.Admin, string, error) {
+	if name == "" || name == BootstrapAdminName || !role.IsValid() {
+		return nil, "", ErrInvalidAdmin
+	}
+
+	key, keyHash, err := newRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	admin := &domain.Admin{
+		ID:      uuid.New(),
+		Name:    name,
+		Role:    role,
+		KeyHash: keyHash,
+	}
+	err = s.adminRepo.Create(ctx, admin)
+	if err != nil {
+		return nil, "", err
+	}
+	return admin, key, nil
+}
+
+func (s *adminService) ListAdmins(ctx context.Context) ([]*domain.Admin, error) {
+	return s.adminRepo.List(ctx)
+}
+
+func (s *adminService) RevokeAdmin(ctx context.Context, id uuid.UUID) error {
+	err := s.adminRepo.Revoke(ctx, id)
+	if err == sql.ErrNoRows {
+		return ErrAdminNotFound
+	}
+	return err
+}
+
+func (s *adminService) SearchUsers(ctx context.Context, search domain.UserSearch) ([]*domain.User, error) {
+	search.Limit = searchLimit(search.Limit)
+	return s.adminRepo.SearchUsers(ctx, search)
+}
+
+func (s *adminService) SearchTasks(ctx context.Context, search domain.TaskSearch) ([]*domain.Task, error) {
+	search.Limit = searchLimit(search.Limit)
+	return s.adminRepo.SearchTasks(ctx, search)
+}
+
+func (s *adminService) SearchClaims(ctx context.Context, search domain.ClaimSearch) ([]*domain.Claim, error) {
+	search.Limit = searchLimit(search.Limit)
+	return s.adminRepo.SearchClaims(ctx, search)
+}
+
+func (s *adminService) GetEscrowTransactions(ctx context.Context, taskID uuid.UUID) ([]*domain.EscrowTransaction, error) {
+	_, err := s.taskSvc.GetTaskForAdmin(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.escrowSvc.GetTransactionsByTaskID(ctx, taskID)
+}
+
+// ForceCancelTask cancels an unfinished task, refunds its escrow and records
+// who did it and why.
+func (s *adminService) ForceCancelTask(ctx context.Context, admin *domain.Admin, taskID uuid.UUID, reason string) (*domain.ModerationAction, error) {
+	if reason == "" {
+		return nil, ErrAdminReasonMissing
+	}
+
+	task, err := s.taskSvc.GetTaskForAdmin(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.Status == domain.TaskStatusCompleted || task.Status == domain.TaskStatusCancelled {
+		return nil, ErrTaskAlreadyClosed
+	}
+
+	err = s.taskSvc.ForceCancelTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	action := &domain.ModerationAction{
+		ID:         uuid.New(),
+		TargetType: domain.ReportTargetTask,
+		TargetID:   taskID,
+		Action:     domain.ModerationForceCancel,
+		Reason:     reason,
+		Moderator:  admin.Name,
+	}
+	err = s.moderationRepo.CreateAction(ctx, action)
+	if err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+func (s *adminService) ResolveDispute(ctx context.Context, admin *domain.Admin, claimID uuid.UUID, decision domain.ArbitrationDecision, reason string) error {
+	if reason == "" {
+		return ErrAdminReasonMissing
+	}
+
+	return s.claimSvc.ResolveDispute(ctx, claimID, Arbiter{AdminID: &admin.ID}, decision, reason)
+}
+
+func (s *adminService) GetStats(ctx context.Context) (*domain.SystemStats, error) {
+	return s.adminRepo.GetStats(ctx)
+}
+
+func searchLimit(limit int) int {
+	if limit <= 0 {
+		return defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		return maxSearchLimit
+	}
+	return limit
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type mockAdminRepo struct {
+	admins map[uuid.UUID]*domain.Admin
+}
+
+func newMockAdminRepo() *mockAdminRepo {
+	return &mockAdminRepo{admins: make(map[uuid.UUID]*domain.Admin)}
+}
+
+func (m *mockAdminRepo) Create(ctx context.Context, admin *domain.Admin) error {
+	admin.CreatedAt = time.Now()
+	m.admins[admin.ID] = admin
+	return nil
+}
+
+func (m *mockAdminRepo) UpsertByName(ctx context.Context, admin *domain.Admin) error {
+	for _, existing := range m.admins {
+		if existing.Name == admin.Name {
+			existing.Role = admin.Role
+			existing.KeyHash = admin.KeyHash
+			existing.RevokedAt = nil
+			admin.ID = existing.ID
+			return nil
+		}
+	}
+	return m.Create(ctx, admin)
+}
+
+func (m *mockAdminRepo) GetByKeyHash(ctx context.Context, keyHash string) (*domain.Admin, error) {
+	for _, admin := range m.admins {
+		if admin.KeyHash == keyHash && admin.RevokedAt == nil {
+			return admin, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockAdminRepo) List(ctx context.Context) ([]*domain.Admin, error) {
+	var result []*domain.Admin
+	for _, admin := range m.admins {
+		result = append(result, admin)
+	}
+	return result, nil
+}
+
+func (m *mockAdminRepo) Revoke(ctx context.Context, id uuid.UUID) error {
+	admin, ok := m.admins[id]
+	if !ok || admin.RevokedAt != nil {
+		return sql.ErrNoRows
+	}
+	now := time.Now()
+	admin.RevokedAt = &now
+	return nil
+}
+
+func (m *mockAdminRepo) SearchUsers(ctx context.Context, search domain.UserSearch) ([]*domain.User, error) {
+	return nil, nil
+}
+
+func (m *mockAdminRepo) SearchTasks(ctx context.Context, search domain.TaskSearch) ([]*domain.Task, error) {
+	return nil, nil
+}
+
+func (m *mockAdminRepo) SearchClaims(ctx context.Context, search domain.ClaimSearch) ([]*domain.Claim, error) {
+	return nil, nil
+}
+
+func (m *mockAdminRepo) GetStats(ctx context.Context) (*domain.SystemStats, error) {
+	return &domain.SystemStats{}, nil
+}
+
+func TestAdminRolePermissions(t *testing.T) {
+	tests := []struct {
+		role       domain.AdminRole
+		permission domain.AdminPermission
+		allowed    bool
+	}{
+		{domain.AdminRoleAdmin, domain.PermManageAdmins, true},
+		{domain.AdminRoleAdmin, domain.PermViewEscrow, true},
+		{domain.AdminRoleModerator, domain.PermModerate, true},
+		{domain.AdminRoleModerator, domain.PermViewUsers, true},
+		{domain.AdminRoleModerator, domain.PermViewEscrow, false},
+		{domain.AdminRoleModerator, domain.PermResolveDisputes, false},
+		{domain.AdminRoleArbitrator, domain.PermResolveDisputes, true},
+		{domain.AdminRoleArbitrator, domain.PermViewTasks, true},
+		{domain.AdminRoleArbitrator, domain.PermCancelTasks, false},
+		{domain.AdminRoleArbitrator, domain.PermModerate, false},
+		{domain.AdminRoleFinance, domain.PermViewEscrow, true},
+		{domain.AdminRoleFinance, domain.PermCancelTasks, true},
+		{domain.AdminRoleFinance, domain.PermViewUsers, false},
+		{domain.AdminRoleFinance, domain.PermManageAdmins, false},
+		{"intern", domain.PermViewStats, false},
+	}
+
+	for _, tt := range tests {
+		admin := &domain.Admin{Role: tt.role}
+		assert.Equal(t, tt.allowed, admin.Can(tt.permission), "%s %s", tt.role, tt.permission)
+	}
+}
+
+func TestAdminKeysAndTaskActions(t *testing.T) {
+	adminRepo := newMockAdminRepo()
+	moderationRepo := newMockModerationRepo()
+	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
+	taskSvc := NewTaskService(taskRepo, claimRepo, &mockEscrowSvc{}, newMockBlockRepo(), nil)
+	claimSvc := NewClaimService(claimRepo, taskRepo, &mockChatRepoForClaimSvc{}, &mockEscrowSvc{}, &mockUserRepo{}, &mockReputationSvc{}, newMockBlockRepo(), nil, nil)
+	service := NewAdminService(adminRepo, moderationRepo, taskSvc, claimSvc, &mockEscrowSvc{})
+	ctx := context.Background()
+
+	// The configured key logs in as a full admin
+	assert.NoError(t, service.Bootstrap(ctx, "root-key"))
+	root, err := service.Authenticate(ctx, "root-key")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.AdminRoleAdmin, root.Role)
+	_, err = service.Authenticate(ctx, "")
+	assert.Equal(t, ErrInvalidAdminKey, err)
+
+	_, _, err = service.CreateAdmin(ctx, "erin", "intern")
+	assert.Equal(t, ErrInvalidAdmin, err)
+	_, _, err = service.CreateAdmin(ctx, BootstrapAdminName, domain.AdminRoleFinance)
+	assert.Equal(t, ErrInvalidAdmin, err)
+
+	arbitrator, key, err := service.CreateAdmin(ctx, "erin", domain.AdminRoleArbitrator)
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, arbitrator.KeyHash)
+	authed, err := service.Authenticate(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, arbitrator.ID, authed.ID)
+
+	// Force-cancelling needs a reason, is audited and only works once
+	task := &domain.Task{ID: uuid.New(), OwnerID: uuid.New(), Status: domain.TaskStatusOpen, EscrowLocked: true}
+	taskRepo.tasks[task.ID] = task
+	_, err = service.ForceCancelTask(ctx, root, task.ID, "")
+	assert.Equal(t, ErrAdminReasonMissing, err)
+	action, err := service.ForceCancelTask(ctx, root, task.ID, "fraudulent listing")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.TaskStatusCancelled, task.Status)
+	assert.Equal(t, domain.ModerationForceCancel, action.Action)
+	assert.Equal(t, BootstrapAdminName, action.Moderator)
+	_, err = service.ForceCancelTask(ctx, root, task.ID, "again")
+	assert.Equal(t, ErrTaskAlreadyClosed, err)
+	_, err = service.GetEscrowTransactions(ctx, uuid.New())
+	assert.Equal(t, ErrTaskNotFound, err)
+
+	// Disputes record which admin decided them
+	disputedTask := &domain.Task{ID: uuid.New(), OwnerID: uuid.New(), Status: domain.TaskStatusClaimed}
+	taskRepo.tasks[disputedTask.ID] = disputedTask
+	claim := &domain.Claim{ID: uuid.New(), TaskID: disputedTask.ID, ClaimerID: uuid.New(), Status: domain.ClaimStatusDisputed}
+	claimRepo.claims[claim.ID] = claim
+	assert.NoError(t, service.ResolveDispute(ctx, authed, claim.ID, domain.ArbitrationReject, "work was missing"))
+	assert.Len(t, claimRepo.arbitrations, 1)
+	assert.Equal(t, arbitrator.ID, *claimRepo.arbitrations[0].ArbitratorID)
+
+	// Revoked keys stop working
+	assert.NoError(t, service.RevokeAdmin(ctx, arbitrator.ID))
+	_, err = service.Authenticate(ctx, key)
+	assert.Equal(t, ErrInvalidAdminKey, err)
+	assert.Equal(t, ErrAdminNotFound, service.RevokeAdmin(ctx, arbitrator.ID))
+}
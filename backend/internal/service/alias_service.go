@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/repository"
+)
+
+var (
+	ErrParticipantNotFound = errors.New("participant not found")
+)
+
+var aliasAdjectives = []string{
+	"Amber", "Azure", "Brisk", "Calm", "Cobalt", "Crimson", "Dusky", "Ember",
+	"Feral", "Frost", "Gilded", "Hazy", "Hollow", "Indigo", "Ivory", "Jade",
+	"Lunar", "Misty", "Nimble", "Onyx", "Pale", "Quiet", "Rapid", "Rusty",
+	"Silent", "Slate", "Solar", "Swift", "Tawny", "Umber", "Velvet", "Wild",
+}
+
+var aliasAnimals = []string{
+	"Badger", "Bat", "Bison", "Cobra", "Crane", "Crow", "Dingo", "Eel",
+	"Falcon", "Ferret", "Fox", "Gecko", "Hare", "Heron", "Ibis", "Jackal",
+	"Lynx", "Marten", "Mink", "Moth", "Newt", "Otter", "Owl", "Panther",
+	"Raven", "Seal", "Shrike", "Stoat", "Viper", "Vole", "Wolf", "Wren",
+}
+
+// AliasService turns internal user IDs into per-task pseudonyms, and device
+// IDs into per-chat ones, and builds the client-facing views of tasks,
+// claims, chats and messages.
+type AliasService interface {
+	Alias(taskID, userID uuid.UUID) string
+	DeviceAlias(chatID, deviceID uuid.UUID) uuid.UUID
+	Participant(ctx context.Context, taskID, userID, viewerID uuid.UUID) (domain.Participant, error)
+	Resolve(ctx context.Context, taskID uuid.UUID, alias string) (uuid.UUID, error)
+	PresentTasks(ctx context.Context, tasks []*domain.Task, viewerID uuid.UUID) ([]*domain.TaskView, error)
+	PresentClaims(ctx context.Context, claims []*domain.Claim, viewerID uuid.UUID) ([]*domain.ClaimView, error)
+	PresentChats(ctx context.Context, chats []*domain.Chat, viewerID uuid.UUID) ([]*domain.ChatView, error)
+	PresentMessages(ctx context.Context, chat *domain.Chat, messages []*domain.Message, viewerID uuid.UUID) ([]*domain.MessageView, error)
+}
+
+type aliasService struct {
+	userRepo  repository.UserRepository
+	taskRepo  repository.TaskRepository
+	claimRepo repository.ClaimRepository
+	secret    []byte
+}
+
+func NewAliasService(
+	userRepo repository.UserRepository,
+	taskRepo repository.TaskRepository,
+	claimRepo repository.ClaimRepository,
+	secret []byte,
+) AliasService {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("task-alias"))
+	return &aliasService{
+		userRepo:  userRepo,
+		taskRepo:  taskRepo,
+		claimRepo: claimRepo,
+		secret:    mac.Sum(nil),
+	}
+}
+
+func (s *aliasService) Alias(taskID, userID uuid.UUID) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(taskID[:])
+	mac.Write(userID[:])
+	sum := mac.Sum(nil)
+
+	adjective := aliasAdjectives[int(sum[0])%len(aliasAdjectives)]
+	animal := aliasAnimals[int(sum[1])%len(aliasAnimals)]
+	return fmt.Sprintf("%s-%s-%04X", adjective, animal, binary.BigEndian.Uint16(sum[2:4]))
+}
+
+// DeviceAlias is the ID a device goes by in one chat's key directory and
+// envelopes. It differs from chat to chat, so a device cannot be recognized
+// across tasks.
+func (s *aliasService) DeviceAlias(chatID, deviceID uuid.UUID) uuid.UUID {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("device"))
+	mac.Write(chatID[:])
+	mac.Write(deviceID[:])
+
+	var alias uuid.UUID
+	copy(alias[:], mac.Sum(nil))
+	alias[6] = alias[6]&0x0f | 0x40
+	alias[8] = alias[8]&0x3f | 0x80
+	return alias
+}
+
+func (s *aliasService) Participant(ctx context.Context, taskID, userID, viewerID uuid.UUID) (domain.Participant, error) {
+	return s.participant(ctx, make(map[uuid.UUID]string), taskID, userID, viewerID)
+}
+
+// Resolve maps an alias back to a user among the task's owner and claimers.
+func (s *aliasService) Resolve(ctx context.Context, taskID uuid.UUID, alias string) (uuid.UUID, error) {
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, ErrTaskNotFound
+		}
+		return uuid.Nil, err
+	}
+
+	if s.Alias(taskID, task.OwnerID) == alias {
+		return task.OwnerID, nil
+	}
+
+	claims, err := s.claimRepo.GetByTaskID(ctx, taskID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	for _, claim := range claims {
+		if s.Alias(taskID, claim.ClaimerID) == alias {
+			return claim.ClaimerID, nil
+		}
+	}
+
+	return uuid.Nil, ErrParticipantNotFound
+}
+
+// participant resolves one participant, caching reputation bands per call so
+// list endpoints look each user up once.
+func (s *aliasService) participant(ctx context.Context, bands map[uuid.UUID]string, taskID, userID, viewerID uuid.UUID) (domain.Participant, error) {
+	band, ok := bands[userID]
+	if !ok {
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return domain.Participant{}, err
+		}
+		band = domain.ReputationBand(user.Reputation)
+		bands[userID] = band
+	}
+
+	return domain.Participant{
+		Alias:          s.Alias(taskID, userID),
+		IsSelf:         userID == viewerID,
+		ReputationBand: band,
+	}, nil
+}
+
+func (s *aliasService) PresentTasks(ctx context.Context, tasks []*domain.Task, viewerID uuid.UUID) ([]*domain.TaskView, error) {
+	bands := make(map[uuid.UUID]string)
+	views := make([]*domain.TaskView, 0, len(tasks))
+	for _, task := range tasks {
+		owner, err := s.participant(ctx, bands, task.ID, task.OwnerID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, &domain.TaskView{Task: task, Owner: owner})
+	}
+	return views, nil
+}
+
+func (s *aliasService) PresentClaims(ctx context.Context, claims []*domain.Claim, viewerID uuid.UUID) ([]*domain.ClaimView, error) {
+	bands := make(map[uuid.UUID]string)
+	views := make([]*domain.ClaimView, 0, len(claims))
+	for _, claim := range claims {
+		claimer, err := s.participant(ctx, bands, claim.TaskID, claim.ClaimerID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, &domain.ClaimView{Claim: claim, Claimer: claimer})
+	}
+	return views, nil
+}
+
+func (s *aliasService) PresentChats(ctx context.Context, chats []*domain.Chat, viewerID uuid.UUID) ([]*domain.ChatView, error) {
+	bands := make(map[uuid.UUID]string)
+	views := make([]*domain.ChatView, 0, len(chats))
+	for _, chat := range chats {
+		counterpart, err := s.participant(ctx, bands, chat.TaskID, chat.Counterpart(viewerID), viewerID)
+		if err != nil {
+			return nil, err
+		}
+		view := &domain.ChatView{Chat: chat, Counterpart: counterpart}
+		if chat.LastMessage != nil {
+			sender, err := s.participant(ctx, bands, chat.TaskID, chat.LastMessage.SenderID, viewerID)
+			if err != nil {
+				return nil, err
+			}
+			view.LastMessage = &domain.MessageView{Message: chat.LastMessage, Sender: sender}
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+func (s *aliasService) PresentMessages(ctx context.Context, chat *domain.Chat, messages []*domain.Message, viewerID uuid.UUID) ([]*domain.MessageView, error) {
+	bands := make(map[uuid.UUID]string)
+	views := make([]*domain.MessageView, 0, len(messages))
+	for _, message := range messages {
+		sender, err := s.participant(ctx, bands, chat.TaskID, message.SenderID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, &domain.MessageView{Message: message, Sender: sender})
+	}
+	return views, nil
+}
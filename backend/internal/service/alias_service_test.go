@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+func newTestAliasService(secret string) AliasService {
+	taskRepo := &mockTaskRepo{tasks: make(map[uuid.UUID]*domain.Task)}
+	claimRepo := &mockClaimRepo{claims: make(map[uuid.UUID]*domain.Claim)}
+	return NewAliasService(&mockUserRepo{}, taskRepo, claimRepo, []byte(secret))
+}
+
+func TestAliasStableWithinTaskAndUnlinkableAcross(t *testing.T) {
+	svc := newTestAliasService("test-secret")
+
+	userID := uuid.New()
+	taskA := uuid.New()
+	taskB := uuid.New()
+
+	assert.Equal(t, svc.Alias(taskA, userID), svc.Alias(taskA, userID))
+	assert.NotEqual(t, svc.Alias(taskA, userID), svc.Alias(taskB, userID))
+	assert.NotEqual(t, svc.Alias(taskA, userID), svc.Alias(taskA, uuid.New()))
+
+	other := newTestAliasService("other-secret")
+	assert.NotEqual(t, svc.Alias(taskA, userID), other.Alias(taskA, userID))
+}
+
+func TestPresentTasksHidesOwnerID(t *testing.T) {
+	svc := newTestAliasService("test-secret")
+
+	ownerID := uuid.New()
+	viewerID := uuid.New()
+	task := &domain.Task{ID: uuid.New(), OwnerID: ownerID, Title: "Test Task"}
+
+	views, err := svc.PresentTasks(context.Background(), []*domain.Task{task}, viewerID)
+	assert.NoError(t, err)
+	assert.Len(t, views, 1)
+	assert.False(t, views[0].Owner.IsSelf)
+	assert.Equal(t, svc.Alias(task.ID, ownerID), views[0].Owner.Alias)
+	assert.Equal(t, domain.ReputationBandNew, views[0].Owner.ReputationBand)
+
+	data, err := json.Marshal(views[0])
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), ownerID.String())
+	assert.Contains(t, string(data), `"title":"Test Task"`)
+
+	own, _ := svc.PresentTasks(context.Background(), []*domain.Task{task}, ownerID)
+	assert.True(t, own[0].Owner.IsSelf)
+}
+
+func TestPresentMessagesUsesChatTask(t *testing.T) {
+	svc := newTestAliasService("test-secret")
+
+	claimerID := uuid.New()
+	ownerID := uuid.New()
+	chat := &domain.Chat{ID: uuid.New(), TaskID: uuid.New(), ParticipantID: claimerID, OtherParticipantID: ownerID}
+	message := &domain.Message{ID: uuid.New(), ChatID: chat.ID, SenderID: claimerID, Content: "hi"}
+
+	views, err := svc.PresentMessages(context.Background(), chat, []*domain.Message{message}, ownerID)
+	assert.NoError(t, err)
+	assert.Equal(t, svc.Alias(chat.TaskID, claimerID), views[0].Sender.Alias)
+
+	chats, err := svc.PresentChats(context.Background(), []*domain.Chat{chat}, ownerID)
+	assert.NoError(t, err)
+	assert.Equal(t, svc.Alias(chat.TaskID, claimerID), chats[0].Counterpart.Alias)
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/repository"
+)
+
+var (
+	ErrArbitrationReasonRequired = errors.New("arbitration reason is required")
+)
+
+// ArbitrationService lets users whose trust tier carries arbitration duty
+// decide disputes they have no stake in. Staff resolve disputes through the
+// admin API instead.
+type ArbitrationService interface {
+	ListDisputes(ctx context.Context, arbitratorID uuid.UUID, limit, offset int) ([]ClaimResource, error)
+	ResolveDispute(ctx context.Context, arbitratorID, claimID uuid.UUID, decision domain.ArbitrationDecision, reason string) error
+}
+
+type arbitrationService struct {
+	claimRepo repository.ClaimRepository
+	taskRepo  repository.TaskRepository
+	blockRepo repository.BlockRepository
+	trustSvc  TrustService
+	claimSvc  ClaimService
+}
+
+func NewArbitrationService(
+	claimRepo repository.ClaimRepository,
+	taskRepo repository.TaskRepository,
+	blockRepo repository.BlockRepository,
+	trustSvc TrustService,
+	claimSvc ClaimService,
+) ArbitrationService {
+	return &arbitrationService{
+		claimRepo: claimRepo,
+		taskRepo:  taskRepo,
+		blockRepo: blockRepo,
+		trustSvc:  trustSvc,
+		claimSvc:  claimSvc,
+	}
+}
+
+// ListDisputes returns open disputes the arbitrator may decide, oldest
+// first, each with its task so the submission can be weighed against the
+// brief.
+func (s *arbitrationService) ListDisputes(ctx context.Context, arbitratorID uuid.UUID, limit, offset int) ([]ClaimResource, error) {
+	if err := s.trustSvc.CheckArbitration(ctx, arbitratorID); err != nil {
+		return nil, err
+	}
+
+	claims, err := s.claimRepo.GetOpenDisputes(ctx, arbitratorID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	disputes := make([]ClaimResource, 0, len(claims))
+	for _, claim := range claims {
+		task, err := s.taskRepo.GetByID(ctx, claim.TaskID)
+		if err != nil {
+			return nil, err
+		}
+		dispute := ClaimResource{Claim: claim, Task: task}
+		if !can(arbitratorID, ActionArbitrateClaim, dispute) {
+			continue
+		}
+		claim.Redact()
+		disputes = append(disputes, dispute)
+	}
+	return disputes, nil
+}
+
+// ResolveDispute decides a dispute on the arbitrator's behalf. Anyone with a
+// stake in it is refused: the owner, the claimer, other claimers of the task
+// and users blocked by or blocking either side.
+func (s *arbitrationService) ResolveDispute(ctx context.Context, arbitratorID, claimID uuid.UUID, decision domain.ArbitrationDecision, reason string) error {
+	if err := s.trustSvc.CheckArbitration(ctx, arbitratorID); err != nil {
+		return err
+	}
+
+	if reason == "" {
+		return ErrArbitrationReasonRequired
+	}
+
+	claim, err := s.claimRepo.GetByID(ctx, claimID)
+	if err != nil {
+		return notFoundOr(err, ErrClaimNotFound)
+	}
+
+	task, err := s.taskRepo.GetByID(ctx, claim.TaskID)
+	if err != nil {
+		return err
+	}
+
+	if !can(arbitratorID, ActionArbitrateClaim, ClaimResource{Claim: claim, Task: task}) {
+		return ErrUnauthorized
+	}
+
+	staked, err := s.hasStake(ctx, arbitratorID, claim, task)
+	if err != nil {
+		return err
+	}
+	if staked {
+		return ErrUnauthorized
+	}
+
+	return s.claimSvc.ResolveDispute(ctx, claimID, Arbiter{UserID: &arbitratorID}, decision, reason)
+}
+
+// hasStake reports whether the arbitrator competes for the same task or has
+// a block with either side of the dispute.
+func (s *arbitrationService) hasStake(ctx context.Context, arbitratorID uuid.UUID, claim *domain.Claim, task *domain.Task) (bool, error) {
+	_, err := s.claimRepo.GetByTaskIDAndClaimerID(ctx, task.ID, arbitratorID)
+	if err == nil {
+		return true, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, err
+	}
+
+	for _, partyID := range []uuid.UUID{task.OwnerID, claim.ClaimerID} {
+		blocked, err := s.blockRepo.IsBlocked(ctx, arbitratorID, partyID)
+		if err != nil || blocked {
+			return blocked, err
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+func TestVeteransArbitrateDisputesTheyHaveNoStakeIn(t *testing.T) {
+	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
+	escrowSvc := &mockEscrowSvc{}
+	blockRepo := newMockBlockRepo()
+	ownerID := uuid.New()
+	claimerID := uuid.New()
+	veteranID := uuid.New()
+	blockedVeteranID := uuid.New()
+	memberID := uuid.New()
+	veteran := func(id uuid.UUID) *domain.User {
+		return &domain.User{ID: id, CreatedAt: time.Now().Add(-100 * 24 * time.Hour), Reputation: 60}
+	}
+	userRepo := &mockUserRepo{
+		users: map[uuid.UUID]*domain.User{
+			ownerID:          veteran(ownerID),
+			veteranID:        veteran(veteranID),
+			blockedVeteranID: veteran(blockedVeteranID),
+			memberID:         {ID: memberID, CreatedAt: time.Now().Add(-10 * 24 * time.Hour), Reputation: 5},
+		},
+		stats: map[uuid.UUID]*domain.UserStats{
+			ownerID:          {ClaimsApproved: 50},
+			veteranID:        {ClaimsApproved: 50},
+			blockedVeteranID: {ClaimsApproved: 50},
+			memberID:         {ClaimsApproved: 5},
+		},
+	}
+	trustSvc := NewTrustService(userRepo)
+	claimSvc := NewClaimService(claimRepo, taskRepo, &mockChatRepoForClaimSvc{}, escrowSvc, userRepo, &mockReputationSvc{}, blockRepo, nil, nil)
+	arbitrationSvc := NewArbitrationService(claimRepo, taskRepo, blockRepo, trustSvc, claimSvc)
+	ctx := context.Background()
+
+	task := &domain.Task{
+		ID:            uuid.New(),
+		OwnerID:       ownerID,
+		RewardAmount:  100,
+		MaxClaimants:  1,
+		ClaimDeadline: time.Now().Add(24 * time.Hour),
+		OwnerDeadline: time.Now().Add(48 * time.Hour),
+		Status:        domain.TaskStatusClaimed,
+		EscrowLocked:  true,
+	}
+	taskRepo.tasks[task.ID] = task
+	disputedAt := time.Now()
+	claim := &domain.Claim{
+		ID:             uuid.New(),
+		TaskID:         task.ID,
+		ClaimerID:      claimerID,
+		Status:         domain.ClaimStatusDisputed,
+		CompletionText: "done",
+		DisputeReason:  "it was done",
+		DisputedAt:     &disputedAt,
+	}
+	claimRepo.claims[claim.ID] = claim
+	blockRepo.blocks[uuid.New()] = &domain.Block{BlockerID: claimerID, BlockedID: blockedVeteranID}
+
+	// Arbitration is a veteran privilege
+	_, err := arbitrationSvc.ListDisputes(ctx, memberID, 20, 0)
+	assert.EqualError(t, err, "trust tier 1: you cannot arbitrate disputes yet")
+	err = arbitrationSvc.ResolveDispute(ctx, memberID, claim.ID, domain.ArbitrationApprove, "looks done")
+	assert.IsType(t, &TierLimitError{}, err)
+
+	// The parties and anyone blocked by either cannot decide it
+	disputes, err := arbitrationSvc.ListDisputes(ctx, ownerID, 20, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, disputes)
+	assert.Equal(t, ErrUnauthorized, arbitrationSvc.ResolveDispute(ctx, ownerID, claim.ID, domain.ArbitrationReject, "not done"))
+	assert.Equal(t, ErrUnauthorized, arbitrationSvc.ResolveDispute(ctx, blockedVeteranID, claim.ID, domain.ArbitrationReject, "not done"))
+
+	disputes, err = arbitrationSvc.ListDisputes(ctx, veteranID, 20, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, disputes, 1) {
+		assert.Equal(t, claim.ID, disputes[0].Claim.ID)
+		assert.Equal(t, task.ID, disputes[0].Task.ID)
+	}
+
+	assert.Equal(t, ErrArbitrationReasonRequired, arbitrationSvc.ResolveDispute(ctx, veteranID, claim.ID, domain.ArbitrationApprove, ""))
+	assert.NoError(t, arbitrationSvc.ResolveDispute(ctx, veteranID, claim.ID, domain.ArbitrationApprove, "looks done"))
+	assert.Equal(t, domain.ClaimStatusApproved, claim.Status)
+	assert.Equal(t, []uuid.UUID{task.ID}, escrowSvc.released)
+	if assert.Len(t, claimRepo.arbitrations, 1) {
+		assert.Nil(t, claimRepo.arbitrations[0].ArbitratorID)
+		assert.Equal(t, &veteranID, claimRepo.arbitrations[0].UserArbitratorID)
+	}
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+const (
+	defaultMaxAttachmentSize = 10 << 20
+	defaultMaxAttachments    = 4
+
+	// maxImagePixels bounds what an image may decode to, so a small file
+	// cannot claim gigabytes of memory.
+	maxImagePixels      = 40_000_000
+	maxFilenameLength   = 255
+	attachmentKeyPrefix = "attachments/"
+)
+
+// AttachmentUpload is a file sent along with a message.
+type AttachmentUpload struct {
+	Filename string
+	Content  io.Reader
+}
+
+// readAttachment reads an upload, checks its size and type, and returns the
+// attachment with the bytes to store. The type is sniffed from the content;
+// whatever the client claims is ignored. JPEG and PNG images are re-encoded,
+// which drops metadata such as the location a photo was taken at.
+func readAttachment(upload AttachmentUpload, maxSize int64) (*domain.Attachment, []byte, error) {
+	data, err := io.ReadAll(io.LimitReader(upload.Content, maxSize+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, nil, ErrAttachmentTooLarge
+	}
+	if len(data) == 0 {
+		return nil, nil, ErrAttachmentType
+	}
+
+	mimeType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	kind, ok := domain.AttachmentTypes[mimeType]
+	if !ok {
+		return nil, nil, ErrAttachmentType
+	}
+
+	attachment := &domain.Attachment{
+		ID:       uuid.New(),
+		Kind:     kind,
+		MimeType: mimeType,
+		Filename: cleanFilename(upload.Filename),
+	}
+	attachment.StorageKey = attachmentKeyPrefix + attachment.ID.String()
+
+	if kind == domain.AttachmentImage {
+		data, err = normalizeImage(attachment, data)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	attachment.Size = int64(len(data))
+	return attachment, data, nil
+}
+
+func normalizeImage(attachment *domain.Attachment, data []byte) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width <= 0 || config.Height <= 0 {
+		return nil, ErrAttachmentType
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, ErrAttachmentTooLarge
+	}
+	attachment.Width = &config.Width
+	attachment.Height = &config.Height
+
+	if attachment.MimeType == "image/gif" {
+		if _, err := gif.DecodeAll(bytes.NewReader(data)); err != nil {
+			return nil, ErrAttachmentType
+		}
+		return data, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrAttachmentType
+	}
+	var out bytes.Buffer
+	if attachment.MimeType == "image/png" {
+		err = png.Encode(&out, img)
+	} else {
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: 90})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// cleanFilename keeps the base name of an uploaded file, without control
+// characters and cut to a length the database takes.
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+
+	runes := []rune(name)
+	if len(runes) > maxFilenameLength {
+		runes = runes[:maxFilenameLength]
+	}
+	return string(runes)
+}
@@ -0,0 +1,581 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/repository"
+)
+
+var (
+	ErrInvalidToken        = errors.New("invalid token")
+	ErrTokenExpired        = errors.New("token expired")
+	ErrTokenRevoked        = errors.New("token revoked")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrDeviceRevoked       = errors.New("device has been revoked")
+	ErrInvalidRecovery     = errors.New("invalid recovery secret")
+	ErrInvalidPairingCode  = errors.New("invalid or expired pairing code")
+	ErrUserSuspended       = errors.New("account suspended")
+)
+
+const (
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+	// activeUserTTL is how long a passed suspension check is trusted before
+	// the user is looked up again.
+	activeUserTTL = 30 * time.Second
+)
+
+type AuthConfig struct {
+	Secret          []byte
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// Gate, when set, vets every account created by a handshake.
+	Gate AccountGate
+	// Connections, when set, has the live connections of revoked sessions
+	// closed.
+	Connections SessionDisconnector
+}
+
+// SessionDisconnector closes the live connections opened with a user's or a
+// single session's access tokens.
+type SessionDisconnector interface {
+	Disconnector
+	DisconnectSession(sessionID uuid.UUID)
+}
+
+// AccountGate decides whether an unknown device may get a new account and
+// is told about each account created. AdmitAccount returns an admission ID,
+// possibly uuid.Nil, to hand to AccountCreated.
+type AccountGate interface {
+	AdmitAccount(ctx context.Context, deviceID string, proof domain.AccountProof) (uuid.UUID, error)
+	AccountCreated(ctx context.Context, admissionID, userID uuid.UUID) error
+}
+
+// AccessClaims is the signed payload of an access token. IssuedAt is in Unix
+// nanoseconds, so a token issued just after its user was revoked is told
+// apart from one issued just before, even within the same second.
+type AccessClaims struct {
+	UserID    uuid.UUID `json:"sub"`
+	SessionID uuid.UUID `json:"sid"`
+	DeviceID  uuid.UUID `json:"did"`
+	IssuedAt  int64     `json:"iat"`
+	ExpiresAt int64     `json:"exp"`
+}
+
+type AuthService interface {
+	Handshake(ctx context.Context, deviceID, deviceName string, proof domain.AccountProof) (*domain.TokenPair, error)
+	Recover(ctx context.Context, recoverySecret, deviceID, deviceName string) (*domain.TokenPair, error)
+	Pair(ctx context.Context, code, deviceID, deviceName string) (*domain.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error)
+	Logout(ctx context.Context, sessionID uuid.UUID) error
+	RevokeDevice(ctx context.Context, deviceID uuid.UUID) error
+	RevokeUser(ctx context.Context, userID uuid.UUID) error
+	ValidateAccessToken(token string) (*AccessClaims, error)
+	CheckUser(ctx context.Context, userID uuid.UUID) error
+}
+
+type authService struct {
+	userRepo    repository.UserRepository
+	deviceRepo  repository.DeviceRepository
+	sessionRepo repository.SessionRepository
+	config      AuthConfig
+	revoked     *revocationList
+	active      *activeUserCache
+}
+
+func NewAuthService(
+	userRepo repository.UserRepository,
+	deviceRepo repository.DeviceRepository,
+	sessionRepo repository.SessionRepository,
+	config AuthConfig,
+) AuthService {
+	if config.AccessTokenTTL <= 0 {
+		config.AccessTokenTTL = DefaultAccessTokenTTL
+	}
+	if config.RefreshTokenTTL <= 0 {
+		config.RefreshTokenTTL = DefaultRefreshTokenTTL
+	}
+	return &authService{
+		userRepo:    userRepo,
+		deviceRepo:  deviceRepo,
+		sessionRepo: sessionRepo,
+		config:      config,
+		revoked:     newRevocationList(),
+		active:      newActiveUserCache(),
+	}
+}
+
+// Handshake signs a device in, creating an account for it if it is unknown.
+// The proof is only looked at for unknown devices.
+func (s *authService) Handshake(ctx context.Context, deviceID, deviceName string, proof domain.AccountProof) (*domain.TokenPair, error) {
+	if deviceID == "" {
+		return nil, errors.New("device_id is required")
+	}
+
+	device, err := s.deviceRepo.GetByDeviceID(ctx, deviceID)
+	if err == sql.ErrNoRows {
+		// Unknown device: create a fresh anonymous account for it
+		var admissionID uuid.UUID
+		if s.config.Gate != nil {
+			admissionID, err = s.config.Gate.AdmitAccount(ctx, deviceID, proof)
+			if err != nil {
+				return nil, err
+			}
+		}
+		user, err := s.userRepo.GetOrCreateByDeviceID(ctx, deviceID)
+		if err != nil {
+			return nil, err
+		}
+		device, err = s.deviceRepo.GetOrCreate(ctx, user.ID, deviceID, deviceName)
+		if err != nil {
+			return nil, err
+		}
+		if s.config.Gate != nil {
+			if err := s.config.Gate.AccountCreated(ctx, admissionID, user.ID); err != nil {
+				return nil, err
+			}
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if device.IsRevoked() {
+		return nil, ErrDeviceRevoked
+	}
+	if err := s.CheckUser(ctx, device.UserID); err != nil {
+		return nil, err
+	}
+
+	return s.startSession(ctx, device)
+}
+
+func (s *authService) Recover(ctx context.Context, recoverySecret, deviceID, deviceName string) (*domain.TokenPair, error) {
+	if deviceID == "" {
+		return nil, errors.New("device_id is required")
+	}
+
+	user, err := s.userRepo.GetByRecoverySecretHash(ctx, hashToken(normalizeCode(recoverySecret)))
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidRecovery
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.linkDevice(ctx, user.ID, deviceID, deviceName)
+}
+
+func (s *authService) Pair(ctx context.Context, code, deviceID, deviceName string) (*domain.TokenPair, error) {
+	if deviceID == "" {
+		return nil, errors.New("device_id is required")
+	}
+
+	userID, err := s.deviceRepo.ConsumePairing(ctx, hashToken(normalizeCode(code)))
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidPairingCode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.linkDevice(ctx, userID, deviceID, deviceName)
+}
+
+// linkDevice attaches deviceID to userID. A device already registered
+// elsewhere is moved over and loses its existing sessions.
+func (s *authService) linkDevice(ctx context.Context, userID uuid.UUID, deviceID, deviceName string) (*domain.TokenPair, error) {
+	if err := s.CheckUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.deviceRepo.GetByDeviceID(ctx, deviceID)
+	if err == nil {
+		if err := s.RevokeDevice(ctx, existing.ID); err != nil {
+			return nil, err
+		}
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	device, err := s.deviceRepo.Link(ctx, userID, deviceID, deviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.startSession(ctx, device)
+}
+
+func (s *authService) startSession(ctx context.Context, device *domain.Device) (*domain.TokenPair, error) {
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &domain.Session{
+		ID:               uuid.New(),
+		UserID:           device.UserID,
+		DeviceID:         device.ID,
+		RefreshTokenHash: refreshHash,
+		ExpiresAt:        time.Now().Add(s.config.RefreshTokenTTL),
+	}
+
+	err = s.sessionRepo.Create(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.deviceRepo.Touch(ctx, device.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issue(session, refreshToken)
+}
+
+func (s *authService) Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	hash := hashToken(refreshToken)
+
+	session, err := s.sessionRepo.GetByRefreshTokenHash(ctx, hash)
+	if err == sql.ErrNoRows {
+		// A rotated-out token being presented again means it leaked; kill the
+		// whole session so neither holder can keep using it.
+		reused, lookupErr := s.sessionRepo.GetByPreviousRefreshTokenHash(ctx, hash)
+		if lookupErr == nil {
+			s.revokeSession(ctx, reused.ID)
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !session.IsActive() {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	device, err := s.deviceRepo.GetByID(ctx, session.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device.IsRevoked() || device.UserID != session.UserID {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err := s.CheckUser(ctx, session.UserID); err != nil {
+		return nil, err
+	}
+
+	newToken, newHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.config.RefreshTokenTTL)
+	rotated, err := s.sessionRepo.Rotate(ctx, session.ID, hash, newHash, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, ErrInvalidRefreshToken
+	}
+	session.RefreshTokenHash = newHash
+	session.ExpiresAt = expiresAt
+
+	err = s.deviceRepo.Touch(ctx, device.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issue(session, newToken)
+}
+
+func (s *authService) Logout(ctx context.Context, sessionID uuid.UUID) error {
+	return s.revokeSession(ctx, sessionID)
+}
+
+func (s *authService) RevokeDevice(ctx context.Context, deviceID uuid.UUID) error {
+	sessionIDs, err := s.sessionRepo.RevokeAllForDevice(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	for _, id := range sessionIDs {
+		s.revoked.revokeSession(id, s.config.AccessTokenTTL)
+		if s.config.Connections != nil {
+			s.config.Connections.DisconnectSession(id)
+		}
+	}
+	return nil
+}
+
+func (s *authService) RevokeUser(ctx context.Context, userID uuid.UUID) error {
+	s.revoked.revokeUser(userID, time.Now(), s.config.AccessTokenTTL)
+	s.active.forget(userID)
+	if s.config.Connections != nil {
+		s.config.Connections.DisconnectUser(userID)
+	}
+
+	_, err := s.sessionRepo.RevokeAllForUser(ctx, userID)
+	return err
+}
+
+func (s *authService) ValidateAccessToken(token string) (*AccessClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, ErrInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if !hmac.Equal(sig, s.sign(parts[0])) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	claims := &AccessClaims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.UserID == uuid.Nil || claims.SessionID == uuid.Nil {
+		return nil, ErrInvalidToken
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+
+	if s.revoked.isRevoked(claims) {
+		return nil, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// CheckUser returns ErrUserSuspended while the user is suspended and
+// ErrInvalidToken once they have erased their account. Users that pass are
+// remembered for activeUserTTL so authenticated requests do not all hit the
+// database.
+func (s *authService) CheckUser(ctx context.Context, userID uuid.UUID) error {
+	if s.active.contains(userID) {
+		return nil
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err == sql.ErrNoRows {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+	if user.ErasedAt != nil {
+		return ErrInvalidToken
+	}
+	if user.IsSuspended(time.Now()) {
+		return ErrUserSuspended
+	}
+
+	s.active.add(userID, activeUserTTL)
+	return nil
+}
+
+func (s *authService) revokeSession(ctx context.Context, sessionID uuid.UUID) error {
+	s.revoked.revokeSession(sessionID, s.config.AccessTokenTTL)
+	if s.config.Connections != nil {
+		s.config.Connections.DisconnectSession(sessionID)
+	}
+	return s.sessionRepo.Revoke(ctx, sessionID)
+}
+
+func (s *authService) issue(session *domain.Session, refreshToken string) (*domain.TokenPair, error) {
+	now := time.Now()
+	claims := AccessClaims{
+		UserID:    session.UserID,
+		SessionID: session.ID,
+		DeviceID:  session.DeviceID,
+		IssuedAt:  now.UnixNano(),
+		ExpiresAt: now.Add(s.config.AccessTokenTTL).Unix(),
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	accessToken := encoded + "." + base64.RawURLEncoding.EncodeToString(s.sign(encoded))
+
+	return &domain.TokenPair{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(s.config.AccessTokenTTL.Seconds()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+func (s *authService) sign(data string) []byte {
+	mac := hmac.New(sha256.New, s.config.Secret)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func newRefreshToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+// normalizeCode lets users type recovery secrets and pairing codes without
+// worrying about case, spaces or dashes.
+func normalizeCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '\t' || r == '\n' {
+			return -1
+		}
+		return r
+	}, code)
+}
+
+// newCode returns n random bytes as grouped base32 text, e.g. ABCD-EFGH.
+func newCode(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	raw := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf)
+
+	var groups []string
+	for len(raw) > 4 {
+		groups = append(groups, raw[:4])
+		raw = raw[4:]
+	}
+	groups = append(groups, raw)
+	return strings.Join(groups, "-"), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// revocationList keeps revoked sessions and users in memory until every access
+// token they could still hold has expired. It is per-instance; refresh tokens
+// are revoked in the database and stop working everywhere.
+type revocationList struct {
+	mu       sync.RWMutex
+	sessions map[uuid.UUID]time.Time
+	users    map[uuid.UUID]revokedUser
+}
+
+type revokedUser struct {
+	before time.Time
+	until  time.Time
+}
+
+func newRevocationList() *revocationList {
+	return &revocationList{
+		sessions: make(map[uuid.UUID]time.Time),
+		users:    make(map[uuid.UUID]revokedUser),
+	}
+}
+
+func (l *revocationList) revokeSession(sessionID uuid.UUID, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(time.Now())
+	l.sessions[sessionID] = time.Now().Add(ttl)
+}
+
+func (l *revocationList) revokeUser(userID uuid.UUID, before time.Time, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(time.Now())
+	l.users[userID] = revokedUser{before: before, until: before.Add(ttl)}
+}
+
+func (l *revocationList) isRevoked(claims *AccessClaims) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if _, ok := l.sessions[claims.SessionID]; ok {
+		return true
+	}
+	if u, ok := l.users[claims.UserID]; ok && claims.IssuedAt <= u.before.UnixNano() {
+		return true
+	}
+	return false
+}
+
+func (l *revocationList) prune(now time.Time) {
+	for id, until := range l.sessions {
+		if now.After(until) {
+			delete(l.sessions, id)
+		}
+	}
+	for id, u := range l.users {
+		if now.After(u.until) {
+			delete(l.users, id)
+		}
+	}
+}
+
+// activeUserCache remembers users that recently passed a suspension check.
+// Suspending a user on this instance drops them at once; other instances
+// notice within activeUserTTL.
+type activeUserCache struct {
+	mu    sync.Mutex
+	users map[uuid.UUID]time.Time
+}
+
+func newActiveUserCache() *activeUserCache {
+	return &activeUserCache{users: make(map[uuid.UUID]time.Time)}
+}
+
+func (c *activeUserCache) contains(userID uuid.UUID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	until, ok := c.users[userID]
+	return ok && time.Now().Before(until)
+}
+
+func (c *activeUserCache) add(userID uuid.UUID, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for id, until := range c.users {
+		if now.After(until) {
+			delete(c.users, id)
+		}
+	}
+	c.users[userID] = now.Add(ttl)
+}
+
+func (c *activeUserCache) forget(userID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.users, userID)
+}
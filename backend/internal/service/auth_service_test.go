@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type mockSessionRepo struct {
+	sessions map[uuid.UUID]*domain.Session
+	previous map[uuid.UUID]string
+}
+
+func newMockSessionRepo() *mockSessionRepo {
+	return &mockSessionRepo{
+		sessions: make(map[uuid.UUID]*domain.Session),
+		previous: make(map[uuid.UUID]string),
+	}
+}
+
+func (m *mockSessionRepo) Create(ctx context.Context, session *domain.Session) error {
+	session.CreatedAt = time.Now()
+	session.LastUsedAt = session.CreatedAt
+	m.sessions[session.ID] = session
+	return nil
+}
+
+func (m *mockSessionRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Session, error) {
+	session, ok := m.sessions[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return session, nil
+}
+
+func (m *mockSessionRepo) GetByRefreshTokenHash(ctx context.Context, hash string) (*domain.Session, error) {
+	for _, session := range m.sessions {
+		if session.RefreshTokenHash == hash {
+			return session, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockSessionRepo) GetByPreviousRefreshTokenHash(ctx context.Context, hash string) (*domain.Session, error) {
+	for id, prev := range m.previous {
+		if prev == hash {
+			return m.sessions[id], nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockSessionRepo) Rotate(ctx context.Context, id uuid.UUID, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	session, ok := m.sessions[id]
+	if !ok || session.RefreshTokenHash != oldHash || session.RevokedAt != nil {
+		return false, nil
+	}
+	m.previous[id] = oldHash
+	session.RefreshTokenHash = newHash
+	session.ExpiresAt = expiresAt
+	return true, nil
+}
+
+func (m *mockSessionRepo) Revoke(ctx context.Context, id uuid.UUID) error {
+	if session, ok := m.sessions[id]; ok && session.RevokedAt == nil {
+		now := time.Now()
+		session.RevokedAt = &now
+	}
+	return nil
+}
+
+func (m *mockSessionRepo) RevokeAllForUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for id, session := range m.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			now := time.Now()
+			session.RevokedAt = &now
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (m *mockSessionRepo) RevokeAllForDevice(ctx context.Context, deviceID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for id, session := range m.sessions {
+		if session.DeviceID == deviceID && session.RevokedAt == nil {
+			now := time.Now()
+			session.RevokedAt = &now
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+type mockDeviceRepo struct {
+	devices  map[uuid.UUID]*domain.Device
+	pairings map[string]uuid.UUID
+}
+
+func newMockDeviceRepo() *mockDeviceRepo {
+	return &mockDeviceRepo{
+		devices:  make(map[uuid.UUID]*domain.Device),
+		pairings: make(map[string]uuid.UUID),
+	}
+}
+
+func (m *mockDeviceRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Device, error) {
+	device, ok := m.devices[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return device, nil
+}
+
+func (m *mockDeviceRepo) GetByDeviceID(ctx context.Context, deviceID string) (*domain.Device, error) {
+	for _, device := range m.devices {
+		if device.DeviceID == deviceID {
+			return device, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockDeviceRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Device, error) {
+	var result []*domain.Device
+	for _, device := range m.devices {
+		if device.UserID == userID {
+			result = append(result, device)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockDeviceRepo) GetOrCreate(ctx context.Context, userID uuid.UUID, deviceID, name string) (*domain.Device, error) {
+	if device, err := m.GetByDeviceID(ctx, deviceID); err == nil {
+		return device, nil
+	}
+	device := &domain.Device{ID: uuid.New(), UserID: userID, DeviceID: deviceID, Name: name}
+	m.devices[device.ID] = device
+	return device, nil
+}
+
+func (m *mockDeviceRepo) Link(ctx context.Context, userID uuid.UUID, deviceID, name string) (*domain.Device, error) {
+	device, err := m.GetOrCreate(ctx, userID, deviceID, name)
+	if err != nil {
+		return nil, err
+	}
+	device.UserID = userID
+	device.Name = name
+	device.RevokedAt = nil
+	return device, nil
+}
+
+func (m *mockDeviceRepo) Touch(ctx context.Context, id uuid.UUID) error {
+	if device, ok := m.devices[id]; ok {
+		device.LastSeenAt = time.Now()
+	}
+	return nil
+}
+
+func (m *mockDeviceRepo) Revoke(ctx context.Context, id uuid.UUID) error {
+	if device, ok := m.devices[id]; ok && device.RevokedAt == nil {
+		now := time.Now()
+		device.RevokedAt = &now
+	}
+	return nil
+}
+
+func (m *mockDeviceRepo) CreatePairing(ctx context.Context, userID uuid.UUID, codeHash string, expiresAt time.Time) error {
+	m.pairings[codeHash] = userID
+	return nil
+}
+
+func (m *mockDeviceRepo) ConsumePairing(ctx context.Context, codeHash string) (uuid.UUID, error) {
+	userID, ok := m.pairings[codeHash]
+	if !ok {
+		return uuid.Nil, sql.ErrNoRows
+	}
+	delete(m.pairings, codeHash)
+	return userID, nil
+}
+
+func newTestAuthService() (AuthService, *mockSessionRepo) {
+	sessionRepo := newMockSessionRepo()
+	svc := NewAuthService(&mockUserRepo{}, newMockDeviceRepo(), sessionRepo, AuthConfig{Secret: []byte("test-secret")})
+	return svc, sessionRepo
+}
+
+func TestHandshakeIssuesValidToken(t *testing.T) {
+	svc, _ := newTestAuthService()
+
+	tokens, err := svc.Handshake(context.Background(), "device-1", "", domain.AccountProof{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer", tokens.TokenType)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	claims, err := svc.ValidateAccessToken(tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, claims.UserID)
+	assert.NotEqual(t, uuid.Nil, claims.SessionID)
+}
+
+func TestValidateAccessTokenRejectsTampering(t *testing.T) {
+	svc, _ := newTestAuthService()
+	tokens, _ := svc.Handshake(context.Background(), "device-1", "", domain.AccountProof{})
+
+	_, err := svc.ValidateAccessToken(tokens.AccessToken + "x")
+	assert.Equal(t, ErrInvalidToken, err)
+
+	other := NewAuthService(&mockUserRepo{}, newMockDeviceRepo(), newMockSessionRepo(), AuthConfig{Secret: []byte("other-secret")})
+	_, err = other.ValidateAccessToken(tokens.AccessToken)
+	assert.Equal(t, ErrInvalidToken, err)
+}
+
+func TestValidateAccessTokenExpired(t *testing.T) {
+	svc := NewAuthService(&mockUserRepo{}, newMockDeviceRepo(), newMockSessionRepo(), AuthConfig{
+		Secret:         []byte("test-secret"),
+		AccessTokenTTL: time.Nanosecond,
+	})
+	tokens, _ := svc.Handshake(context.Background(), "device-1", "", domain.AccountProof{})
+	time.Sleep(time.Second)
+
+	_, err := svc.ValidateAccessToken(tokens.AccessToken)
+	assert.Equal(t, ErrTokenExpired, err)
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	svc, sessionRepo := newTestAuthService()
+	ctx := context.Background()
+
+	first, _ := svc.Handshake(ctx, "device-1", "", domain.AccountProof{})
+
+	second, err := svc.Refresh(ctx, first.RefreshToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	// Replaying the rotated-out token revokes the session entirely
+	_, err = svc.Refresh(ctx, first.RefreshToken)
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+
+	_, err = svc.Refresh(ctx, second.RefreshToken)
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+
+	claims, _ := svc.ValidateAccessToken(first.AccessToken)
+	assert.Nil(t, claims)
+	for _, session := range sessionRepo.sessions {
+		assert.NotNil(t, session.RevokedAt)
+	}
+}
+
+func TestLogoutRevokesAccessToken(t *testing.T) {
+	connections := &mockDisconnector{}
+	svc := NewAuthService(&mockUserRepo{}, newMockDeviceRepo(), newMockSessionRepo(), AuthConfig{Secret: []byte("test-secret"), Connections: connections})
+	ctx := context.Background()
+
+	tokens, _ := svc.Handshake(ctx, "device-1", "", domain.AccountProof{})
+	claims, err := svc.ValidateAccessToken(tokens.AccessToken)
+	assert.NoError(t, err)
+
+	err = svc.Logout(ctx, claims.SessionID)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{claims.SessionID}, connections.sessions)
+
+	_, err = svc.ValidateAccessToken(tokens.AccessToken)
+	assert.Equal(t, ErrTokenRevoked, err)
+
+	_, err = svc.Refresh(ctx, tokens.RefreshToken)
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+}
+
+func TestRevokeUserInvalidatesAllSessions(t *testing.T) {
+	connections := &mockDisconnector{}
+	svc := NewAuthService(&mockUserRepo{}, newMockDeviceRepo(), newMockSessionRepo(), AuthConfig{Secret: []byte("test-secret"), Connections: connections})
+	ctx := context.Background()
+
+	tokens, _ := svc.Handshake(ctx, "device-1", "", domain.AccountProof{})
+	claims, _ := svc.ValidateAccessToken(tokens.AccessToken)
+
+	err := svc.RevokeUser(ctx, claims.UserID)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{claims.UserID}, connections.disconnected)
+
+	_, err = svc.ValidateAccessToken(tokens.AccessToken)
+	assert.Equal(t, ErrTokenRevoked, err)
+
+	_, err = svc.Refresh(ctx, tokens.RefreshToken)
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+
+	// Signing in again straight away, within the same second, works
+	tokens, err = svc.Handshake(ctx, "device-1", "", domain.AccountProof{})
+	assert.NoError(t, err)
+	_, err = svc.ValidateAccessToken(tokens.AccessToken)
+	assert.NoError(t, err)
+}
+
+func TestPairLinksDeviceToSameUser(t *testing.T) {
+	userRepo := &mockUserRepo{}
+	deviceRepo := newMockDeviceRepo()
+	authSvc := NewAuthService(userRepo, deviceRepo, newMockSessionRepo(), AuthConfig{Secret: []byte("test-secret")})
+	deviceSvc := NewDeviceService(deviceRepo, userRepo, authSvc)
+	ctx := context.Background()
+
+	first, _ := authSvc.Handshake(ctx, "phone", "Phone", domain.AccountProof{})
+	owner, _ := authSvc.ValidateAccessToken(first.AccessToken)
+
+	pairing, err := deviceSvc.CreatePairingCode(ctx, owner.UserID)
+	assert.NoError(t, err)
+
+	linked, err := authSvc.Pair(ctx, strings.ToLower(pairing.Code), "tablet", "Tablet")
+	assert.NoError(t, err)
+	claims, _ := authSvc.ValidateAccessToken(linked.AccessToken)
+	assert.Equal(t, owner.UserID, claims.UserID)
+	assert.NotEqual(t, owner.DeviceID, claims.DeviceID)
+
+	// Codes are single use
+	_, err = authSvc.Pair(ctx, pairing.Code, "laptop", "")
+	assert.Equal(t, ErrInvalidPairingCode, err)
+
+	devices, _ := deviceSvc.ListDevices(ctx, owner.UserID, owner.DeviceID)
+	assert.Len(t, devices, 2)
+}
+
+func TestRecoverRestoresAccountOnNewDevice(t *testing.T) {
+	userRepo := &mockUserRepo{}
+	deviceRepo := newMockDeviceRepo()
+	authSvc := NewAuthService(userRepo, deviceRepo, newMockSessionRepo(), AuthConfig{Secret: []byte("test-secret")})
+	deviceSvc := NewDeviceService(deviceRepo, userRepo, authSvc)
+	ctx := context.Background()
+
+	first, _ := authSvc.Handshake(ctx, "old-phone", "", domain.AccountProof{})
+	owner, _ := authSvc.ValidateAccessToken(first.AccessToken)
+
+	secret, err := deviceSvc.SetupRecovery(ctx, owner.UserID)
+	assert.NoError(t, err)
+
+	_, err = authSvc.Recover(ctx, "WRONG-SECRET", "new-phone", "")
+	assert.Equal(t, ErrInvalidRecovery, err)
+
+	restored, err := authSvc.Recover(ctx, strings.ReplaceAll(secret, "-", " "), "new-phone", "")
+	assert.NoError(t, err)
+	claims, _ := authSvc.ValidateAccessToken(restored.AccessToken)
+	assert.Equal(t, owner.UserID, claims.UserID)
+
+	err = deviceSvc.DisableRecovery(ctx, owner.UserID)
+	assert.NoError(t, err)
+	_, err = authSvc.Recover(ctx, secret, "another-phone", "")
+	assert.Equal(t, ErrInvalidRecovery, err)
+}
+
+func TestRevokeDeviceEndsItsSessions(t *testing.T) {
+	userRepo := &mockUserRepo{}
+	deviceRepo := newMockDeviceRepo()
+	connections := &mockDisconnector{}
+	authSvc := NewAuthService(userRepo, deviceRepo, newMockSessionRepo(), AuthConfig{Secret: []byte("test-secret"), Connections: connections})
+	deviceSvc := NewDeviceService(deviceRepo, userRepo, authSvc)
+	ctx := context.Background()
+
+	tokens, _ := authSvc.Handshake(ctx, "lost-phone", "", domain.AccountProof{})
+	claims, _ := authSvc.ValidateAccessToken(tokens.AccessToken)
+
+	// Another user cannot revoke it
+	err := deviceSvc.RevokeDevice(ctx, uuid.New(), claims.DeviceID)
+	assert.Equal(t, ErrDeviceNotFound, err)
+
+	err = deviceSvc.RevokeDevice(ctx, claims.UserID, claims.DeviceID)
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{claims.SessionID}, connections.sessions)
+
+	_, err = authSvc.ValidateAccessToken(tokens.AccessToken)
+	assert.Equal(t, ErrTokenRevoked, err)
+	_, err = authSvc.Refresh(ctx, tokens.RefreshToken)
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+	_, err = authSvc.Handshake(ctx, "lost-phone", "", domain.AccountProof{})
+	assert.Equal(t, ErrDeviceRevoked, err)
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/repository"
+)
+
+var (
+	ErrCannotBlockSelf = errors.New("cannot block yourself")
+	ErrBlockNotFound   = errors.New("block not found")
+)
+
+type BlockService interface {
+	Block(ctx context.Context, blockerID, taskID uuid.UUID, alias string) (*domain.Block, error)
+	ListBlocks(ctx context.Context, blockerID uuid.UUID) ([]*domain.Block, error)
+	Unblock(ctx context.Context, blockerID, blockID uuid.UUID) error
+	IsBlocked(ctx context.Context, userID, otherUserID uuid.UUID) (bool, error)
+}
+
+type blockService struct {
+	blockRepo repository.BlockRepository
+	taskRepo  repository.TaskRepository
+	aliasSvc  AliasService
+}
+
+func NewBlockService(
+	blockRepo repository.BlockRepository,
+	taskRepo repository.TaskRepository,
+	aliasSvc AliasService,
+) BlockService {
+	return &blockService{
+		blockRepo: blockRepo,
+		taskRepo:  taskRepo,
+		aliasSvc:  aliasSvc,
+	}
+}
+
+// Block blocks a participant the blocker can see on a task, following the
+// same visibility rule as public profiles.
+func (s *blockService) Block(ctx context.Context, blockerID, taskID uuid.UUID, alias string) (*domain.Block, error) {
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return nil, ErrTaskNotFound
+	}
+
+	blockedID, err := s.aliasSvc.Resolve(ctx, taskID, alias)
+	if err != nil {
+		return nil, err
+	}
+
+	if blockedID == blockerID {
+		return nil, ErrCannotBlockSelf
+	}
+	if !can(blockerID, ActionViewParticipant, ParticipantResource{Task: task, UserID: blockedID}) {
+		return nil, ErrParticipantNotFound
+	}
+
+	block := &domain.Block{
+		ID:        uuid.New(),
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+		TaskID:    &taskID,
+	}
+	err = s.blockRepo.Create(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	s.setAlias(block)
+	return block, nil
+}
+
+func (s *blockService) ListBlocks(ctx context.Context, blockerID uuid.UUID) ([]*domain.Block, error) {
+	blocks, err := s.blockRepo.GetByBlockerID(ctx, blockerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range blocks {
+		s.setAlias(block)
+	}
+	return blocks, nil
+}
+
+func (s *blockService) Unblock(ctx context.Context, blockerID, blockID uuid.UUID) error {
+	deleted, err := s.blockRepo.Delete(ctx, blockID, blockerID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrBlockNotFound
+	}
+	return nil
+}
+
+func (s *blockService) IsBlocked(ctx context.Context, userID, otherUserID uuid.UUID) (bool, error) {
+	return s.blockRepo.IsBlocked(ctx, userID, otherUserID)
+}
+
+// setAlias labels a block with the alias the blocked user had on the task it
+// was made from, so the list never exposes a cross-task identifier.
+func (s *blockService) setAlias(block *domain.Block) {
+	if block.TaskID != nil {
+		block.Alias = s.aliasSvc.Alias(*block.TaskID, block.BlockedID)
+	}
+}
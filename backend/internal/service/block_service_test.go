@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type mockBlockRepo struct {
+	blocks map[uuid.UUID]*domain.Block
+}
+
+func newMockBlockRepo() *mockBlockRepo {
+	return &mockBlockRepo{blocks: make(map[uuid.UUID]*domain.Block)}
+}
+
+func (m *mockBlockRepo) Create(ctx context.Context, block *domain.Block) error {
+	for _, existing := range m.blocks {
+		if existing.BlockerID == block.BlockerID && existing.BlockedID == block.BlockedID {
+			*block = *existing
+			return nil
+		}
+	}
+	block.CreatedAt = time.Now()
+	m.blocks[block.ID] = block
+	return nil
+}
+
+func (m *mockBlockRepo) GetByBlockerID(ctx context.Context, blockerID uuid.UUID) ([]*domain.Block, error) {
+	var result []*domain.Block
+	for _, block := range m.blocks {
+		if block.BlockerID == blockerID {
+			result = append(result, block)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockBlockRepo) Delete(ctx context.Context, id, blockerID uuid.UUID) (bool, error) {
+	block, ok := m.blocks[id]
+	if !ok || block.BlockerID != blockerID {
+		return false, nil
+	}
+	delete(m.blocks, id)
+	return true, nil
+}
+
+func (m *mockBlockRepo) IsBlocked(ctx context.Context, userID, otherUserID uuid.UUID) (bool, error) {
+	for _, block := range m.blocks {
+		if (block.BlockerID == userID && block.BlockedID == otherUserID) ||
+			(block.BlockerID == otherUserID && block.BlockedID == userID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestBlockPreventsClaimsAndChat(t *testing.T) {
+	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
+	blockRepo := newMockBlockRepo()
+	aliasSvc := NewAliasService(&mockUserRepo{}, taskRepo, claimRepo, []byte("test-secret"))
+	blockSvc := NewBlockService(blockRepo, taskRepo, aliasSvc)
+	claimSvc := NewClaimService(claimRepo, taskRepo, &mockChatRepoForClaimSvc{}, &mockEscrowSvc{}, &mockUserRepo{}, &mockReputationSvc{}, blockRepo, nil, nil)
+	chatSvc := NewChatService(&mockChatRepoForClaimSvc{}, taskRepo, claimRepo, blockRepo, nil, nil, nil, ChatConfig{})
+	ctx := context.Background()
+
+	ownerID := uuid.New()
+	claimerID := uuid.New()
+	newTask := func() *domain.Task {
+		task := &domain.Task{
+			ID:            uuid.New(),
+			OwnerID:       ownerID,
+			MaxClaimants:  5,
+			ClaimDeadline: time.Now().Add(24 * time.Hour),
+			OwnerDeadline: time.Now().Add(48 * time.Hour),
+			Status:        domain.TaskStatusOpen,
+		}
+		taskRepo.tasks[task.ID] = task
+		return task
+	}
+
+	// Nobody can block themselves; the claimer blocks the owner of a task
+	// they can see
+	first := newTask()
+	_, err := blockSvc.Block(ctx, ownerID, first.ID, aliasSvc.Alias(first.ID, ownerID))
+	assert.Equal(t, ErrCannotBlockSelf, err)
+	block, err := blockSvc.Block(ctx, claimerID, first.ID, aliasSvc.Alias(first.ID, ownerID))
+	assert.NoError(t, err)
+	assert.Equal(t, aliasSvc.Alias(first.ID, ownerID), block.Alias)
+
+	// The block applies to the owner's other tasks in both directions, and
+	// looks like any other unclaimable task
+	second := newTask()
+	_, err = claimSvc.ClaimTask(ctx, second.ID, claimerID)
+	assert.Equal(t, ErrTaskNotClaimable, err)
+
+	// A claim made before the block no longer gets a chat
+	earlier := &domain.Claim{ID: uuid.New(), TaskID: first.ID, ClaimerID: claimerID, Status: domain.ClaimStatusPending}
+	claimRepo.claims[earlier.ID] = earlier
+	_, err = chatSvc.GetOrCreateChat(ctx, first.ID, ownerID, earlier.ID)
+	assert.Equal(t, ErrChatUnavailable, err)
+
+	// Strangers can still claim
+	_, err = claimSvc.ClaimTask(ctx, second.ID, uuid.New())
+	assert.NoError(t, err)
+
+	// The owner cannot undo the claimer's block
+	assert.Equal(t, ErrBlockNotFound, blockSvc.Unblock(ctx, ownerID, block.ID))
+	assert.NoError(t, blockSvc.Unblock(ctx, claimerID, block.ID))
+
+	_, err = claimSvc.ClaimTask(ctx, second.ID, claimerID)
+	assert.NoError(t, err)
+}
@@ -1,83 +1,913 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
 	"errors"
+	"io"
+	"log"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/task-underground/backend/internal/domain"
 	"github.com/task-underground/backend/internal/repository"
+	"github.com/task-underground/backend/internal/storage"
 )
 
 var (
-	ErrChatNotFound = errors.New("chat not found")
+	ErrChatNotFound    = errors.New("chat not found")
+	ErrChatUnavailable = errors.New("chat is unavailable")
+	ErrChatDeleted     = errors.New("chat is deleted")
+	ErrMessageEmpty    = errors.New("message content cannot be empty")
+	ErrMessageNotFound = errors.New("message not found")
+	ErrInvalidCursor   = errors.New("invalid cursor")
+	ErrMessageDeleted  = errors.New("message was deleted")
+	ErrEditWindowOver  = errors.New("message can no longer be edited")
+	ErrInvalidTimer    = errors.New("disappearing timer must be off, 1 hour, 1 day or 1 week")
+
+	ErrInvalidCiphertext = errors.New("ciphertext is malformed or too large")
+	ErrNoRecipientKeys   = errors.New("the other participant has not published encryption keys")
+	ErrEnvelopeMismatch  = errors.New("envelopes do not match the chat's devices")
+	ErrMessageEncrypted  = errors.New("encrypted messages cannot be edited")
+	ErrPlaintextRefused  = errors.New("chat is end-to-end encrypted; send ciphertext")
+
+	ErrTooManyAttachments     = errors.New("too many attachments")
+	ErrAttachmentTooLarge     = errors.New("attachment is too large")
+	ErrAttachmentType         = errors.New("attachment type is not allowed")
+	ErrAttachmentNotFound     = errors.New("attachment not found")
+	ErrAttachmentsUnavailable = errors.New("attachments are not available")
 )
 
+const (
+	defaultMessageEditWindow = 15 * time.Minute
+	defaultMessageRetention  = 90 * 24 * time.Hour
+	defaultPurgeBatchSize    = 500
+)
+
+// ChatConfig tunes chat. Zero values fall back to the defaults.
+type ChatConfig struct {
+	// EditWindow is how long after sending a message its sender may still
+	// edit it. Unsending has no time limit.
+	EditWindow time.Duration
+	// Blobs keeps attachment files. Without it messages cannot carry
+	// attachments.
+	Blobs storage.BlobStore
+	// Each message may carry MaxAttachments files of up to
+	// MaxAttachmentSize bytes each.
+	MaxAttachmentSize int64
+	MaxAttachments    int
+	// Retention is how long the messages of a completed or cancelled task
+	// are kept after it ended. PurgeMessages deletes up to PurgeBatchSize
+	// messages per transaction.
+	Retention      time.Duration
+	PurgeBatchSize int
+}
+
+// PurgeResult counts what one run of PurgeMessages deleted: chats both
+// sides had deleted, messages whose disappearing timer ran out, messages
+// past the retention period, and the attachment files that went with any
+// of them.
+type PurgeResult struct {
+	Chats       int
+	Expired     int
+	Retention   int
+	Attachments int
+}
+
+// EventPublisher pushes real-time events to a user's connected devices.
+// BroadcastToChat only reaches the connections subscribed to the chat.
+type EventPublisher interface {
+	BroadcastToUser(userID uuid.UUID, event domain.Event)
+	BroadcastToChat(userID, chatID uuid.UUID, event domain.Event)
+}
+
 type ChatService interface {
-	GetOrCreateChat(ctx context.Context, taskID, userID, otherUserID uuid.UUID) (*domain.Chat, error)
+	GetOrCreateChat(ctx context.Context, taskID, userID, claimID uuid.UUID) (*domain.Chat, error)
+	GetChat(ctx context.Context, chatID, userID uuid.UUID) (*domain.Chat, error)
 	GetChatsByTaskID(ctx context.Context, taskID, userID uuid.UUID) ([]*domain.Chat, error)
+	GetInbox(ctx context.Context, userID uuid.UUID, cursor string, limit int) ([]*domain.Chat, string, error)
 	DeleteChat(ctx context.Context, chatID, userID uuid.UUID) error
-	SendMessage(ctx context.Context, chatID, senderID uuid.UUID, content string) (*domain.Message, error)
-	GetMessages(ctx context.Context, chatID uuid.UUID, limit, offset int) ([]*domain.Message, error)
+	SendMessage(ctx context.Context, chatID, senderID uuid.UUID, content string, attachments []AttachmentUpload) (*domain.Message, error)
+	SendEncryptedMessage(ctx context.Context, chatID, senderID, senderDeviceID uuid.UUID, ciphertext []byte, envelopes []*domain.Envelope) (*domain.Message, error)
+	EditMessage(ctx context.Context, chatID, messageID, userID uuid.UUID, content string) (*domain.Message, error)
+	UnsendMessage(ctx context.Context, chatID, messageID, userID uuid.UUID) (*domain.Message, error)
+	SendTyping(ctx context.Context, chatID, userID uuid.UUID) error
+	MarkRead(ctx context.Context, chatID, userID, messageID uuid.UUID) error
+	SetTimer(ctx context.Context, chatID, userID uuid.UUID, seconds int) (*domain.Chat, error)
+	PurgeMessages(ctx context.Context) (*PurgeResult, error)
+	UnreadCount(ctx context.Context, userID uuid.UUID) (int, error)
+	GetMessages(ctx context.Context, chatID, userID uuid.UUID, limit, offset int) ([]*domain.Message, error)
+	OpenAttachment(ctx context.Context, chatID, attachmentID, userID uuid.UUID) (*domain.Attachment, io.ReadCloser, error)
 }
 
 type chatService struct {
-	chatRepo repository.ChatRepository
+	chatRepo  repository.ChatRepository
+	taskRepo  repository.TaskRepository
+	claimRepo repository.ClaimRepository
+	blockRepo repository.BlockRepository
+	keyRepo   repository.KeyRepository
+	aliasSvc  AliasService
+	publisher EventPublisher
+	config    ChatConfig
 }
 
-func NewChatService(chatRepo repository.ChatRepository) ChatService {
-	return &chatService{chatRepo: chatRepo}
+func NewChatService(
+	chatRepo repository.ChatRepository,
+	taskRepo repository.TaskRepository,
+	claimRepo repository.ClaimRepository,
+	blockRepo repository.BlockRepository,
+	keyRepo repository.KeyRepository,
+	aliasSvc AliasService,
+	publisher EventPublisher,
+	config ChatConfig,
+) ChatService {
+	if config.EditWindow <= 0 {
+		config.EditWindow = defaultMessageEditWindow
+	}
+	if config.MaxAttachmentSize <= 0 {
+		config.MaxAttachmentSize = defaultMaxAttachmentSize
+	}
+	if config.MaxAttachments <= 0 {
+		config.MaxAttachments = defaultMaxAttachments
+	}
+	if config.Retention <= 0 {
+		config.Retention = defaultMessageRetention
+	}
+	if config.PurgeBatchSize <= 0 {
+		config.PurgeBatchSize = defaultPurgeBatchSize
+	}
+	return &chatService{
+		chatRepo:  chatRepo,
+		taskRepo:  taskRepo,
+		claimRepo: claimRepo,
+		blockRepo: blockRepo,
+		keyRepo:   keyRepo,
+		aliasSvc:  aliasSvc,
+		publisher: publisher,
+		config:    config,
+	}
 }
 
-func (s *chatService) GetOrCreateChat(ctx context.Context, taskID, userID, otherUserID uuid.UUID) (*domain.Chat, error) {
+// GetOrCreateChat opens the chat between a task's owner and one of its
+// claimers. The owner picks the claimer by claimID; a claimer always talks
+// to the owner and claimID is ignored.
+func (s *chatService) GetOrCreateChat(ctx context.Context, taskID, userID, claimID uuid.UUID) (*domain.Chat, error) {
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+
+	var claim *domain.Claim
+	if userID == task.OwnerID {
+		claim, err = s.claimRepo.GetByID(ctx, claimID)
+		if err == nil && claim.TaskID != taskID {
+			err = sql.ErrNoRows
+		}
+	} else {
+		claim, err = s.claimRepo.GetByTaskIDAndClaimerID(ctx, taskID, userID)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrClaimNotFound
+		}
+		return nil, err
+	}
+
+	if !can(userID, ActionOpenChat, ClaimResource{Claim: claim, Task: task}) {
+		return nil, ErrUnauthorized
+	}
+
+	otherUserID := task.OwnerID
+	if userID == task.OwnerID {
+		otherUserID = claim.ClaimerID
+	}
+
+	blocked, err := s.blockRepo.IsBlocked(ctx, userID, otherUserID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrChatUnavailable
+	}
 	return s.chatRepo.GetOrCreate(ctx, taskID, userID, otherUserID)
 }
 
+// GetChat returns a chat to one of its participants.
+func (s *chatService) GetChat(ctx context.Context, chatID, userID uuid.UUID) (*domain.Chat, error) {
+	chat, err := s.chatRepo.GetByID(ctx, chatID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrChatNotFound
+		}
+		return nil, err
+	}
+
+	if !can(userID, ActionReadChat, chat) {
+		return nil, ErrUnauthorized
+	}
+	return chat, nil
+}
+
+// GetChatsByTaskID lists userID's chats on a task they own or have claimed.
 func (s *chatService) GetChatsByTaskID(ctx context.Context, taskID, userID uuid.UUID) ([]*domain.Chat, error) {
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+
+	if !can(userID, ActionListChats, task) {
+		claim, err := s.claimRepo.GetByTaskIDAndClaimerID(ctx, taskID, userID)
+		if err == sql.ErrNoRows {
+			return nil, ErrUnauthorized
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !can(userID, ActionListChats, ClaimResource{Claim: claim, Task: task}) {
+			return nil, ErrUnauthorized
+		}
+	}
 	return s.chatRepo.GetByTaskIDAndUserID(ctx, taskID, userID)
 }
 
-func (s *chatService) DeleteChat(ctx context.Context, chatID, userID uuid.UUID) error {
-	return s.chatRepo.DeleteForUser(ctx, chatID, userID)
-}
+// GetInbox returns a page of userID's chats across all tasks, most recently
+// active first, and the cursor of the next page, which is empty on the
+// last one.
+func (s *chatService) GetInbox(ctx context.Context, userID uuid.UUID, cursor string, limit int) ([]*domain.Chat, string, error) {
+	var after *domain.InboxCursor
+	if cursor != "" {
+		parsed, err := domain.ParseInboxCursor(cursor)
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		after = &parsed
+	}
 
-func (s *chatService) SendMessage(ctx context.Context, chatID, senderID uuid.UUID, content string) (*domain.Message, error) {
-	if content == "" {
-		return nil, errors.New("message content cannot be empty")
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	listed, err := s.chatRepo.ListInbox(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(listed) > limit {
+		listed = listed[:limit]
+		last := listed[limit-1]
+		next = domain.InboxCursor{ActiveAt: last.ActiveAt, ChatID: last.ID}.String()
 	}
+	chats := make([]*domain.Chat, 0, len(listed))
+	for _, chat := range listed {
+		if can(userID, ActionReadChat, chat) {
+			chats = append(chats, chat)
+		}
+	}
+	return chats, next, nil
+}
 
-	// Verify chat exists and user is participant
+// DeleteChat hides the chat from userID and drops its history so far for
+// them; the other participant keeps theirs. Once both have deleted it, the
+// chat and its messages are purged for good, unless its claim is in
+// dispute, in which case PurgeMessages purges it after the dispute is
+// resolved.
+func (s *chatService) DeleteChat(ctx context.Context, chatID, userID uuid.UUID) error {
 	chat, err := s.chatRepo.GetByID(ctx, chatID)
 	if err != nil {
-		return nil, ErrChatNotFound
+		if err == sql.ErrNoRows {
+			return ErrChatNotFound
+		}
+		return err
 	}
 
-	if chat.ParticipantID != senderID && chat.OtherParticipantID != senderID {
-		return nil, errors.New("unauthorized")
+	if !can(userID, ActionDeleteChat, chat) {
+		return ErrUnauthorized
+	}
+	both, err := s.chatRepo.DeleteForUser(ctx, chatID, userID)
+	if err != nil || !both {
+		return err
 	}
 
-	if !chat.IsVisibleTo(senderID) {
-		return nil, errors.New("chat is deleted")
+	_, keys, err := s.chatRepo.PurgeChat(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	s.deleteFiles(ctx, keys)
+	return nil
+}
+
+// SendMessage posts a message with optional attachments. A message needs
+// content, attachments or both. Once both participants have published keys
+// the chat only takes encrypted messages, so the server never stores
+// readable content for it again.
+func (s *chatService) SendMessage(ctx context.Context, chatID, senderID uuid.UUID, content string, attachments []AttachmentUpload) (*domain.Message, error) {
+	if content == "" && len(attachments) == 0 {
+		return nil, ErrMessageEmpty
+	}
+	if len(attachments) > 0 && s.config.Blobs == nil {
+		return nil, ErrAttachmentsUnavailable
+	}
+	if len(attachments) > s.config.MaxAttachments {
+		return nil, ErrTooManyAttachments
+	}
+
+	chat, err := s.writableChat(ctx, chatID, senderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refusePlaintext(ctx, chat); err != nil {
+		return nil, err
 	}
 
 	message := &domain.Message{
-		ID:       uuid.New(),
-		ChatID:   chatID,
-		SenderID: senderID,
-		Content:  content,
+		ID:        uuid.New(),
+		ChatID:    chatID,
+		SenderID:  senderID,
+		Content:   content,
+		ExpiresAt: chat.MessageExpiry(time.Now()),
+	}
+
+	for _, upload := range attachments {
+		attachment, data, err := readAttachment(upload, s.config.MaxAttachmentSize)
+		if err == nil {
+			attachment.MessageID = message.ID
+			attachment.ChatID = chat.ID
+			err = s.config.Blobs.Put(ctx, attachment.StorageKey, bytes.NewReader(data))
+		}
+		if err != nil {
+			s.deleteBlobs(ctx, message.Attachments)
+			return nil, err
+		}
+		message.Attachments = append(message.Attachments, attachment)
 	}
 
 	err = s.chatRepo.CreateMessage(ctx, message)
 	if err != nil {
+		s.deleteBlobs(ctx, message.Attachments)
 		return nil, err
 	}
 
+	s.publishMessage(ctx, chat, message, domain.EventChatMessage)
 	return message, nil
 }
 
-func (s *chatService) GetMessages(ctx context.Context, chatID uuid.UUID, limit, offset int) ([]*domain.Message, error) {
+// refusePlaintext fails with ErrPlaintextRefused if both participants of
+// chat have published keys, so its messages can be encrypted.
+func (s *chatService) refusePlaintext(ctx context.Context, chat *domain.Chat) error {
+	if s.keyRepo == nil {
+		return nil
+	}
+	devices, err := s.keyRepo.GetKeyedDevices(ctx, chat.ID, []uuid.UUID{chat.ParticipantID, chat.OtherParticipantID})
+	if err != nil {
+		return err
+	}
+	keyed := make(map[uuid.UUID]bool, 2)
+	for _, userID := range devices {
+		keyed[userID] = true
+	}
+	if keyed[chat.ParticipantID] && keyed[chat.OtherParticipantID] {
+		return ErrPlaintextRefused
+	}
+	return nil
+}
+
+// SendEncryptedMessage posts an end-to-end encrypted message. Only the
+// ciphertext is stored, with the message key sealed in an envelope for each
+// device that may read it. Every device of the other participant that has
+// published keys in the chat needs one, and so do the sender's own other
+// devices; when they do not match, the sender's copy of the key directory is
+// out of date and it should fetch the chat's keys again. Envelopes name
+// devices by their alias in the chat.
+func (s *chatService) SendEncryptedMessage(ctx context.Context, chatID, senderID, senderDeviceID uuid.UUID, ciphertext []byte, envelopes []*domain.Envelope) (*domain.Message, error) {
+	if len(ciphertext) < minCiphertextSize || len(ciphertext) > maxCiphertextSize {
+		return nil, ErrInvalidCiphertext
+	}
+
+	chat, err := s.writableChat(ctx, chatID, senderID)
+	if err != nil {
+		return nil, err
+	}
+	recipientID := chat.Counterpart(senderID)
+
+	devices, err := s.keyRepo.GetKeyedDevices(ctx, chatID, []uuid.UUID{recipientID, senderID})
+	if err != nil {
+		return nil, err
+	}
+	byAlias := make(map[uuid.UUID]uuid.UUID, len(devices))
+	for deviceID := range devices {
+		byAlias[s.aliasSvc.DeviceAlias(chatID, deviceID)] = deviceID
+	}
+	recipientDevices := 0
+	for _, userID := range devices {
+		if userID == recipientID {
+			recipientDevices++
+		}
+	}
+	if recipientDevices == 0 {
+		return nil, ErrNoRecipientKeys
+	}
+
+	// The sender's current device may keep a copy for itself but needs none
+	sealed := make(map[uuid.UUID]bool, len(envelopes))
+	stored := make([]*domain.Envelope, 0, len(envelopes))
+	for _, envelope := range envelopes {
+		deviceID, ok := byAlias[envelope.DeviceID]
+		if !ok || sealed[deviceID] {
+			return nil, ErrEnvelopeMismatch
+		}
+		if len(envelope.Payload) == 0 || len(envelope.Payload) > maxEnvelopeSize {
+			return nil, ErrEnvelopeMismatch
+		}
+		sealed[deviceID] = true
+		stored = append(stored, &domain.Envelope{DeviceID: deviceID, Payload: envelope.Payload})
+	}
+	for deviceID := range devices {
+		if !sealed[deviceID] && deviceID != senderDeviceID {
+			return nil, ErrEnvelopeMismatch
+		}
+	}
+
+	message := &domain.Message{
+		ID:         uuid.New(),
+		ChatID:     chatID,
+		SenderID:   senderID,
+		Encrypted:  true,
+		Ciphertext: ciphertext,
+		Envelopes:  stored,
+		ExpiresAt:  chat.MessageExpiry(time.Now()),
+	}
+	err = s.chatRepo.CreateMessage(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each side only gets the envelopes sealed for its own devices
+	delivered := *message
+	delivered.Envelopes = s.envelopesOf(chatID, stored, devices, recipientID)
+	s.publishMessage(ctx, chat, &delivered, domain.EventChatMessage)
+
+	message.Envelopes = s.envelopesOf(chatID, stored, devices, senderID)
+	return message, nil
+}
+
+// envelopesOf returns the envelopes sealed for userID's devices, addressed
+// by their alias in the chat.
+func (s *chatService) envelopesOf(chatID uuid.UUID, envelopes []*domain.Envelope, devices map[uuid.UUID]uuid.UUID, userID uuid.UUID) []*domain.Envelope {
+	var own []*domain.Envelope
+	for _, envelope := range envelopes {
+		if devices[envelope.DeviceID] == userID {
+			aliased := *envelope
+			aliased.DeviceID = s.aliasSvc.DeviceAlias(chatID, envelope.DeviceID)
+			own = append(own, &aliased)
+		}
+	}
+	return own
+}
+
+// deleteBlobs cleans up the files of attachments that were never stored.
+func (s *chatService) deleteBlobs(ctx context.Context, attachments []*domain.Attachment) {
+	for _, attachment := range attachments {
+		if err := s.config.Blobs.Delete(ctx, attachment.StorageKey); err != nil {
+			log.Printf("Error deleting attachment %s: %v", attachment.ID, err)
+		}
+	}
+}
+
+// OpenAttachment opens an attachment's file for a participant of its chat.
+// Attachments of unsent, hidden or expired messages are gone for
+// participants, as are those from before a participant deleted the chat.
+func (s *chatService) OpenAttachment(ctx context.Context, chatID, attachmentID, userID uuid.UUID) (*domain.Attachment, io.ReadCloser, error) {
+	chat, err := s.GetChat(ctx, chatID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !chat.IsVisibleTo(userID) {
+		return nil, nil, ErrChatNotFound
+	}
+	if s.config.Blobs == nil {
+		return nil, nil, ErrAttachmentNotFound
+	}
+
+	attachment, err := s.chatRepo.GetAttachment(ctx, attachmentID)
+	if err != nil || attachment.ChatID != chat.ID {
+		if err == nil || err == sql.ErrNoRows {
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, err
+	}
+	message, err := s.chatRepo.GetMessageByID(ctx, attachment.MessageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if message.IsTombstone() || message.IsExpired(time.Now()) || !chat.ShowsMessage(userID, message) {
+		return nil, nil, ErrAttachmentNotFound
+	}
+
+	file, err := s.config.Blobs.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		if err == storage.ErrBlobNotFound {
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, err
+	}
+	return attachment, file, nil
+}
+
+// loadAttachments fills in the attachments of messages that are not
+// tombstones.
+func (s *chatService) loadAttachments(ctx context.Context, messages []*domain.Message) error {
+	byID := make(map[uuid.UUID]*domain.Message, len(messages))
+	ids := make([]uuid.UUID, 0, len(messages))
+	for _, message := range messages {
+		if !message.IsTombstone() {
+			byID[message.ID] = message
+			ids = append(ids, message.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	attachments, err := s.chatRepo.GetAttachments(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		if message, ok := byID[attachment.MessageID]; ok {
+			message.Attachments = append(message.Attachments, attachment)
+		}
+	}
+	return nil
+}
+
+// EditMessage replaces the content of one of userID's messages while the
+// edit window is open and tells the recipient. The earlier version is kept
+// for moderators. Messages sent in the clear cannot be edited any more once
+// the chat is encrypted.
+func (s *chatService) EditMessage(ctx context.Context, chatID, messageID, userID uuid.UUID, content string) (*domain.Message, error) {
+	if content == "" {
+		return nil, ErrMessageEmpty
+	}
+
+	chat, err := s.writableChat(ctx, chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	message, err := s.ownMessage(ctx, chat, messageID, userID, ActionEditMessage)
+	if err != nil {
+		return nil, err
+	}
+	if message.Encrypted {
+		return nil, ErrMessageEncrypted
+	}
+	if time.Since(message.CreatedAt) > s.config.EditWindow {
+		return nil, ErrEditWindowOver
+	}
+	if err := s.refusePlaintext(ctx, chat); err != nil {
+		return nil, err
+	}
+
+	message.Content = content
+	if err := s.chatRepo.EditMessage(ctx, message); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMessageDeleted
+		}
+		return nil, err
+	}
+	if err := s.loadAttachments(ctx, []*domain.Message{message}); err != nil {
+		return nil, err
+	}
+
+	s.publishMessage(ctx, chat, message, domain.EventMessageEdited)
+	return message, nil
+}
+
+// UnsendMessage removes one of userID's messages for both sides, leaving a
+// tombstone in the history, and tells the recipient. Unlike editing it is
+// allowed across a block, since it only takes content away.
+func (s *chatService) UnsendMessage(ctx context.Context, chatID, messageID, userID uuid.UUID) (*domain.Message, error) {
+	chat, err := s.GetChat(ctx, chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !chat.IsVisibleTo(userID) {
+		return nil, ErrChatNotFound
+	}
+	message, err := s.ownMessage(ctx, chat, messageID, userID, ActionUnsendMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.chatRepo.UnsendMessage(ctx, message); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMessageDeleted
+		}
+		return nil, err
+	}
+	message.Content = ""
+	message.Encrypted = false
+	message.Ciphertext = nil
+
+	if s.publisher != nil && chat.IsVisibleTo(chat.Counterpart(userID)) {
+		s.publisher.BroadcastToUser(chat.Counterpart(userID), domain.Event{
+			Type:    domain.EventMessageDeleted,
+			Payload: domain.ChatActivity{ChatID: chat.ID, MessageID: &message.ID},
+			From:    userID,
+		})
+	}
+	return message, nil
+}
+
+// ownMessage loads a message of chat that userID may change with action.
+// Tombstones cannot be changed any more.
+func (s *chatService) ownMessage(ctx context.Context, chat *domain.Chat, messageID, userID uuid.UUID, action Action) (*domain.Message, error) {
+	message, err := s.chatRepo.GetMessageByID(ctx, messageID)
+	if err != nil || message.ChatID != chat.ID || message.IsExpired(time.Now()) || !chat.ShowsMessage(userID, message) {
+		if err == nil || err == sql.ErrNoRows {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	if !can(userID, action, message) {
+		return nil, ErrUnauthorized
+	}
+	if message.IsTombstone() {
+		return nil, ErrMessageDeleted
+	}
+	return message, nil
+}
+
+// SendTyping tells the other participant that userID is typing. It is
+// refused wherever sending a message would be.
+func (s *chatService) SendTyping(ctx context.Context, chatID, userID uuid.UUID) error {
+	chat, err := s.writableChat(ctx, chatID, userID)
+	if err != nil {
+		return err
+	}
+
+	if s.publisher != nil {
+		s.publisher.BroadcastToChat(chat.Counterpart(userID), chat.ID, domain.Event{
+			Type:    domain.EventTyping,
+			Payload: domain.ChatActivity{ChatID: chat.ID},
+			From:    userID,
+		})
+	}
+	return nil
+}
+
+// MarkRead records that userID has read the chat up to messageID, or up to
+// its latest message when messageID is nil, and tells the other
+// participant's devices. Reading never moves backwards, so marking an older
+// message again is a no-op.
+func (s *chatService) MarkRead(ctx context.Context, chatID, userID, messageID uuid.UUID) error {
+	chat, err := s.GetChat(ctx, chatID, userID)
+	if err != nil {
+		return err
+	}
+	if !chat.IsVisibleTo(userID) {
+		return ErrChatNotFound
+	}
+
+	var message *domain.Message
+	if messageID == uuid.Nil {
+		message, err = s.chatRepo.GetLastMessage(ctx, chat.ID, userID)
+	} else {
+		message, err = s.chatRepo.GetMessageByID(ctx, messageID)
+	}
+	if err != nil || message.ChatID != chat.ID || message.IsExpired(time.Now()) || !chat.ShowsMessage(userID, message) {
+		if err == nil || err == sql.ErrNoRows {
+			return ErrMessageNotFound
+		}
+		return err
+	}
+
+	moved, err := s.chatRepo.MarkRead(ctx, chat.ID, userID, message.ID)
+	if err != nil {
+		return err
+	}
+
+	if moved && s.publisher != nil {
+		s.publisher.BroadcastToUser(chat.Counterpart(userID), domain.Event{
+			Type:    domain.EventMessageRead,
+			Payload: domain.ChatActivity{ChatID: chat.ID, MessageID: &message.ID},
+			From:    userID,
+		})
+	}
+	return nil
+}
+
+// SetTimer sets the chat's disappearing-messages timer to seconds, one of
+// domain.MessageTTLs or zero to turn it off, and tells the other
+// participant. Either participant may change it wherever they could send a
+// message; it applies to messages sent from then on.
+func (s *chatService) SetTimer(ctx context.Context, chatID, userID uuid.UUID, seconds int) (*domain.Chat, error) {
+	if !domain.IsValidMessageTTL(seconds) {
+		return nil, ErrInvalidTimer
+	}
+
+	chat, err := s.writableChat(ctx, chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if chat.MessageTTL == seconds {
+		return chat, nil
+	}
+
+	if err := s.chatRepo.SetMessageTTL(ctx, chat.ID, seconds); err != nil {
+		return nil, err
+	}
+	chat.MessageTTL = seconds
+
+	if s.publisher != nil && chat.IsVisibleTo(chat.Counterpart(userID)) {
+		s.publisher.BroadcastToUser(chat.Counterpart(userID), domain.Event{
+			Type:    domain.EventChatTimer,
+			Payload: domain.ChatTimer{ChatID: chat.ID, MessageTTL: seconds},
+			From:    userID,
+		})
+	}
+	return chat, nil
+}
+
+// PurgeMessages hard-deletes chats both participants deleted while they were
+// in dispute, messages whose disappearing timer has run out and messages
+// of tasks that were completed or cancelled longer ago than the retention
+// period, in batches, along with their attachment files.
+// Messages of a chat whose claim is in dispute are kept as evidence until
+// the dispute is resolved. Participants stop seeing expired messages as
+// soon as they expire; purging only reclaims the space.
+func (s *chatService) PurgeMessages(ctx context.Context) (*PurgeResult, error) {
+	result := &PurgeResult{}
+
+	var err error
+	result.Chats, err = s.purgeBatches(ctx, result, func() (int, []string, error) {
+		return s.chatRepo.PurgeDeletedChats(ctx, s.config.PurgeBatchSize)
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Expired, err = s.purgeBatches(ctx, result, func() (int, []string, error) {
+		return s.chatRepo.PurgeExpiredMessages(ctx, s.config.PurgeBatchSize)
+	})
+	if err != nil {
+		return result, err
+	}
+
+	endedBefore := time.Now().Add(-s.config.Retention)
+	result.Retention, err = s.purgeBatches(ctx, result, func() (int, []string, error) {
+		return s.chatRepo.PurgeEndedTaskMessages(ctx, endedBefore, s.config.PurgeBatchSize)
+	})
+	return result, err
+}
+
+// purgeBatches runs purge until it comes back with less than a full batch
+// and returns how many messages it deleted in all.
+func (s *chatService) purgeBatches(ctx context.Context, result *PurgeResult, purge func() (int, []string, error)) (int, error) {
+	total := 0
+	for {
+		n, keys, err := purge()
+		if err != nil {
+			return total, err
+		}
+		total += n
+		result.Attachments += len(keys)
+		s.deleteFiles(ctx, keys)
+		if n < s.config.PurgeBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// deleteFiles removes attachment files whose rows are already gone. A file
+// that cannot be removed is only logged.
+func (s *chatService) deleteFiles(ctx context.Context, keys []string) {
+	if s.config.Blobs == nil {
+		return
+	}
+	for _, key := range keys {
+		if err := s.config.Blobs.Delete(ctx, key); err != nil {
+			log.Printf("Error deleting attachment file %s: %v", key, err)
+		}
+	}
+}
+
+// UnreadCount is the number of unread messages across all of userID's
+// chats, for the app icon badge.
+func (s *chatService) UnreadCount(ctx context.Context, userID uuid.UUID) (int, error) {
+	return s.chatRepo.CountUnread(ctx, userID)
+}
+
+// writableChat returns the chat if userID may post to it: they take part,
+// neither side has deleted it and there is no block between them.
+func (s *chatService) writableChat(ctx context.Context, chatID, userID uuid.UUID) (*domain.Chat, error) {
+	chat, err := s.chatRepo.GetByID(ctx, chatID)
+	if err != nil {
+		return nil, notFoundOr(err, ErrChatNotFound)
+	}
+
+	if !can(userID, ActionSendMessage, chat) {
+		return nil, ErrUnauthorized
+	}
+
+	if !chat.IsVisibleTo(userID) {
+		return nil, ErrChatDeleted
+	}
+
+	blocked, err := s.blockRepo.IsBlocked(ctx, userID, chat.Counterpart(userID))
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrChatUnavailable
+	}
+	return chat, nil
+}
+
+// publishMessage pushes a new or edited message to the recipient's connected
+// devices, presented as they would fetch it. The message is already stored,
+// so a failed push is only logged; the recipient still gets it from history.
+func (s *chatService) publishMessage(ctx context.Context, chat *domain.Chat, message *domain.Message, eventType domain.EventType) {
+	if s.publisher == nil {
+		return
+	}
+
+	recipientID := chat.Counterpart(message.SenderID)
+	if !chat.IsVisibleTo(recipientID) {
+		return
+	}
+
+	views, err := s.aliasSvc.PresentMessages(ctx, chat, []*domain.Message{message}, recipientID)
+	if err != nil {
+		log.Printf("Error presenting message %s for delivery: %v", message.ID, err)
+		return
+	}
+
+	s.publisher.BroadcastToUser(recipientID, domain.Event{
+		Type:    eventType,
+		Payload: views[0],
+		From:    message.SenderID,
+	})
+}
+
+// GetMessages returns a page of a chat's history to one of its participants,
+// starting after they last deleted it. While they have it deleted there is
+// nothing to show.
+func (s *chatService) GetMessages(ctx context.Context, chatID, userID uuid.UUID, limit, offset int) ([]*domain.Message, error) {
+	chat, err := s.GetChat(ctx, chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !chat.IsVisibleTo(userID) {
+		return nil, ErrChatNotFound
+	}
+
 	if limit <= 0 || limit > 100 {
 		limit = 50
 	}
-	return s.chatRepo.GetMessagesByChatID(ctx, chatID, limit, offset)
+	messages, err := s.chatRepo.GetMessagesByChatID(ctx, chatID, chat.HistoryStart(userID), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadAttachments(ctx, messages); err != nil {
+		return nil, err
+	}
+	if err := s.loadEnvelopes(ctx, messages, userID); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// loadEnvelopes fills in the envelopes sealed for userID's devices on the
+// encrypted messages, addressed by the devices' aliases.
+func (s *chatService) loadEnvelopes(ctx context.Context, messages []*domain.Message, userID uuid.UUID) error {
+	byID := make(map[uuid.UUID]*domain.Message, len(messages))
+	ids := make([]uuid.UUID, 0, len(messages))
+	for _, message := range messages {
+		if message.Ciphertext != nil {
+			byID[message.ID] = message
+			ids = append(ids, message.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	envelopes, err := s.chatRepo.GetEnvelopes(ctx, ids, userID)
+	if err != nil {
+		return err
+	}
+	for _, envelope := range envelopes {
+		if message, ok := byID[envelope.MessageID]; ok {
+			envelope.DeviceID = s.aliasSvc.DeviceAlias(message.ChatID, envelope.DeviceID)
+			message.Envelopes = append(message.Envelopes, envelope)
+		}
+	}
+	return nil
 }
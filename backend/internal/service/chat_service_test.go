@@ -0,0 +1,370 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/storage"
+)
+
+// mockInboxRepo serves an inbox already sorted most recently active first.
+type mockInboxRepo struct {
+	mockChatRepoForClaimSvc
+	inbox []*domain.Chat
+}
+
+func (m *mockInboxRepo) ListInbox(ctx context.Context, userID uuid.UUID, after *domain.InboxCursor, limit int) ([]*domain.Chat, error) {
+	var page []*domain.Chat
+	for _, chat := range m.inbox {
+		if after != nil && !chat.ActiveAt.Before(after.ActiveAt) {
+			continue
+		}
+		if len(page) == limit {
+			break
+		}
+		page = append(page, chat)
+	}
+	return page, nil
+}
+
+func TestInboxPagesWithCursor(t *testing.T) {
+	chatRepo := &mockInboxRepo{}
+	userID := uuid.New()
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		chatRepo.inbox = append(chatRepo.inbox, &domain.Chat{ID: uuid.New(), ParticipantID: userID, OtherParticipantID: uuid.New(), ActiveAt: now.Add(-time.Duration(i) * time.Minute)})
+	}
+	service := NewChatService(chatRepo, nil, nil, nil, nil, nil, nil, ChatConfig{})
+	ctx := context.Background()
+
+	first, cursor, err := service.GetInbox(ctx, userID, "", 2)
+	require.NoError(t, err)
+	assert.Equal(t, chatRepo.inbox[:2], first)
+	assert.NotEmpty(t, cursor)
+
+	second, cursor, err := service.GetInbox(ctx, userID, cursor, 2)
+	require.NoError(t, err)
+	assert.Equal(t, chatRepo.inbox[2:4], second)
+
+	// The last page says so with an empty cursor
+	last, cursor, err := service.GetInbox(ctx, userID, cursor, 2)
+	require.NoError(t, err)
+	assert.Equal(t, chatRepo.inbox[4:], last)
+	assert.Empty(t, cursor)
+
+	_, _, err = service.GetInbox(ctx, userID, "not-a-cursor", 2)
+	assert.Equal(t, ErrInvalidCursor, err)
+}
+
+// mockPurgeRepo purges the messages it is given in batches of the limit
+// asked for, each message with one attachment file.
+type mockPurgeRepo struct {
+	mockChatRepoForClaimSvc
+	expired     []string
+	ended       []string
+	endedBefore time.Time
+	batches     int
+}
+
+func (m *mockPurgeRepo) PurgeExpiredMessages(ctx context.Context, limit int) (int, []string, error) {
+	return m.take(&m.expired, limit)
+}
+
+func (m *mockPurgeRepo) PurgeEndedTaskMessages(ctx context.Context, endedBefore time.Time, limit int) (int, []string, error) {
+	m.endedBefore = endedBefore
+	return m.take(&m.ended, limit)
+}
+
+func (m *mockPurgeRepo) take(keys *[]string, limit int) (int, []string, error) {
+	m.batches++
+	n := len(*keys)
+	if n > limit {
+		n = limit
+	}
+	batch := (*keys)[:n]
+	*keys = (*keys)[n:]
+	return n, batch, nil
+}
+
+func TestPurgeMessagesDeletesInBatches(t *testing.T) {
+	blobs, err := storage.NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var keys []string
+	for i := 0; i < 5; i++ {
+		key := uuid.NewString()
+		require.NoError(t, blobs.Put(ctx, key, strings.NewReader("file")))
+		keys = append(keys, key)
+	}
+	chatRepo := &mockPurgeRepo{expired: append([]string(nil), keys...)}
+	chatRepo.ended = []string{uuid.NewString(), uuid.NewString()}
+	service := NewChatService(chatRepo, nil, nil, nil, nil, nil, nil, ChatConfig{
+		Blobs:          blobs,
+		Retention:      30 * 24 * time.Hour,
+		PurgeBatchSize: 2,
+	})
+
+	result, err := service.PurgeMessages(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &PurgeResult{Expired: 5, Retention: 2, Attachments: 7}, result)
+	assert.WithinDuration(t, time.Now().Add(-30*24*time.Hour), chatRepo.endedBefore, time.Minute)
+
+	// Three batches of expired messages, then a full one and an empty one
+	assert.Equal(t, 5, chatRepo.batches)
+	for _, key := range keys {
+		_, err := blobs.Open(ctx, key)
+		assert.Equal(t, storage.ErrBlobNotFound, err)
+	}
+
+	// Nothing left to do
+	result, err = service.PurgeMessages(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &PurgeResult{}, result)
+}
+
+// mockDeletionRepo keeps one task's chats and their messages, deleting them
+// per side and purging a chat once both sides have, unless disputed.
+type mockDeletionRepo struct {
+	mockChatRepoForClaimSvc
+	messages map[uuid.UUID][]*domain.Message
+	disputed bool
+}
+
+func newMockDeletionRepo() *mockDeletionRepo {
+	return &mockDeletionRepo{
+		mockChatRepoForClaimSvc: mockChatRepoForClaimSvc{chats: make(map[uuid.UUID]*domain.Chat)},
+		messages:                make(map[uuid.UUID][]*domain.Message),
+	}
+}
+
+func (m *mockDeletionRepo) GetOrCreate(ctx context.Context, taskID, participantID, otherParticipantID uuid.UUID) (*domain.Chat, error) {
+	for _, chat := range m.chats {
+		if chat.TaskID != taskID || chat.Counterpart(participantID) != otherParticipantID {
+			continue
+		}
+		if chat.ParticipantID == participantID {
+			chat.DeletedByParticipant = false
+		} else {
+			chat.DeletedByOther = false
+		}
+		return chat, nil
+	}
+	return m.mockChatRepoForClaimSvc.GetOrCreate(ctx, taskID, participantID, otherParticipantID)
+}
+
+func (m *mockDeletionRepo) DeleteForUser(ctx context.Context, chatID, userID uuid.UUID) (bool, error) {
+	chat := m.chats[chatID]
+	now := time.Now()
+	if chat.ParticipantID == userID {
+		chat.DeletedByParticipant, chat.ParticipantClearedAt = true, &now
+	} else {
+		chat.DeletedByOther, chat.OtherClearedAt = true, &now
+	}
+	return chat.DeletedByParticipant && chat.DeletedByOther, nil
+}
+
+func (m *mockDeletionRepo) PurgeChat(ctx context.Context, chatID uuid.UUID) (bool, []string, error) {
+	chat, ok := m.chats[chatID]
+	if !ok || m.disputed || !chat.DeletedByParticipant || !chat.DeletedByOther {
+		return false, nil, nil
+	}
+	delete(m.chats, chatID)
+	delete(m.messages, chatID)
+	return true, nil, nil
+}
+
+func (m *mockDeletionRepo) PurgeDeletedChats(ctx context.Context, limit int) (int, []string, error) {
+	purged := 0
+	for chatID := range m.chats {
+		if ok, _, _ := m.PurgeChat(ctx, chatID); ok {
+			purged++
+		}
+	}
+	return purged, nil, nil
+}
+
+func (m *mockDeletionRepo) CreateMessage(ctx context.Context, message *domain.Message) error {
+	message.CreatedAt = time.Now()
+	m.messages[message.ChatID] = append(m.messages[message.ChatID], message)
+	return nil
+}
+
+func (m *mockDeletionRepo) GetMessagesByChatID(ctx context.Context, chatID uuid.UUID, since *time.Time, limit, offset int) ([]*domain.Message, error) {
+	var messages []*domain.Message
+	for _, message := range m.messages[chatID] {
+		if since == nil || message.CreatedAt.After(*since) {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
+
+func (m *mockDeletionRepo) GetMessageByID(ctx context.Context, id uuid.UUID) (*domain.Message, error) {
+	for _, messages := range m.messages {
+		for _, message := range messages {
+			if message.ID == id {
+				return message, nil
+			}
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+type deletionFixture struct {
+	service   ChatService
+	chatRepo  *mockDeletionRepo
+	taskID    uuid.UUID
+	claimID   uuid.UUID
+	ownerID   uuid.UUID
+	claimerID uuid.UUID
+}
+
+func newDeletionFixture() *deletionFixture {
+	f := &deletionFixture{
+		chatRepo:  newMockDeletionRepo(),
+		taskID:    uuid.New(),
+		claimID:   uuid.New(),
+		ownerID:   uuid.New(),
+		claimerID: uuid.New(),
+	}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: map[uuid.UUID]*domain.Task{
+		f.taskID: {ID: f.taskID, OwnerID: f.ownerID, Status: domain.TaskStatusClaimed},
+	}}
+	claimRepo := &mockClaimRepoForClaimSvc{claims: map[uuid.UUID]*domain.Claim{
+		f.claimID: {ID: f.claimID, TaskID: f.taskID, ClaimerID: f.claimerID, Status: domain.ClaimStatusPending},
+	}}
+	f.service = NewChatService(f.chatRepo, taskRepo, claimRepo, newMockBlockRepo(), nil, nil, nil, ChatConfig{})
+	return f
+}
+
+func (f *deletionFixture) open(t *testing.T, userID uuid.UUID) *domain.Chat {
+	chat, err := f.service.GetOrCreateChat(context.Background(), f.taskID, userID, f.claimID)
+	require.NoError(t, err)
+	return chat
+}
+
+func (f *deletionFixture) send(t *testing.T, chatID, senderID uuid.UUID, content string) *domain.Message {
+	message, err := f.service.SendMessage(context.Background(), chatID, senderID, content, nil)
+	require.NoError(t, err)
+	return message
+}
+
+func contents(messages []*domain.Message) []string {
+	var result []string
+	for _, message := range messages {
+		result = append(result, message.Content)
+	}
+	return result
+}
+
+func TestDeletingAChatHidesItFromThatSideOnly(t *testing.T) {
+	f := newDeletionFixture()
+	ctx := context.Background()
+	chat := f.open(t, f.claimerID)
+	f.send(t, chat.ID, f.claimerID, "hi")
+	f.send(t, chat.ID, f.ownerID, "hello")
+
+	require.NoError(t, f.service.DeleteChat(ctx, chat.ID, f.claimerID))
+
+	_, err := f.service.GetMessages(ctx, chat.ID, f.claimerID, 0, 0)
+	assert.Equal(t, ErrChatNotFound, err)
+	_, err = f.service.SendMessage(ctx, chat.ID, f.claimerID, "still there?", nil)
+	assert.Equal(t, ErrChatDeleted, err)
+
+	// The owner keeps the whole history and can still write
+	messages, err := f.service.GetMessages(ctx, chat.ID, f.ownerID, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hi", "hello"}, contents(messages))
+	f.send(t, chat.ID, f.ownerID, "are you still on it?")
+}
+
+func TestRejoiningAChatOnlyShowsHistoryAfterDeleting(t *testing.T) {
+	f := newDeletionFixture()
+	ctx := context.Background()
+	chat := f.open(t, f.claimerID)
+	old := f.send(t, chat.ID, f.claimerID, "before")
+	require.NoError(t, f.service.DeleteChat(ctx, chat.ID, f.claimerID))
+	f.send(t, chat.ID, f.ownerID, "while hidden")
+
+	// Opening the chat again, from either side, is the same thread
+	rejoined := f.open(t, f.claimerID)
+	assert.Equal(t, chat.ID, rejoined.ID)
+	assert.Equal(t, chat.ID, f.open(t, f.ownerID).ID)
+	f.send(t, chat.ID, f.claimerID, "after")
+
+	messages, err := f.service.GetMessages(ctx, chat.ID, f.claimerID, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"while hidden", "after"}, contents(messages))
+	_, err = f.service.UnsendMessage(ctx, chat.ID, old.ID, f.claimerID)
+	assert.Equal(t, ErrMessageNotFound, err)
+
+	messages, err = f.service.GetMessages(ctx, chat.ID, f.ownerID, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"before", "while hidden", "after"}, contents(messages))
+}
+
+func TestChatIsPurgedOnceBothSidesDelete(t *testing.T) {
+	f := newDeletionFixture()
+	ctx := context.Background()
+	chat := f.open(t, f.claimerID)
+	f.send(t, chat.ID, f.claimerID, "hi")
+
+	require.NoError(t, f.service.DeleteChat(ctx, chat.ID, f.claimerID))
+	require.NoError(t, f.service.DeleteChat(ctx, chat.ID, f.ownerID))
+	assert.Empty(t, f.chatRepo.chats)
+	assert.Empty(t, f.chatRepo.messages)
+
+	// Nothing is left to rejoin, so opening it again starts over
+	reopened := f.open(t, f.ownerID)
+	assert.NotEqual(t, chat.ID, reopened.ID)
+	messages, err := f.service.GetMessages(ctx, reopened.ID, f.ownerID, 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+
+	// A disputed chat is kept as evidence and purged by the background job
+	// once the dispute is resolved
+	f.chatRepo.disputed = true
+	f.send(t, reopened.ID, f.ownerID, "see attached")
+	require.NoError(t, f.service.DeleteChat(ctx, reopened.ID, f.ownerID))
+	require.NoError(t, f.service.DeleteChat(ctx, reopened.ID, f.claimerID))
+	assert.Len(t, f.chatRepo.chats, 1)
+	assert.Len(t, f.chatRepo.messages[reopened.ID], 1)
+
+	f.chatRepo.disputed = false
+	result, err := f.service.PurgeMessages(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Chats)
+	assert.Empty(t, f.chatRepo.chats)
+}
+
+// mockFailingChatRepo fails every chat lookup with err.
+type mockFailingChatRepo struct {
+	mockChatRepoForClaimSvc
+	err error
+}
+
+func (m *mockFailingChatRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Chat, error) {
+	return nil, m.err
+}
+
+func TestWritingToAChatOnlyReportsMissingChatsAsNotFound(t *testing.T) {
+	ctx := context.Background()
+	outage := errors.New("connection refused")
+	newService := func(err error) ChatService {
+		return NewChatService(&mockFailingChatRepo{err: err}, &mockTaskRepoForClaimSvc{}, &mockClaimRepoForClaimSvc{}, newMockBlockRepo(), nil, nil, nil, ChatConfig{})
+	}
+
+	_, err := newService(sql.ErrNoRows).SendMessage(ctx, uuid.New(), uuid.New(), "hi", nil)
+	assert.Equal(t, ErrChatNotFound, err)
+	_, err = newService(outage).SendMessage(ctx, uuid.New(), uuid.New(), "hi", nil)
+	assert.Equal(t, outage, err)
+}
@@ -15,23 +15,74 @@ var (
 	ErrAlreadyClaimed   = errors.New("task already claimed by this user")
 	ErrClaimLimitReached = errors.New("claim limit reached")
 	ErrInvalidCompletion = errors.New("invalid completion submission")
+	ErrInvalidClaimState = errors.New("claim cannot be changed in its current state")
+	ErrDisputeReasonRequired = errors.New("dispute reason is required")
+	ErrInvalidDecision   = errors.New("invalid arbitration decision")
+	ErrCannotClaimOwnTask = errors.New("cannot claim your own task")
 )
 
+// ClaimGate vets a claimer before a claim is created. It runs after the
+// task and block checks, so it only sees claims that would otherwise go
+// through.
+type ClaimGate interface {
+	CheckClaim(ctx context.Context, claimerID uuid.UUID, task *domain.Task) error
+}
+
+// ClaimGates runs several gates in order and stops at the first refusal.
+type ClaimGates []ClaimGate
+
+func (g ClaimGates) CheckClaim(ctx context.Context, claimerID uuid.UUID, task *domain.Task) error {
+	for _, gate := range g {
+		if err := gate.CheckClaim(ctx, claimerID, task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Arbiter is whoever decides a dispute: staff through the admin API, or a
+// user whose trust tier carries arbitration duty. Only one ID is set.
+type Arbiter struct {
+	AdminID *uuid.UUID
+	UserID  *uuid.UUID
+}
+
+// ApprovalReviewer looks at a claim before its approval is paid out and
+// reports whether it looks like self-dealing. A flagged approval is held,
+// unpaid and without reputation for either side, until it is settled and
+// ReviewFlag marks the flag reviewed.
+type ApprovalReviewer interface {
+	ReviewApproval(ctx context.Context, claim *domain.Claim, task *domain.Task) (bool, error)
+	OpenFlag(ctx context.Context, claimID uuid.UUID) (*domain.FraudFlag, error)
+	ReviewFlag(ctx context.Context, claimID uuid.UUID, status domain.FraudFlagStatus, reviewer string) error
+}
+
 type ClaimService interface {
 	ClaimTask(ctx context.Context, taskID, claimerID uuid.UUID) (*domain.Claim, error)
-	GetClaim(ctx context.Context, id uuid.UUID) (*domain.Claim, error)
-	GetClaimsByTaskID(ctx context.Context, taskID uuid.UUID) ([]*domain.Claim, error)
+	GetClaim(ctx context.Context, id, userID uuid.UUID) (*domain.Claim, error)
+	GetClaimsByTaskID(ctx context.Context, taskID, userID uuid.UUID) ([]*domain.Claim, error)
 	SubmitCompletion(ctx context.Context, claimID, userID uuid.UUID, text, imageURL string) (*domain.Claim, error)
+	AcceptApplication(ctx context.Context, claimID, ownerID uuid.UUID) (*domain.Claim, error)
 	ApproveClaim(ctx context.Context, claimID, ownerID uuid.UUID) error
 	RejectClaim(ctx context.Context, claimID, ownerID uuid.UUID) error
+	WithdrawClaim(ctx context.Context, claimID, claimerID uuid.UUID) error
+	DisputeClaim(ctx context.Context, claimID, claimerID uuid.UUID, reason string) (*domain.Claim, error)
+	ResolveDispute(ctx context.Context, claimID uuid.UUID, arbiter Arbiter, decision domain.ArbitrationDecision, reason string) error
+	SettleFlaggedClaim(ctx context.Context, claimID uuid.UUID, status domain.FraudFlagStatus, reviewer string) error
+	ExpireAbandonedClaims(ctx context.Context) error
+	CancelClaimsByClaimer(ctx context.Context, claimerID uuid.UUID) error
 }
 
 type claimService struct {
-	claimRepo repository.ClaimRepository
-	taskRepo  repository.TaskRepository
-	chatRepo  repository.ChatRepository
-	escrowSvc EscrowService
-	userRepo  repository.UserRepository
+	claimRepo     repository.ClaimRepository
+	taskRepo      repository.TaskRepository
+	chatRepo      repository.ChatRepository
+	escrowSvc     EscrowService
+	userRepo      repository.UserRepository
+	reputationSvc ReputationService
+	blockRepo     repository.BlockRepository
+	gate          ClaimGate
+	reviewer      ApprovalReviewer
 }
 
 func NewClaimService(
@@ -40,13 +91,21 @@ func NewClaimService(
 	chatRepo repository.ChatRepository,
 	escrowSvc EscrowService,
 	userRepo repository.UserRepository,
+	reputationSvc ReputationService,
+	blockRepo repository.BlockRepository,
+	gate ClaimGate,
+	reviewer ApprovalReviewer,
 ) ClaimService {
 	return &claimService{
-		claimRepo: claimRepo,
-		taskRepo:  taskRepo,
-		chatRepo:  chatRepo,
-		escrowSvc: escrowSvc,
-		userRepo:  userRepo,
+		claimRepo:     claimRepo,
+		taskRepo:      taskRepo,
+		chatRepo:      chatRepo,
+		escrowSvc:     escrowSvc,
+		userRepo:      userRepo,
+		reputationSvc: reputationSvc,
+		blockRepo:     blockRepo,
+		gate:          gate,
+		reviewer:      reviewer,
 	}
 }
 
@@ -65,6 +124,19 @@ func (s *claimService) ClaimTask(ctx context.Context, taskID, claimerID uuid.UUI
 		return nil, ErrTaskNotClaimable
 	}
 
+	if !can(claimerID, ActionClaimTask, task) {
+		return nil, ErrCannotClaimOwnTask
+	}
+
+	// Blocked pairs get the same answer as an unclaimable task
+	blocked, err := s.blockRepo.IsBlocked(ctx, claimerID, task.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrTaskNotClaimable
+	}
+
 	// Check if user already claimed
 	existing, err := s.claimRepo.GetByTaskIDAndClaimerID(ctx, taskID, claimerID)
 	if err == nil {
@@ -74,6 +146,12 @@ func (s *claimService) ClaimTask(ctx context.Context, taskID, claimerID uuid.UUI
 		return nil, err
 	}
 
+	if s.gate != nil {
+		if err := s.gate.CheckClaim(ctx, claimerID, task); err != nil {
+			return nil, err
+		}
+	}
+
 	// Check claim count
 	count, err := s.claimRepo.CountByTaskID(ctx, taskID)
 	if err != nil {
@@ -83,13 +161,16 @@ func (s *claimService) ClaimTask(ctx context.Context, taskID, claimerID uuid.UUI
 		return nil, ErrClaimLimitReached
 	}
 
-	// Create claim
+	// Create claim; on application-mode tasks it waits for the owner
 	claim := &domain.Claim{
 		ID:        uuid.New(),
 		TaskID:    taskID,
 		ClaimerID: claimerID,
 		Status:    domain.ClaimStatusPending,
 	}
+	if task.ApplicationMode {
+		claim.Status = domain.ClaimStatusApplied
+	}
 
 	err = s.claimRepo.Create(ctx, claim)
 	if err != nil {
@@ -97,7 +178,7 @@ func (s *claimService) ClaimTask(ctx context.Context, taskID, claimerID uuid.UUI
 	}
 
 	// Update task status if first claim
-	if count == 0 {
+	if count == 0 && !task.ApplicationMode {
 		err = s.taskRepo.UpdateStatus(ctx, taskID, domain.TaskStatusClaimed)
 		if err != nil {
 			return nil, err
@@ -107,34 +188,80 @@ func (s *claimService) ClaimTask(ctx context.Context, taskID, claimerID uuid.UUI
 	return claim, nil
 }
 
-func (s *claimService) GetClaim(ctx context.Context, id uuid.UUID) (*domain.Claim, error) {
-	claim, err := s.claimRepo.GetByID(ctx, id)
+// GetClaim returns a claim to its claimer or the task owner.
+func (s *claimService) GetClaim(ctx context.Context, id, userID uuid.UUID) (*domain.Claim, error) {
+	claim, task, err := s.claimWithTask(ctx, id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, ErrClaimNotFound
-		}
 		return nil, err
 	}
+
+	if !can(userID, ActionViewClaim, ClaimResource{Claim: claim, Task: task}) {
+		return nil, ErrUnauthorized
+	}
+
+	claim.Redact()
 	return claim, nil
 }
 
-func (s *claimService) GetClaimsByTaskID(ctx context.Context, taskID uuid.UUID) ([]*domain.Claim, error) {
-	return s.claimRepo.GetByTaskID(ctx, taskID)
+// GetClaimsByTaskID returns the task's claims that userID may see: all of
+// them for the owner, only their own for a claimer.
+func (s *claimService) GetClaimsByTaskID(ctx context.Context, taskID, userID uuid.UUID) ([]*domain.Claim, error) {
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+
+	claims, err := s.claimRepo.GetByTaskID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make([]*domain.Claim, 0, len(claims))
+	for _, claim := range claims {
+		if !can(userID, ActionViewClaim, ClaimResource{Claim: claim, Task: task}) {
+			continue
+		}
+		claim.Redact()
+		visible = append(visible, claim)
+	}
+	return visible, nil
 }
 
-func (s *claimService) SubmitCompletion(ctx context.Context, claimID, userID uuid.UUID, text, imageURL string) (*domain.Claim, error) {
+// claimWithTask loads a claim and the task it belongs to.
+func (s *claimService) claimWithTask(ctx context.Context, claimID uuid.UUID) (*domain.Claim, *domain.Task, error) {
 	claim, err := s.claimRepo.GetByID(ctx, claimID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, ErrClaimNotFound
+			return nil, nil, ErrClaimNotFound
 		}
+		return nil, nil, err
+	}
+
+	task, err := s.taskRepo.GetByID(ctx, claim.TaskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return claim, task, nil
+}
+
+func (s *claimService) SubmitCompletion(ctx context.Context, claimID, userID uuid.UUID, text, imageURL string) (*domain.Claim, error) {
+	claim, task, err := s.claimWithTask(ctx, claimID)
+	if err != nil {
 		return nil, err
 	}
 
-	if claim.ClaimerID != userID {
+	if !can(userID, ActionSubmitClaim, ClaimResource{Claim: claim, Task: task}) {
 		return nil, ErrUnauthorized
 	}
 
+	// Applicants have nothing to hand in until the owner accepts them
+	if claim.Status == domain.ClaimStatusApplied {
+		return nil, ErrInvalidClaimState
+	}
+
 	if text == "" {
 		return nil, errors.New("completion text is required")
 	}
@@ -144,12 +271,6 @@ func (s *claimService) SubmitCompletion(ctx context.Context, claimID, userID uui
 		return nil, err
 	}
 
-	// Get task to find owner
-	task, err := s.taskRepo.GetByID(ctx, claim.TaskID)
-	if err != nil {
-		return nil, err
-	}
-
 	// Open/reopen chat (chat will be created/retrieved)
 	_, err = s.chatRepo.GetOrCreate(ctx, claim.TaskID, claim.ClaimerID, task.OwnerID)
 	if err != nil {
@@ -161,36 +282,135 @@ func (s *claimService) SubmitCompletion(ctx context.Context, claimID, userID uui
 	return s.claimRepo.GetByID(ctx, claimID)
 }
 
-func (s *claimService) ApproveClaim(ctx context.Context, claimID, ownerID uuid.UUID) error {
-	claim, err := s.claimRepo.GetByID(ctx, claimID)
+// AcceptApplication lets the owner take an applicant on. The claim then
+// counts towards MaxClaimants and proceeds like any other.
+func (s *claimService) AcceptApplication(ctx context.Context, claimID, ownerID uuid.UUID) (*domain.Claim, error) {
+	claim, task, err := s.claimWithTask(ctx, claimID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return ErrClaimNotFound
+		return nil, err
+	}
+
+	if !can(ownerID, ActionDecideClaim, ClaimResource{Claim: claim, Task: task}) {
+		return nil, ErrUnauthorized
+	}
+
+	if claim.Status != domain.ClaimStatusApplied {
+		return nil, ErrInvalidClaimState
+	}
+
+	if !task.CanBeClaimed() {
+		return nil, ErrTaskNotClaimable
+	}
+	blocked, err := s.blockRepo.IsBlocked(ctx, claim.ClaimerID, task.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrTaskNotClaimable
+	}
+
+	count, err := s.claimRepo.CountByTaskID(ctx, task.ID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= task.MaxClaimants {
+		return nil, ErrClaimLimitReached
+	}
+
+	err = s.claimRepo.UpdateStatus(ctx, claim.ID, domain.ClaimStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		err = s.taskRepo.UpdateStatus(ctx, task.ID, domain.TaskStatusClaimed)
+		if err != nil {
+			return nil, err
 		}
-		return err
 	}
 
-	task, err := s.taskRepo.GetByID(ctx, claim.TaskID)
+	return s.claimRepo.GetByID(ctx, claimID)
+}
+
+func (s *claimService) ApproveClaim(ctx context.Context, claimID, ownerID uuid.UUID) error {
+	claim, task, err := s.claimWithTask(ctx, claimID)
 	if err != nil {
 		return err
 	}
 
-	if task.OwnerID != ownerID {
+	if !can(ownerID, ActionDecideClaim, ClaimResource{Claim: claim, Task: task}) {
 		return ErrUnauthorized
 	}
 
+	if claim.Status != domain.ClaimStatusPending {
+		return ErrInvalidClaimState
+	}
+
 	if !claim.IsSubmitted() {
 		return errors.New("claim has not been submitted")
 	}
 
-	// Update claim status
-	err = s.claimRepo.UpdateStatus(ctx, claimID, domain.ClaimStatusApproved)
-	if err != nil {
+	flagged, err := s.approveClaim(ctx, claim, task)
+	if err != nil || flagged {
 		return err
 	}
+	return s.recordApproval(ctx, claim, task)
+}
+
+// approveClaim approves the claim once the reviewer has looked at it. A
+// clean approval is paid out right away; a flagged one holds the task, with
+// the reward still in escrow, until the flag is settled.
+func (s *claimService) approveClaim(ctx context.Context, claim *domain.Claim, task *domain.Task) (bool, error) {
+	flagged := false
+	if s.reviewer != nil {
+		var err error
+		flagged, err = s.reviewer.ReviewApproval(ctx, claim, task)
+		if err != nil {
+			return false, err
+		}
+	}
 
+	err := s.claimRepo.UpdateStatus(ctx, claim.ID, domain.ClaimStatusApproved)
+	if err != nil {
+		return false, err
+	}
+	if flagged {
+		return true, s.taskRepo.UpdateStatus(ctx, task.ID, domain.TaskStatusHeld)
+	}
+	return false, s.payOut(ctx, claim, task)
+}
+
+// recordApproval grants the reputation an approval earns. A rejection
+// overturned in arbitration also counts against the owner who made it.
+func (s *claimService) recordApproval(ctx context.Context, claim *domain.Claim, task *domain.Task) error {
+	type event struct {
+		userID uuid.UUID
+		role   domain.ReputationRole
+		kind   domain.ReputationEventKind
+	}
+	events := []event{{claim.ClaimerID, domain.ReputationRoleWorker, domain.ReputationClaimApproved}}
+	if claim.DisputedAt != nil {
+		events = append(events,
+			event{claim.ClaimerID, domain.ReputationRoleWorker, domain.ReputationRejectionOverturned},
+			event{task.OwnerID, domain.ReputationRolePoster, domain.ReputationUnjustifiedRejection},
+			event{task.OwnerID, domain.ReputationRolePoster, domain.ReputationDisputeLost},
+		)
+	} else {
+		events = append(events, event{task.OwnerID, domain.ReputationRolePoster, domain.ReputationTaskCompleted})
+	}
+
+	for _, e := range events {
+		err := s.recordReputation(ctx, e.userID, e.role, e.kind, claim, task)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// payOut pays the claimer out of escrow and closes the task.
+func (s *claimService) payOut(ctx context.Context, claim *domain.Claim, task *domain.Task) error {
 	// Release escrow to claimer
-	err = s.escrowSvc.ReleaseEscrow(ctx, task.ID, claim.ClaimerID, task.RewardAmount)
+	err := s.escrowSvc.ReleaseEscrow(ctx, task.ID, claim.ClaimerID, task.RewardAmount)
 	if err != nil {
 		return err
 	}
@@ -200,21 +420,102 @@ func (s *claimService) ApproveClaim(ctx context.Context, claimID, ownerID uuid.U
 	if err != nil {
 		return err
 	}
-	err = s.userRepo.UpdateReputation(ctx, claim.ClaimerID, 1)
+	err = s.userRepo.UpdateSpending(ctx, task.OwnerID, task.RewardAmount)
 	if err != nil {
 		return err
 	}
 
 	// Update task status
-	err = s.taskRepo.UpdateStatus(ctx, task.ID, domain.TaskStatusCompleted)
+	return s.taskRepo.UpdateStatus(ctx, task.ID, domain.TaskStatusCompleted)
+}
+
+func (s *claimService) RejectClaim(ctx context.Context, claimID, ownerID uuid.UUID) error {
+	claim, task, err := s.claimWithTask(ctx, claimID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if !can(ownerID, ActionDecideClaim, ClaimResource{Claim: claim, Task: task}) {
+		return ErrUnauthorized
+	}
+
+	// Declining an application says nothing about the applicant's work
+	if claim.Status == domain.ClaimStatusApplied {
+		return s.cancelClaim(ctx, claim, task)
+	}
+
+	if claim.Status != domain.ClaimStatusPending {
+		return ErrInvalidClaimState
+	}
+
+	err = s.claimRepo.UpdateStatus(ctx, claimID, domain.ClaimStatusRejected)
+	if err != nil {
+		return err
+	}
+
+	return s.recordReputation(ctx, claim.ClaimerID, domain.ReputationRoleWorker, domain.ReputationClaimRejected, claim, task)
 }
 
-func (s *claimService) RejectClaim(ctx context.Context, claimID, ownerID uuid.UUID) error {
+// WithdrawClaim lets a claimer give up a pending claim. The task reopens if
+// nobody else is still working on it.
+func (s *claimService) WithdrawClaim(ctx context.Context, claimID, claimerID uuid.UUID) error {
+	claim, task, err := s.claimWithTask(ctx, claimID)
+	if err != nil {
+		return err
+	}
+
+	if !can(claimerID, ActionWithdrawClaim, ClaimResource{Claim: claim, Task: task}) {
+		return ErrUnauthorized
+	}
+
+	// Withdrawing an application costs nothing
+	if claim.Status == domain.ClaimStatusApplied {
+		return s.cancelClaim(ctx, claim, task)
+	}
+
+	if claim.Status != domain.ClaimStatusPending {
+		return ErrInvalidClaimState
+	}
+
+	err = s.cancelClaim(ctx, claim, task)
+	if err != nil {
+		return err
+	}
+
+	return s.recordReputation(ctx, claim.ClaimerID, domain.ReputationRoleWorker, domain.ReputationClaimWithdrawn, claim, task)
+}
+
+// DisputeClaim lets a claimer contest a rejection. The claim waits in the
+// disputed state until an arbitrator resolves it.
+func (s *claimService) DisputeClaim(ctx context.Context, claimID, claimerID uuid.UUID, reason string) (*domain.Claim, error) {
+	claim, task, err := s.claimWithTask(ctx, claimID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !can(claimerID, ActionDisputeClaim, ClaimResource{Claim: claim, Task: task}) {
+		return nil, ErrUnauthorized
+	}
+
+	if claim.Status != domain.ClaimStatusRejected {
+		return nil, ErrInvalidClaimState
+	}
+
+	if reason == "" {
+		return nil, ErrDisputeReasonRequired
+	}
+
+	err = s.claimRepo.OpenDispute(ctx, claimID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.claimRepo.GetByID(ctx, claimID)
+}
+
+// ResolveDispute records an arbitration decision. Approving overturns the
+// rejection and pays the claimer; the losing side takes a reputation hit.
+func (s *claimService) ResolveDispute(ctx context.Context, claimID uuid.UUID, arbiter Arbiter, decision domain.ArbitrationDecision, reason string) error {
 	claim, err := s.claimRepo.GetByID(ctx, claimID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -223,19 +524,181 @@ func (s *claimService) RejectClaim(ctx context.Context, claimID, ownerID uuid.UU
 		return err
 	}
 
+	if claim.Status != domain.ClaimStatusDisputed {
+		return ErrInvalidClaimState
+	}
+
+	if decision != domain.ArbitrationApprove && decision != domain.ArbitrationReject {
+		return ErrInvalidDecision
+	}
+
 	task, err := s.taskRepo.GetByID(ctx, claim.TaskID)
 	if err != nil {
 		return err
 	}
 
-	if task.OwnerID != ownerID {
-		return ErrUnauthorized
+	if decision == domain.ArbitrationApprove &&
+		(task.Status == domain.TaskStatusCompleted || task.Status == domain.TaskStatusCancelled || task.Status == domain.TaskStatusHeld) {
+		return ErrInvalidClaimState
 	}
 
-	err = s.claimRepo.UpdateStatus(ctx, claimID, domain.ClaimStatusRejected)
+	err = s.claimRepo.CreateArbitration(ctx, &domain.Arbitration{
+		ID:               uuid.New(),
+		TaskID:           task.ID,
+		ClaimID:          claim.ID,
+		ArbitratorID:     arbiter.AdminID,
+		UserArbitratorID: arbiter.UserID,
+		Decision:         decision,
+		Reason:           reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	switch decision {
+	case domain.ArbitrationApprove:
+		// Overturned rejections go past the same review as approvals
+		flagged, err := s.approveClaim(ctx, claim, task)
+		if err != nil || flagged {
+			return err
+		}
+		return s.recordApproval(ctx, claim, task)
+	case domain.ArbitrationReject:
+		err = s.claimRepo.UpdateStatus(ctx, claim.ID, domain.ClaimStatusRejected)
+		if err != nil {
+			return err
+		}
+		return s.recordReputation(ctx, claim.ClaimerID, domain.ReputationRoleWorker, domain.ReputationDisputeLost, claim, task)
+	}
+	return nil
+}
+
+// SettleFlaggedClaim records a moderator's review of a flagged approval.
+// Clearing the flag pays the held reward out and grants the reputation the
+// approval would have earned; confirming it withdraws the approval, refunds
+// the owner and cancels the task. The flag is only marked reviewed once the reward is settled,
+// so a failed settlement leaves it open to be tried again.
+func (s *claimService) SettleFlaggedClaim(ctx context.Context, claimID uuid.UUID, status domain.FraudFlagStatus, reviewer string) error {
+	if s.reviewer == nil {
+		return ErrFraudFlagNotFound
+	}
+	if status != domain.FraudFlagCleared && status != domain.FraudFlagConfirmed {
+		return ErrInvalidFraudReview
+	}
+	_, err := s.reviewer.OpenFlag(ctx, claimID)
+	if err != nil {
+		return err
+	}
+
+	claim, task, err := s.claimWithTask(ctx, claimID)
+	if err != nil {
+		return err
+	}
+	// Approvals flagged before rewards were held have been paid already
+	if task.Status == domain.TaskStatusHeld {
+		err = s.settleHeldReward(ctx, claim, task, status)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.reviewer.ReviewFlag(ctx, claimID, status, reviewer)
+	if err != nil {
+		return err
+	}
+	if status != domain.FraudFlagCleared {
+		return nil
+	}
+	return s.recordApproval(ctx, claim, task)
+}
+
+// settleHeldReward pays a held reward out, or cancels the approved claim
+// so it no longer counts as approved anywhere and refunds the owner.
+func (s *claimService) settleHeldReward(ctx context.Context, claim *domain.Claim, task *domain.Task, status domain.FraudFlagStatus) error {
+	if status == domain.FraudFlagCleared {
+		return s.payOut(ctx, claim, task)
+	}
+	err := s.claimRepo.UpdateStatus(ctx, claim.ID, domain.ClaimStatusCancelled)
+	if err != nil {
+		return err
+	}
+	err = s.taskRepo.UpdateStatus(ctx, task.ID, domain.TaskStatusCancelled)
 	if err != nil {
 		return err
 	}
+	return s.escrowSvc.RefundEscrow(ctx, task.ID, task.OwnerID, task.RewardAmount)
+}
+
+// ExpireAbandonedClaims cancels claims that were never submitted before the
+// owner deadline and penalizes the claimer. Applications nobody accepted
+// before the claim deadline are cancelled without a penalty.
+func (s *claimService) ExpireAbandonedClaims(ctx context.Context) error {
+	claims, err := s.claimRepo.GetAbandoned(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, claim := range claims {
+		task, err := s.taskRepo.GetByID(ctx, claim.TaskID)
+		if err != nil {
+			continue
+		}
+		if err := s.cancelClaim(ctx, claim, task); err != nil {
+			continue
+		}
+		if claim.Status == domain.ClaimStatusApplied {
+			continue
+		}
+		s.recordReputation(ctx, claim.ClaimerID, domain.ReputationRoleWorker, domain.ReputationClaimAbandoned, claim, task)
+	}
 
 	return nil
 }
+
+// CancelClaimsByClaimer cancels the claimer's pending claims and
+// applications without a reputation penalty, used when the claimer is suspended. Tasks left without
+// an active claim reopen.
+func (s *claimService) CancelClaimsByClaimer(ctx context.Context, claimerID uuid.UUID) error {
+	claims, err := s.claimRepo.GetPendingByClaimerID(ctx, claimerID)
+	if err != nil {
+		return err
+	}
+
+	for _, claim := range claims {
+		task, err := s.taskRepo.GetByID(ctx, claim.TaskID)
+		if err != nil {
+			return err
+		}
+		if err := s.cancelClaim(ctx, claim, task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *claimService) cancelClaim(ctx context.Context, claim *domain.Claim, task *domain.Task) error {
+	err := s.claimRepo.UpdateStatus(ctx, claim.ID, domain.ClaimStatusCancelled)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.claimRepo.CountByTaskID(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	if count == 0 && task.Status == domain.TaskStatusClaimed {
+		return s.taskRepo.UpdateStatus(ctx, task.ID, domain.TaskStatusOpen)
+	}
+	return nil
+}
+
+func (s *claimService) recordReputation(ctx context.Context, userID uuid.UUID, role domain.ReputationRole, kind domain.ReputationEventKind, claim *domain.Claim, task *domain.Task) error {
+	return s.reputationSvc.Record(ctx, &domain.ReputationEvent{
+		UserID:       userID,
+		Role:         role,
+		Kind:         kind,
+		TaskID:       &task.ID,
+		ClaimID:      &claim.ID,
+		RewardAmount: task.RewardAmount,
+	})
+}
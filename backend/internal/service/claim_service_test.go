@@ -12,7 +12,8 @@ import (
 )
 
 type mockClaimRepoForClaimSvc struct {
-	claims map[uuid.UUID]*domain.Claim
+	claims       map[uuid.UUID]*domain.Claim
+	arbitrations []*domain.Arbitration
 }
 
 func (m *mockClaimRepoForClaimSvc) Create(ctx context.Context, claim *domain.Claim) error {
@@ -50,7 +51,7 @@ func (m *mockClaimRepoForClaimSvc) GetByTaskIDAndClaimerID(ctx context.Context,
 func (m *mockClaimRepoForClaimSvc) CountByTaskID(ctx context.Context, taskID uuid.UUID) (int, error) {
 	count := 0
 	for _, claim := range m.claims {
-		if claim.TaskID == taskID && claim.Status != domain.ClaimStatusCancelled {
+		if claim.TaskID == taskID && claim.Status != domain.ClaimStatusCancelled && claim.Status != domain.ClaimStatusApplied {
 			count++
 		}
 	}
@@ -63,6 +64,10 @@ func (m *mockClaimRepoForClaimSvc) UpdateStatus(ctx context.Context, id uuid.UUI
 		return sql.ErrNoRows
 	}
 	claim.Status = status
+	if status == domain.ClaimStatusApproved || status == domain.ClaimStatusRejected {
+		now := time.Now()
+		claim.DecidedAt = &now
+	}
 	return nil
 }
 
@@ -78,6 +83,47 @@ func (m *mockClaimRepoForClaimSvc) SubmitCompletion(ctx context.Context, id uuid
 	return nil
 }
 
+func (m *mockClaimRepoForClaimSvc) OpenDispute(ctx context.Context, id uuid.UUID, reason string) error {
+	claim, ok := m.claims[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	now := time.Now()
+	claim.Status = domain.ClaimStatusDisputed
+	claim.DisputeReason = reason
+	claim.DisputedAt = &now
+	return nil
+}
+
+func (m *mockClaimRepoForClaimSvc) CreateArbitration(ctx context.Context, arbitration *domain.Arbitration) error {
+	m.arbitrations = append(m.arbitrations, arbitration)
+	return nil
+}
+
+func (m *mockClaimRepoForClaimSvc) GetAbandoned(ctx context.Context) ([]*domain.Claim, error) {
+	return nil, nil
+}
+
+func (m *mockClaimRepoForClaimSvc) GetOpenDisputes(ctx context.Context, arbitratorID uuid.UUID, limit, offset int) ([]*domain.Claim, error) {
+	var result []*domain.Claim
+	for _, claim := range m.claims {
+		if claim.Status == domain.ClaimStatusDisputed {
+			result = append(result, claim)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockClaimRepoForClaimSvc) GetPendingByClaimerID(ctx context.Context, claimerID uuid.UUID) ([]*domain.Claim, error) {
+	var result []*domain.Claim
+	for _, claim := range m.claims {
+		if claim.ClaimerID == claimerID && (claim.Status == domain.ClaimStatusPending || claim.Status == domain.ClaimStatusApplied) {
+			result = append(result, claim)
+		}
+	}
+	return result, nil
+}
+
 type mockTaskRepoForClaimSvc struct {
 	tasks map[uuid.UUID]*domain.Task
 }
@@ -105,7 +151,7 @@ func (m *mockTaskRepoForClaimSvc) GetByOwnerID(ctx context.Context, ownerID uuid
 	return result, nil
 }
 
-func (m *mockTaskRepoForClaimSvc) GetOpenTasks(ctx context.Context, limit, offset int) ([]*domain.Task, error) {
+func (m *mockTaskRepoForClaimSvc) GetOpenTasks(ctx context.Context, viewerID uuid.UUID, limit, offset int) ([]*domain.Task, error) {
 	var result []*domain.Task
 	for _, task := range m.tasks {
 		if task.Status == domain.TaskStatusOpen {
@@ -141,18 +187,38 @@ func (m *mockTaskRepoForClaimSvc) GetTasksPastOwnerDeadline(ctx context.Context)
 	return nil, nil
 }
 
-type mockChatRepoForClaimSvc struct{}
+func (m *mockTaskRepoForClaimSvc) GetActiveByOwnerID(ctx context.Context, ownerID uuid.UUID) ([]*domain.Task, error) {
+	var result []*domain.Task
+	for _, task := range m.tasks {
+		if task.OwnerID == ownerID && task.Status != domain.TaskStatusCompleted && task.Status != domain.TaskStatusCancelled {
+			result = append(result, task)
+		}
+	}
+	return result, nil
+}
+
+// mockChatRepoForClaimSvc remembers the chats it creates when chats is set.
+type mockChatRepoForClaimSvc struct {
+	chats map[uuid.UUID]*domain.Chat
+}
 
 func (m *mockChatRepoForClaimSvc) GetOrCreate(ctx context.Context, taskID, participantID, otherParticipantID uuid.UUID) (*domain.Chat, error) {
-	return &domain.Chat{
+	chat := &domain.Chat{
 		ID:                 uuid.New(),
 		TaskID:             taskID,
 		ParticipantID:      participantID,
 		OtherParticipantID: otherParticipantID,
-	}, nil
+	}
+	if m.chats != nil {
+		m.chats[chat.ID] = chat
+	}
+	return chat, nil
 }
 
 func (m *mockChatRepoForClaimSvc) GetByID(ctx context.Context, id uuid.UUID) (*domain.Chat, error) {
+	if chat, ok := m.chats[id]; ok {
+		return chat, nil
+	}
 	return &domain.Chat{ID: id}, nil
 }
 
@@ -160,26 +226,122 @@ func (m *mockChatRepoForClaimSvc) GetByTaskIDAndUserID(ctx context.Context, task
 	return []*domain.Chat{}, nil
 }
 
-func (m *mockChatRepoForClaimSvc) DeleteForUser(ctx context.Context, chatID, userID uuid.UUID) error {
-	return nil
+func (m *mockChatRepoForClaimSvc) ListInbox(ctx context.Context, userID uuid.UUID, after *domain.InboxCursor, limit int) ([]*domain.Chat, error) {
+	return []*domain.Chat{}, nil
+}
+
+func (m *mockChatRepoForClaimSvc) DeleteForUser(ctx context.Context, chatID, userID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+func (m *mockChatRepoForClaimSvc) PurgeChat(ctx context.Context, chatID uuid.UUID) (bool, []string, error) {
+	return false, nil, nil
+}
+
+func (m *mockChatRepoForClaimSvc) PurgeDeletedChats(ctx context.Context, limit int) (int, []string, error) {
+	return 0, nil, nil
 }
 
 func (m *mockChatRepoForClaimSvc) CreateMessage(ctx context.Context, message *domain.Message) error {
 	return nil
 }
 
-func (m *mockChatRepoForClaimSvc) GetMessagesByChatID(ctx context.Context, chatID uuid.UUID, limit, offset int) ([]*domain.Message, error) {
+func (m *mockChatRepoForClaimSvc) GetMessagesByChatID(ctx context.Context, chatID uuid.UUID, since *time.Time, limit, offset int) ([]*domain.Message, error) {
 	return []*domain.Message{}, nil
 }
 
+func (m *mockChatRepoForClaimSvc) GetMessageByID(ctx context.Context, id uuid.UUID) (*domain.Message, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockChatRepoForClaimSvc) GetLastMessage(ctx context.Context, chatID, userID uuid.UUID) (*domain.Message, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockChatRepoForClaimSvc) EditMessage(ctx context.Context, message *domain.Message) error {
+	return nil
+}
+
+func (m *mockChatRepoForClaimSvc) UnsendMessage(ctx context.Context, message *domain.Message) error {
+	return nil
+}
+
+func (m *mockChatRepoForClaimSvc) GetMessageEdits(ctx context.Context, messageID uuid.UUID) ([]*domain.MessageEdit, error) {
+	return nil, nil
+}
+
+func (m *mockChatRepoForClaimSvc) GetAttachment(ctx context.Context, id uuid.UUID) (*domain.Attachment, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockChatRepoForClaimSvc) GetAttachments(ctx context.Context, messageIDs []uuid.UUID) ([]*domain.Attachment, error) {
+	return nil, nil
+}
+
+func (m *mockChatRepoForClaimSvc) GetEnvelopes(ctx context.Context, messageIDs []uuid.UUID, userID uuid.UUID) ([]*domain.Envelope, error) {
+	return nil, nil
+}
+
+func (m *mockChatRepoForClaimSvc) MarkRead(ctx context.Context, chatID, userID, messageID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+func (m *mockChatRepoForClaimSvc) CountUnread(ctx context.Context, userID uuid.UUID) (int, error) {
+	return 0, nil
+}
+
+func (m *mockChatRepoForClaimSvc) SetMessageTTL(ctx context.Context, chatID uuid.UUID, seconds int) error {
+	if chat, ok := m.chats[chatID]; ok {
+		chat.MessageTTL = seconds
+	}
+	return nil
+}
+
+func (m *mockChatRepoForClaimSvc) PurgeExpiredMessages(ctx context.Context, limit int) (int, []string, error) {
+	return 0, nil, nil
+}
+
+func (m *mockChatRepoForClaimSvc) PurgeEndedTaskMessages(ctx context.Context, endedBefore time.Time, limit int) (int, []string, error) {
+	return 0, nil, nil
+}
+
+type mockReputationSvc struct {
+	events []*domain.ReputationEvent
+}
+
+func (m *mockReputationSvc) Record(ctx context.Context, event *domain.ReputationEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *mockReputationSvc) Recompute(ctx context.Context, userID uuid.UUID) error {
+	return nil
+}
+
+func (m *mockReputationSvc) RecomputeAll(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (m *mockReputationSvc) kinds(userID uuid.UUID) []domain.ReputationEventKind {
+	var kinds []domain.ReputationEventKind
+	for _, event := range m.events {
+		if event.UserID == userID {
+			kinds = append(kinds, event.Kind)
+		}
+	}
+	return kinds
+}
+
 func TestClaimTask(t *testing.T) {
 	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
 	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
 	chatRepo := &mockChatRepoForClaimSvc{}
 	escrowSvc := &mockEscrowSvc{}
 	userRepo := &mockUserRepo{}
+	reputationSvc := &mockReputationSvc{}
+	blockRepo := newMockBlockRepo()
 
-	service := NewClaimService(claimRepo, taskRepo, chatRepo, escrowSvc, userRepo)
+	service := NewClaimService(claimRepo, taskRepo, chatRepo, escrowSvc, userRepo, reputationSvc, blockRepo, nil, nil)
 
 	ownerID := uuid.New()
 	claimerID := uuid.New()
@@ -217,8 +379,10 @@ func TestClaimTaskLimitReached(t *testing.T) {
 	chatRepo := &mockChatRepoForClaimSvc{}
 	escrowSvc := &mockEscrowSvc{}
 	userRepo := &mockUserRepo{}
+	reputationSvc := &mockReputationSvc{}
+	blockRepo := newMockBlockRepo()
 
-	service := NewClaimService(claimRepo, taskRepo, chatRepo, escrowSvc, userRepo)
+	service := NewClaimService(claimRepo, taskRepo, chatRepo, escrowSvc, userRepo, reputationSvc, blockRepo, nil, nil)
 
 	ownerID := uuid.New()
 	claimerID1 := uuid.New()
@@ -249,24 +413,176 @@ func TestClaimTaskLimitReached(t *testing.T) {
 	assert.Equal(t, ErrClaimLimitReached, err)
 }
 
-type mockUserRepo struct{}
+func TestDisputedRejectionOverturned(t *testing.T) {
+	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
+	chatRepo := &mockChatRepoForClaimSvc{}
+	escrowSvc := &mockEscrowSvc{}
+	userRepo := &mockUserRepo{}
+	reputationSvc := &mockReputationSvc{}
+	blockRepo := newMockBlockRepo()
+
+	service := NewClaimService(claimRepo, taskRepo, chatRepo, escrowSvc, userRepo, reputationSvc, blockRepo, nil, nil)
+
+	ownerID := uuid.New()
+	claimerID := uuid.New()
+	taskID := uuid.New()
+
+	taskRepo.tasks[taskID] = &domain.Task{
+		ID:            taskID,
+		OwnerID:       ownerID,
+		RewardAmount:  100.0,
+		MaxClaimants:  1,
+		ClaimDeadline: time.Now().Add(7 * 24 * time.Hour),
+		OwnerDeadline: time.Now().Add(30 * 24 * time.Hour),
+		Status:        domain.TaskStatusOpen,
+		EscrowLocked:  true,
+	}
+
+	ctx := context.Background()
+	claim, err := service.ClaimTask(ctx, taskID, claimerID)
+	assert.NoError(t, err)
+	_, err = service.SubmitCompletion(ctx, claim.ID, claimerID, "done", "")
+	assert.NoError(t, err)
+
+	// Only a rejected claim can be disputed
+	_, err = service.DisputeClaim(ctx, claim.ID, claimerID, "it was done")
+	assert.Equal(t, ErrInvalidClaimState, err)
+
+	assert.NoError(t, service.RejectClaim(ctx, claim.ID, ownerID))
+	assert.Equal(t, ErrInvalidClaimState, service.RejectClaim(ctx, claim.ID, ownerID))
+
+	_, err = service.DisputeClaim(ctx, claim.ID, ownerID, "not mine")
+	assert.Equal(t, ErrUnauthorized, err)
+
+	disputed, err := service.DisputeClaim(ctx, claim.ID, claimerID, "it was done")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ClaimStatusDisputed, disputed.Status)
+
+	err = service.ResolveDispute(ctx, claim.ID, Arbiter{}, domain.ArbitrationApprove, "work matches the brief")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ClaimStatusApproved, claimRepo.claims[claim.ID].Status)
+	assert.Equal(t, domain.TaskStatusCompleted, taskRepo.tasks[taskID].Status)
+	assert.Equal(t, []uuid.UUID{taskID}, escrowSvc.released)
+	assert.Len(t, claimRepo.arbitrations, 1)
+
+	assert.Equal(t, []domain.ReputationEventKind{
+		domain.ReputationClaimRejected,
+		domain.ReputationClaimApproved,
+		domain.ReputationRejectionOverturned,
+	}, reputationSvc.kinds(claimerID))
+	assert.Equal(t, []domain.ReputationEventKind{
+		domain.ReputationUnjustifiedRejection,
+		domain.ReputationDisputeLost,
+	}, reputationSvc.kinds(ownerID))
+}
+
+func TestWithdrawClaimReopensTask(t *testing.T) {
+	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
+	chatRepo := &mockChatRepoForClaimSvc{}
+	escrowSvc := &mockEscrowSvc{}
+	userRepo := &mockUserRepo{}
+	reputationSvc := &mockReputationSvc{}
+	blockRepo := newMockBlockRepo()
+
+	service := NewClaimService(claimRepo, taskRepo, chatRepo, escrowSvc, userRepo, reputationSvc, blockRepo, nil, nil)
+
+	claimerID := uuid.New()
+	taskID := uuid.New()
+
+	taskRepo.tasks[taskID] = &domain.Task{
+		ID:            taskID,
+		OwnerID:       uuid.New(),
+		RewardAmount:  10.0,
+		MaxClaimants:  1,
+		ClaimDeadline: time.Now().Add(7 * 24 * time.Hour),
+		OwnerDeadline: time.Now().Add(30 * 24 * time.Hour),
+		Status:        domain.TaskStatusOpen,
+		EscrowLocked:  true,
+	}
+
+	ctx := context.Background()
+	claim, err := service.ClaimTask(ctx, taskID, claimerID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.TaskStatusClaimed, taskRepo.tasks[taskID].Status)
+
+	assert.Equal(t, ErrUnauthorized, service.WithdrawClaim(ctx, claim.ID, uuid.New()))
+	assert.NoError(t, service.WithdrawClaim(ctx, claim.ID, claimerID))
+	assert.Equal(t, domain.ClaimStatusCancelled, claimRepo.claims[claim.ID].Status)
+	assert.Equal(t, domain.TaskStatusOpen, taskRepo.tasks[taskID].Status)
+	assert.Equal(t, []domain.ReputationEventKind{domain.ReputationClaimWithdrawn}, reputationSvc.kinds(claimerID))
+}
+
+type mockUserRepo struct {
+	recoveryHashes map[string]uuid.UUID
+	suspended      map[uuid.UUID]*domain.User
+	users          map[uuid.UUID]*domain.User
+	stats          map[uuid.UUID]*domain.UserStats
+}
 
 func (m *mockUserRepo) GetOrCreateByDeviceID(ctx context.Context, deviceID string) (*domain.User, error) {
 	return &domain.User{ID: uuid.New()}, nil
 }
 
 func (m *mockUserRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	if user, ok := m.suspended[id]; ok {
+		return user, nil
+	}
+	if user, ok := m.users[id]; ok {
+		return user, nil
+	}
 	return &domain.User{ID: id}, nil
 }
 
-func (m *mockUserRepo) UpdateReputation(ctx context.Context, id uuid.UUID, delta int) error {
+func (m *mockUserRepo) UpdateEarnings(ctx context.Context, id uuid.UUID, amount float64) error {
 	return nil
 }
 
-func (m *mockUserRepo) UpdateEarnings(ctx context.Context, id uuid.UUID, amount float64) error {
+func (m *mockUserRepo) UpdateSpending(ctx context.Context, id uuid.UUID, amount float64) error {
 	return nil
 }
 
-func (m *mockUserRepo) UpdateSpending(ctx context.Context, id uuid.UUID, amount float64) error {
+func (m *mockUserRepo) GetByRecoverySecretHash(ctx context.Context, hash string) (*domain.User, error) {
+	id, ok := m.recoveryHashes[hash]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &domain.User{ID: id}, nil
+}
+
+func (m *mockUserRepo) SetRecoverySecretHash(ctx context.Context, id uuid.UUID, hash *string) error {
+	if m.recoveryHashes == nil {
+		m.recoveryHashes = make(map[string]uuid.UUID)
+	}
+	for h, userID := range m.recoveryHashes {
+		if userID == id {
+			delete(m.recoveryHashes, h)
+		}
+	}
+	if hash != nil {
+		m.recoveryHashes[*hash] = id
+	}
+	return nil
+}
+
+func (m *mockUserRepo) GetStats(ctx context.Context, id uuid.UUID) (*domain.UserStats, error) {
+	if stats, ok := m.stats[id]; ok {
+		return stats, nil
+	}
+	return &domain.UserStats{}, nil
+}
+
+func (m *mockUserRepo) Suspend(ctx context.Context, id uuid.UUID, until *time.Time, reason string) error {
+	if m.suspended == nil {
+		m.suspended = make(map[uuid.UUID]*domain.User)
+	}
+	now := time.Now()
+	m.suspended[id] = &domain.User{ID: id, SuspendedAt: &now, SuspendedUntil: until, SuspensionReason: reason}
+	return nil
+}
+
+func (m *mockUserRepo) Unsuspend(ctx context.Context, id uuid.UUID) error {
+	delete(m.suspended, id)
 	return nil
 }
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/repository"
+)
+
+var (
+	ErrDeviceNotFound = errors.New("device not found")
+)
+
+const (
+	pairingCodeTTL      = 10 * time.Minute
+	pairingCodeBytes    = 10
+	recoverySecretBytes = 20
+)
+
+type DeviceService interface {
+	ListDevices(ctx context.Context, userID, currentDeviceID uuid.UUID) ([]*domain.Device, error)
+	RevokeDevice(ctx context.Context, userID, deviceID uuid.UUID) error
+	CreatePairingCode(ctx context.Context, userID uuid.UUID) (*domain.PairingCode, error)
+	SetupRecovery(ctx context.Context, userID uuid.UUID) (string, error)
+	DisableRecovery(ctx context.Context, userID uuid.UUID) error
+}
+
+type deviceService struct {
+	deviceRepo repository.DeviceRepository
+	userRepo   repository.UserRepository
+	authSvc    AuthService
+}
+
+func NewDeviceService(
+	deviceRepo repository.DeviceRepository,
+	userRepo repository.UserRepository,
+	authSvc AuthService,
+) DeviceService {
+	return &deviceService{
+		deviceRepo: deviceRepo,
+		userRepo:   userRepo,
+		authSvc:    authSvc,
+	}
+}
+
+func (s *deviceService) ListDevices(ctx context.Context, userID, currentDeviceID uuid.UUID) ([]*domain.Device, error) {
+	devices, err := s.deviceRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, device := range devices {
+		device.Current = device.ID == currentDeviceID
+	}
+	return devices, nil
+}
+
+func (s *deviceService) RevokeDevice(ctx context.Context, userID, deviceID uuid.UUID) error {
+	device, err := s.deviceRepo.GetByID(ctx, deviceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrDeviceNotFound
+		}
+		return err
+	}
+
+	if device.UserID != userID {
+		return ErrDeviceNotFound
+	}
+
+	err = s.deviceRepo.Revoke(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+
+	return s.authSvc.RevokeDevice(ctx, deviceID)
+}
+
+func (s *deviceService) CreatePairingCode(ctx context.Context, userID uuid.UUID) (*domain.PairingCode, error) {
+	code, err := newCode(pairingCodeBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(pairingCodeTTL)
+	err = s.deviceRepo.CreatePairing(ctx, userID, hashToken(normalizeCode(code)), expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.PairingCode{
+		Code:      code,
+		QRPayload: "taskunderground://pair?code=" + code,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// SetupRecovery generates a new recovery secret, replacing any previous one.
+// The secret is only returned here; the server keeps just its hash.
+func (s *deviceService) SetupRecovery(ctx context.Context, userID uuid.UUID) (string, error) {
+	secret, err := newCode(recoverySecretBytes)
+	if err != nil {
+		return "", err
+	}
+
+	hash := hashToken(normalizeCode(secret))
+	err = s.userRepo.SetRecoverySecretHash(ctx, userID, &hash)
+	if err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+func (s *deviceService) DisableRecovery(ctx context.Context, userID uuid.UUID) error {
+	return s.userRepo.SetRecoverySecretHash(ctx, userID, nil)
+}
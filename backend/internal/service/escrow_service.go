@@ -18,6 +18,7 @@ type EscrowService interface {
 	LockEscrow(ctx context.Context, taskID, userID uuid.UUID, amount float64) error
 	ReleaseEscrow(ctx context.Context, taskID, userID uuid.UUID, amount float64) error
 	RefundEscrow(ctx context.Context, taskID, userID uuid.UUID, amount float64) error
+	GetTransactionsByTaskID(ctx context.Context, taskID uuid.UUID) ([]*domain.EscrowTransaction, error)
 }
 
 type escrowService struct {
@@ -131,3 +132,7 @@ func (s *escrowService) RefundEscrow(ctx context.Context, taskID, userID uuid.UU
 
 	return nil
 }
+
+func (s *escrowService) GetTransactionsByTaskID(ctx context.Context, taskID uuid.UUID) ([]*domain.EscrowTransaction, error) {
+	return s.escrowRepo.GetTransactionsByTaskID(ctx, taskID)
+}
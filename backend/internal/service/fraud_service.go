@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/repository"
+)
+
+var (
+	ErrFraudFlagNotFound  = errors.New("fraud flag not found")
+	ErrFraudFlagReviewed  = errors.New("fraud flag has already been reviewed")
+	ErrInvalidFraudReview = errors.New("invalid fraud review decision")
+)
+
+const (
+	defaultFraudWindow     = 30 * 24 * time.Hour
+	defaultInstantApproval = 2 * time.Minute
+	defaultRepeatedPairs   = 3
+	defaultFlagScore       = 50
+)
+
+// FraudConfig tunes the collusion checks run when an owner approves a claim.
+// Zero values fall back to the defaults.
+type FraudConfig struct {
+	Secret []byte
+	// Window is how far back fingerprints and earlier approvals count.
+	Window time.Duration
+	// Approving a submission within InstantApproval counts as instant.
+	InstantApproval time.Duration
+	// RepeatedPairs earlier approvals between the same owner and claimer
+	// within Window make a repeated pair.
+	RepeatedPairs int
+	// Approvals scoring FlagScore or more are flagged for review.
+	FlagScore int
+}
+
+type clientInfoKey struct{}
+
+// WithClientInfo attaches where the request came from to ctx.
+func WithClientInfo(ctx context.Context, info domain.ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+func clientInfoFrom(ctx context.Context) domain.ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(domain.ClientInfo)
+	return info
+}
+
+type FraudService interface {
+	TaskGate
+	ClaimGate
+	ApprovalReviewer
+	ListFlags(ctx context.Context, status domain.FraudFlagStatus, limit, offset int) ([]*domain.FraudFlag, error)
+	ReviewFlag(ctx context.Context, claimID uuid.UUID, status domain.FraudFlagStatus, reviewer string) error
+}
+
+type fraudService struct {
+	fraudRepo repository.FraudRepository
+	config    FraudConfig
+}
+
+func NewFraudService(
+	fraudRepo repository.FraudRepository,
+	config FraudConfig,
+) FraudService {
+	if config.Window <= 0 {
+		config.Window = defaultFraudWindow
+	}
+	if config.InstantApproval <= 0 {
+		config.InstantApproval = defaultInstantApproval
+	}
+	if config.RepeatedPairs <= 0 {
+		config.RepeatedPairs = defaultRepeatedPairs
+	}
+	if config.FlagScore <= 0 {
+		config.FlagScore = defaultFlagScore
+	}
+	return &fraudService{
+		fraudRepo: fraudRepo,
+		config:    config,
+	}
+}
+
+// CheckTask never refuses; it notes where the owner posts from.
+func (s *fraudService) CheckTask(ctx context.Context, ownerID uuid.UUID, req CreateTaskRequest) error {
+	return s.recordFingerprints(ctx, ownerID)
+}
+
+// CheckClaim never refuses; it notes where the claimer claims from.
+func (s *fraudService) CheckClaim(ctx context.Context, claimerID uuid.UUID, task *domain.Task) error {
+	return s.recordFingerprints(ctx, claimerID)
+}
+
+// ReviewApproval scores an approval against the signals of one person
+// playing both sides and flags it when the score reaches FlagScore.
+func (s *fraudService) ReviewApproval(ctx context.Context, claim *domain.Claim, task *domain.Task) (bool, error) {
+	if err := s.recordFingerprints(ctx, task.OwnerID); err != nil {
+		return false, err
+	}
+
+	since := time.Now().Add(-s.config.Window)
+	var signals []domain.FraudSignal
+
+	for _, kind := range []domain.FingerprintKind{domain.FingerprintDevice, domain.FingerprintNetwork} {
+		shared, err := s.fraudRepo.SharesFingerprint(ctx, task.OwnerID, claim.ClaimerID, kind, since)
+		if err != nil {
+			return false, err
+		}
+		if shared && kind == domain.FingerprintDevice {
+			signals = append(signals, domain.FraudSignalSharedDevice)
+		}
+		if shared && kind == domain.FingerprintNetwork {
+			signals = append(signals, domain.FraudSignalSharedNetwork)
+		}
+	}
+
+	pairs, err := s.fraudRepo.CountApprovedBetween(ctx, task.OwnerID, claim.ClaimerID, since, claim.ID)
+	if err != nil {
+		return false, err
+	}
+	if pairs >= s.config.RepeatedPairs {
+		signals = append(signals, domain.FraudSignalRepeatedPair)
+	}
+
+	if claim.SubmittedAt != nil && time.Since(*claim.SubmittedAt) < s.config.InstantApproval {
+		signals = append(signals, domain.FraudSignalInstantApproval)
+	}
+
+	// Money that went the other way recently suggests the pair is passing
+	// the same funds back and forth
+	reverse, err := s.fraudRepo.CountApprovedBetween(ctx, claim.ClaimerID, task.OwnerID, since, claim.ID)
+	if err != nil {
+		return false, err
+	}
+	if reverse > 0 {
+		signals = append(signals, domain.FraudSignalCircularPayment)
+	}
+
+	score := 0
+	for _, signal := range signals {
+		score += domain.FraudSignalWeights[signal]
+	}
+	if score < s.config.FlagScore {
+		return false, nil
+	}
+
+	err = s.fraudRepo.CreateFlag(ctx, &domain.FraudFlag{
+		ClaimID:   claim.ID,
+		TaskID:    task.ID,
+		OwnerID:   task.OwnerID,
+		ClaimerID: claim.ClaimerID,
+		Score:     score,
+		Signals:   signals,
+		Status:    domain.FraudFlagOpen,
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *fraudService) ListFlags(ctx context.Context, status domain.FraudFlagStatus, limit, offset int) ([]*domain.FraudFlag, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.fraudRepo.ListFlags(ctx, status, limit, offset)
+}
+
+// OpenFlag returns the claim's flag if it still awaits review.
+func (s *fraudService) OpenFlag(ctx context.Context, claimID uuid.UUID) (*domain.FraudFlag, error) {
+	flag, err := s.fraudRepo.GetFlag(ctx, claimID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrFraudFlagNotFound
+		}
+		return nil, err
+	}
+	if flag.Status != domain.FraudFlagOpen {
+		return nil, ErrFraudFlagReviewed
+	}
+	return flag, nil
+}
+
+// ReviewFlag marks an open flag cleared or confirmed. The claim service
+// settles the held reward and the withheld reputation before calling it.
+func (s *fraudService) ReviewFlag(ctx context.Context, claimID uuid.UUID, status domain.FraudFlagStatus, reviewer string) error {
+	if status != domain.FraudFlagCleared && status != domain.FraudFlagConfirmed {
+		return ErrInvalidFraudReview
+	}
+	if _, err := s.OpenFlag(ctx, claimID); err != nil {
+		return err
+	}
+	return s.fraudRepo.UpdateFlagStatus(ctx, claimID, status, reviewer)
+}
+
+// recordFingerprints notes the network and device the current request came
+// from. Requests without client info, such as background jobs, are skipped.
+func (s *fraudService) recordFingerprints(ctx context.Context, userID uuid.UUID) error {
+	info := clientInfoFrom(ctx)
+	if info.IP == "" {
+		return nil
+	}
+
+	err := s.fraudRepo.RecordFingerprint(ctx, userID, domain.FingerprintNetwork, hashClientIP(s.config.Secret, info.IP))
+	if err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha256.New, s.config.Secret)
+	mac.Write([]byte("device|" + info.IP + "|" + info.UserAgent))
+	return s.fraudRepo.RecordFingerprint(ctx, userID, domain.FingerprintDevice, hex.EncodeToString(mac.Sum(nil)))
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type mockFraudRepo struct {
+	fingerprints map[uuid.UUID][]string
+	shared       map[domain.FingerprintKind]bool
+	approved     map[[2]uuid.UUID]int
+	flags        map[uuid.UUID]*domain.FraudFlag
+}
+
+func newMockFraudRepo() *mockFraudRepo {
+	return &mockFraudRepo{
+		fingerprints: make(map[uuid.UUID][]string),
+		shared:       make(map[domain.FingerprintKind]bool),
+		approved:     make(map[[2]uuid.UUID]int),
+		flags:        make(map[uuid.UUID]*domain.FraudFlag),
+	}
+}
+
+func (m *mockFraudRepo) RecordFingerprint(ctx context.Context, userID uuid.UUID, kind domain.FingerprintKind, hash string) error {
+	m.fingerprints[userID] = append(m.fingerprints[userID], string(kind)+":"+hash)
+	return nil
+}
+
+func (m *mockFraudRepo) SharesFingerprint(ctx context.Context, userA, userB uuid.UUID, kind domain.FingerprintKind, since time.Time) (bool, error) {
+	return m.shared[kind], nil
+}
+
+func (m *mockFraudRepo) CountApprovedBetween(ctx context.Context, ownerID, claimerID uuid.UUID, since time.Time, excludeClaimID uuid.UUID) (int, error) {
+	return m.approved[[2]uuid.UUID{ownerID, claimerID}], nil
+}
+
+func (m *mockFraudRepo) CreateFlag(ctx context.Context, flag *domain.FraudFlag) error {
+	m.flags[flag.ClaimID] = flag
+	return nil
+}
+
+func (m *mockFraudRepo) GetFlag(ctx context.Context, claimID uuid.UUID) (*domain.FraudFlag, error) {
+	flag, ok := m.flags[claimID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return flag, nil
+}
+
+func (m *mockFraudRepo) ListFlags(ctx context.Context, status domain.FraudFlagStatus, limit, offset int) ([]*domain.FraudFlag, error) {
+	var flags []*domain.FraudFlag
+	for _, flag := range m.flags {
+		if flag.Status == status {
+			flags = append(flags, flag)
+		}
+	}
+	return flags, nil
+}
+
+func (m *mockFraudRepo) UpdateFlagStatus(ctx context.Context, claimID uuid.UUID, status domain.FraudFlagStatus, reviewedBy string) error {
+	m.flags[claimID].Status = status
+	m.flags[claimID].ReviewedBy = reviewedBy
+	return nil
+}
+
+func TestClaimTaskRefusesOwnTask(t *testing.T) {
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
+	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
+	claimSvc := NewClaimService(claimRepo, taskRepo, &mockChatRepoForClaimSvc{}, &mockEscrowSvc{}, &mockUserRepo{}, &mockReputationSvc{}, newMockBlockRepo(), nil, nil)
+
+	ownerID := uuid.New()
+	task := &domain.Task{
+		ID:            uuid.New(),
+		OwnerID:       ownerID,
+		RewardAmount:  10,
+		MaxClaimants:  1,
+		ClaimDeadline: time.Now().Add(24 * time.Hour),
+		OwnerDeadline: time.Now().Add(48 * time.Hour),
+		Status:        domain.TaskStatusOpen,
+		EscrowLocked:  true,
+	}
+	taskRepo.tasks[task.ID] = task
+
+	assert.True(t, can(uuid.New(), ActionClaimTask, task))
+	_, err := claimSvc.ClaimTask(context.Background(), task.ID, ownerID)
+	assert.Equal(t, ErrCannotClaimOwnTask, err)
+	assert.Empty(t, claimRepo.claims)
+}
+
+func TestReviewApprovalScoresSignals(t *testing.T) {
+	ownerID := uuid.New()
+	claimerID := uuid.New()
+	task := &domain.Task{ID: uuid.New(), OwnerID: ownerID}
+
+	tests := []struct {
+		name     string
+		shared   []domain.FingerprintKind
+		pairs    int
+		reverse  int
+		instant  bool
+		flagged  bool
+		expected []domain.FraudSignal
+	}{
+		{name: "clean"},
+		{name: "instant approval alone", instant: true},
+		{name: "shared network alone", shared: []domain.FingerprintKind{domain.FingerprintNetwork}},
+		{
+			name:     "shared device",
+			shared:   []domain.FingerprintKind{domain.FingerprintDevice, domain.FingerprintNetwork},
+			flagged:  true,
+			expected: []domain.FraudSignal{domain.FraudSignalSharedDevice, domain.FraudSignalSharedNetwork},
+		},
+		{
+			name:     "repeated pair approved instantly",
+			pairs:    3,
+			instant:  true,
+			flagged:  true,
+			expected: []domain.FraudSignal{domain.FraudSignalRepeatedPair, domain.FraudSignalInstantApproval},
+		},
+		{
+			name:     "circular payment on a shared network",
+			shared:   []domain.FingerprintKind{domain.FingerprintNetwork},
+			reverse:  1,
+			flagged:  true,
+			expected: []domain.FraudSignal{domain.FraudSignalSharedNetwork, domain.FraudSignalCircularPayment},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fraudRepo := newMockFraudRepo()
+			for _, kind := range tt.shared {
+				fraudRepo.shared[kind] = true
+			}
+			fraudRepo.approved[[2]uuid.UUID{ownerID, claimerID}] = tt.pairs
+			fraudRepo.approved[[2]uuid.UUID{claimerID, ownerID}] = tt.reverse
+			fraudSvc := NewFraudService(fraudRepo, FraudConfig{Secret: []byte("test-secret")})
+
+			submittedAt := time.Now().Add(-time.Hour)
+			if tt.instant {
+				submittedAt = time.Now().Add(-10 * time.Second)
+			}
+			claim := &domain.Claim{ID: uuid.New(), TaskID: task.ID, ClaimerID: claimerID, SubmittedAt: &submittedAt}
+
+			flagged, err := fraudSvc.ReviewApproval(context.Background(), claim, task)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.flagged, flagged)
+			if tt.flagged {
+				assert.Equal(t, tt.expected, fraudRepo.flags[claim.ID].Signals)
+			} else {
+				assert.Empty(t, fraudRepo.flags)
+			}
+		})
+	}
+}
+
+func TestFlaggedApprovalWithholdsReputationUntilCleared(t *testing.T) {
+	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
+	reputationSvc := &mockReputationSvc{}
+	fraudRepo := newMockFraudRepo()
+	fraudSvc := NewFraudService(fraudRepo, FraudConfig{Secret: []byte("test-secret")})
+	escrowSvc := &mockEscrowSvc{}
+	claimSvc := NewClaimService(claimRepo, taskRepo, &mockChatRepoForClaimSvc{}, escrowSvc, &mockUserRepo{}, reputationSvc, newMockBlockRepo(), fraudSvc, fraudSvc)
+
+	ownerID := uuid.New()
+	claimerID := uuid.New()
+	task := &domain.Task{
+		ID:            uuid.New(),
+		OwnerID:       ownerID,
+		RewardAmount:  10,
+		MaxClaimants:  1,
+		ClaimDeadline: time.Now().Add(24 * time.Hour),
+		OwnerDeadline: time.Now().Add(48 * time.Hour),
+		Status:        domain.TaskStatusOpen,
+		EscrowLocked:  true,
+	}
+	taskRepo.tasks[task.ID] = task
+
+	// Both sides act from the same phone
+	phone := domain.ClientInfo{IP: "203.0.113.7", UserAgent: "TaskUnderground/1.0"}
+	ctx := WithClientInfo(context.Background(), phone)
+
+	claim, err := claimSvc.ClaimTask(ctx, task.ID, claimerID)
+	assert.NoError(t, err)
+	assert.Len(t, fraudRepo.fingerprints[claimerID], 2)
+
+	_, err = claimSvc.SubmitCompletion(ctx, claim.ID, claimerID, "done", "")
+	assert.NoError(t, err)
+
+	fraudRepo.shared[domain.FingerprintDevice] = true
+	fraudRepo.shared[domain.FingerprintNetwork] = true
+	assert.NoError(t, claimSvc.ApproveClaim(ctx, claim.ID, ownerID))
+	assert.Equal(t, fraudRepo.fingerprints[claimerID], fraudRepo.fingerprints[ownerID])
+
+	// Approved, but held: nothing is paid and neither side gains reputation
+	assert.Equal(t, domain.ClaimStatusApproved, claimRepo.claims[claim.ID].Status)
+	assert.Equal(t, domain.TaskStatusHeld, task.Status)
+	assert.Empty(t, escrowSvc.released)
+	assert.Empty(t, reputationSvc.events)
+
+	flags, err := fraudSvc.ListFlags(ctx, domain.FraudFlagOpen, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, flags, 1)
+	assert.Equal(t, []domain.FraudSignal{domain.FraudSignalSharedDevice, domain.FraudSignalSharedNetwork, domain.FraudSignalInstantApproval}, flags[0].Signals)
+
+	assert.Equal(t, ErrInvalidFraudReview, claimSvc.SettleFlaggedClaim(ctx, claim.ID, domain.FraudFlagOpen, "mod"))
+	assert.Equal(t, ErrFraudFlagNotFound, claimSvc.SettleFlaggedClaim(ctx, uuid.New(), domain.FraudFlagCleared, "mod"))
+	assert.Empty(t, escrowSvc.released)
+
+	// Clearing the flag pays out and grants what was withheld, once
+	assert.NoError(t, claimSvc.SettleFlaggedClaim(ctx, claim.ID, domain.FraudFlagCleared, "mod"))
+	assert.Equal(t, []uuid.UUID{task.ID}, escrowSvc.released)
+	assert.Equal(t, domain.TaskStatusCompleted, task.Status)
+	assert.Equal(t, []domain.ReputationEventKind{domain.ReputationClaimApproved}, reputationSvc.kinds(claimerID))
+	assert.Equal(t, []domain.ReputationEventKind{domain.ReputationTaskCompleted}, reputationSvc.kinds(ownerID))
+	assert.Equal(t, ErrFraudFlagReviewed, claimSvc.SettleFlaggedClaim(ctx, claim.ID, domain.FraudFlagConfirmed, "mod"))
+	assert.Equal(t, []uuid.UUID{task.ID}, escrowSvc.released)
+}
+
+func TestOverturnedRejectionIsReviewedBeforePayout(t *testing.T) {
+	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
+	reputationSvc := &mockReputationSvc{}
+	fraudRepo := newMockFraudRepo()
+	fraudSvc := NewFraudService(fraudRepo, FraudConfig{Secret: []byte("test-secret")})
+	escrowSvc := &mockEscrowSvc{}
+	claimSvc := NewClaimService(claimRepo, taskRepo, &mockChatRepoForClaimSvc{}, escrowSvc, &mockUserRepo{}, reputationSvc, newMockBlockRepo(), nil, fraudSvc)
+
+	ownerID := uuid.New()
+	claimerID := uuid.New()
+	task := &domain.Task{
+		ID:            uuid.New(),
+		OwnerID:       ownerID,
+		RewardAmount:  10,
+		MaxClaimants:  1,
+		ClaimDeadline: time.Now().Add(24 * time.Hour),
+		OwnerDeadline: time.Now().Add(48 * time.Hour),
+		Status:        domain.TaskStatusOpen,
+		EscrowLocked:  true,
+	}
+	taskRepo.tasks[task.ID] = task
+	ctx := context.Background()
+
+	claim, err := claimSvc.ClaimTask(ctx, task.ID, claimerID)
+	assert.NoError(t, err)
+	_, err = claimSvc.SubmitCompletion(ctx, claim.ID, claimerID, "done", "")
+	assert.NoError(t, err)
+	assert.NoError(t, claimSvc.RejectClaim(ctx, claim.ID, ownerID))
+	_, err = claimSvc.DisputeClaim(ctx, claim.ID, claimerID, "it was done")
+	assert.NoError(t, err)
+
+	// An arbitrator overturning the rejection cannot pay a self-dealing
+	// pair either
+	fraudRepo.shared[domain.FingerprintDevice] = true
+	assert.NoError(t, claimSvc.ResolveDispute(ctx, claim.ID, Arbiter{}, domain.ArbitrationApprove, "looks done"))
+	assert.Len(t, fraudRepo.flags, 1)
+	assert.Equal(t, domain.TaskStatusHeld, task.Status)
+	assert.Empty(t, escrowSvc.released)
+	assert.Equal(t, []domain.ReputationEventKind{domain.ReputationClaimRejected}, reputationSvc.kinds(claimerID))
+	assert.Empty(t, reputationSvc.kinds(ownerID))
+
+	// Confirming the flag withdraws the approval and sends the reward back
+	// to the owner
+	assert.NoError(t, claimSvc.SettleFlaggedClaim(ctx, claim.ID, domain.FraudFlagConfirmed, "mod"))
+	assert.Empty(t, escrowSvc.released)
+	assert.Equal(t, []uuid.UUID{task.ID}, escrowSvc.refunded)
+	assert.Equal(t, domain.TaskStatusCancelled, task.Status)
+	assert.Equal(t, domain.ClaimStatusCancelled, claimRepo.claims[claim.ID].Status)
+	assert.Empty(t, reputationSvc.kinds(ownerID))
+}
+
+func TestClearedOverturnSettlesBeforeMarkingTheFlag(t *testing.T) {
+	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
+	reputationSvc := &mockReputationSvc{}
+	fraudRepo := newMockFraudRepo()
+	fraudSvc := NewFraudService(fraudRepo, FraudConfig{Secret: []byte("test-secret")})
+	escrowSvc := &mockEscrowSvc{}
+	claimSvc := NewClaimService(claimRepo, taskRepo, &mockChatRepoForClaimSvc{}, escrowSvc, &mockUserRepo{}, reputationSvc, newMockBlockRepo(), nil, fraudSvc)
+
+	ownerID := uuid.New()
+	claimerID := uuid.New()
+	task := &domain.Task{
+		ID:            uuid.New(),
+		OwnerID:       ownerID,
+		RewardAmount:  10,
+		MaxClaimants:  1,
+		ClaimDeadline: time.Now().Add(24 * time.Hour),
+		OwnerDeadline: time.Now().Add(48 * time.Hour),
+		Status:        domain.TaskStatusOpen,
+		EscrowLocked:  true,
+	}
+	taskRepo.tasks[task.ID] = task
+	ctx := context.Background()
+
+	claim, err := claimSvc.ClaimTask(ctx, task.ID, claimerID)
+	assert.NoError(t, err)
+	_, err = claimSvc.SubmitCompletion(ctx, claim.ID, claimerID, "done", "")
+	assert.NoError(t, err)
+	assert.NoError(t, claimSvc.RejectClaim(ctx, claim.ID, ownerID))
+	_, err = claimSvc.DisputeClaim(ctx, claim.ID, claimerID, "it was done")
+	assert.NoError(t, err)
+	fraudRepo.shared[domain.FingerprintDevice] = true
+	assert.NoError(t, claimSvc.ResolveDispute(ctx, claim.ID, Arbiter{}, domain.ArbitrationApprove, "looks done"))
+
+	// A payout that fails leaves the flag open to be settled again
+	escrowSvc.releaseErr = sql.ErrConnDone
+	assert.Equal(t, sql.ErrConnDone, claimSvc.SettleFlaggedClaim(ctx, claim.ID, domain.FraudFlagCleared, "mod"))
+	assert.Equal(t, domain.FraudFlagOpen, fraudRepo.flags[claim.ID].Status)
+	assert.Equal(t, domain.TaskStatusHeld, task.Status)
+
+	// Clearing grants what the arbitration would have: the overturned
+	// rejection counts against the owner
+	escrowSvc.releaseErr = nil
+	assert.NoError(t, claimSvc.SettleFlaggedClaim(ctx, claim.ID, domain.FraudFlagCleared, "mod"))
+	assert.Equal(t, domain.FraudFlagCleared, fraudRepo.flags[claim.ID].Status)
+	assert.Equal(t, []uuid.UUID{task.ID}, escrowSvc.released)
+	assert.Equal(t, []domain.ReputationEventKind{
+		domain.ReputationClaimRejected,
+		domain.ReputationClaimApproved,
+		domain.ReputationRejectionOverturned,
+	}, reputationSvc.kinds(claimerID))
+	assert.Equal(t, []domain.ReputationEventKind{
+		domain.ReputationUnjustifiedRejection,
+		domain.ReputationDisputeLost,
+	}, reputationSvc.kinds(ownerID))
+}
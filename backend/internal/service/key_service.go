@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"database/sql"
+	"errors"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/repository"
+)
+
+var (
+	ErrInvalidKeys       = errors.New("invalid key bundle")
+	ErrTooManyPrekeys    = errors.New("too many one-time prekeys")
+	ErrInvalidDisclosure = errors.New("message key does not decrypt the message")
+)
+
+const (
+	maxPublicKeySize    = 64
+	maxSignatureSize    = 128
+	maxPrekeysPerUpload = 100
+
+	// Encrypted messages are a 12-byte nonce followed by the content sealed
+	// with AES-256-GCM under a key made for the message alone, with the
+	// chat's ID as associated data.
+	messageKeySize    = 32
+	messageNonceSize  = 12
+	minCiphertextSize = messageNonceSize + 16
+	maxCiphertextSize = 64 << 10
+	maxEnvelopeSize   = 4 << 10
+)
+
+// KeyUpload is a device publishing its keys. OneTimePrekeys are added to
+// the ones it already has.
+type KeyUpload struct {
+	IdentityKey    []byte
+	SignedPrekey   domain.SignedPrekey
+	OneTimePrekeys []domain.Prekey
+}
+
+// KeyService is the directory of the public keys devices use to encrypt
+// chat messages to each other. Devices publish keys per chat and are known
+// there by their alias in the chat, so the directory cannot be used to link
+// a user's chats on different tasks. The server never sees a private key or
+// a message key.
+type KeyService interface {
+	PublishKeys(ctx context.Context, chatID, userID, deviceID uuid.UUID, upload KeyUpload) (*domain.KeyStatus, error)
+	GetKeyStatus(ctx context.Context, chatID, userID, deviceID uuid.UUID) (*domain.KeyStatus, error)
+	TakeChatKeys(ctx context.Context, chatID, userID, deviceID uuid.UUID) ([]*domain.KeyBundle, error)
+}
+
+type keyService struct {
+	keyRepo  repository.KeyRepository
+	chatRepo repository.ChatRepository
+	aliasSvc AliasService
+}
+
+func NewKeyService(
+	keyRepo repository.KeyRepository,
+	chatRepo repository.ChatRepository,
+	aliasSvc AliasService,
+) KeyService {
+	return &keyService{
+		keyRepo:  keyRepo,
+		chatRepo: chatRepo,
+		aliasSvc: aliasSvc,
+	}
+}
+
+// PublishKeys stores the keys deviceID uses in the chat, replacing its
+// identity key and signed prekey there and adding to its one-time prekeys.
+func (s *keyService) PublishKeys(ctx context.Context, chatID, userID, deviceID uuid.UUID, upload KeyUpload) (*domain.KeyStatus, error) {
+	if !validPublicKey(upload.IdentityKey) || !validPublicKey(upload.SignedPrekey.PublicKey) {
+		return nil, ErrInvalidKeys
+	}
+	if len(upload.SignedPrekey.Signature) == 0 || len(upload.SignedPrekey.Signature) > maxSignatureSize {
+		return nil, ErrInvalidKeys
+	}
+	if len(upload.OneTimePrekeys) > maxPrekeysPerUpload {
+		return nil, ErrTooManyPrekeys
+	}
+	for _, prekey := range upload.OneTimePrekeys {
+		if !validPublicKey(prekey.PublicKey) {
+			return nil, ErrInvalidKeys
+		}
+	}
+
+	if _, err := s.visibleChat(ctx, chatID, userID); err != nil {
+		return nil, err
+	}
+
+	bundle := &domain.KeyBundle{
+		DeviceID:     deviceID,
+		IdentityKey:  upload.IdentityKey,
+		SignedPrekey: upload.SignedPrekey,
+	}
+	if err := s.keyRepo.Publish(ctx, chatID, bundle, upload.OneTimePrekeys); err != nil {
+		return nil, err
+	}
+	return s.keyStatus(ctx, chatID, deviceID)
+}
+
+func (s *keyService) GetKeyStatus(ctx context.Context, chatID, userID, deviceID uuid.UUID) (*domain.KeyStatus, error) {
+	if _, err := s.visibleChat(ctx, chatID, userID); err != nil {
+		return nil, err
+	}
+	return s.keyStatus(ctx, chatID, deviceID)
+}
+
+func (s *keyService) keyStatus(ctx context.Context, chatID, deviceID uuid.UUID) (*domain.KeyStatus, error) {
+	status, err := s.keyRepo.GetStatus(ctx, chatID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	status.DeviceID = s.aliasSvc.DeviceAlias(chatID, deviceID)
+	return status, nil
+}
+
+// TakeChatKeys returns the key bundles of every device that needs a copy of
+// a message userID sends to the chat from deviceID: the other
+// participant's, and userID's own other devices. Each bundle uses up one of
+// its device's one-time prekeys; once they have run out it carries only the
+// signed prekey, which sessions fall back to until the device tops them up.
+func (s *keyService) TakeChatKeys(ctx context.Context, chatID, userID, deviceID uuid.UUID) ([]*domain.KeyBundle, error) {
+	chat, err := s.visibleChat(ctx, chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	bundles, err := s.keyRepo.TakeBundles(ctx, chatID, []uuid.UUID{chat.Counterpart(userID), userID}, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, bundle := range bundles {
+		bundle.Mine = bundle.UserID == userID
+		bundle.DeviceID = s.aliasSvc.DeviceAlias(chatID, bundle.DeviceID)
+	}
+	return bundles, nil
+}
+
+// visibleChat returns the chat if userID takes part in it and has not
+// deleted it.
+func (s *keyService) visibleChat(ctx context.Context, chatID, userID uuid.UUID) (*domain.Chat, error) {
+	chat, err := s.chatRepo.GetByID(ctx, chatID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrChatNotFound
+		}
+		return nil, err
+	}
+	if !can(userID, ActionReadChat, chat) {
+		return nil, ErrUnauthorized
+	}
+	if !chat.IsVisibleTo(userID) {
+		return nil, ErrChatNotFound
+	}
+	return chat, nil
+}
+
+func validPublicKey(key []byte) bool {
+	return len(key) > 0 && len(key) <= maxPublicKeySize
+}
+
+// openMessage decrypts an encrypted message of chatID with its message key.
+// It fails unless the key is the one the message was sealed with.
+func openMessage(chatID uuid.UUID, ciphertext, key []byte) (string, error) {
+	if len(key) != messageKeySize || len(ciphertext) < minCiphertextSize {
+		return "", ErrInvalidDisclosure
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", ErrInvalidDisclosure
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", ErrInvalidDisclosure
+	}
+
+	content, err := gcm.Open(nil, ciphertext[:messageNonceSize], ciphertext[messageNonceSize:], chatID[:])
+	if err != nil || !utf8.Valid(content) {
+		return "", ErrInvalidDisclosure
+	}
+	return string(content), nil
+}
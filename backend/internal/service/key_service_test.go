@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+// chatDevice identifies the keys a device uses in one chat.
+type chatDevice struct {
+	chatID, deviceID uuid.UUID
+}
+
+type mockKeyRepo struct {
+	bundles map[chatDevice]*domain.KeyBundle
+	prekeys map[chatDevice][]domain.Prekey
+}
+
+func newMockKeyRepo() *mockKeyRepo {
+	return &mockKeyRepo{
+		bundles: make(map[chatDevice]*domain.KeyBundle),
+		prekeys: make(map[chatDevice][]domain.Prekey),
+	}
+}
+
+func (m *mockKeyRepo) Publish(ctx context.Context, chatID uuid.UUID, bundle *domain.KeyBundle, prekeys []domain.Prekey) error {
+	key := chatDevice{chatID, bundle.DeviceID}
+	if existing, ok := m.bundles[key]; ok && string(existing.IdentityKey) != string(bundle.IdentityKey) {
+		m.prekeys[key] = nil
+	}
+	stored := *bundle
+	if existing, ok := m.bundles[key]; ok {
+		stored.UserID = existing.UserID
+	}
+	m.bundles[key] = &stored
+	m.prekeys[key] = append(m.prekeys[key], prekeys...)
+	return nil
+}
+
+func (m *mockKeyRepo) GetStatus(ctx context.Context, chatID, deviceID uuid.UUID) (*domain.KeyStatus, error) {
+	key := chatDevice{chatID, deviceID}
+	_, published := m.bundles[key]
+	return &domain.KeyStatus{Published: published, OneTimePrekeys: len(m.prekeys[key])}, nil
+}
+
+func (m *mockKeyRepo) TakeBundles(ctx context.Context, chatID uuid.UUID, userIDs []uuid.UUID, excludeDeviceID uuid.UUID) ([]*domain.KeyBundle, error) {
+	var bundles []*domain.KeyBundle
+	for key, stored := range m.bundles {
+		for _, userID := range userIDs {
+			if key.chatID != chatID || stored.UserID != userID || key.deviceID == excludeDeviceID {
+				continue
+			}
+			bundle := *stored
+			if prekeys := m.prekeys[key]; len(prekeys) > 0 {
+				bundle.OneTimePrekey = &prekeys[0]
+				m.prekeys[key] = prekeys[1:]
+			}
+			bundles = append(bundles, &bundle)
+		}
+	}
+	return bundles, nil
+}
+
+func (m *mockKeyRepo) GetKeyedDevices(ctx context.Context, chatID uuid.UUID, userIDs []uuid.UUID) (map[uuid.UUID]uuid.UUID, error) {
+	devices := make(map[uuid.UUID]uuid.UUID)
+	for key, bundle := range m.bundles {
+		for _, userID := range userIDs {
+			if key.chatID == chatID && bundle.UserID == userID {
+				devices[key.deviceID] = userID
+			}
+		}
+	}
+	return devices, nil
+}
+
+func TestKeyDirectoryHandsOutEachPrekeyOnce(t *testing.T) {
+	ownerID, claimerID := uuid.New(), uuid.New()
+	chat := &domain.Chat{ID: uuid.New(), ParticipantID: claimerID, OtherParticipantID: ownerID}
+	otherChat := &domain.Chat{ID: uuid.New(), ParticipantID: claimerID, OtherParticipantID: uuid.New()}
+	keyRepo := newMockKeyRepo()
+	aliasSvc := NewAliasService(&mockUserRepo{}, nil, nil, []byte("test-secret"))
+	chats := map[uuid.UUID]*domain.Chat{chat.ID: chat, otherChat.ID: otherChat}
+	service := NewKeyService(keyRepo, &mockChatRepoForClaimSvc{chats: chats}, aliasSvc)
+	ctx := context.Background()
+
+	upload := KeyUpload{
+		IdentityKey:  []byte("identity-key"),
+		SignedPrekey: domain.SignedPrekey{Prekey: domain.Prekey{KeyID: 1, PublicKey: []byte("signed-prekey")}, Signature: []byte("signature")},
+	}
+
+	// Keys have to be present and of a sensible size
+	_, err := service.PublishKeys(ctx, chat.ID, claimerID, uuid.New(), KeyUpload{SignedPrekey: upload.SignedPrekey})
+	assert.Equal(t, ErrInvalidKeys, err)
+	unsigned := upload
+	unsigned.SignedPrekey.Signature = nil
+	_, err = service.PublishKeys(ctx, chat.ID, claimerID, uuid.New(), unsigned)
+	assert.Equal(t, ErrInvalidKeys, err)
+	_, err = service.PublishKeys(ctx, chat.ID, claimerID, uuid.New(), KeyUpload{
+		IdentityKey:    upload.IdentityKey,
+		SignedPrekey:   upload.SignedPrekey,
+		OneTimePrekeys: make([]domain.Prekey, maxPrekeysPerUpload+1),
+	})
+	assert.Equal(t, ErrTooManyPrekeys, err)
+
+	ownerPhone, ownerTablet, claimerPhone := uuid.New(), uuid.New(), uuid.New()
+	devices := map[uuid.UUID]uuid.UUID{ownerPhone: ownerID, ownerTablet: ownerID, claimerPhone: claimerID}
+	for deviceID, userID := range devices {
+		keyRepo.bundles[chatDevice{chat.ID, deviceID}] = &domain.KeyBundle{DeviceID: deviceID, UserID: userID}
+	}
+	keyRepo.bundles[chatDevice{otherChat.ID, claimerPhone}] = &domain.KeyBundle{DeviceID: claimerPhone, UserID: claimerID}
+	withPrekey := upload
+	withPrekey.OneTimePrekeys = []domain.Prekey{{KeyID: 7, PublicKey: []byte("one-time")}}
+	status, err := service.PublishKeys(ctx, chat.ID, claimerID, claimerPhone, withPrekey)
+	assert.NoError(t, err)
+	claimerAlias := aliasSvc.DeviceAlias(chat.ID, claimerPhone)
+	assert.Equal(t, &domain.KeyStatus{DeviceID: claimerAlias, Published: true, OneTimePrekeys: 1}, status)
+
+	// The same device goes by another ID in the claimer's other chat, so
+	// the two cannot be linked
+	status, err = service.GetKeyStatus(ctx, otherChat.ID, claimerID, claimerPhone)
+	assert.NoError(t, err)
+	assert.NotEqual(t, claimerAlias, status.DeviceID)
+	assert.NotEqual(t, claimerPhone, status.DeviceID)
+	assert.NotEqual(t, claimerPhone, claimerAlias)
+
+	// Outsiders get nothing
+	_, err = service.TakeChatKeys(ctx, chat.ID, uuid.New(), uuid.New())
+	assert.Equal(t, ErrUnauthorized, err)
+	_, err = service.PublishKeys(ctx, chat.ID, uuid.New(), uuid.New(), upload)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	// The owner gets the claimer's device and their own other one
+	bundles, err := service.TakeChatKeys(ctx, chat.ID, ownerID, ownerPhone)
+	assert.NoError(t, err)
+	assert.Len(t, bundles, 2)
+	for _, bundle := range bundles {
+		assert.NotEqual(t, aliasSvc.DeviceAlias(chat.ID, ownerPhone), bundle.DeviceID)
+		assert.Equal(t, bundle.DeviceID == aliasSvc.DeviceAlias(chat.ID, ownerTablet), bundle.Mine)
+		if bundle.DeviceID == claimerAlias {
+			assert.Equal(t, &domain.Prekey{KeyID: 7, PublicKey: []byte("one-time")}, bundle.OneTimePrekey)
+		}
+	}
+
+	// The one-time prekey is used up, leaving the signed prekey to fall
+	// back to
+	bundles, err = service.TakeChatKeys(ctx, chat.ID, ownerID, ownerPhone)
+	assert.NoError(t, err)
+	assert.Len(t, bundles, 2)
+	for _, bundle := range bundles {
+		assert.Nil(t, bundle.OneTimePrekey)
+		if bundle.DeviceID == claimerAlias {
+			assert.Equal(t, upload.SignedPrekey, bundle.SignedPrekey)
+		}
+	}
+	status, _ = service.GetKeyStatus(ctx, chat.ID, claimerID, claimerPhone)
+	assert.Equal(t, 0, status.OneTimePrekeys)
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/repository"
+)
+
+var (
+	ErrReportTargetNotFound    = errors.New("report target not found")
+	ErrInvalidReport           = errors.New("invalid report")
+	ErrCannotReportSelf        = errors.New("cannot report your own content")
+	ErrInvalidModerationAction = errors.New("invalid moderation action")
+	ErrReportNoteTooLong       = errors.New("report note is too long")
+)
+
+const defaultHideThreshold = 3
+
+type ModerationConfig struct {
+	// HideThreshold is how many open reports from different users hide a
+	// task, claim or message until a moderator reviews it.
+	HideThreshold int
+}
+
+type ReportInput struct {
+	TargetType domain.ReportTargetType
+	TargetID   uuid.UUID
+	// TaskID and Alias identify a reported user, since users only ever see
+	// each other's per-task aliases.
+	TaskID   uuid.UUID
+	Alias    string
+	Category domain.ReportCategory
+	Note     string
+	// MessageKey is the key of a reported encrypted message, which the
+	// reporter's app opened from its envelope. Handing it over is the only
+	// way moderators get to read the message.
+	MessageKey []byte
+}
+
+type ModerationActionInput struct {
+	TargetType   domain.ReportTargetType
+	TargetID     uuid.UUID
+	Action       domain.ModerationActionType
+	Reason       string
+	SuspendUntil *time.Time
+	Moderator    string
+}
+
+type ModerationService interface {
+	Report(ctx context.Context, reporterID uuid.UUID, input ReportInput) (*domain.Report, error)
+	GetQueue(ctx context.Context, status domain.ReportStatus, limit, offset int) ([]*domain.ModerationQueueItem, error)
+	GetReports(ctx context.Context, targetType domain.ReportTargetType, targetID uuid.UUID) ([]*domain.Report, error)
+	TakeAction(ctx context.Context, input ModerationActionInput) (*domain.ModerationAction, error)
+	GetMessage(ctx context.Context, messageID uuid.UUID) (*domain.Message, []*domain.MessageEdit, error)
+}
+
+type moderationService struct {
+	moderationRepo repository.ModerationRepository
+	taskRepo       repository.TaskRepository
+	claimRepo      repository.ClaimRepository
+	chatRepo       repository.ChatRepository
+	userRepo       repository.UserRepository
+	taskSvc        TaskService
+	suspensionSvc  SuspensionService
+	aliasSvc       AliasService
+	config         ModerationConfig
+}
+
+func NewModerationService(
+	moderationRepo repository.ModerationRepository,
+	taskRepo repository.TaskRepository,
+	claimRepo repository.ClaimRepository,
+	chatRepo repository.ChatRepository,
+	userRepo repository.UserRepository,
+	taskSvc TaskService,
+	suspensionSvc SuspensionService,
+	aliasSvc AliasService,
+	config ModerationConfig,
+) ModerationService {
+	if config.HideThreshold <= 0 {
+		config.HideThreshold = defaultHideThreshold
+	}
+	return &moderationService{
+		moderationRepo: moderationRepo,
+		taskRepo:       taskRepo,
+		claimRepo:      claimRepo,
+		chatRepo:       chatRepo,
+		userRepo:       userRepo,
+		taskSvc:        taskSvc,
+		suspensionSvc:  suspensionSvc,
+		aliasSvc:       aliasSvc,
+		config:         config,
+	}
+}
+
+// Report files a report against something the reporter can see. Once enough
+// different users have reported the same content it is hidden pending review.
+func (s *moderationService) Report(ctx context.Context, reporterID uuid.UUID, input ReportInput) (*domain.Report, error) {
+	if !input.Category.IsValid() {
+		return nil, ErrInvalidReport
+	}
+	if len([]rune(input.Note)) > domain.MaxReportNoteLength {
+		return nil, ErrReportNoteTooLong
+	}
+
+	targetID, authorID, err := s.resolveReportTarget(ctx, reporterID, input)
+	if err != nil {
+		return nil, err
+	}
+	if authorID == reporterID {
+		return nil, ErrCannotReportSelf
+	}
+
+	report := &domain.Report{
+		ID:         uuid.New(),
+		ReporterID: &reporterID,
+		TargetType: input.TargetType,
+		TargetID:   targetID,
+		Category:   input.Category,
+		Note:       input.Note,
+	}
+	if input.MessageKey != nil {
+		report.DisclosedContent, err = s.disclose(ctx, input.TargetType, targetID, input.MessageKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = s.moderationRepo.CreateReport(ctx, report)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.moderationRepo.CountOpenReports(ctx, report.TargetType, report.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= s.config.HideThreshold {
+		err = s.moderationRepo.SetHidden(ctx, report.TargetType, report.TargetID, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// resolveReportTarget checks the reporter can see the target and returns its
+// ID together with the user responsible for it. Targets the reporter cannot
+// see are reported as not found.
+func (s *moderationService) resolveReportTarget(ctx context.Context, reporterID uuid.UUID, input ReportInput) (uuid.UUID, uuid.UUID, error) {
+	switch input.TargetType {
+	case domain.ReportTargetTask:
+		task, err := s.taskRepo.GetByID(ctx, input.TargetID)
+		if err != nil {
+			return uuid.Nil, uuid.Nil, notFoundOr(err, ErrReportTargetNotFound)
+		}
+		visible, err := canViewTask(ctx, s.claimRepo, reporterID, task)
+		if err != nil {
+			return uuid.Nil, uuid.Nil, err
+		}
+		if !visible {
+			return uuid.Nil, uuid.Nil, ErrReportTargetNotFound
+		}
+		return task.ID, task.OwnerID, nil
+
+	case domain.ReportTargetClaim:
+		claim, err := s.claimRepo.GetByID(ctx, input.TargetID)
+		if err != nil {
+			return uuid.Nil, uuid.Nil, notFoundOr(err, ErrReportTargetNotFound)
+		}
+		task, err := s.taskRepo.GetByID(ctx, claim.TaskID)
+		if err != nil {
+			return uuid.Nil, uuid.Nil, notFoundOr(err, ErrReportTargetNotFound)
+		}
+		if !can(reporterID, ActionViewClaim, ClaimResource{Claim: claim, Task: task}) {
+			return uuid.Nil, uuid.Nil, ErrReportTargetNotFound
+		}
+		return claim.ID, claim.ClaimerID, nil
+
+	case domain.ReportTargetMessage:
+		message, err := s.chatRepo.GetMessageByID(ctx, input.TargetID)
+		if err != nil {
+			return uuid.Nil, uuid.Nil, notFoundOr(err, ErrReportTargetNotFound)
+		}
+		chat, err := s.chatRepo.GetByID(ctx, message.ChatID)
+		if err != nil {
+			return uuid.Nil, uuid.Nil, notFoundOr(err, ErrReportTargetNotFound)
+		}
+		if !can(reporterID, ActionReadChat, chat) {
+			return uuid.Nil, uuid.Nil, ErrReportTargetNotFound
+		}
+		return message.ID, message.SenderID, nil
+
+	case domain.ReportTargetUser:
+		task, err := s.taskRepo.GetByID(ctx, input.TaskID)
+		if err != nil {
+			return uuid.Nil, uuid.Nil, notFoundOr(err, ErrReportTargetNotFound)
+		}
+		userID, err := s.aliasSvc.Resolve(ctx, input.TaskID, input.Alias)
+		if err != nil {
+			if err == ErrParticipantNotFound {
+				return uuid.Nil, uuid.Nil, ErrReportTargetNotFound
+			}
+			return uuid.Nil, uuid.Nil, err
+		}
+		if !can(reporterID, ActionViewParticipant, ParticipantResource{Task: task, UserID: userID}) {
+			return uuid.Nil, uuid.Nil, ErrReportTargetNotFound
+		}
+		return userID, userID, nil
+	}
+
+	return uuid.Nil, uuid.Nil, ErrInvalidReport
+}
+
+// disclose opens a reported encrypted message with the key the reporter
+// handed over. Since the key has to decrypt the stored ciphertext, a
+// reporter cannot put words in the sender's mouth.
+func (s *moderationService) disclose(ctx context.Context, targetType domain.ReportTargetType, messageID uuid.UUID, key []byte) (string, error) {
+	if targetType != domain.ReportTargetMessage {
+		return "", ErrInvalidDisclosure
+	}
+	message, err := s.chatRepo.GetMessageByID(ctx, messageID)
+	if err != nil {
+		return "", notFoundOr(err, ErrReportTargetNotFound)
+	}
+	if !message.Encrypted {
+		return "", ErrInvalidDisclosure
+	}
+	return openMessage(message.ChatID, message.Ciphertext, key)
+}
+
+func (s *moderationService) GetQueue(ctx context.Context, status domain.ReportStatus, limit, offset int) ([]*domain.ModerationQueueItem, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if status == "" {
+		status = domain.ReportStatusOpen
+	}
+	return s.moderationRepo.GetQueue(ctx, status, limit, offset)
+}
+
+func (s *moderationService) GetReports(ctx context.Context, targetType domain.ReportTargetType, targetID uuid.UUID) ([]*domain.Report, error) {
+	return s.moderationRepo.GetReportsByTarget(ctx, targetType, targetID)
+}
+
+// GetMessage shows moderators a message as it was sent, even if it has been
+// unsent or hidden since, with its attachments and its earlier versions if it
+// was edited.
+func (s *moderationService) GetMessage(ctx context.Context, messageID uuid.UUID) (*domain.Message, []*domain.MessageEdit, error) {
+	message, err := s.chatRepo.GetMessageByID(ctx, messageID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, ErrReportTargetNotFound
+		}
+		return nil, nil, err
+	}
+
+	message.Attachments, err = s.chatRepo.GetAttachments(ctx, []uuid.UUID{messageID})
+	if err != nil {
+		return nil, nil, err
+	}
+	edits, err := s.chatRepo.GetMessageEdits(ctx, messageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return message, edits, nil
+}
+
+// TakeAction applies a moderator's decision to a target, closes its open
+// reports and records the decision.
+func (s *moderationService) TakeAction(ctx context.Context, input ModerationActionInput) (*domain.ModerationAction, error) {
+	var err error
+	switch input.Action {
+	case domain.ModerationHide:
+		if input.TargetType == domain.ReportTargetUser {
+			return nil, ErrInvalidModerationAction
+		}
+		err = s.moderationRepo.SetHidden(ctx, input.TargetType, input.TargetID, true)
+		if err != nil {
+			return nil, err
+		}
+		if input.TargetType == domain.ReportTargetTask {
+			err = s.taskSvc.ForceCancelTask(ctx, input.TargetID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		err = s.moderationRepo.ResolveReports(ctx, input.TargetType, input.TargetID, domain.ReportStatusActioned)
+
+	case domain.ModerationSuspendUser:
+		var userID uuid.UUID
+		userID, err = s.responsibleUser(ctx, input.TargetType, input.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		err = s.suspensionSvc.Suspend(ctx, userID, input.SuspendUntil, input.Reason)
+		if err != nil {
+			return nil, err
+		}
+		err = s.moderationRepo.ResolveReports(ctx, input.TargetType, input.TargetID, domain.ReportStatusActioned)
+
+	case domain.ModerationUnsuspendUser:
+		var userID uuid.UUID
+		userID, err = s.responsibleUser(ctx, input.TargetType, input.TargetID)
+		if err != nil {
+			return nil, err
+		}
+		err = s.suspensionSvc.Unsuspend(ctx, userID)
+
+	case domain.ModerationDismiss:
+		err = s.moderationRepo.SetHidden(ctx, input.TargetType, input.TargetID, false)
+		if err != nil {
+			return nil, err
+		}
+		err = s.moderationRepo.ResolveReports(ctx, input.TargetType, input.TargetID, domain.ReportStatusDismissed)
+
+	default:
+		return nil, ErrInvalidModerationAction
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	action := &domain.ModerationAction{
+		ID:         uuid.New(),
+		TargetType: input.TargetType,
+		TargetID:   input.TargetID,
+		Action:     input.Action,
+		Reason:     input.Reason,
+		Moderator:  input.Moderator,
+	}
+	err = s.moderationRepo.CreateAction(ctx, action)
+	if err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// responsibleUser returns the user a target belongs to: the user itself, the
+// task owner, the claimer or the message sender.
+func (s *moderationService) responsibleUser(ctx context.Context, targetType domain.ReportTargetType, targetID uuid.UUID) (uuid.UUID, error) {
+	switch targetType {
+	case domain.ReportTargetUser:
+		if _, err := s.userRepo.GetByID(ctx, targetID); err != nil {
+			return uuid.Nil, notFoundOr(err, ErrReportTargetNotFound)
+		}
+		return targetID, nil
+	case domain.ReportTargetTask:
+		task, err := s.taskRepo.GetByID(ctx, targetID)
+		if err != nil {
+			return uuid.Nil, notFoundOr(err, ErrReportTargetNotFound)
+		}
+		return task.OwnerID, nil
+	case domain.ReportTargetClaim:
+		claim, err := s.claimRepo.GetByID(ctx, targetID)
+		if err != nil {
+			return uuid.Nil, notFoundOr(err, ErrReportTargetNotFound)
+		}
+		return claim.ClaimerID, nil
+	case domain.ReportTargetMessage:
+		message, err := s.chatRepo.GetMessageByID(ctx, targetID)
+		if err != nil {
+			return uuid.Nil, notFoundOr(err, ErrReportTargetNotFound)
+		}
+		return message.SenderID, nil
+	}
+	return uuid.Nil, ErrInvalidModerationAction
+}
+
+// notFoundOr maps sql.ErrNoRows to notFound and passes other errors through.
+func notFoundOr(err, notFound error) error {
+	if err == sql.ErrNoRows {
+		return notFound
+	}
+	return err
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type mockModerationRepo struct {
+	reports []*domain.Report
+	hidden  map[uuid.UUID]bool
+	actions []*domain.ModerationAction
+}
+
+func newMockModerationRepo() *mockModerationRepo {
+	return &mockModerationRepo{hidden: make(map[uuid.UUID]bool)}
+}
+
+func (m *mockModerationRepo) CreateReport(ctx context.Context, report *domain.Report) error {
+	for _, existing := range m.reports {
+		if *existing.ReporterID == *report.ReporterID && existing.TargetType == report.TargetType && existing.TargetID == report.TargetID {
+			existing.Category = report.Category
+			existing.Note = report.Note
+			report.ID = existing.ID
+			report.Status = existing.Status
+			return nil
+		}
+	}
+	report.Status = domain.ReportStatusOpen
+	report.CreatedAt = time.Now()
+	m.reports = append(m.reports, report)
+	return nil
+}
+
+func (m *mockModerationRepo) CountOpenReports(ctx context.Context, targetType domain.ReportTargetType, targetID uuid.UUID) (int, error) {
+	count := 0
+	for _, report := range m.reports {
+		if report.TargetType == targetType && report.TargetID == targetID && report.Status == domain.ReportStatusOpen {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockModerationRepo) GetReportsByTarget(ctx context.Context, targetType domain.ReportTargetType, targetID uuid.UUID) ([]*domain.Report, error) {
+	var result []*domain.Report
+	for _, report := range m.reports {
+		if report.TargetType == targetType && report.TargetID == targetID {
+			result = append(result, report)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockModerationRepo) GetQueue(ctx context.Context, status domain.ReportStatus, limit, offset int) ([]*domain.ModerationQueueItem, error) {
+	return nil, nil
+}
+
+func (m *mockModerationRepo) ResolveReports(ctx context.Context, targetType domain.ReportTargetType, targetID uuid.UUID, status domain.ReportStatus) error {
+	for _, report := range m.reports {
+		if report.TargetType == targetType && report.TargetID == targetID && report.Status == domain.ReportStatusOpen {
+			report.Status = status
+		}
+	}
+	return nil
+}
+
+func (m *mockModerationRepo) SetHidden(ctx context.Context, targetType domain.ReportTargetType, targetID uuid.UUID, hidden bool) error {
+	m.hidden[targetID] = hidden
+	return nil
+}
+
+func (m *mockModerationRepo) CreateAction(ctx context.Context, action *domain.ModerationAction) error {
+	m.actions = append(m.actions, action)
+	return nil
+}
+
+func TestReportsAutoHideAndModeratorActions(t *testing.T) {
+	moderationRepo := newMockModerationRepo()
+	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
+	userRepo := &mockUserRepo{}
+	aliasSvc := NewAliasService(userRepo, taskRepo, claimRepo, []byte("test-secret"))
+	taskSvc := NewTaskService(taskRepo, claimRepo, &mockEscrowSvc{}, newMockBlockRepo(), nil)
+	claimSvc := NewClaimService(claimRepo, taskRepo, &mockChatRepoForClaimSvc{}, &mockEscrowSvc{}, userRepo, &mockReputationSvc{}, newMockBlockRepo(), nil, nil)
+	authSvc := NewAuthService(userRepo, newMockDeviceRepo(), newMockSessionRepo(), AuthConfig{Secret: []byte("test-secret")})
+	suspensionSvc := NewSuspensionService(userRepo, authSvc, taskSvc, claimSvc, nil)
+	service := NewModerationService(moderationRepo, taskRepo, claimRepo, &mockChatRepoForClaimSvc{}, userRepo, taskSvc, suspensionSvc, aliasSvc, ModerationConfig{
+		HideThreshold: 2,
+	})
+	ctx := context.Background()
+
+	ownerID := uuid.New()
+	task := &domain.Task{
+		ID:            uuid.New(),
+		OwnerID:       ownerID,
+		MaxClaimants:  1,
+		ClaimDeadline: time.Now().Add(24 * time.Hour),
+		OwnerDeadline: time.Now().Add(48 * time.Hour),
+		Status:        domain.TaskStatusOpen,
+		EscrowLocked:  true,
+	}
+	taskRepo.tasks[task.ID] = task
+
+	report := func(reporterID uuid.UUID) error {
+		_, err := service.Report(ctx, reporterID, ReportInput{
+			TargetType: domain.ReportTargetTask,
+			TargetID:   task.ID,
+			Category:   domain.ReportCategoryScam,
+		})
+		return err
+	}
+
+	// Owners cannot report their own task and unknown categories are refused
+	assert.Equal(t, ErrCannotReportSelf, report(ownerID))
+	_, err := service.Report(ctx, uuid.New(), ReportInput{TargetType: domain.ReportTargetTask, TargetID: task.ID, Category: "rude"})
+	assert.Equal(t, ErrInvalidReport, err)
+	_, err = service.Report(ctx, uuid.New(), ReportInput{TargetType: domain.ReportTargetTask, TargetID: uuid.New(), Category: domain.ReportCategorySpam})
+	assert.Equal(t, ErrReportTargetNotFound, err)
+
+	// Reporting twice counts once, a second reporter crosses the threshold
+	reporterID := uuid.New()
+	assert.NoError(t, report(reporterID))
+	assert.NoError(t, report(reporterID))
+	assert.False(t, moderationRepo.hidden[task.ID])
+	assert.NoError(t, report(uuid.New()))
+	assert.True(t, moderationRepo.hidden[task.ID])
+
+	// Dismissing restores the task and closes its reports
+	_, err = service.TakeAction(ctx, ModerationActionInput{
+		TargetType: domain.ReportTargetTask,
+		TargetID:   task.ID,
+		Action:     domain.ModerationDismiss,
+		Moderator:  "admin",
+	})
+	assert.NoError(t, err)
+	assert.False(t, moderationRepo.hidden[task.ID])
+	count, _ := moderationRepo.CountOpenReports(ctx, domain.ReportTargetTask, task.ID)
+	assert.Equal(t, 0, count)
+
+	// Hiding cancels the task
+	_, err = service.TakeAction(ctx, ModerationActionInput{
+		TargetType: domain.ReportTargetTask,
+		TargetID:   task.ID,
+		Action:     domain.ModerationHide,
+		Moderator:  "admin",
+	})
+	assert.NoError(t, err)
+	assert.True(t, moderationRepo.hidden[task.ID])
+	assert.Equal(t, domain.TaskStatusCancelled, task.Status)
+
+	// Suspending via the task suspends its owner
+	action, err := service.TakeAction(ctx, ModerationActionInput{
+		TargetType: domain.ReportTargetTask,
+		TargetID:   task.ID,
+		Action:     domain.ModerationSuspendUser,
+		Reason:     "repeat scams",
+		Moderator:  "admin",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "repeat scams", userRepo.suspended[ownerID].SuspensionReason)
+	assert.Len(t, moderationRepo.actions, 3)
+	assert.Equal(t, moderationRepo.actions[2], action)
+
+	_, err = service.TakeAction(ctx, ModerationActionInput{TargetType: domain.ReportTargetUser, TargetID: ownerID, Action: domain.ModerationHide})
+	assert.Equal(t, ErrInvalidModerationAction, err)
+}
+
+func TestReportsOnlyReachVisibleTargets(t *testing.T) {
+	ownerID, claimerID, strangerID := uuid.New(), uuid.New(), uuid.New()
+	task := &domain.Task{ID: uuid.New(), OwnerID: ownerID, Status: domain.TaskStatusClaimed}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: map[uuid.UUID]*domain.Task{task.ID: task}}
+	claim := &domain.Claim{ID: uuid.New(), TaskID: task.ID, ClaimerID: claimerID, Status: domain.ClaimStatusPending}
+	claimRepo := &mockClaimRepoForClaimSvc{claims: map[uuid.UUID]*domain.Claim{claim.ID: claim}}
+	service := NewModerationService(newMockModerationRepo(), taskRepo, claimRepo, &mockChatRepoForClaimSvc{}, &mockUserRepo{}, nil, nil, nil, ModerationConfig{HideThreshold: 3})
+	ctx := context.Background()
+
+	report := func(reporterID uuid.UUID, targetType domain.ReportTargetType, targetID uuid.UUID) error {
+		_, err := service.Report(ctx, reporterID, ReportInput{TargetType: targetType, TargetID: targetID, Category: domain.ReportCategorySpam})
+		return err
+	}
+
+	// A task that has left the feed is only reported by those who still see it
+	assert.NoError(t, report(claimerID, domain.ReportTargetTask, task.ID))
+	assert.Equal(t, ErrReportTargetNotFound, report(strangerID, domain.ReportTargetTask, task.ID))
+	assert.NoError(t, report(ownerID, domain.ReportTargetClaim, claim.ID))
+	assert.Equal(t, ErrReportTargetNotFound, report(strangerID, domain.ReportTargetClaim, claim.ID))
+}
+
+// mockMessageRepo serves the messages of the chats it holds.
+type mockMessageRepo struct {
+	mockChatRepoForClaimSvc
+	messages map[uuid.UUID]*domain.Message
+}
+
+func (m *mockMessageRepo) GetMessageByID(ctx context.Context, id uuid.UUID) (*domain.Message, error) {
+	if message, ok := m.messages[id]; ok {
+		return message, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func sealMessage(t *testing.T, chatID uuid.UUID, content string) (ciphertext, key []byte) {
+	key = make([]byte, messageKeySize)
+	nonce := make([]byte, messageNonceSize)
+	_, err := rand.Read(key)
+	assert.NoError(t, err)
+	_, err = rand.Read(nonce)
+	assert.NoError(t, err)
+	block, err := aes.NewCipher(key)
+	assert.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	assert.NoError(t, err)
+	return gcm.Seal(nonce, nonce, []byte(content), chatID[:]), key
+}
+
+func TestReportsDiscloseEncryptedMessagesWithTheirKey(t *testing.T) {
+	senderID, reporterID := uuid.New(), uuid.New()
+	chat := &domain.Chat{ID: uuid.New(), TaskID: uuid.New(), ParticipantID: reporterID, OtherParticipantID: senderID}
+	ciphertext, key := sealMessage(t, chat.ID, "send the deposit off-app")
+	encrypted := &domain.Message{ID: uuid.New(), ChatID: chat.ID, SenderID: senderID, Encrypted: true, Ciphertext: ciphertext}
+	plain := &domain.Message{ID: uuid.New(), ChatID: chat.ID, SenderID: senderID, Content: "hello"}
+	chatRepo := &mockMessageRepo{
+		mockChatRepoForClaimSvc: mockChatRepoForClaimSvc{chats: map[uuid.UUID]*domain.Chat{chat.ID: chat}},
+		messages:                map[uuid.UUID]*domain.Message{encrypted.ID: encrypted, plain.ID: plain},
+	}
+	task := &domain.Task{ID: chat.TaskID, OwnerID: senderID, Status: domain.TaskStatusClaimed}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: map[uuid.UUID]*domain.Task{task.ID: task}}
+	claim := &domain.Claim{ID: uuid.New(), TaskID: task.ID, ClaimerID: reporterID, Status: domain.ClaimStatusPending}
+	claimRepo := &mockClaimRepoForClaimSvc{claims: map[uuid.UUID]*domain.Claim{claim.ID: claim}}
+	moderationRepo := newMockModerationRepo()
+	service := NewModerationService(moderationRepo, taskRepo, claimRepo, chatRepo, &mockUserRepo{}, nil, nil, nil, ModerationConfig{HideThreshold: 3})
+	ctx := context.Background()
+
+	report := func(messageID uuid.UUID, key []byte) (*domain.Report, error) {
+		return service.Report(ctx, reporterID, ReportInput{
+			TargetType: domain.ReportTargetMessage,
+			TargetID:   messageID,
+			Category:   domain.ReportCategoryScam,
+			MessageKey: key,
+		})
+	}
+
+	// A key that does not open the message discloses nothing
+	wrongKey := make([]byte, messageKeySize)
+	_, err := report(encrypted.ID, wrongKey)
+	assert.Equal(t, ErrInvalidDisclosure, err)
+	_, err = report(encrypted.ID, key[:16])
+	assert.Equal(t, ErrInvalidDisclosure, err)
+
+	// Plaintext messages and other targets have nothing to disclose
+	_, err = report(plain.ID, key)
+	assert.Equal(t, ErrInvalidDisclosure, err)
+	_, err = service.Report(ctx, reporterID, ReportInput{
+		TargetType: domain.ReportTargetTask,
+		TargetID:   task.ID,
+		Category:   domain.ReportCategoryScam,
+		MessageKey: key,
+	})
+	assert.Equal(t, ErrInvalidDisclosure, err)
+	assert.Empty(t, moderationRepo.reports)
+
+	// The right key puts the content in front of moderators
+	created, err := report(encrypted.ID, key)
+	assert.NoError(t, err)
+	assert.Equal(t, "send the deposit off-app", created.DisclosedContent)
+	assert.Len(t, moderationRepo.reports, 1)
+}
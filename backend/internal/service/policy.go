@@ -0,0 +1,97 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+// Action is something a user asks to do to a resource.
+type Action string
+
+const (
+	ActionPostTask        Action = "task.post"
+	ActionViewTask        Action = "task.view"
+	ActionClaimTask       Action = "task.claim"
+	ActionListChats       Action = "task.chats"
+	ActionViewClaim       Action = "claim.view"
+	ActionSubmitClaim     Action = "claim.submit"
+	ActionDecideClaim     Action = "claim.decide"
+	ActionWithdrawClaim   Action = "claim.withdraw"
+	ActionDisputeClaim    Action = "claim.dispute"
+	ActionReviewClaim     Action = "claim.review"
+	ActionArbitrateClaim  Action = "claim.arbitrate"
+	ActionOpenChat        Action = "chat.open"
+	ActionReadChat        Action = "chat.read"
+	ActionSendMessage     Action = "chat.send"
+	ActionDeleteChat      Action = "chat.delete"
+	ActionEditMessage     Action = "message.edit"
+	ActionUnsendMessage   Action = "message.unsend"
+	ActionViewParticipant Action = "participant.view"
+)
+
+// ClaimResource is a claim together with its task, since most claim rules
+// depend on who owns the task.
+type ClaimResource struct {
+	Claim *domain.Claim
+	Task  *domain.Task
+}
+
+// ParticipantResource is a user as they appear on a task, behind their
+// alias.
+type ParticipantResource struct {
+	Task   *domain.Task
+	UserID uuid.UUID
+}
+
+// can is the one place that decides whether userID may perform action on
+// resource. Services consult it before doing anything on a user's behalf;
+// whether the resource is in a state that allows the action is checked
+// separately. Unknown actions and resources are refused.
+func can(userID uuid.UUID, action Action, resource interface{}) bool {
+	switch r := resource.(type) {
+	case *domain.Task:
+		isOwner := r.OwnerID == userID
+		switch action {
+		case ActionPostTask, ActionListChats:
+			return isOwner
+		case ActionViewTask:
+			return isOwner || (r.Status == domain.TaskStatusOpen && r.HiddenAt == nil)
+		case ActionClaimTask:
+			return !isOwner
+		}
+	case ClaimResource:
+		isOwner := r.Task.OwnerID == userID
+		isClaimer := r.Claim.ClaimerID == userID
+		switch action {
+		case ActionViewClaim, ActionReviewClaim, ActionOpenChat, ActionListChats:
+			return isOwner || isClaimer
+		case ActionSubmitClaim, ActionWithdrawClaim, ActionDisputeClaim:
+			return isClaimer
+		case ActionDecideClaim:
+			return isOwner
+		case ActionArbitrateClaim:
+			// Arbitrators must have no part in the dispute
+			return !isOwner && !isClaimer
+		case ActionViewTask:
+			return isOwner || (isClaimer && r.Task.HiddenAt == nil)
+		}
+	case *domain.Chat:
+		isParticipant := r.ParticipantID == userID || r.OtherParticipantID == userID
+		switch action {
+		case ActionReadChat, ActionSendMessage, ActionDeleteChat:
+			return isParticipant
+		}
+	case *domain.Message:
+		switch action {
+		case ActionEditMessage, ActionUnsendMessage:
+			return r.SenderID == userID
+		}
+	case ParticipantResource:
+		// The owner deals with every claimer, claimers only with the owner
+		switch action {
+		case ActionViewParticipant:
+			return r.Task.OwnerID == userID || r.UserID == r.Task.OwnerID || r.UserID == userID
+		}
+	}
+	return false
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+// callerScoped are the authenticated routes that only touch the caller's own
+// account, so there is no resource for the policy to check.
+var callerScoped = map[string]string{
+	"POST /api/v1/auth/logout":        "ends the caller's session",
+	"GET /api/v1/me":                  "the caller's profile",
+	"GET /api/v1/me/export":           "the caller's data",
+	"DELETE /api/v1/me":               "erases the caller",
+	"GET /api/v1/me/blocks":           "the caller's blocks",
+	"DELETE /api/v1/me/blocks/:id":    "removes one of the caller's blocks",
+	"GET /api/v1/me/devices":          "the caller's devices",
+	"DELETE /api/v1/me/devices/:id":   "revokes one of the caller's devices",
+	"POST /api/v1/me/devices/pairing": "pairs a device to the caller",
+	"POST /api/v1/me/recovery":        "the caller's recovery code",
+	"DELETE /api/v1/me/recovery":      "the caller's recovery code",
+	"GET /api/v1/me/unread":           "counts the caller's own chats",
+}
+
+// registeredRoutes lists the authenticated routes cmd/server registers on its
+// api group, as "METHOD /path".
+func registeredRoutes(t *testing.T) []string {
+	file, err := parser.ParseFile(token.NewFileSet(), "../../cmd/server/main.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var routes []string
+	ast.Inspect(file, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		selector, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		group, ok := selector.X.(*ast.Ident)
+		if !ok || group.Name != "api" {
+			return true
+		}
+		switch selector.Sel.Name {
+		case "GET", "POST", "PUT", "PATCH", "DELETE":
+		default:
+			return true
+		}
+		literal, ok := call.Args[0].(*ast.BasicLit)
+		if !ok {
+			return true
+		}
+		path, err := strconv.Unquote(literal.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		routes = append(routes, selector.Sel.Name+" /api/v1"+path)
+		return true
+	})
+	return routes
+}
+
+func TestPolicyCoversEveryRoute(t *testing.T) {
+	ownerID := uuid.New()
+	claimerID := uuid.New()
+	strangerID := uuid.New()
+
+	task := &domain.Task{ID: uuid.New(), OwnerID: ownerID, Status: domain.TaskStatusClaimed}
+	open := &domain.Task{ID: uuid.New(), OwnerID: ownerID, Status: domain.TaskStatusOpen}
+	hiddenAt := time.Now()
+	hidden := &domain.Task{ID: uuid.New(), OwnerID: ownerID, Status: domain.TaskStatusOpen, HiddenAt: &hiddenAt}
+	claim := ClaimResource{
+		Claim: &domain.Claim{ID: uuid.New(), TaskID: task.ID, ClaimerID: claimerID},
+		Task:  task,
+	}
+	hiddenClaim := ClaimResource{
+		Claim: &domain.Claim{ID: uuid.New(), TaskID: hidden.ID, ClaimerID: claimerID},
+		Task:  hidden,
+	}
+	chat := &domain.Chat{ID: uuid.New(), TaskID: task.ID, ParticipantID: claimerID, OtherParticipantID: ownerID}
+	message := &domain.Message{ID: uuid.New(), ChatID: chat.ID, SenderID: claimerID}
+	claimer := ParticipantResource{Task: task, UserID: claimerID}
+	owner := ParticipantResource{Task: task, UserID: ownerID}
+
+	tests := []struct {
+		route    string
+		action   Action
+		resource interface{}
+		owner    bool
+		claimer  bool
+		stranger bool
+	}{
+		{"POST /api/v1/tasks", ActionPostTask, task, true, false, false},
+		{"GET /api/v1/tasks", ActionViewTask, open, true, true, true},
+		{"GET /api/v1/tasks hidden", ActionViewTask, hidden, true, false, false},
+		{"GET /api/v1/tasks/my", ActionViewTask, task, true, false, false},
+		{"GET /api/v1/task/:id", ActionViewTask, task, true, false, false},
+		{"GET /api/v1/task/:id as a claimer", ActionViewTask, claim, true, true, false},
+		{"GET /api/v1/task/:id hidden, as a claimer", ActionViewTask, hiddenClaim, true, false, false},
+		{"POST /api/v1/tasks/:tid/claims", ActionClaimTask, task, false, true, true},
+		{"GET /api/v1/claims/:id", ActionViewClaim, claim, true, true, false},
+		{"GET /api/v1/tasks/:tid/claims", ActionViewClaim, claim, true, true, false},
+		{"POST /api/v1/claims/:id/submit", ActionSubmitClaim, claim, false, true, false},
+		{"POST /api/v1/claims/:id/accept", ActionDecideClaim, claim, true, false, false},
+		{"POST /api/v1/claims/:id/approve", ActionDecideClaim, claim, true, false, false},
+		{"POST /api/v1/claims/:id/reject", ActionDecideClaim, claim, true, false, false},
+		{"POST /api/v1/claims/:id/withdraw", ActionWithdrawClaim, claim, false, true, false},
+		{"POST /api/v1/claims/:id/dispute", ActionDisputeClaim, claim, false, true, false},
+		{"GET /api/v1/disputes", ActionArbitrateClaim, claim, false, false, true},
+		{"POST /api/v1/disputes/:id/resolve", ActionArbitrateClaim, claim, false, false, true},
+		{"GET /api/v1/claims/:id/reviews", ActionReviewClaim, claim, true, true, false},
+		{"POST /api/v1/claims/:id/reviews", ActionReviewClaim, claim, true, true, false},
+		{"POST /api/v1/tasks/:tid/chats", ActionOpenChat, claim, true, true, false},
+		{"GET /api/v1/tasks/:tid/chats", ActionListChats, claim, true, true, false},
+		{"GET /api/v1/tasks/:tid/chats without a claim", ActionListChats, task, true, false, false},
+		{"GET /api/v1/tasks/:tid/chats on an open task", ActionListChats, open, true, false, false},
+		{"GET /api/v1/chats", ActionReadChat, chat, true, true, false},
+		{"DELETE /api/v1/chats/:id", ActionDeleteChat, chat, true, true, false},
+		{"PUT /api/v1/chats/:id/timer", ActionSendMessage, chat, true, true, false},
+		{"GET /api/v1/chats/:id/messages", ActionReadChat, chat, true, true, false},
+		{"POST /api/v1/chats/:id/messages", ActionSendMessage, chat, true, true, false},
+		{"PATCH /api/v1/chats/:id/messages/:mid", ActionEditMessage, message, false, true, false},
+		{"DELETE /api/v1/chats/:id/messages/:mid", ActionUnsendMessage, message, false, true, false},
+		{"GET /api/v1/chats/:id/attachments/:aid", ActionReadChat, chat, true, true, false},
+		{"POST /api/v1/chats/:id/read", ActionReadChat, chat, true, true, false},
+		{"GET /api/v1/chats/:id/keys/mine", ActionReadChat, chat, true, true, false},
+		{"PUT /api/v1/chats/:id/keys/mine", ActionReadChat, chat, true, true, false},
+		{"POST /api/v1/chats/:id/keys", ActionReadChat, chat, true, true, false},
+		{"GET /api/v1/tasks/:tid/participants/:alias", ActionViewParticipant, claimer, true, true, false},
+		{"GET /api/v1/tasks/:tid/participants/:alias of the owner", ActionViewParticipant, owner, true, true, true},
+		{"POST /api/v1/tasks/:tid/participants/:alias/block", ActionViewParticipant, claimer, true, true, false},
+		{"POST /api/v1/reports on a task", ActionViewTask, task, true, false, false},
+		{"POST /api/v1/reports on a task, as a claimer", ActionViewTask, claim, true, true, false},
+		{"POST /api/v1/reports on a claim", ActionViewClaim, claim, true, true, false},
+		{"POST /api/v1/reports on a message", ActionReadChat, chat, true, true, false},
+		{"POST /api/v1/reports on a user", ActionViewParticipant, claimer, true, true, false},
+		{"unknown action", Action("claim.delete"), claim, false, false, false},
+		{"unknown resource", ActionViewClaim, task, false, false, false},
+	}
+
+	covered := make(map[string]bool)
+	for _, tt := range tests {
+		fields := strings.Fields(tt.route)
+		if len(fields) >= 2 {
+			covered[fields[0]+" "+fields[1]] = true
+		}
+		t.Run(tt.route, func(t *testing.T) {
+			assert.Equal(t, tt.owner, can(ownerID, tt.action, tt.resource), "owner")
+			assert.Equal(t, tt.claimer, can(claimerID, tt.action, tt.resource), "claimer")
+			assert.Equal(t, tt.stranger, can(strangerID, tt.action, tt.resource), "stranger")
+		})
+	}
+
+	// Every route the server registers is either in the table or only
+	// touches the caller's own account
+	routes := registeredRoutes(t)
+	assert.NotEmpty(t, routes)
+	for _, route := range routes {
+		_, scoped := callerScoped[route]
+		assert.True(t, covered[route] || scoped, "no policy entry for %s", route)
+		assert.False(t, covered[route] && scoped, "%s is both checked and caller-scoped", route)
+	}
+}
+
+func TestServicesEnforcePolicy(t *testing.T) {
+	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
+	chatRepo := &mockChatRepoForClaimSvc{chats: make(map[uuid.UUID]*domain.Chat)}
+	claimSvc := NewClaimService(claimRepo, taskRepo, chatRepo, &mockEscrowSvc{}, &mockUserRepo{}, &mockReputationSvc{}, newMockBlockRepo(), nil, nil)
+	chatSvc := NewChatService(chatRepo, taskRepo, claimRepo, newMockBlockRepo(), nil, nil, nil, ChatConfig{})
+	ctx := context.Background()
+
+	ownerID := uuid.New()
+	claimerID := uuid.New()
+	otherClaimerID := uuid.New()
+	strangerID := uuid.New()
+
+	task := &domain.Task{
+		ID:            uuid.New(),
+		OwnerID:       ownerID,
+		MaxClaimants:  3,
+		ClaimDeadline: time.Now().Add(24 * time.Hour),
+		OwnerDeadline: time.Now().Add(48 * time.Hour),
+		Status:        domain.TaskStatusOpen,
+	}
+	taskRepo.tasks[task.ID] = task
+	otherTask := &domain.Task{ID: uuid.New(), OwnerID: uuid.New(), Status: domain.TaskStatusOpen}
+	taskRepo.tasks[otherTask.ID] = otherTask
+
+	claim, err := claimSvc.ClaimTask(ctx, task.ID, claimerID)
+	assert.NoError(t, err)
+	_, err = claimSvc.ClaimTask(ctx, task.ID, otherClaimerID)
+	assert.NoError(t, err)
+	foreignClaim := &domain.Claim{ID: uuid.New(), TaskID: otherTask.ID, ClaimerID: claimerID, Status: domain.ClaimStatusPending}
+	claimRepo.claims[foreignClaim.ID] = foreignClaim
+
+	// Only the owner and the claimer see a claim; claimers only see their own
+	_, err = claimSvc.GetClaim(ctx, claim.ID, ownerID)
+	assert.NoError(t, err)
+	_, err = claimSvc.GetClaim(ctx, claim.ID, claimerID)
+	assert.NoError(t, err)
+	_, err = claimSvc.GetClaim(ctx, claim.ID, strangerID)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	claims, err := claimSvc.GetClaimsByTaskID(ctx, task.ID, ownerID)
+	assert.NoError(t, err)
+	assert.Len(t, claims, 2)
+	claims, err = claimSvc.GetClaimsByTaskID(ctx, task.ID, claimerID)
+	assert.NoError(t, err)
+	assert.Len(t, claims, 1)
+	claims, err = claimSvc.GetClaimsByTaskID(ctx, task.ID, strangerID)
+	assert.NoError(t, err)
+	assert.Empty(t, claims)
+
+	// The owner cannot address a claim on someone else's task, and strangers
+	// cannot open a chat at all
+	_, err = chatSvc.GetOrCreateChat(ctx, task.ID, ownerID, foreignClaim.ID)
+	assert.Equal(t, ErrClaimNotFound, err)
+	_, err = chatSvc.GetOrCreateChat(ctx, task.ID, strangerID, uuid.Nil)
+	assert.Equal(t, ErrClaimNotFound, err)
+
+	chat, err := chatSvc.GetOrCreateChat(ctx, task.ID, ownerID, claim.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, claimerID, chat.Counterpart(ownerID))
+
+	// Chats on a task are only listed to its owner and claimers
+	_, err = chatSvc.GetChatsByTaskID(ctx, task.ID, ownerID)
+	assert.NoError(t, err)
+	_, err = chatSvc.GetChatsByTaskID(ctx, task.ID, claimerID)
+	assert.NoError(t, err)
+	_, err = chatSvc.GetChatsByTaskID(ctx, task.ID, strangerID)
+	assert.Equal(t, ErrUnauthorized, err)
+	_, err = chatSvc.GetChatsByTaskID(ctx, otherTask.ID, ownerID)
+	assert.Equal(t, ErrUnauthorized, err)
+
+	// Only participants read, write or delete the chat
+	_, err = chatSvc.GetMessages(ctx, chat.ID, claimerID, 50, 0)
+	assert.NoError(t, err)
+	_, err = chatSvc.GetMessages(ctx, chat.ID, otherClaimerID, 50, 0)
+	assert.Equal(t, ErrUnauthorized, err)
+	_, err = chatSvc.SendMessage(ctx, chat.ID, strangerID, "hello", nil)
+	assert.Equal(t, ErrUnauthorized, err)
+	assert.Equal(t, ErrUnauthorized, chatSvc.DeleteChat(ctx, chat.ID, strangerID))
+
+	// Claim actions are limited to the right side
+	_, err = claimSvc.SubmitCompletion(ctx, claim.ID, ownerID, "done", "")
+	assert.Equal(t, ErrUnauthorized, err)
+	assert.Equal(t, ErrUnauthorized, claimSvc.RejectClaim(ctx, claim.ID, claimerID))
+	assert.Equal(t, ErrUnauthorized, claimSvc.WithdrawClaim(ctx, claim.ID, ownerID))
+}
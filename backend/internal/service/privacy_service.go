@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/repository"
+	"github.com/task-underground/backend/internal/storage"
+)
+
+var (
+	ErrErasureEscrowLocked = errors.New("cannot erase account while escrow is locked on your tasks")
+	ErrErasureDisputeOpen  = errors.New("cannot erase account while a dispute is open")
+)
+
+type PrivacyService interface {
+	Export(ctx context.Context, userID uuid.UUID) (*domain.DataExport, error)
+	Erase(ctx context.Context, userID uuid.UUID) error
+}
+
+type privacyService struct {
+	privacyRepo  repository.PrivacyRepository
+	userRepo     repository.UserRepository
+	deviceRepo   repository.DeviceRepository
+	authSvc      AuthService
+	claimSvc     ClaimService
+	disconnector Disconnector
+	blobs        storage.BlobStore
+}
+
+func NewPrivacyService(
+	privacyRepo repository.PrivacyRepository,
+	userRepo repository.UserRepository,
+	deviceRepo repository.DeviceRepository,
+	authSvc AuthService,
+	claimSvc ClaimService,
+	disconnector Disconnector,
+	blobs storage.BlobStore,
+) PrivacyService {
+	return &privacyService{
+		privacyRepo:  privacyRepo,
+		userRepo:     userRepo,
+		deviceRepo:   deviceRepo,
+		authSvc:      authSvc,
+		claimSvc:     claimSvc,
+		disconnector: disconnector,
+		blobs:        blobs,
+	}
+}
+
+// Export collects everything stored about the user: profile, devices, posted
+// tasks, claims with their submissions, chats with both sides of the
+// conversation, escrow transactions and the reviews they wrote.
+func (s *privacyService) Export(ctx context.Context, userID uuid.UUID) (*domain.DataExport, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	export := &domain.DataExport{ExportedAt: time.Now(), User: user}
+
+	if export.Devices, err = s.deviceRepo.GetByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Tasks, err = s.privacyRepo.GetTasks(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Claims, err = s.privacyRepo.GetClaims(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.EscrowTransactions, err = s.privacyRepo.GetEscrowTransactions(ctx, userID); err != nil {
+		return nil, err
+	}
+	if export.Reviews, err = s.privacyRepo.GetReviews(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	chats, err := s.privacyRepo.GetChats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := s.privacyRepo.GetMessages(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byChat := make(map[uuid.UUID]*domain.ChatExport, len(chats))
+	export.Chats = make([]*domain.ChatExport, 0, len(chats))
+	for _, chat := range chats {
+		chatExport := &domain.ChatExport{Chat: chat, Messages: []*domain.ExportedMessage{}}
+		byChat[chat.ID] = chatExport
+		export.Chats = append(export.Chats, chatExport)
+	}
+	for _, message := range messages {
+		chatExport, ok := byChat[message.ChatID]
+		if !ok {
+			continue
+		}
+		chatExport.Messages = append(chatExport.Messages, &domain.ExportedMessage{
+			ID:        message.ID,
+			Content:   message.Content,
+			Mine:      message.SenderID == userID,
+			CreatedAt: message.CreatedAt,
+		})
+	}
+
+	return export, nil
+}
+
+// Erase anonymizes the account. It is refused while the user still has
+// money locked in escrow or a dispute open, since both need them to settle.
+// Pending claims are cancelled, personal content is redacted, and every
+// session and live connection is ended. Escrow transactions are kept, and
+// the counterpart keeps their chats with the user's messages redacted.
+func (s *privacyService) Erase(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if user.ErasedAt != nil {
+		return ErrUserNotFound
+	}
+
+	locked, err := s.privacyRepo.CountLockedEscrow(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if locked > 0 {
+		return ErrErasureEscrowLocked
+	}
+
+	disputes, err := s.privacyRepo.CountOpenDisputes(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if disputes > 0 {
+		return ErrErasureDisputeOpen
+	}
+
+	err = s.claimSvc.CancelClaimsByClaimer(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	attachmentKeys, err := s.privacyRepo.GetAttachmentKeys(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.privacyRepo.Erase(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// The rows are gone, so a file left behind here is unreachable
+	if s.blobs != nil {
+		for _, key := range attachmentKeys {
+			if err := s.blobs.Delete(ctx, key); err != nil {
+				log.Printf("Error deleting attachment %s of erased user: %v", key, err)
+			}
+		}
+	}
+
+	err = s.authSvc.RevokeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if s.disconnector != nil {
+		s.disconnector.DisconnectUser(userID)
+	}
+	return nil
+}
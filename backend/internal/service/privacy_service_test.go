@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type mockPrivacyRepo struct {
+	chats        []*domain.Chat
+	messages     []*domain.Message
+	lockedEscrow int
+	disputes     int
+	erased       []uuid.UUID
+}
+
+func (m *mockPrivacyRepo) GetTasks(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error) {
+	return nil, nil
+}
+
+func (m *mockPrivacyRepo) GetClaims(ctx context.Context, userID uuid.UUID) ([]*domain.Claim, error) {
+	return nil, nil
+}
+
+func (m *mockPrivacyRepo) GetChats(ctx context.Context, userID uuid.UUID) ([]*domain.Chat, error) {
+	return m.chats, nil
+}
+
+func (m *mockPrivacyRepo) GetMessages(ctx context.Context, userID uuid.UUID) ([]*domain.Message, error) {
+	return m.messages, nil
+}
+
+func (m *mockPrivacyRepo) GetEscrowTransactions(ctx context.Context, userID uuid.UUID) ([]*domain.EscrowTransaction, error) {
+	return nil, nil
+}
+
+func (m *mockPrivacyRepo) GetReviews(ctx context.Context, userID uuid.UUID) ([]*domain.Review, error) {
+	return nil, nil
+}
+
+func (m *mockPrivacyRepo) CountLockedEscrow(ctx context.Context, userID uuid.UUID) (int, error) {
+	return m.lockedEscrow, nil
+}
+
+func (m *mockPrivacyRepo) CountOpenDisputes(ctx context.Context, userID uuid.UUID) (int, error) {
+	return m.disputes, nil
+}
+
+func (m *mockPrivacyRepo) GetAttachmentKeys(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	return nil, nil
+}
+
+func (m *mockPrivacyRepo) Erase(ctx context.Context, userID uuid.UUID) error {
+	m.erased = append(m.erased, userID)
+	return nil
+}
+
+func TestExportGroupsMessagesByChat(t *testing.T) {
+	userID := uuid.New()
+	otherID := uuid.New()
+	chat := &domain.Chat{ID: uuid.New(), TaskID: uuid.New(), ParticipantID: userID, OtherParticipantID: otherID}
+	privacyRepo := &mockPrivacyRepo{
+		chats: []*domain.Chat{chat},
+		messages: []*domain.Message{
+			{ID: uuid.New(), ChatID: chat.ID, SenderID: userID, Content: "hi", CreatedAt: time.Now()},
+			{ID: uuid.New(), ChatID: chat.ID, SenderID: otherID, Content: "hello", CreatedAt: time.Now()},
+		},
+	}
+	service := NewPrivacyService(privacyRepo, &mockUserRepo{}, newMockDeviceRepo(), nil, nil, nil, nil)
+
+	export, err := service.Export(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, userID, export.User.ID)
+	assert.Len(t, export.Chats, 1)
+	assert.Len(t, export.Chats[0].Messages, 2)
+	assert.True(t, export.Chats[0].Messages[0].Mine)
+	assert.False(t, export.Chats[0].Messages[1].Mine)
+}
+
+func TestEraseRefusesUnsettledAccountsAndEndsAccess(t *testing.T) {
+	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
+	userRepo := &mockUserRepo{}
+	authSvc := NewAuthService(userRepo, newMockDeviceRepo(), newMockSessionRepo(), AuthConfig{Secret: []byte("test-secret")})
+	claimSvc := NewClaimService(claimRepo, taskRepo, &mockChatRepoForClaimSvc{}, &mockEscrowSvc{}, userRepo, &mockReputationSvc{}, newMockBlockRepo(), nil, nil)
+	privacyRepo := &mockPrivacyRepo{}
+	disconnector := &mockDisconnector{}
+	service := NewPrivacyService(privacyRepo, userRepo, newMockDeviceRepo(), authSvc, claimSvc, disconnector, nil)
+	ctx := context.Background()
+
+	tokens, err := authSvc.Handshake(ctx, "device-1", "", domain.AccountProof{})
+	assert.NoError(t, err)
+	claims, err := authSvc.ValidateAccessToken(tokens.AccessToken)
+	assert.NoError(t, err)
+	userID := claims.UserID
+
+	task := &domain.Task{ID: uuid.New(), OwnerID: uuid.New(), Status: domain.TaskStatusClaimed}
+	taskRepo.tasks[task.ID] = task
+	claim := &domain.Claim{ID: uuid.New(), TaskID: task.ID, ClaimerID: userID, Status: domain.ClaimStatusPending}
+	claimRepo.claims[claim.ID] = claim
+
+	// Money in escrow or an open dispute has to be settled first
+	privacyRepo.lockedEscrow = 1
+	assert.Equal(t, ErrErasureEscrowLocked, service.Erase(ctx, userID))
+	privacyRepo.lockedEscrow = 0
+	privacyRepo.disputes = 1
+	assert.Equal(t, ErrErasureDisputeOpen, service.Erase(ctx, userID))
+	assert.Empty(t, privacyRepo.erased)
+	assert.Equal(t, domain.ClaimStatusPending, claim.Status)
+
+	privacyRepo.disputes = 0
+	assert.NoError(t, service.Erase(ctx, userID))
+	assert.Equal(t, []uuid.UUID{userID}, privacyRepo.erased)
+	assert.Equal(t, domain.ClaimStatusCancelled, claim.Status)
+
+	_, err = authSvc.ValidateAccessToken(tokens.AccessToken)
+	assert.Equal(t, ErrTokenRevoked, err)
+	assert.Equal(t, []uuid.UUID{userID}, disconnector.disconnected)
+}
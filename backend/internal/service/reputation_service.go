@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/repository"
+)
+
+// reputationPoints is the base value of each event before it is weighted by
+// reward size and decayed by age.
+var reputationPoints = map[domain.ReputationEventKind]float64{
+	domain.ReputationClaimApproved:        1.0,
+	domain.ReputationClaimRejected:        -0.3,
+	domain.ReputationClaimWithdrawn:       -0.2,
+	domain.ReputationClaimAbandoned:       -0.6,
+	domain.ReputationRejectionOverturned:  0.3,
+	domain.ReputationTaskCompleted:        0.5,
+	domain.ReputationUnjustifiedRejection: -0.5,
+	domain.ReputationDisputeLost:          -1.0,
+}
+
+// reputationHalfLife is how long it takes an event to lose half its weight.
+const reputationHalfLife = 180 * 24 * time.Hour
+
+type ReputationService interface {
+	Record(ctx context.Context, event *domain.ReputationEvent) error
+	Recompute(ctx context.Context, userID uuid.UUID) error
+	RecomputeAll(ctx context.Context) (int, error)
+}
+
+type reputationService struct {
+	reputationRepo repository.ReputationRepository
+}
+
+func NewReputationService(reputationRepo repository.ReputationRepository) ReputationService {
+	return &reputationService{reputationRepo: reputationRepo}
+}
+
+// Record appends an event and refreshes the user's cached scores.
+func (s *reputationService) Record(ctx context.Context, event *domain.ReputationEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if err := s.reputationRepo.CreateEvent(ctx, event); err != nil {
+		return err
+	}
+	return s.Recompute(ctx, event.UserID)
+}
+
+func (s *reputationService) Recompute(ctx context.Context, userID uuid.UUID) error {
+	events, err := s.reputationRepo.GetEventsByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	worker, poster := ComputeReputation(events, time.Now())
+	return s.reputationRepo.UpdateScores(ctx, userID, worker, poster, int(math.Round(worker+poster)))
+}
+
+// RecomputeAll rebuilds every user's scores from their event history and
+// returns how many users were updated.
+func (s *reputationService) RecomputeAll(ctx context.Context) (int, error) {
+	userIDs, err := s.reputationRepo.GetUserIDsWithEvents(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, userID := range userIDs {
+		if err := s.Recompute(ctx, userID); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// ComputeReputation folds an event history into worker and poster scores.
+// Each event is worth its base points, scaled up logarithmically by the
+// reward at stake and halved every reputationHalfLife.
+func ComputeReputation(events []*domain.ReputationEvent, now time.Time) (worker, poster float64) {
+	for _, event := range events {
+		points, ok := reputationPoints[event.Kind]
+		if !ok {
+			continue
+		}
+
+		weight := 1 + math.Log10(1+math.Max(event.RewardAmount, 0))
+		age := now.Sub(event.CreatedAt)
+		if age < 0 {
+			age = 0
+		}
+		decay := math.Pow(0.5, float64(age)/float64(reputationHalfLife))
+
+		value := points * weight * decay
+		switch event.Role {
+		case domain.ReputationRoleWorker:
+			worker += value
+		case domain.ReputationRolePoster:
+			poster += value
+		}
+	}
+	return roundScore(worker), roundScore(poster)
+}
+
+func roundScore(score float64) float64 {
+	return math.Round(score*100) / 100
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type mockReputationRepo struct {
+	events map[uuid.UUID][]*domain.ReputationEvent
+	scores map[uuid.UUID][2]float64
+	totals map[uuid.UUID]int
+}
+
+func newMockReputationRepo() *mockReputationRepo {
+	return &mockReputationRepo{
+		events: make(map[uuid.UUID][]*domain.ReputationEvent),
+		scores: make(map[uuid.UUID][2]float64),
+		totals: make(map[uuid.UUID]int),
+	}
+}
+
+func (m *mockReputationRepo) CreateEvent(ctx context.Context, event *domain.ReputationEvent) error {
+	event.CreatedAt = time.Now()
+	m.events[event.UserID] = append(m.events[event.UserID], event)
+	return nil
+}
+
+func (m *mockReputationRepo) GetEventsByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.ReputationEvent, error) {
+	return m.events[userID], nil
+}
+
+func (m *mockReputationRepo) GetUserIDsWithEvents(ctx context.Context) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for id := range m.events {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (m *mockReputationRepo) UpdateScores(ctx context.Context, userID uuid.UUID, workerScore, posterScore float64, reputation int) error {
+	m.scores[userID] = [2]float64{workerScore, posterScore}
+	m.totals[userID] = reputation
+	return nil
+}
+
+func TestComputeReputation(t *testing.T) {
+	now := time.Now()
+	event := func(role domain.ReputationRole, kind domain.ReputationEventKind, reward float64, age time.Duration) *domain.ReputationEvent {
+		return &domain.ReputationEvent{Role: role, Kind: kind, RewardAmount: reward, CreatedAt: now.Add(-age)}
+	}
+
+	tests := []struct {
+		name   string
+		events []*domain.ReputationEvent
+		worker float64
+		poster float64
+	}{
+		{
+			name: "no history",
+		},
+		{
+			name:   "approval with zero reward counts at base value",
+			events: []*domain.ReputationEvent{event(domain.ReputationRoleWorker, domain.ReputationClaimApproved, 0, 0)},
+			worker: 1,
+		},
+		{
+			name:   "larger rewards weigh more",
+			events: []*domain.ReputationEvent{event(domain.ReputationRoleWorker, domain.ReputationClaimApproved, 99, 0)},
+			worker: 3,
+		},
+		{
+			name:   "events lose half their weight after the half-life",
+			events: []*domain.ReputationEvent{event(domain.ReputationRoleWorker, domain.ReputationClaimApproved, 0, reputationHalfLife)},
+			worker: 0.5,
+		},
+		{
+			name: "roles are scored separately",
+			events: []*domain.ReputationEvent{
+				event(domain.ReputationRoleWorker, domain.ReputationClaimApproved, 0, 0),
+				event(domain.ReputationRolePoster, domain.ReputationTaskCompleted, 0, 0),
+				event(domain.ReputationRolePoster, domain.ReputationDisputeLost, 0, 0),
+			},
+			worker: 1,
+			poster: -0.5,
+		},
+		{
+			name: "penalties pull the score negative",
+			events: []*domain.ReputationEvent{
+				event(domain.ReputationRoleWorker, domain.ReputationClaimAbandoned, 0, 0),
+				event(domain.ReputationRoleWorker, domain.ReputationClaimWithdrawn, 0, 0),
+			},
+			worker: -0.8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker, poster := ComputeReputation(tt.events, now)
+			assert.InDelta(t, tt.worker, worker, 0.01)
+			assert.InDelta(t, tt.poster, poster, 0.01)
+		})
+	}
+}
+
+func TestRecordUpdatesScores(t *testing.T) {
+	repo := newMockReputationRepo()
+	service := NewReputationService(repo)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	for i := 0; i < 3; i++ {
+		err := service.Record(ctx, &domain.ReputationEvent{
+			UserID: userID,
+			Role:   domain.ReputationRoleWorker,
+			Kind:   domain.ReputationClaimApproved,
+		})
+		assert.NoError(t, err)
+	}
+	err := service.Record(ctx, &domain.ReputationEvent{
+		UserID: userID,
+		Role:   domain.ReputationRolePoster,
+		Kind:   domain.ReputationTaskCompleted,
+	})
+	assert.NoError(t, err)
+
+	assert.InDelta(t, 3, repo.scores[userID][0], 0.01)
+	assert.InDelta(t, 0.5, repo.scores[userID][1], 0.01)
+	assert.Equal(t, 4, repo.totals[userID])
+
+	n, err := service.RecomputeAll(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/repository"
+)
+
+var (
+	ErrClaimNotDecided     = errors.New("claim has not been decided yet")
+	ErrReviewWindowClosed  = errors.New("review window has closed")
+	ErrAlreadyReviewed     = errors.New("already reviewed")
+	ErrInvalidRating       = errors.New("rating must be between 1 and 5")
+	ErrInvalidReviewTag    = errors.New("invalid review tag")
+	ErrTooManyReviewTags   = errors.New("too many review tags")
+	ErrReviewCommentLength = errors.New("review comment is too long")
+)
+
+type ReviewInput struct {
+	Rating  int
+	Tags    []string
+	Comment string
+}
+
+type ReviewService interface {
+	SubmitReview(ctx context.Context, claimID, reviewerID uuid.UUID, input ReviewInput) (*domain.ReviewThread, error)
+	GetReviews(ctx context.Context, claimID, viewerID uuid.UUID) (*domain.ReviewThread, error)
+}
+
+type reviewService struct {
+	reviewRepo repository.ReviewRepository
+	claimRepo  repository.ClaimRepository
+	taskRepo   repository.TaskRepository
+}
+
+func NewReviewService(
+	reviewRepo repository.ReviewRepository,
+	claimRepo repository.ClaimRepository,
+	taskRepo repository.TaskRepository,
+) ReviewService {
+	return &reviewService{
+		reviewRepo: reviewRepo,
+		claimRepo:  claimRepo,
+		taskRepo:   taskRepo,
+	}
+}
+
+// SubmitReview records one side's rating of the other once a claim has been
+// approved, rejected or resolved, and only within domain.ReviewWindow.
+func (s *reviewService) SubmitReview(ctx context.Context, claimID, reviewerID uuid.UUID, input ReviewInput) (*domain.ReviewThread, error) {
+	if input.Rating < 1 || input.Rating > 5 {
+		return nil, ErrInvalidRating
+	}
+	if len(input.Tags) > domain.MaxReviewTags {
+		return nil, ErrTooManyReviewTags
+	}
+	tags := make([]string, 0, len(input.Tags))
+	seen := make(map[string]bool)
+	for _, tag := range input.Tags {
+		if !domain.IsValidReviewTag(tag) {
+			return nil, ErrInvalidReviewTag
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	if len([]rune(input.Comment)) > domain.MaxReviewCommentLength {
+		return nil, ErrReviewCommentLength
+	}
+
+	claim, task, err := s.participantClaim(ctx, claimID, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if claim.DecidedAt == nil || (claim.Status != domain.ClaimStatusApproved && claim.Status != domain.ClaimStatusRejected) {
+		return nil, ErrClaimNotDecided
+	}
+	if time.Now().After(claim.DecidedAt.Add(domain.ReviewWindow)) {
+		return nil, ErrReviewWindowClosed
+	}
+
+	reviews, err := s.reviewRepo.GetByClaimID(ctx, claimID)
+	if err != nil {
+		return nil, err
+	}
+	for _, review := range reviews {
+		if review.ReviewerID == reviewerID {
+			return nil, ErrAlreadyReviewed
+		}
+	}
+
+	review := &domain.Review{
+		ID:           uuid.New(),
+		ClaimID:      claim.ID,
+		TaskID:       task.ID,
+		ReviewerID:   reviewerID,
+		RevieweeID:   task.OwnerID,
+		ReviewerRole: domain.ReputationRoleWorker,
+		Rating:       input.Rating,
+		Tags:         tags,
+		Comment:      input.Comment,
+	}
+	if reviewerID == task.OwnerID {
+		review.RevieweeID = claim.ClaimerID
+		review.ReviewerRole = domain.ReputationRolePoster
+	}
+
+	err = s.reviewRepo.Create(ctx, review)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildReviewThread(claim, append(reviews, review), reviewerID, time.Now()), nil
+}
+
+func (s *reviewService) GetReviews(ctx context.Context, claimID, viewerID uuid.UUID) (*domain.ReviewThread, error) {
+	claim, _, err := s.participantClaim(ctx, claimID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if claim.DecidedAt == nil {
+		return nil, ErrClaimNotDecided
+	}
+
+	reviews, err := s.reviewRepo.GetByClaimID(ctx, claimID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildReviewThread(claim, reviews, viewerID, time.Now()), nil
+}
+
+// participantClaim loads a claim and its task, making sure userID is either
+// the task owner or the claimer.
+func (s *reviewService) participantClaim(ctx context.Context, claimID, userID uuid.UUID) (*domain.Claim, *domain.Task, error) {
+	claim, err := s.claimRepo.GetByID(ctx, claimID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, ErrClaimNotFound
+		}
+		return nil, nil, err
+	}
+
+	task, err := s.taskRepo.GetByID(ctx, claim.TaskID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, ErrTaskNotFound
+		}
+		return nil, nil, err
+	}
+
+	if !can(userID, ActionReviewClaim, ClaimResource{Claim: claim, Task: task}) {
+		return nil, nil, ErrUnauthorized
+	}
+	return claim, task, nil
+}
+
+// buildReviewThread applies the double-blind rule: the counterpart's review
+// is only included once both sides have submitted or the window has closed.
+func buildReviewThread(claim *domain.Claim, reviews []*domain.Review, viewerID uuid.UUID, now time.Time) *domain.ReviewThread {
+	thread := &domain.ReviewThread{
+		ClaimID:        claim.ID,
+		WindowClosesAt: claim.DecidedAt.Add(domain.ReviewWindow),
+	}
+
+	var theirs *domain.Review
+	for _, review := range reviews {
+		if review.ReviewerID == viewerID {
+			thread.Mine = review
+		} else {
+			theirs = review
+		}
+	}
+
+	thread.CounterpartSubmitted = theirs != nil
+	thread.Revealed = (thread.Mine != nil && theirs != nil) || !now.Before(thread.WindowClosesAt)
+	if thread.Revealed {
+		thread.Theirs = theirs
+	}
+	return thread
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type mockReviewRepo struct {
+	reviews map[uuid.UUID][]*domain.Review
+}
+
+func newMockReviewRepo() *mockReviewRepo {
+	return &mockReviewRepo{reviews: make(map[uuid.UUID][]*domain.Review)}
+}
+
+func (m *mockReviewRepo) Create(ctx context.Context, review *domain.Review) error {
+	review.CreatedAt = time.Now()
+	m.reviews[review.ClaimID] = append(m.reviews[review.ClaimID], review)
+	return nil
+}
+
+func (m *mockReviewRepo) GetByClaimID(ctx context.Context, claimID uuid.UUID) ([]*domain.Review, error) {
+	return m.reviews[claimID], nil
+}
+
+func (m *mockReviewRepo) GetRatings(ctx context.Context, userID uuid.UUID, decidedBefore time.Time) (*domain.Ratings, error) {
+	return &domain.Ratings{}, nil
+}
+
+func setupReviewTest(decidedAt time.Time) (ReviewService, *mockReviewRepo, *domain.Claim, uuid.UUID) {
+	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
+	reviewRepo := newMockReviewRepo()
+
+	ownerID := uuid.New()
+	task := &domain.Task{ID: uuid.New(), OwnerID: ownerID, Status: domain.TaskStatusCompleted}
+	taskRepo.tasks[task.ID] = task
+	claim := &domain.Claim{
+		ID:        uuid.New(),
+		TaskID:    task.ID,
+		ClaimerID: uuid.New(),
+		Status:    domain.ClaimStatusApproved,
+		DecidedAt: &decidedAt,
+	}
+	claimRepo.claims[claim.ID] = claim
+
+	return NewReviewService(reviewRepo, claimRepo, taskRepo), reviewRepo, claim, ownerID
+}
+
+func TestReviewsAreDoubleBlind(t *testing.T) {
+	service, _, claim, ownerID := setupReviewTest(time.Now())
+	ctx := context.Background()
+
+	thread, err := service.SubmitReview(ctx, claim.ID, ownerID, ReviewInput{Rating: 5, Tags: []string{"quality_work"}})
+	assert.NoError(t, err)
+	assert.False(t, thread.Revealed)
+	assert.Equal(t, domain.ReputationRolePoster, thread.Mine.ReviewerRole)
+	assert.Equal(t, claim.ClaimerID, thread.Mine.RevieweeID)
+
+	// The claimer knows a review exists but cannot read it yet
+	thread, err = service.GetReviews(ctx, claim.ID, claim.ClaimerID)
+	assert.NoError(t, err)
+	assert.True(t, thread.CounterpartSubmitted)
+	assert.Nil(t, thread.Theirs)
+
+	thread, err = service.SubmitReview(ctx, claim.ID, claim.ClaimerID, ReviewInput{Rating: 4, Comment: "clear brief"})
+	assert.NoError(t, err)
+	assert.True(t, thread.Revealed)
+	assert.Equal(t, 5, thread.Theirs.Rating)
+
+	_, err = service.SubmitReview(ctx, claim.ID, ownerID, ReviewInput{Rating: 1})
+	assert.Equal(t, ErrAlreadyReviewed, err)
+}
+
+func TestReviewsRevealWhenWindowCloses(t *testing.T) {
+	service, reviewRepo, claim, ownerID := setupReviewTest(time.Now().Add(-domain.ReviewWindow - time.Hour))
+	ctx := context.Background()
+
+	_, err := service.SubmitReview(ctx, claim.ID, ownerID, ReviewInput{Rating: 3})
+	assert.Equal(t, ErrReviewWindowClosed, err)
+
+	reviewRepo.reviews[claim.ID] = []*domain.Review{{ClaimID: claim.ID, ReviewerID: ownerID, Rating: 2}}
+	thread, err := service.GetReviews(ctx, claim.ID, claim.ClaimerID)
+	assert.NoError(t, err)
+	assert.True(t, thread.Revealed)
+	assert.Nil(t, thread.Mine)
+	assert.Equal(t, 2, thread.Theirs.Rating)
+}
+
+func TestSubmitReviewValidation(t *testing.T) {
+	service, _, claim, ownerID := setupReviewTest(time.Now())
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		userID uuid.UUID
+		input  ReviewInput
+		err    error
+	}{
+		{"rating too low", ownerID, ReviewInput{Rating: 0}, ErrInvalidRating},
+		{"rating too high", ownerID, ReviewInput{Rating: 6}, ErrInvalidRating},
+		{"unknown tag", ownerID, ReviewInput{Rating: 3, Tags: []string{"my_signature"}}, ErrInvalidReviewTag},
+		{"too many tags", ownerID, ReviewInput{Rating: 3, Tags: []string{"fair", "slow", "friendly", "on_time", "unclear", "responsive"}}, ErrTooManyReviewTags},
+		{"stranger", uuid.New(), ReviewInput{Rating: 3}, ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.SubmitReview(ctx, claim.ID, tt.userID, tt.input)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func TestRatingSummaryCoarse(t *testing.T) {
+	average := 4.3
+	assert.Nil(t, domain.RatingSummary{Average: &average, Count: 2}.Coarse())
+	assert.Equal(t, 4.5, *domain.RatingSummary{Average: &average, Count: 3}.Coarse())
+}
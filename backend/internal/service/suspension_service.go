@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/repository"
+)
+
+var (
+	ErrSuspensionReasonRequired = errors.New("suspension reason is required")
+	ErrInvalidSuspension        = errors.New("suspension must end in the future")
+)
+
+// Disconnector closes a user's live connections.
+type Disconnector interface {
+	DisconnectUser(userID uuid.UUID)
+}
+
+type SuspensionService interface {
+	Suspend(ctx context.Context, userID uuid.UUID, until *time.Time, reason string) error
+	Unsuspend(ctx context.Context, userID uuid.UUID) error
+}
+
+type suspensionService struct {
+	userRepo     repository.UserRepository
+	authSvc      AuthService
+	taskSvc      TaskService
+	claimSvc     ClaimService
+	disconnector Disconnector
+}
+
+func NewSuspensionService(
+	userRepo repository.UserRepository,
+	authSvc AuthService,
+	taskSvc TaskService,
+	claimSvc ClaimService,
+	disconnector Disconnector,
+) SuspensionService {
+	return &suspensionService{
+		userRepo:     userRepo,
+		authSvc:      authSvc,
+		taskSvc:      taskSvc,
+		claimSvc:     claimSvc,
+		disconnector: disconnector,
+	}
+}
+
+// Suspend locks a user out until the given time, or permanently when until
+// is nil. Their sessions and live connections are ended, their unfinished
+// tasks are cancelled and refunded, and their pending claims are cancelled.
+func (s *suspensionService) Suspend(ctx context.Context, userID uuid.UUID, until *time.Time, reason string) error {
+	if reason == "" {
+		return ErrSuspensionReasonRequired
+	}
+	if until != nil && !until.After(time.Now()) {
+		return ErrInvalidSuspension
+	}
+
+	_, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	err = s.userRepo.Suspend(ctx, userID, until, reason)
+	if err != nil {
+		return err
+	}
+
+	err = s.authSvc.RevokeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if s.disconnector != nil {
+		s.disconnector.DisconnectUser(userID)
+	}
+
+	err = s.taskSvc.CancelTasksByOwner(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.claimSvc.CancelClaimsByClaimer(ctx, userID)
+}
+
+// Unsuspend lifts a suspension. Cancelled tasks and claims stay cancelled.
+func (s *suspensionService) Unsuspend(ctx context.Context, userID uuid.UUID) error {
+	_, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	return s.userRepo.Unsuspend(ctx, userID)
+}
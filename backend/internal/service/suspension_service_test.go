@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type mockDisconnector struct {
+	disconnected []uuid.UUID
+	sessions     []uuid.UUID
+}
+
+func (m *mockDisconnector) DisconnectUser(userID uuid.UUID) {
+	m.disconnected = append(m.disconnected, userID)
+}
+
+func (m *mockDisconnector) DisconnectSession(sessionID uuid.UUID) {
+	m.sessions = append(m.sessions, sessionID)
+}
+
+func TestSuspendEndsAccessAndActivity(t *testing.T) {
+	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
+	userRepo := &mockUserRepo{}
+	authSvc := NewAuthService(userRepo, newMockDeviceRepo(), newMockSessionRepo(), AuthConfig{Secret: []byte("test-secret")})
+	taskSvc := NewTaskService(taskRepo, claimRepo, &mockEscrowSvc{}, newMockBlockRepo(), nil)
+	claimSvc := NewClaimService(claimRepo, taskRepo, &mockChatRepoForClaimSvc{}, &mockEscrowSvc{}, userRepo, &mockReputationSvc{}, newMockBlockRepo(), nil, nil)
+	disconnector := &mockDisconnector{}
+	service := NewSuspensionService(userRepo, authSvc, taskSvc, claimSvc, disconnector)
+	ctx := context.Background()
+
+	tokens, err := authSvc.Handshake(ctx, "device-1", "", domain.AccountProof{})
+	assert.NoError(t, err)
+	claims, err := authSvc.ValidateAccessToken(tokens.AccessToken)
+	assert.NoError(t, err)
+	userID := claims.UserID
+
+	// The user owns an open task and has a pending claim on someone else's
+	ownTask := &domain.Task{ID: uuid.New(), OwnerID: userID, Status: domain.TaskStatusOpen, EscrowLocked: true}
+	otherTask := &domain.Task{ID: uuid.New(), OwnerID: uuid.New(), Status: domain.TaskStatusClaimed}
+	taskRepo.tasks[ownTask.ID] = ownTask
+	taskRepo.tasks[otherTask.ID] = otherTask
+	claim := &domain.Claim{ID: uuid.New(), TaskID: otherTask.ID, ClaimerID: userID, Status: domain.ClaimStatusPending}
+	claimRepo.claims[claim.ID] = claim
+
+	assert.Equal(t, ErrSuspensionReasonRequired, service.Suspend(ctx, userID, nil, ""))
+	past := time.Now().Add(-time.Hour)
+	assert.Equal(t, ErrInvalidSuspension, service.Suspend(ctx, userID, &past, "spam"))
+
+	until := time.Now().Add(24 * time.Hour)
+	assert.NoError(t, service.Suspend(ctx, userID, &until, "spam"))
+
+	// Existing tokens and connections stop working and no new ones are issued
+	_, err = authSvc.ValidateAccessToken(tokens.AccessToken)
+	assert.Equal(t, ErrTokenRevoked, err)
+	assert.Equal(t, ErrUserSuspended, authSvc.CheckUser(ctx, userID))
+	_, err = authSvc.Handshake(ctx, "device-1", "", domain.AccountProof{})
+	assert.Equal(t, ErrUserSuspended, err)
+	assert.Equal(t, []uuid.UUID{userID}, disconnector.disconnected)
+
+	// Their task is cancelled and their claim released
+	assert.Equal(t, domain.TaskStatusCancelled, ownTask.Status)
+	assert.Equal(t, domain.ClaimStatusCancelled, claim.Status)
+	assert.Equal(t, domain.TaskStatusOpen, otherTask.Status)
+
+	assert.NoError(t, service.Unsuspend(ctx, userID))
+	assert.NoError(t, authSvc.CheckUser(ctx, userID))
+	_, err = authSvc.Handshake(ctx, "device-1", "", domain.AccountProof{})
+	assert.NoError(t, err)
+}
+
+func TestUserIsSuspended(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	assert.False(t, (&domain.User{}).IsSuspended(now))
+	assert.True(t, (&domain.User{SuspendedAt: &past}).IsSuspended(now))
+	assert.True(t, (&domain.User{SuspendedAt: &past, SuspendedUntil: &future}).IsSuspended(now))
+	assert.False(t, (&domain.User{SuspendedAt: &past, SuspendedUntil: &past}).IsSuspended(now))
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/bits"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/repository"
+)
+
+var (
+	ErrProofOfWorkRequired  = errors.New("proof of work required")
+	ErrTooManyAccounts      = errors.New("too many new accounts from this network")
+	ErrProbationClaimLimit  = errors.New("new accounts cannot hold more claims yet")
+	ErrProbationRewardLimit = errors.New("reward is too large for new accounts")
+)
+
+const (
+	defaultPowDifficulty      = 16
+	defaultAccountsPerIP      = 5
+	defaultAccountWindow      = 24 * time.Hour
+	defaultProbationPeriod    = 72 * time.Hour
+	defaultProbationMaxClaims = 2
+	defaultProbationMaxReward = 20
+	defaultClusterWindow      = 30 * 24 * time.Hour
+	defaultClusterAccountAge  = 7 * 24 * time.Hour
+	defaultClusterMinAccounts = 3
+
+	challengeTTL = 5 * time.Minute
+)
+
+// SybilConfig tunes the defenses against mass-created accounts. Zero values
+// fall back to the defaults; a negative PowDifficulty, AccountsPerIP,
+// ProbationMaxClaims or ProbationMaxReward turns that check off.
+type SybilConfig struct {
+	Secret []byte
+	// PowDifficulty is how many leading zero bits a new device's
+	// proof-of-work hash needs.
+	PowDifficulty int
+	// AccountsPerIP accounts may be created from one address per
+	// AccountWindow.
+	AccountsPerIP int
+	AccountWindow time.Duration
+	// Accounts younger than ProbationPeriod may hold at most
+	// ProbationMaxClaims pending claims and only claim tasks paying up to
+	// ProbationMaxReward.
+	ProbationPeriod    time.Duration
+	ProbationMaxClaims int
+	ProbationMaxReward float64
+	// A cluster is ClusterMinAccounts or more accounts, each younger than
+	// ClusterAccountAge when they claimed, claiming the same owner's tasks
+	// within ClusterWindow.
+	ClusterWindow      time.Duration
+	ClusterAccountAge  time.Duration
+	ClusterMinAccounts int
+}
+
+type SybilService interface {
+	AccountGate
+	ClaimGate
+	Challenge(deviceID string) (*domain.AccountChallenge, error)
+	FindClusters(ctx context.Context) ([]*domain.SybilCluster, error)
+}
+
+type sybilService struct {
+	sybilRepo repository.SybilRepository
+	userRepo  repository.UserRepository
+	claimRepo repository.ClaimRepository
+	config    SybilConfig
+}
+
+func NewSybilService(
+	sybilRepo repository.SybilRepository,
+	userRepo repository.UserRepository,
+	claimRepo repository.ClaimRepository,
+	config SybilConfig,
+) SybilService {
+	if config.PowDifficulty == 0 {
+		config.PowDifficulty = defaultPowDifficulty
+	}
+	if config.AccountsPerIP == 0 {
+		config.AccountsPerIP = defaultAccountsPerIP
+	}
+	if config.AccountWindow <= 0 {
+		config.AccountWindow = defaultAccountWindow
+	}
+	if config.ProbationPeriod <= 0 {
+		config.ProbationPeriod = defaultProbationPeriod
+	}
+	if config.ProbationMaxClaims == 0 {
+		config.ProbationMaxClaims = defaultProbationMaxClaims
+	}
+	if config.ProbationMaxReward == 0 {
+		config.ProbationMaxReward = defaultProbationMaxReward
+	}
+	if config.ClusterWindow <= 0 {
+		config.ClusterWindow = defaultClusterWindow
+	}
+	if config.ClusterAccountAge <= 0 {
+		config.ClusterAccountAge = defaultClusterAccountAge
+	}
+	if config.ClusterMinAccounts <= 0 {
+		config.ClusterMinAccounts = defaultClusterMinAccounts
+	}
+	return &sybilService{
+		sybilRepo: sybilRepo,
+		userRepo:  userRepo,
+		claimRepo: claimRepo,
+		config:    config,
+	}
+}
+
+// Challenge issues a proof-of-work challenge for deviceID. It is signed
+// rather than stored, and bound to the device so one solution cannot be
+// reused to create further accounts.
+func (s *sybilService) Challenge(deviceID string) (*domain.AccountChallenge, error) {
+	expiresAt := time.Now().Add(challengeTTL)
+
+	payload := make([]byte, 24)
+	binary.BigEndian.PutUint64(payload, uint64(expiresAt.Unix()))
+	if _, err := rand.Read(payload[8:]); err != nil {
+		return nil, err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return &domain.AccountChallenge{
+		Challenge:  encoded + "." + s.challengeMAC(encoded, deviceID),
+		Difficulty: s.config.PowDifficulty,
+		ExpiresAt:  time.Unix(expiresAt.Unix(), 0),
+	}, nil
+}
+
+// AdmitAccount decides whether an account may be created for an unknown
+// device. The creation is counted against the client address as it is
+// admitted, in the same step as the limit is checked, so parallel
+// handshakes cannot all get under it.
+func (s *sybilService) AdmitAccount(ctx context.Context, deviceID string, proof domain.AccountProof) (uuid.UUID, error) {
+	if s.config.PowDifficulty > 0 && !s.validProof(deviceID, proof) {
+		return uuid.Nil, ErrProofOfWorkRequired
+	}
+	if proof.IP == "" {
+		return uuid.Nil, nil
+	}
+
+	admissionID := uuid.New()
+	admitted, err := s.sybilRepo.AdmitAccountCreation(ctx, admissionID, s.hashIP(proof.IP), time.Now().Add(-s.config.AccountWindow), s.config.AccountsPerIP)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !admitted {
+		return uuid.Nil, ErrTooManyAccounts
+	}
+	return admissionID, nil
+}
+
+func (s *sybilService) AccountCreated(ctx context.Context, admissionID, userID uuid.UUID) error {
+	if admissionID == uuid.Nil {
+		return nil
+	}
+	return s.sybilRepo.RecordAccountCreation(ctx, admissionID, userID)
+}
+
+// CheckClaim keeps accounts on probation to a few small claims.
+func (s *sybilService) CheckClaim(ctx context.Context, claimerID uuid.UUID, task *domain.Task) error {
+	user, err := s.userRepo.GetByID(ctx, claimerID)
+	if err != nil {
+		return err
+	}
+	if time.Since(user.CreatedAt) >= s.config.ProbationPeriod {
+		return nil
+	}
+
+	if s.config.ProbationMaxReward > 0 && task.RewardAmount > s.config.ProbationMaxReward {
+		return ErrProbationRewardLimit
+	}
+	if s.config.ProbationMaxClaims > 0 {
+		pending, err := s.claimRepo.GetPendingByClaimerID(ctx, claimerID)
+		if err != nil {
+			return err
+		}
+		if len(pending) >= s.config.ProbationMaxClaims {
+			return ErrProbationClaimLimit
+		}
+	}
+	return nil
+}
+
+func (s *sybilService) FindClusters(ctx context.Context) ([]*domain.SybilCluster, error) {
+	return s.sybilRepo.FindClusters(ctx, time.Now().Add(-s.config.ClusterWindow), s.config.ClusterAccountAge, s.config.ClusterMinAccounts)
+}
+
+func (s *sybilService) validProof(deviceID string, proof domain.AccountProof) bool {
+	parts := strings.SplitN(proof.Challenge, ".", 2)
+	if len(parts) != 2 || proof.Nonce == "" {
+		return false
+	}
+	if !hmac.Equal([]byte(parts[1]), []byte(s.challengeMAC(parts[0], deviceID))) {
+		return false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(payload) != 24 {
+		return false
+	}
+	if time.Now().Unix() > int64(binary.BigEndian.Uint64(payload)) {
+		return false
+	}
+
+	return leadingZeroBits(sha256.Sum256([]byte(proof.Challenge+proof.Nonce))) >= s.config.PowDifficulty
+}
+
+func (s *sybilService) challengeMAC(payload, deviceID string) string {
+	mac := hmac.New(sha256.New, s.config.Secret)
+	mac.Write([]byte("challenge|" + payload + "|" + deviceID))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+func (s *sybilService) hashIP(ip string) string {
+	return hashClientIP(s.config.Secret, ip)
+}
+
+// hashClientIP keys the address with the server secret, so the stored
+// hashes cannot be reversed by enumerating the address space.
+func hashClientIP(secret []byte, ip string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("ip|" + ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func leadingZeroBits(sum [sha256.Size]byte) int {
+	n := 0
+	for _, b := range sum {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type mockSybilRepo struct {
+	creations map[string]int
+}
+
+func (m *mockSybilRepo) AdmitAccountCreation(ctx context.Context, id uuid.UUID, ipHash string, since time.Time, limit int) (bool, error) {
+	if limit > 0 && m.creations[ipHash] >= limit {
+		return false, nil
+	}
+	m.creations[ipHash]++
+	return true, nil
+}
+
+func (m *mockSybilRepo) RecordAccountCreation(ctx context.Context, id, userID uuid.UUID) error {
+	return nil
+}
+
+func (m *mockSybilRepo) FindClusters(ctx context.Context, since time.Time, maxAccountAge time.Duration, minAccounts int) ([]*domain.SybilCluster, error) {
+	return nil, nil
+}
+
+func solveChallenge(challenge *domain.AccountChallenge) string {
+	for nonce := 0; ; nonce++ {
+		candidate := strconv.Itoa(nonce)
+		if leadingZeroBits(sha256.Sum256([]byte(challenge.Challenge+candidate))) >= challenge.Difficulty {
+			return candidate
+		}
+	}
+}
+
+func TestAccountCreationNeedsWorkAndIsThrottled(t *testing.T) {
+	sybilRepo := &mockSybilRepo{creations: make(map[string]int)}
+	sybilSvc := NewSybilService(sybilRepo, &mockUserRepo{}, &mockClaimRepoForClaimSvc{}, SybilConfig{
+		Secret:        []byte("test-secret"),
+		PowDifficulty: 8,
+		AccountsPerIP: 2,
+	})
+	authSvc := NewAuthService(&mockUserRepo{}, newMockDeviceRepo(), newMockSessionRepo(), AuthConfig{
+		Secret: []byte("test-secret"),
+		Gate:   sybilSvc,
+	})
+	ctx := context.Background()
+
+	handshake := func(deviceID, ip string) error {
+		challenge, err := sybilSvc.Challenge(deviceID)
+		assert.NoError(t, err)
+		_, err = authSvc.Handshake(ctx, deviceID, "", domain.AccountProof{Challenge: challenge.Challenge, Nonce: solveChallenge(challenge), IP: ip})
+		return err
+	}
+
+	// New devices must present a solved challenge
+	_, err := authSvc.Handshake(ctx, "device-1", "", domain.AccountProof{IP: "10.0.0.1"})
+	assert.Equal(t, ErrProofOfWorkRequired, err)
+	assert.NoError(t, handshake("device-1", "10.0.0.1"))
+
+	// Known devices sign in again without one
+	_, err = authSvc.Handshake(ctx, "device-1", "", domain.AccountProof{IP: "10.0.0.1"})
+	assert.NoError(t, err)
+
+	// A solution is bound to the device it was issued for
+	challenge, _ := sybilSvc.Challenge("device-2")
+	_, err = authSvc.Handshake(ctx, "device-3", "", domain.AccountProof{Challenge: challenge.Challenge, Nonce: solveChallenge(challenge), IP: "10.0.0.1"})
+	assert.Equal(t, ErrProofOfWorkRequired, err)
+
+	// One address only gets a few accounts per window
+	assert.NoError(t, handshake("device-2", "10.0.0.1"))
+	assert.Equal(t, ErrTooManyAccounts, handshake("device-3", "10.0.0.1"))
+	assert.NoError(t, handshake("device-3", "10.0.0.2"))
+}
+
+func TestProbationLimitsNewClaimers(t *testing.T) {
+	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
+	newcomer := &domain.User{ID: uuid.New(), CreatedAt: time.Now().Add(-time.Hour)}
+	veteran := &domain.User{ID: uuid.New(), CreatedAt: time.Now().Add(-30 * 24 * time.Hour)}
+	userRepo := &mockUserRepo{users: map[uuid.UUID]*domain.User{newcomer.ID: newcomer, veteran.ID: veteran}}
+	sybilSvc := NewSybilService(&mockSybilRepo{}, userRepo, claimRepo, SybilConfig{
+		ProbationMaxClaims: 1,
+		ProbationMaxReward: 10,
+	})
+	claimSvc := NewClaimService(claimRepo, taskRepo, &mockChatRepoForClaimSvc{}, &mockEscrowSvc{}, userRepo, &mockReputationSvc{}, newMockBlockRepo(), sybilSvc, nil)
+	ctx := context.Background()
+
+	newTask := func(reward float64) *domain.Task {
+		task := &domain.Task{
+			ID:            uuid.New(),
+			OwnerID:       uuid.New(),
+			RewardAmount:  reward,
+			MaxClaimants:  3,
+			ClaimDeadline: time.Now().Add(24 * time.Hour),
+			OwnerDeadline: time.Now().Add(48 * time.Hour),
+			Status:        domain.TaskStatusOpen,
+		}
+		taskRepo.tasks[task.ID] = task
+		return task
+	}
+
+	big := newTask(50)
+	_, err := claimSvc.ClaimTask(ctx, big.ID, newcomer.ID)
+	assert.Equal(t, ErrProbationRewardLimit, err)
+	_, err = claimSvc.ClaimTask(ctx, big.ID, veteran.ID)
+	assert.NoError(t, err)
+
+	_, err = claimSvc.ClaimTask(ctx, newTask(5).ID, newcomer.ID)
+	assert.NoError(t, err)
+	_, err = claimSvc.ClaimTask(ctx, newTask(5).ID, newcomer.ID)
+	assert.Equal(t, ErrProbationClaimLimit, err)
+}
@@ -19,38 +19,66 @@ var (
 	ErrTaskAlreadyLocked = errors.New("task escrow already locked")
 )
 
+// TaskGate vets an owner before a task is created, once the request itself
+// is valid.
+type TaskGate interface {
+	CheckTask(ctx context.Context, ownerID uuid.UUID, req CreateTaskRequest) error
+}
+
+// TaskGates runs several gates in order and stops at the first refusal.
+type TaskGates []TaskGate
+
+func (g TaskGates) CheckTask(ctx context.Context, ownerID uuid.UUID, req CreateTaskRequest) error {
+	for _, gate := range g {
+		if err := gate.CheckTask(ctx, ownerID, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type TaskService interface {
 	CreateTask(ctx context.Context, ownerID uuid.UUID, req CreateTaskRequest) (*domain.Task, error)
-	GetTask(ctx context.Context, id uuid.UUID) (*domain.Task, error)
-	GetOpenTasks(ctx context.Context, limit, offset int) ([]*domain.Task, error)
+	GetTask(ctx context.Context, id, viewerID uuid.UUID) (*domain.Task, error)
+	GetTaskForAdmin(ctx context.Context, id uuid.UUID) (*domain.Task, error)
+	GetOpenTasks(ctx context.Context, viewerID uuid.UUID, limit, offset int) ([]*domain.Task, error)
 	GetUserTasks(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*domain.Task, error)
 	AutoCancelExpiredTasks(ctx context.Context) error
+	ForceCancelTask(ctx context.Context, taskID uuid.UUID) error
+	CancelTasksByOwner(ctx context.Context, ownerID uuid.UUID) error
 }
 
 type CreateTaskRequest struct {
-	Title         string    `json:"title"`
-	Description   string    `json:"description"`
-	RewardAmount  float64   `json:"reward_amount"`
-	MaxClaimants  int       `json:"max_claimants"`
-	ClaimDeadline time.Time `json:"claim_deadline"`
-	OwnerDeadline time.Time `json:"owner_deadline"`
+	Title           string    `json:"title"`
+	Description     string    `json:"description"`
+	RewardAmount    float64   `json:"reward_amount"`
+	MaxClaimants    int       `json:"max_claimants"`
+	ClaimDeadline   time.Time `json:"claim_deadline"`
+	OwnerDeadline   time.Time `json:"owner_deadline"`
+	ApplicationMode bool      `json:"application_mode"`
 }
 
 type taskService struct {
 	taskRepo  repository.TaskRepository
 	claimRepo repository.ClaimRepository
 	escrowSvc EscrowService
+	blockRepo repository.BlockRepository
+	gate      TaskGate
 }
 
 func NewTaskService(
 	taskRepo repository.TaskRepository,
 	claimRepo repository.ClaimRepository,
 	escrowSvc EscrowService,
+	blockRepo repository.BlockRepository,
+	gate TaskGate,
 ) TaskService {
 	return &taskService{
 		taskRepo:  taskRepo,
 		claimRepo: claimRepo,
 		escrowSvc: escrowSvc,
+		blockRepo: blockRepo,
+		gate:      gate,
 	}
 }
 
@@ -75,18 +103,27 @@ func (s *taskService) CreateTask(ctx context.Context, ownerID uuid.UUID, req Cre
 	if req.OwnerDeadline.Before(req.ClaimDeadline) {
 		return nil, errors.New("owner_deadline must be after claim_deadline")
 	}
+	if s.gate != nil {
+		if err := s.gate.CheckTask(ctx, ownerID, req); err != nil {
+			return nil, err
+		}
+	}
 
 	task := &domain.Task{
-		ID:            uuid.New(),
-		OwnerID:       ownerID,
-		Title:         req.Title,
-		Description:   req.Description,
-		RewardAmount:  req.RewardAmount,
-		MaxClaimants:  req.MaxClaimants,
-		ClaimDeadline: req.ClaimDeadline,
-		OwnerDeadline: req.OwnerDeadline,
-		Status:        domain.TaskStatusOpen,
-		EscrowLocked:  false,
+		ID:              uuid.New(),
+		OwnerID:         ownerID,
+		Title:           req.Title,
+		Description:     req.Description,
+		RewardAmount:    req.RewardAmount,
+		MaxClaimants:    req.MaxClaimants,
+		ClaimDeadline:   req.ClaimDeadline,
+		OwnerDeadline:   req.OwnerDeadline,
+		Status:          domain.TaskStatusOpen,
+		EscrowLocked:    false,
+		ApplicationMode: req.ApplicationMode,
+	}
+	if !can(ownerID, ActionPostTask, task) {
+		return nil, ErrUnauthorized
 	}
 
 	err := s.taskRepo.Create(ctx, task)
@@ -105,7 +142,55 @@ func (s *taskService) CreateTask(ctx context.Context, ownerID uuid.UUID, req Cre
 	return task, nil
 }
 
-func (s *taskService) GetTask(ctx context.Context, id uuid.UUID) (*domain.Task, error) {
+// GetTask returns a task viewerID may see: the owner's own tasks, open tasks
+// that are not hidden, and tasks viewerID has claimed. Tasks the viewer may
+// not see, including those of a user on the other side of a block, are
+// reported as not found.
+func (s *taskService) GetTask(ctx context.Context, id, viewerID uuid.UUID) (*domain.Task, error) {
+	task, err := s.GetTaskForAdmin(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	visible, err := canViewTask(ctx, s.claimRepo, viewerID, task)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, ErrTaskNotFound
+	}
+
+	if task.OwnerID != viewerID {
+		blocked, err := s.blockRepo.IsBlocked(ctx, viewerID, task.OwnerID)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, ErrTaskNotFound
+		}
+	}
+	return task, nil
+}
+
+// canViewTask reports whether userID may see task, either as the policy
+// allows anyone to or as one of its claimers.
+func canViewTask(ctx context.Context, claimRepo repository.ClaimRepository, userID uuid.UUID, task *domain.Task) (bool, error) {
+	if can(userID, ActionViewTask, task) {
+		return true, nil
+	}
+	claim, err := claimRepo.GetByTaskIDAndClaimerID(ctx, task.ID, userID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return can(userID, ActionViewTask, ClaimResource{Claim: claim, Task: task}), nil
+}
+
+// GetTaskForAdmin looks a task up for the admin tools, without a viewer to
+// check.
+func (s *taskService) GetTaskForAdmin(ctx context.Context, id uuid.UUID) (*domain.Task, error) {
 	task, err := s.taskRepo.GetByID(ctx, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -116,18 +201,37 @@ func (s *taskService) GetTask(ctx context.Context, id uuid.UUID) (*domain.Task,
 	return task, nil
 }
 
-func (s *taskService) GetOpenTasks(ctx context.Context, limit, offset int) ([]*domain.Task, error) {
+func (s *taskService) GetOpenTasks(ctx context.Context, viewerID uuid.UUID, limit, offset int) ([]*domain.Task, error) {
 	if limit <= 0 || limit > 100 {
 		limit = 20
 	}
-	return s.taskRepo.GetOpenTasks(ctx, limit, offset)
+	tasks, err := s.taskRepo.GetOpenTasks(ctx, viewerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return visibleTasks(tasks, viewerID), nil
 }
 
 func (s *taskService) GetUserTasks(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*domain.Task, error) {
 	if limit <= 0 || limit > 100 {
 		limit = 20
 	}
-	return s.taskRepo.GetByOwnerID(ctx, userID, limit, offset)
+	tasks, err := s.taskRepo.GetByOwnerID(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return visibleTasks(tasks, userID), nil
+}
+
+// visibleTasks keeps the tasks viewerID may see.
+func visibleTasks(tasks []*domain.Task, viewerID uuid.UUID) []*domain.Task {
+	visible := make([]*domain.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if can(viewerID, ActionViewTask, task) {
+			visible = append(visible, task)
+		}
+	}
+	return visible
 }
 
 func (s *taskService) AutoCancelExpiredTasks(ctx context.Context) error {
@@ -154,3 +258,59 @@ func (s *taskService) AutoCancelExpiredTasks(ctx context.Context) error {
 
 	return nil
 }
+
+// ForceCancelTask cancels a task regardless of its deadlines, refunds the
+// owner's escrow and cancels claims that are still in progress. Finished
+// tasks are left alone.
+func (s *taskService) ForceCancelTask(ctx context.Context, taskID uuid.UUID) error {
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrTaskNotFound
+		}
+		return err
+	}
+
+	if task.Status == domain.TaskStatusCompleted || task.Status == domain.TaskStatusCancelled {
+		return nil
+	}
+
+	claims, err := s.claimRepo.GetByTaskID(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	for _, claim := range claims {
+		if claim.Status == domain.ClaimStatusPending || claim.Status == domain.ClaimStatusDisputed || claim.Status == domain.ClaimStatusApplied {
+			err = s.claimRepo.UpdateStatus(ctx, claim.ID, domain.ClaimStatusCancelled)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = s.taskRepo.UpdateStatus(ctx, taskID, domain.TaskStatusCancelled)
+	if err != nil {
+		return err
+	}
+
+	if task.EscrowLocked {
+		return s.escrowSvc.RefundEscrow(ctx, task.ID, task.OwnerID, task.RewardAmount)
+	}
+	return nil
+}
+
+// CancelTasksByOwner force-cancels every unfinished task the owner has, used
+// when the owner is suspended.
+func (s *taskService) CancelTasksByOwner(ctx context.Context, ownerID uuid.UUID) error {
+	tasks, err := s.taskRepo.GetActiveByOwnerID(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		if err := s.ForceCancelTask(ctx, task.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
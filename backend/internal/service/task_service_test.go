@@ -39,7 +39,7 @@ func (m *mockTaskRepo) GetByOwnerID(ctx context.Context, ownerID uuid.UUID, limi
 	return result, nil
 }
 
-func (m *mockTaskRepo) GetOpenTasks(ctx context.Context, limit, offset int) ([]*domain.Task, error) {
+func (m *mockTaskRepo) GetOpenTasks(ctx context.Context, viewerID uuid.UUID, limit, offset int) ([]*domain.Task, error) {
 	var result []*domain.Task
 	for _, task := range m.tasks {
 		if task.Status == domain.TaskStatusOpen {
@@ -89,6 +89,16 @@ func (m *mockTaskRepo) GetTasksPastOwnerDeadline(ctx context.Context) ([]*domain
 	return result, nil
 }
 
+func (m *mockTaskRepo) GetActiveByOwnerID(ctx context.Context, ownerID uuid.UUID) ([]*domain.Task, error) {
+	var result []*domain.Task
+	for _, task := range m.tasks {
+		if task.OwnerID == ownerID && task.Status != domain.TaskStatusCompleted && task.Status != domain.TaskStatusCancelled {
+			result = append(result, task)
+		}
+	}
+	return result, nil
+}
+
 type mockClaimRepo struct {
 	claims map[uuid.UUID]*domain.Claim
 }
@@ -128,7 +138,7 @@ func (m *mockClaimRepo) GetByTaskIDAndClaimerID(ctx context.Context, taskID, cla
 func (m *mockClaimRepo) CountByTaskID(ctx context.Context, taskID uuid.UUID) (int, error) {
 	count := 0
 	for _, claim := range m.claims {
-		if claim.TaskID == taskID && claim.Status != domain.ClaimStatusCancelled {
+		if claim.TaskID == taskID && claim.Status != domain.ClaimStatusCancelled && claim.Status != domain.ClaimStatusApplied {
 			count++
 		}
 	}
@@ -156,26 +166,72 @@ func (m *mockClaimRepo) SubmitCompletion(ctx context.Context, id uuid.UUID, text
 	return nil
 }
 
-type mockEscrowSvc struct{}
+func (m *mockClaimRepo) OpenDispute(ctx context.Context, id uuid.UUID, reason string) error {
+	return nil
+}
+
+func (m *mockClaimRepo) CreateArbitration(ctx context.Context, arbitration *domain.Arbitration) error {
+	return nil
+}
+
+func (m *mockClaimRepo) GetAbandoned(ctx context.Context) ([]*domain.Claim, error) {
+	return nil, nil
+}
+
+func (m *mockClaimRepo) GetOpenDisputes(ctx context.Context, arbitratorID uuid.UUID, limit, offset int) ([]*domain.Claim, error) {
+	var result []*domain.Claim
+	for _, claim := range m.claims {
+		if claim.Status == domain.ClaimStatusDisputed {
+			result = append(result, claim)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockClaimRepo) GetPendingByClaimerID(ctx context.Context, claimerID uuid.UUID) ([]*domain.Claim, error) {
+	var result []*domain.Claim
+	for _, claim := range m.claims {
+		if claim.ClaimerID == claimerID && (claim.Status == domain.ClaimStatusPending || claim.Status == domain.ClaimStatusApplied) {
+			result = append(result, claim)
+		}
+	}
+	return result, nil
+}
+
+// mockEscrowSvc records the tasks whose escrow was released or refunded.
+type mockEscrowSvc struct {
+	released   []uuid.UUID
+	refunded   []uuid.UUID
+	releaseErr error
+}
 
 func (m *mockEscrowSvc) LockEscrow(ctx context.Context, taskID, userID uuid.UUID, amount float64) error {
 	return nil
 }
 
 func (m *mockEscrowSvc) ReleaseEscrow(ctx context.Context, taskID, userID uuid.UUID, amount float64) error {
+	if m.releaseErr != nil {
+		return m.releaseErr
+	}
+	m.released = append(m.released, taskID)
 	return nil
 }
 
 func (m *mockEscrowSvc) RefundEscrow(ctx context.Context, taskID, userID uuid.UUID, amount float64) error {
+	m.refunded = append(m.refunded, taskID)
 	return nil
 }
 
+func (m *mockEscrowSvc) GetTransactionsByTaskID(ctx context.Context, taskID uuid.UUID) ([]*domain.EscrowTransaction, error) {
+	return nil, nil
+}
+
 func TestAutoCancelExpiredTasks(t *testing.T) {
 	taskRepo := &mockTaskRepo{tasks: make(map[uuid.UUID]*domain.Task)}
 	claimRepo := &mockClaimRepo{claims: make(map[uuid.UUID]*domain.Claim)}
 	escrowSvc := &mockEscrowSvc{}
 
-	service := NewTaskService(taskRepo, claimRepo, escrowSvc)
+	service := NewTaskService(taskRepo, claimRepo, escrowSvc, newMockBlockRepo(), nil)
 
 	ownerID := uuid.New()
 	pastDeadline := time.Now().Add(-1 * time.Hour)
@@ -210,7 +266,7 @@ func TestCreateTask(t *testing.T) {
 	claimRepo := &mockClaimRepo{claims: make(map[uuid.UUID]*domain.Claim)}
 	escrowSvc := &mockEscrowSvc{}
 
-	service := NewTaskService(taskRepo, claimRepo, escrowSvc)
+	service := NewTaskService(taskRepo, claimRepo, escrowSvc, newMockBlockRepo(), nil)
 
 	ownerID := uuid.New()
 	req := CreateTaskRequest{
@@ -228,3 +284,50 @@ func TestCreateTask(t *testing.T) {
 	assert.Equal(t, req.Title, task.Title)
 	assert.Equal(t, domain.TaskStatusOpen, task.Status)
 }
+
+func TestGetTaskChecksTheViewer(t *testing.T) {
+	taskRepo := &mockTaskRepo{tasks: make(map[uuid.UUID]*domain.Task)}
+	claimRepo := &mockClaimRepo{claims: make(map[uuid.UUID]*domain.Claim)}
+	blockRepo := newMockBlockRepo()
+	service := NewTaskService(taskRepo, claimRepo, &mockEscrowSvc{}, blockRepo, nil)
+	ctx := context.Background()
+
+	ownerID := uuid.New()
+	claimerID := uuid.New()
+	strangerID := uuid.New()
+
+	open := &domain.Task{ID: uuid.New(), OwnerID: ownerID, Status: domain.TaskStatusOpen}
+	claimed := &domain.Task{ID: uuid.New(), OwnerID: ownerID, Status: domain.TaskStatusClaimed}
+	hiddenAt := time.Now()
+	hidden := &domain.Task{ID: uuid.New(), OwnerID: ownerID, Status: domain.TaskStatusOpen, HiddenAt: &hiddenAt}
+	for _, task := range []*domain.Task{open, claimed, hidden} {
+		taskRepo.tasks[task.ID] = task
+	}
+	claim := &domain.Claim{ID: uuid.New(), TaskID: claimed.ID, ClaimerID: claimerID, Status: domain.ClaimStatusPending}
+	claimRepo.claims[claim.ID] = claim
+
+	// Open tasks are public, the rest only reach the owner and claimers
+	_, err := service.GetTask(ctx, open.ID, strangerID)
+	assert.NoError(t, err)
+	_, err = service.GetTask(ctx, claimed.ID, claimerID)
+	assert.NoError(t, err)
+	_, err = service.GetTask(ctx, claimed.ID, strangerID)
+	assert.Equal(t, ErrTaskNotFound, err)
+
+	// Hidden tasks stay with their owner
+	_, err = service.GetTask(ctx, hidden.ID, ownerID)
+	assert.NoError(t, err)
+	_, err = service.GetTask(ctx, hidden.ID, strangerID)
+	assert.Equal(t, ErrTaskNotFound, err)
+
+	// A block hides the owner's tasks in both directions
+	blockRepo.Create(ctx, &domain.Block{ID: uuid.New(), BlockerID: ownerID, BlockedID: strangerID})
+	_, err = service.GetTask(ctx, open.ID, strangerID)
+	assert.Equal(t, ErrTaskNotFound, err)
+	_, err = service.GetTask(ctx, open.ID, ownerID)
+	assert.NoError(t, err)
+
+	// The admin lookup sees everything
+	_, err = service.GetTaskForAdmin(ctx, hidden.ID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/repository"
+)
+
+// TierLimitError refuses an action that is beyond the user's trust tier.
+type TierLimitError struct {
+	Tier   domain.TrustTier
+	Reason string
+}
+
+func (e *TierLimitError) Error() string {
+	return fmt.Sprintf("trust tier %d: %s", e.Tier, e.Reason)
+}
+
+type TrustService interface {
+	TaskGate
+	ClaimGate
+	GetTier(ctx context.Context, userID uuid.UUID) (*domain.TrustTierInfo, *domain.UserStats, error)
+	CheckArbitration(ctx context.Context, userID uuid.UUID) error
+}
+
+type trustService struct {
+	userRepo repository.UserRepository
+}
+
+func NewTrustService(userRepo repository.UserRepository) TrustService {
+	return &trustService{userRepo: userRepo}
+}
+
+// GetTier computes the user's current tier along with the stats it was
+// computed from.
+func (s *trustService) GetTier(ctx context.Context, userID uuid.UUID) (*domain.TrustTierInfo, *domain.UserStats, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, ErrUserNotFound
+		}
+		return nil, nil, err
+	}
+
+	stats, err := s.userRepo.GetStats(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tier := domain.ComputeTrustTier(user, stats, time.Now())
+	return &tier, stats, nil
+}
+
+// CheckTask caps the reward an owner may offer and keeps application mode
+// to the tiers that unlock it.
+func (s *trustService) CheckTask(ctx context.Context, ownerID uuid.UUID, req CreateTaskRequest) error {
+	tier, _, err := s.GetTier(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if req.RewardAmount > tier.Privileges.MaxTaskReward {
+		return &TierLimitError{
+			Tier:   tier.Tier,
+			Reason: fmt.Sprintf("tasks you post can offer at most %.2f", tier.Privileges.MaxTaskReward),
+		}
+	}
+	if req.ApplicationMode && !tier.Privileges.ApplicationTasks {
+		return &TierLimitError{
+			Tier:   tier.Tier,
+			Reason: "you cannot post application-mode tasks yet",
+		}
+	}
+	return nil
+}
+
+// CheckClaim caps the reward of tasks a worker may claim and how many of
+// their claims may be pending at once.
+func (s *trustService) CheckClaim(ctx context.Context, claimerID uuid.UUID, task *domain.Task) error {
+	tier, stats, err := s.GetTier(ctx, claimerID)
+	if err != nil {
+		return err
+	}
+
+	if task.RewardAmount > tier.Privileges.MaxTaskReward {
+		return &TierLimitError{
+			Tier:   tier.Tier,
+			Reason: fmt.Sprintf("you can only claim tasks offering up to %.2f", tier.Privileges.MaxTaskReward),
+		}
+	}
+	if stats.ActiveClaims >= tier.Privileges.MaxActiveClaims {
+		return &TierLimitError{
+			Tier:   tier.Tier,
+			Reason: fmt.Sprintf("you can hold at most %d active claims", tier.Privileges.MaxActiveClaims),
+		}
+	}
+	return nil
+}
+
+// CheckArbitration keeps dispute arbitration to the tiers that unlock it.
+func (s *trustService) CheckArbitration(ctx context.Context, userID uuid.UUID) error {
+	tier, _, err := s.GetTier(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !tier.Privileges.Arbitration {
+		return &TierLimitError{
+			Tier:   tier.Tier,
+			Reason: "you cannot arbitrate disputes yet",
+		}
+	}
+	return nil
+}
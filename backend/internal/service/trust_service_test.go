@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+func TestComputeTrustTier(t *testing.T) {
+	now := time.Now()
+	daysAgo := func(days int) time.Time { return now.Add(-time.Duration(days) * 24 * time.Hour) }
+	past := now.Add(-time.Hour)
+
+	tests := []struct {
+		name  string
+		user  domain.User
+		stats domain.UserStats
+		tier  domain.TrustTier
+	}{
+		{"brand new", domain.User{CreatedAt: now}, domain.UserStats{}, domain.TrustTierNew},
+		{"old but idle", domain.User{CreatedAt: daysAgo(365)}, domain.UserStats{}, domain.TrustTierNew},
+		{"member", domain.User{CreatedAt: daysAgo(10), Reputation: 3}, domain.UserStats{ClaimsApproved: 2, TasksCompleted: 1}, domain.TrustTierMember},
+		{"too young for trusted", domain.User{CreatedAt: daysAgo(20), Reputation: 30}, domain.UserStats{ClaimsApproved: 20}, domain.TrustTierMember},
+		{"trusted", domain.User{CreatedAt: daysAgo(40), Reputation: 30}, domain.UserStats{ClaimsApproved: 20}, domain.TrustTierTrusted},
+		{"poor approval rate", domain.User{CreatedAt: daysAgo(40), Reputation: 30}, domain.UserStats{ClaimsApproved: 20, ClaimsRejected: 10}, domain.TrustTierMember},
+		{"veteran", domain.User{CreatedAt: daysAgo(100), Reputation: 80}, domain.UserStats{ClaimsApproved: 40, TasksCompleted: 20, ClaimsRejected: 2}, domain.TrustTierVeteran},
+		{"suspended veteran", domain.User{CreatedAt: daysAgo(100), Reputation: 80, SuspendedAt: &past}, domain.UserStats{ClaimsApproved: 60}, domain.TrustTierNew},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.tier, domain.ComputeTrustTier(&tt.user, &tt.stats, now).Tier)
+		})
+	}
+}
+
+func TestTrustTiersGateTasksAndClaims(t *testing.T) {
+	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
+	newcomerID := uuid.New()
+	memberID := uuid.New()
+	userRepo := &mockUserRepo{
+		users: map[uuid.UUID]*domain.User{
+			newcomerID: {ID: newcomerID, CreatedAt: time.Now()},
+			memberID:   {ID: memberID, CreatedAt: time.Now().Add(-10 * 24 * time.Hour), Reputation: 5},
+		},
+		stats: map[uuid.UUID]*domain.UserStats{
+			newcomerID: {ActiveClaims: 2},
+			memberID:   {ClaimsApproved: 5},
+		},
+	}
+	trustSvc := NewTrustService(userRepo)
+	taskSvc := NewTaskService(taskRepo, claimRepo, &mockEscrowSvc{}, newMockBlockRepo(), trustSvc)
+	claimSvc := NewClaimService(claimRepo, taskRepo, &mockChatRepoForClaimSvc{}, &mockEscrowSvc{}, userRepo, &mockReputationSvc{}, newMockBlockRepo(), trustSvc, nil)
+	ctx := context.Background()
+
+	request := func(reward float64) CreateTaskRequest {
+		return CreateTaskRequest{
+			Title:         "Task",
+			Description:   "Description",
+			RewardAmount:  reward,
+			MaxClaimants:  3,
+			ClaimDeadline: time.Now().Add(24 * time.Hour),
+			OwnerDeadline: time.Now().Add(48 * time.Hour),
+		}
+	}
+
+	// Rewards above the tier's cap are refused with the cap in the message
+	_, err := taskSvc.CreateTask(ctx, newcomerID, request(50))
+	assert.IsType(t, &TierLimitError{}, err)
+	assert.Contains(t, err.Error(), "25.00")
+	task, err := taskSvc.CreateTask(ctx, memberID, request(50))
+	assert.NoError(t, err)
+
+	// The newcomer can neither claim it nor hold more claims
+	_, err = claimSvc.ClaimTask(ctx, task.ID, newcomerID)
+	assert.IsType(t, &TierLimitError{}, err)
+	small, err := taskSvc.CreateTask(ctx, memberID, request(10))
+	assert.NoError(t, err)
+	_, err = claimSvc.ClaimTask(ctx, small.ID, newcomerID)
+	assert.EqualError(t, err, "trust tier 0: you can hold at most 2 active claims")
+
+	userRepo.stats[newcomerID].ActiveClaims = 0
+	_, err = claimSvc.ClaimTask(ctx, small.ID, newcomerID)
+	assert.NoError(t, err)
+}
+
+func TestApplicationModeTasks(t *testing.T) {
+	claimRepo := &mockClaimRepoForClaimSvc{claims: make(map[uuid.UUID]*domain.Claim)}
+	taskRepo := &mockTaskRepoForClaimSvc{tasks: make(map[uuid.UUID]*domain.Task)}
+	memberID := uuid.New()
+	trustedID := uuid.New()
+	applicantID := uuid.New()
+	otherID := uuid.New()
+	userRepo := &mockUserRepo{
+		users: map[uuid.UUID]*domain.User{
+			memberID:    {ID: memberID, CreatedAt: time.Now().Add(-10 * 24 * time.Hour), Reputation: 5},
+			trustedID:   {ID: trustedID, CreatedAt: time.Now().Add(-40 * 24 * time.Hour), Reputation: 30},
+			applicantID: {ID: applicantID, CreatedAt: time.Now()},
+			otherID:     {ID: otherID, CreatedAt: time.Now()},
+		},
+		stats: map[uuid.UUID]*domain.UserStats{
+			memberID:    {ClaimsApproved: 5},
+			trustedID:   {ClaimsApproved: 20},
+			applicantID: {},
+			otherID:     {},
+		},
+	}
+	trustSvc := NewTrustService(userRepo)
+	taskSvc := NewTaskService(taskRepo, claimRepo, &mockEscrowSvc{}, newMockBlockRepo(), trustSvc)
+	claimSvc := NewClaimService(claimRepo, taskRepo, &mockChatRepoForClaimSvc{}, &mockEscrowSvc{}, userRepo, &mockReputationSvc{}, newMockBlockRepo(), trustSvc, nil)
+	ctx := context.Background()
+
+	request := CreateTaskRequest{
+		Title:           "Task",
+		Description:     "Description",
+		RewardAmount:    20,
+		MaxClaimants:    1,
+		ClaimDeadline:   time.Now().Add(24 * time.Hour),
+		OwnerDeadline:   time.Now().Add(48 * time.Hour),
+		ApplicationMode: true,
+	}
+
+	// Application mode is a trusted-tier privilege
+	_, err := taskSvc.CreateTask(ctx, memberID, request)
+	assert.EqualError(t, err, "trust tier 1: you cannot post application-mode tasks yet")
+	task, err := taskSvc.CreateTask(ctx, trustedID, request)
+	assert.NoError(t, err)
+	assert.True(t, task.ApplicationMode)
+
+	// Applying does not take the only slot or start the work
+	application, err := claimSvc.ClaimTask(ctx, task.ID, applicantID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ClaimStatusApplied, application.Status)
+	other, err := claimSvc.ClaimTask(ctx, task.ID, otherID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.TaskStatusOpen, task.Status)
+	_, err = claimSvc.SubmitCompletion(ctx, application.ID, applicantID, "done", "")
+	assert.Equal(t, ErrInvalidClaimState, err)
+
+	// Only the owner accepts, and only as many as the task takes
+	_, err = claimSvc.AcceptApplication(ctx, application.ID, applicantID)
+	assert.Equal(t, ErrUnauthorized, err)
+	accepted, err := claimSvc.AcceptApplication(ctx, application.ID, trustedID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ClaimStatusPending, accepted.Status)
+	assert.Equal(t, domain.TaskStatusClaimed, task.Status)
+	_, err = claimSvc.AcceptApplication(ctx, other.ID, trustedID)
+	assert.Equal(t, ErrClaimLimitReached, err)
+
+	// Declining the other application cancels it
+	assert.NoError(t, claimSvc.RejectClaim(ctx, other.ID, trustedID))
+	assert.Equal(t, domain.ClaimStatusCancelled, claimRepo.claims[other.ID].Status)
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/task-underground/backend/internal/domain"
@@ -16,14 +17,29 @@ var (
 type UserService interface {
 	GetOrCreateUser(ctx context.Context, deviceID string) (*domain.User, error)
 	GetUser(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	GetProfile(ctx context.Context, userID uuid.UUID) (*domain.Profile, error)
+	GetPublicProfile(ctx context.Context, taskID uuid.UUID, alias string, viewerID uuid.UUID) (*domain.PublicProfile, error)
 }
 
 type userService struct {
-	userRepo repository.UserRepository
+	userRepo   repository.UserRepository
+	taskRepo   repository.TaskRepository
+	reviewRepo repository.ReviewRepository
+	aliasSvc   AliasService
 }
 
-func NewUserService(userRepo repository.UserRepository) UserService {
-	return &userService{userRepo: userRepo}
+func NewUserService(
+	userRepo repository.UserRepository,
+	taskRepo repository.TaskRepository,
+	reviewRepo repository.ReviewRepository,
+	aliasSvc AliasService,
+) UserService {
+	return &userService{
+		userRepo:   userRepo,
+		taskRepo:   taskRepo,
+		reviewRepo: reviewRepo,
+		aliasSvc:   aliasSvc,
+	}
 }
 
 func (s *userService) GetOrCreateUser(ctx context.Context, deviceID string) (*domain.User, error) {
@@ -40,3 +56,92 @@ func (s *userService) GetUser(ctx context.Context, id uuid.UUID) (*domain.User,
 	}
 	return user, nil
 }
+
+func (s *userService) GetProfile(ctx context.Context, userID uuid.UUID) (*domain.Profile, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.userRepo.GetStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ratings, err := s.reviewRepo.GetRatings(ctx, userID, time.Now().Add(-domain.ReviewWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	tier := domain.ComputeTrustTier(user, stats, time.Now())
+	var nextTier *domain.TrustRequirement
+	if next := domain.NextTrustTier(tier.Tier); next != nil {
+		nextTier = &next.Requirement
+	}
+
+	return &domain.Profile{
+		CreatedAt:      user.CreatedAt,
+		Reputation:     user.Reputation,
+		ReputationBand: domain.ReputationBand(user.Reputation),
+		WorkerScore:    user.WorkerScore,
+		PosterScore:    user.PosterScore,
+		TotalEarned:    user.TotalEarned,
+		TotalSpent:     user.TotalSpent,
+		Stats:          *stats,
+		ApprovalRate:   stats.ApprovalRate(),
+		Ratings:        *ratings,
+		TrustTier:      tier.Tier,
+		Privileges:     tier.Privileges,
+		NextTier:       nextTier,
+	}, nil
+}
+
+// GetPublicProfile shows a task participant to another user. Anyone may look
+// at a task owner; claimers are only visible to the owner and themselves.
+func (s *userService) GetPublicProfile(ctx context.Context, taskID uuid.UUID, alias string, viewerID uuid.UUID) (*domain.PublicProfile, error) {
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return nil, ErrTaskNotFound
+	}
+
+	userID, err := s.aliasSvc.Resolve(ctx, taskID, alias)
+	if err != nil {
+		return nil, err
+	}
+
+	if !can(viewerID, ActionViewParticipant, ParticipantResource{Task: task, UserID: userID}) {
+		return nil, ErrParticipantNotFound
+	}
+
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.userRepo.GetStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ratings, err := s.reviewRepo.GetRatings(ctx, userID, time.Now().Add(-domain.ReviewWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	// Show how the participant rates in the role they play on this task
+	summary := ratings.AsWorker
+	if userID == task.OwnerID {
+		summary = ratings.AsPoster
+	}
+
+	return &domain.PublicProfile{
+		Participant: domain.Participant{
+			Alias:          alias,
+			IsSelf:         userID == viewerID,
+			ReputationBand: domain.ReputationBand(user.Reputation),
+		},
+		CompletionRate:   stats.CompletionRate(),
+		Rating:           summary.Coarse(),
+		AccountAgeBucket: domain.AccountAgeBucket(user.CreatedAt, time.Now()),
+	}, nil
+}
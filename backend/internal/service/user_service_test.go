@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+func TestGetPublicProfileVisibility(t *testing.T) {
+	taskRepo := &mockTaskRepo{tasks: make(map[uuid.UUID]*domain.Task)}
+	claimRepo := &mockClaimRepo{claims: make(map[uuid.UUID]*domain.Claim)}
+	aliasSvc := NewAliasService(&mockUserRepo{}, taskRepo, claimRepo, []byte("test-secret"))
+	service := NewUserService(&mockUserRepo{}, taskRepo, newMockReviewRepo(), aliasSvc)
+	ctx := context.Background()
+
+	ownerID := uuid.New()
+	claimerID := uuid.New()
+	strangerID := uuid.New()
+	taskID := uuid.New()
+	taskRepo.tasks[taskID] = &domain.Task{ID: taskID, OwnerID: ownerID}
+	claimID := uuid.New()
+	claimRepo.claims[claimID] = &domain.Claim{ID: claimID, TaskID: taskID, ClaimerID: claimerID}
+
+	ownerAlias := aliasSvc.Alias(taskID, ownerID)
+	claimerAlias := aliasSvc.Alias(taskID, claimerID)
+
+	// Anyone can see the owner
+	profile, err := service.GetPublicProfile(ctx, taskID, ownerAlias, strangerID)
+	assert.NoError(t, err)
+	assert.Equal(t, ownerAlias, profile.Alias)
+	assert.Equal(t, domain.AccountAgeOverHalfYear, profile.AccountAgeBucket)
+	assert.Nil(t, profile.CompletionRate)
+
+	// Only the owner can see a claimer
+	profile, err = service.GetPublicProfile(ctx, taskID, claimerAlias, ownerID)
+	assert.NoError(t, err)
+	assert.False(t, profile.IsSelf)
+
+	_, err = service.GetPublicProfile(ctx, taskID, claimerAlias, strangerID)
+	assert.Equal(t, ErrParticipantNotFound, err)
+
+	_, err = service.GetPublicProfile(ctx, taskID, "Amber-Fox-0000", ownerID)
+	assert.Equal(t, ErrParticipantNotFound, err)
+}
+
+func TestCompletionRateIsRounded(t *testing.T) {
+	stats := &domain.UserStats{ClaimsApproved: 2, ClaimsRejected: 1}
+	assert.InDelta(t, 0.6667, *stats.ApprovalRate(), 0.001)
+	assert.Equal(t, 0.7, *stats.CompletionRate())
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalBlobStore(t *testing.T) {
+	store, err := NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "attachments/a", strings.NewReader("hello")))
+	r, err := store.Open(ctx, "attachments/a")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	r.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	require.NoError(t, store.Delete(ctx, "attachments/a"))
+	_, err = store.Open(ctx, "attachments/a")
+	assert.Equal(t, ErrBlobNotFound, err)
+	assert.NoError(t, store.Delete(ctx, "attachments/a"))
+
+	// Keys cannot reach outside the store
+	assert.Error(t, store.Put(ctx, "../escape", strings.NewReader("x")))
+	assert.Error(t, store.Put(ctx, "/etc/escape", strings.NewReader("x")))
+}
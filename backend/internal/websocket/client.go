@@ -8,10 +8,9 @@ import (
 )
 
 const (
-	writeWait      = 10 * time.Second
-	pongWait       = 60 * time.Second
-	pingPeriod     = (pongWait * 9) / 10
-	maxMessageSize = 512
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = (pongWait * 9) / 10
 )
 
 func (c *Client) ReadPump() {
@@ -21,25 +20,37 @@ func (c *Client) ReadPump() {
 	}()
 
 	c.Conn.SetReadDeadline(time.Now().Add(pongWait))
-	c.Conn.SetReadLimit(maxMessageSize)
+	// A larger frame closes the connection with 1009 (message too big)
+	c.Conn.SetReadLimit(c.maxMessageSize)
 	c.Conn.SetPongHandler(func(string) error {
 		c.Conn.SetReadDeadline(time.Now().Add(pongWait))
 		return nil
 	})
 
 	for {
-		_, _, err := c.Conn.ReadMessage()
+		_, data, err := c.Conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("WebSocket error: %v", err)
 			}
 			break
 		}
+		if c.commands != nil {
+			c.commands.handleCommand(c, data)
+		}
 	}
 }
 
 func (c *Client) WritePump() {
 	ticker := time.NewTicker(pingPeriod)
+	// The connection ends with the access token it was opened with, and
+	// the client reconnects with a fresh one
+	var expired <-chan time.Time
+	if !c.ExpiresAt.IsZero() {
+		expiry := time.NewTimer(time.Until(c.ExpiresAt))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
 	defer func() {
 		ticker.Stop()
 		c.Conn.Close()
@@ -70,6 +81,11 @@ func (c *Client) WritePump() {
 				return
 			}
 
+		case <-expired:
+			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+			c.Conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "access token expired"))
+			return
+
 		case <-ticker.C:
 			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
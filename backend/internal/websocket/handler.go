@@ -14,20 +14,51 @@ import (
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
+	Subprotocols:    []string{middleware.WebSocketTokenProtocol},
 	CheckOrigin: func(r *http.Request) bool {
 		return true // Allow all origins in development
 	},
 }
 
+const defaultMaxMessageSize = 4096
+
+// WSConfig tunes the WebSocket endpoint. Zero values fall back to the
+// defaults.
+type WSConfig struct {
+	// MaxMessageSize is the largest frame, in bytes, a client may send.
+	MaxMessageSize int64
+}
+
 type WSHandler struct {
 	hub      *Hub
 	userSvc  service.UserService
+	chatSvc  service.ChatService
+	aliasSvc service.AliasService
+	limiter  *middleware.RateLimiter
+	config   WSConfig
 }
 
-func NewWSHandler(hub *Hub, userSvc service.UserService) *WSHandler {
+// NewWSHandler serves the WebSocket endpoint. Commands are authorized by
+// the same services as the REST routes and, when limiter is set, share
+// their per-user rate limits.
+func NewWSHandler(
+	hub *Hub,
+	userSvc service.UserService,
+	chatSvc service.ChatService,
+	aliasSvc service.AliasService,
+	limiter *middleware.RateLimiter,
+	config WSConfig,
+) *WSHandler {
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = defaultMaxMessageSize
+	}
 	return &WSHandler{
-		hub:     hub,
-		userSvc: userSvc,
+		hub:      hub,
+		userSvc:  userSvc,
+		chatSvc:  chatSvc,
+		aliasSvc: aliasSvc,
+		limiter:  limiter,
+		config:   config,
 	}
 }
 
@@ -45,11 +76,17 @@ func (h *WSHandler) HandleWebSocket(c *gin.Context) {
 	}
 
 	client := &Client{
-		ID:     uuid.New(),
-		UserID: userID,
-		Conn:   conn,
-		Send:   make(chan []byte, 256),
-		Hub:    h.hub,
+		ID:        uuid.New(),
+		UserID:    userID,
+		DeviceID:  middleware.GetDeviceID(c),
+		SessionID: middleware.GetSessionID(c),
+		ExpiresAt: middleware.GetExpiresAt(c),
+		Conn:      conn,
+		Send:      make(chan []byte, 256),
+		Hub:       h.hub,
+
+		commands:       h,
+		maxMessageSize: h.config.MaxMessageSize,
 	}
 
 	client.Hub.register <- client
@@ -1,42 +1,95 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"github.com/task-underground/backend/internal/domain"
 )
 
+// BlockChecker reports whether two users have blocked each other.
+type BlockChecker interface {
+	IsBlocked(ctx context.Context, userID, otherUserID uuid.UUID) (bool, error)
+}
+
 type Hub struct {
 	clients    map[uuid.UUID]*Client
 	broadcast  chan []byte
 	register   chan *Client
 	unregister chan *Client
+	blocks     BlockChecker
 	mu         sync.RWMutex
 }
 
 type Client struct {
-	ID       uuid.UUID
-	UserID   uuid.UUID
-	Conn     *websocket.Conn
-	Send     chan []byte
-	Hub      *Hub
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	DeviceID  uuid.UUID
+	SessionID uuid.UUID
+	// ExpiresAt is when the access token the connection was opened with
+	// expires, and the connection with it. Zero never expires.
+	ExpiresAt time.Time
+	Conn      *websocket.Conn
+	Send      chan []byte
+	Hub       *Hub
+
+	// commands answers the frames the client sends; maxMessageSize caps
+	// their size.
+	commands       *WSHandler
+	maxMessageSize int64
+
+	mu            sync.Mutex
+	subscriptions map[uuid.UUID]bool
+}
+
+func (c *Client) subscribe(chatID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.subscriptions == nil {
+		c.subscriptions = make(map[uuid.UUID]bool)
+	}
+	c.subscriptions[chatID] = true
 }
 
-type Message struct {
-	Type    string      `json:"type"`
-	Payload interface{} `json:"payload"`
+func (c *Client) unsubscribe(chatID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.subscriptions, chatID)
 }
 
-func NewHub() *Hub {
+func (c *Client) subscribed(chatID uuid.UUID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.subscriptions[chatID]
+}
+
+func NewHub(blocks BlockChecker) *Hub {
 	return &Hub{
 		clients:    make(map[uuid.UUID]*Client),
 		broadcast:  make(chan []byte),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		blocks:     blocks,
+	}
+}
+
+// deliverable reports whether an event from event.From may reach userID.
+// Errors fail closed.
+func (h *Hub) deliverable(event domain.Event, userID uuid.UUID) bool {
+	if event.From == uuid.Nil || event.From == userID || h.blocks == nil {
+		return true
 	}
+	blocked, err := h.blocks.IsBlocked(context.Background(), event.From, userID)
+	if err != nil {
+		log.Printf("Error checking block list: %v", err)
+		return false
+	}
+	return !blocked
 }
 
 func (h *Hub) Run() {
@@ -58,65 +111,152 @@ func (h *Hub) Run() {
 			log.Printf("Client disconnected: %s", client.ID)
 
 		case message := <-h.broadcast:
-			h.mu.RLock()
-			for _, client := range h.clients {
-				select {
-				case client.Send <- message:
-				default:
-					close(client.Send)
-					delete(h.clients, client.ID)
-				}
-			}
-			h.mu.RUnlock()
+			h.deliver(message, func(client *Client) bool { return true })
 		}
 	}
 }
 
-func (h *Hub) BroadcastToUser(userID uuid.UUID, message Message) {
-	data, err := json.Marshal(message)
+// BroadcastToUser pushes an event to every device the user has connected.
+func (h *Hub) BroadcastToUser(userID uuid.UUID, event domain.Event) {
+	if !h.deliverable(event, userID) {
+		return
+	}
+
+	data, err := encodeEvent(event)
 	if err != nil {
 		log.Printf("Error marshaling message: %v", err)
 		return
 	}
 
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	h.deliver(data, func(client *Client) bool { return client.UserID == userID })
+}
 
-	for _, client := range h.clients {
-		if client.UserID == userID {
-			select {
-			case client.Send <- data:
-			default:
-				close(client.Send)
-				delete(h.clients, client.ID)
-			}
+// BroadcastToChat pushes an event to the user's devices that are
+// subscribed to the chat.
+func (h *Hub) BroadcastToChat(userID, chatID uuid.UUID, event domain.Event) {
+	if !h.deliverable(event, userID) {
+		return
+	}
+
+	data, err := encodeEvent(event)
+	if err != nil {
+		log.Printf("Error marshaling message: %v", err)
+		return
+	}
+
+	h.deliver(data, func(client *Client) bool {
+		return client.UserID == userID && client.subscribed(chatID)
+	})
+}
+
+func (h *Hub) BroadcastToTask(taskID uuid.UUID, event domain.Event, userIDs []uuid.UUID) {
+	data, err := encodeEvent(event)
+	if err != nil {
+		log.Printf("Error marshaling message: %v", err)
+		return
+	}
+
+	userMap := make(map[uuid.UUID]bool)
+	for _, id := range userIDs {
+		if h.deliverable(event, id) {
+			userMap[id] = true
 		}
 	}
+
+	h.deliver(data, func(client *Client) bool { return userMap[client.UserID] })
 }
 
-func (h *Hub) BroadcastToTask(taskID uuid.UUID, message Message, userIDs []uuid.UUID) {
-	data, err := json.Marshal(message)
+// reply sends an answer to one of the client's commands.
+func (h *Hub) reply(client *Client, event domain.Event) {
+	data, err := encodeEvent(event)
 	if err != nil {
 		log.Printf("Error marshaling message: %v", err)
 		return
 	}
 
 	h.mu.RLock()
-	defer h.mu.RUnlock()
+	registered := h.clients[client.ID] == client
+	full := false
+	if registered {
+		select {
+		case client.Send <- data:
+		default:
+			full = true
+		}
+	}
+	h.mu.RUnlock()
 
-	userMap := make(map[uuid.UUID]bool)
-	for _, id := range userIDs {
-		userMap[id] = true
+	if full {
+		h.drop([]*Client{client})
 	}
+}
+
+// deliver queues data for every client that matches. A client whose queue
+// is full cannot keep up and is disconnected. Send channels are only closed
+// under the write lock, so sending under the read lock is safe.
+func (h *Hub) deliver(data []byte, match func(client *Client) bool) {
+	var slow []*Client
 
+	h.mu.RLock()
 	for _, client := range h.clients {
-		if userMap[client.UserID] {
-			select {
-			case client.Send <- data:
-			default:
-				close(client.Send)
-				delete(h.clients, client.ID)
-			}
+		if !match(client) {
+			continue
+		}
+		select {
+		case client.Send <- data:
+		default:
+			slow = append(slow, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	h.drop(slow)
+}
+
+func (h *Hub) drop(clients []*Client) {
+	if len(clients) == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, client := range clients {
+		if _, ok := h.clients[client.ID]; ok {
+			close(client.Send)
+			delete(h.clients, client.ID)
 		}
 	}
 }
+
+// DisconnectUser closes every connection the user has open, used when the
+// user's sessions are revoked.
+func (h *Hub) DisconnectUser(userID uuid.UUID) {
+	h.disconnect(func(client *Client) bool { return client.UserID == userID })
+}
+
+// DisconnectSession closes the connections opened with the session's access
+// tokens, used on logout and when a device is revoked.
+func (h *Hub) DisconnectSession(sessionID uuid.UUID) {
+	h.disconnect(func(client *Client) bool { return client.SessionID == sessionID })
+}
+
+func (h *Hub) disconnect(match func(client *Client) bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, client := range h.clients {
+		if match(client) {
+			close(client.Send)
+			delete(h.clients, client.ID)
+		}
+	}
+}
+
+func encodeEvent(event domain.Event) ([]byte, error) {
+	event.Version = domain.EventProtocolVersion
+	if event.SentAt.IsZero() {
+		event.SentAt = time.Now()
+	}
+	return json.Marshal(event)
+}
@@ -0,0 +1,795 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/middleware"
+	"github.com/task-underground/backend/internal/repository"
+	"github.com/task-underground/backend/internal/service"
+	"github.com/task-underground/backend/internal/storage"
+)
+
+// fakeChatRepo hands out copies of what it stores, like a database would.
+type fakeChatRepo struct {
+	repository.ChatRepository
+	mu          sync.Mutex
+	chat        domain.Chat
+	messages    []*domain.Message
+	attachments []*domain.Attachment
+	envelopes   []*domain.Envelope
+	reads       map[uuid.UUID]time.Time
+	// devices maps the devices that have published keys to their users
+	devices map[uuid.UUID]uuid.UUID
+}
+
+func (f *fakeChatRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Chat, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if id != f.chat.ID {
+		return nil, sql.ErrNoRows
+	}
+	chat := f.chat
+	return &chat, nil
+}
+
+func (f *fakeChatRepo) GetMessageByID(ctx context.Context, id uuid.UUID) (*domain.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, message := range f.messages {
+		if message.ID == id {
+			stored := *message
+			return &stored, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeChatRepo) CreateMessage(ctx context.Context, message *domain.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	message.CreatedAt = time.Now()
+	stored := *message
+	stored.Attachments = nil
+	stored.Envelopes = nil
+	f.messages = append(f.messages, &stored)
+	f.attachments = append(f.attachments, message.Attachments...)
+	for _, envelope := range message.Envelopes {
+		f.envelopes = append(f.envelopes, &domain.Envelope{MessageID: message.ID, DeviceID: envelope.DeviceID, Payload: envelope.Payload})
+	}
+	return nil
+}
+
+func (f *fakeChatRepo) SetMessageTTL(ctx context.Context, chatID uuid.UUID, seconds int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.chat.MessageTTL = seconds
+	return nil
+}
+
+func (f *fakeChatRepo) GetMessagesByChatID(ctx context.Context, chatID uuid.UUID, since *time.Time, limit, offset int) ([]*domain.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var messages []*domain.Message
+	for _, message := range f.messages {
+		if since != nil && !message.CreatedAt.After(*since) {
+			continue
+		}
+		stored := *message
+		if stored.IsTombstone() {
+			stored.Content, stored.Ciphertext = "", nil
+		}
+		messages = append(messages, &stored)
+	}
+	return messages, nil
+}
+
+func (f *fakeChatRepo) GetEnvelopes(ctx context.Context, messageIDs []uuid.UUID, userID uuid.UUID) ([]*domain.Envelope, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var envelopes []*domain.Envelope
+	for _, envelope := range f.envelopes {
+		for _, id := range messageIDs {
+			if envelope.MessageID == id && f.devices[envelope.DeviceID] == userID {
+				envelopes = append(envelopes, envelope)
+			}
+		}
+	}
+	return envelopes, nil
+}
+
+func (f *fakeChatRepo) EditMessage(ctx context.Context, message *domain.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	message.EditedAt = &now
+	for _, stored := range f.messages {
+		if stored.ID == message.ID {
+			stored.Content = message.Content
+			stored.EditedAt = &now
+		}
+	}
+	return nil
+}
+
+func (f *fakeChatRepo) UnsendMessage(ctx context.Context, message *domain.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	message.DeletedAt = &now
+	for _, stored := range f.messages {
+		if stored.ID == message.ID {
+			stored.DeletedAt = &now
+		}
+	}
+	return nil
+}
+
+func (f *fakeChatRepo) GetAttachment(ctx context.Context, id uuid.UUID) (*domain.Attachment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, attachment := range f.attachments {
+		if attachment.ID == id {
+			return attachment, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeChatRepo) GetAttachments(ctx context.Context, messageIDs []uuid.UUID) ([]*domain.Attachment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var attachments []*domain.Attachment
+	for _, attachment := range f.attachments {
+		for _, id := range messageIDs {
+			if attachment.MessageID == id {
+				attachments = append(attachments, attachment)
+			}
+		}
+	}
+	return attachments, nil
+}
+
+func (f *fakeChatRepo) GetLastMessage(ctx context.Context, chatID, userID uuid.UUID) (*domain.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	start := f.chat.HistoryStart(userID)
+	for i := len(f.messages) - 1; i >= 0; i-- {
+		if start == nil || f.messages[i].CreatedAt.After(*start) {
+			stored := *f.messages[i]
+			return &stored, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeChatRepo) MarkRead(ctx context.Context, chatID, userID, messageID uuid.UUID) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, message := range f.messages {
+		if message.ID == messageID {
+			if !message.CreatedAt.After(f.reads[userID]) {
+				return false, nil
+			}
+			if f.reads == nil {
+				f.reads = make(map[uuid.UUID]time.Time)
+			}
+			f.reads[userID] = message.CreatedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeChatRepo) CountUnread(ctx context.Context, userID uuid.UUID) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, message := range f.messages {
+		if message.SenderID != userID && message.CreatedAt.After(f.reads[userID]) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// fakeKeys shares the chat fake's devices, which the envelopes need too.
+type fakeKeys struct {
+	repository.KeyRepository
+	chatRepo *fakeChatRepo
+}
+
+func (f *fakeKeys) GetKeyedDevices(ctx context.Context, chatID uuid.UUID, userIDs []uuid.UUID) (map[uuid.UUID]uuid.UUID, error) {
+	f.chatRepo.mu.Lock()
+	defer f.chatRepo.mu.Unlock()
+	devices := make(map[uuid.UUID]uuid.UUID)
+	for deviceID, userID := range f.chatRepo.devices {
+		for _, id := range userIDs {
+			if userID == id {
+				devices[deviceID] = userID
+			}
+		}
+	}
+	return devices, nil
+}
+
+type fakeBlocks struct {
+	repository.BlockRepository
+	mu      sync.Mutex
+	blocked bool
+}
+
+func (f *fakeBlocks) IsBlocked(ctx context.Context, userID, otherUserID uuid.UUID) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.blocked, nil
+}
+
+type fakeAliases struct {
+	service.AliasService
+}
+
+func (f *fakeAliases) PresentMessages(ctx context.Context, chat *domain.Chat, messages []*domain.Message, viewerID uuid.UUID) ([]*domain.MessageView, error) {
+	views := make([]*domain.MessageView, 0, len(messages))
+	for _, message := range messages {
+		views = append(views, &domain.MessageView{
+			Message: message,
+			Sender:  domain.Participant{Alias: "Quiet Otter", IsSelf: message.SenderID == viewerID},
+		})
+	}
+	return views, nil
+}
+
+func (f *fakeAliases) DeviceAlias(chatID, deviceID uuid.UUID) uuid.UUID {
+	return uuid.NewSHA1(chatID, deviceID[:])
+}
+
+type chatFixture struct {
+	hub       *Hub
+	server    *httptest.Server
+	chatRepo  *fakeChatRepo
+	blocks    *fakeBlocks
+	chatSvc   service.ChatService
+	ownerID   uuid.UUID
+	claimerID uuid.UUID
+}
+
+func newChatFixture(t *testing.T) *chatFixture {
+	gin.SetMode(gin.TestMode)
+
+	f := &chatFixture{
+		blocks:    &fakeBlocks{},
+		ownerID:   uuid.New(),
+		claimerID: uuid.New(),
+	}
+	f.chatRepo = &fakeChatRepo{chat: domain.Chat{
+		ID:                 uuid.New(),
+		TaskID:             uuid.New(),
+		ParticipantID:      f.claimerID,
+		OtherParticipantID: f.ownerID,
+	}}
+	f.hub = NewHub(f.blocks)
+	go f.hub.Run()
+	blobs, err := storage.NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+	f.chatSvc = service.NewChatService(f.chatRepo, nil, nil, f.blocks, &fakeKeys{chatRepo: f.chatRepo}, &fakeAliases{}, f.hub, service.ChatConfig{EditWindow: time.Minute, Blobs: blobs})
+
+	limiter := middleware.NewRateLimiter(middleware.NewMemoryRateLimitStore(), middleware.DefaultIPRateLimit, map[middleware.RouteClass]middleware.RateLimit{
+		middleware.RouteClassRead:    {Requests: 100, Window: time.Minute},
+		middleware.RouteClassWrite:   {Requests: 100, Window: time.Minute},
+		middleware.RouteClassMessage: {Requests: 3, Window: time.Minute},
+	})
+	wsHandler := NewWSHandler(f.hub, nil, f.chatSvc, &fakeAliases{}, limiter, WSConfig{MaxMessageSize: 1024})
+
+	// Authentication is covered elsewhere; here the user, session and
+	// token expiry come from the query
+	r := gin.New()
+	r.GET("/ws", func(c *gin.Context) {
+		c.Set(middleware.UserIDKey, uuid.MustParse(c.Query("user")))
+		if session := c.Query("session"); session != "" {
+			c.Set(middleware.SessionIDKey, uuid.MustParse(session))
+		}
+		if expires := c.Query("expires"); expires != "" {
+			expiresAt, _ := time.Parse(time.RFC3339Nano, expires)
+			c.Set(middleware.ExpiresAtKey, expiresAt)
+		}
+	}, wsHandler.HandleWebSocket)
+	f.server = httptest.NewServer(r)
+	t.Cleanup(f.server.Close)
+
+	return f
+}
+
+// testConn reads the server's frames one event at a time, since several
+// queued events can share a frame.
+type testConn struct {
+	*websocket.Conn
+	pending []string
+}
+
+// connect opens a WebSocket for userID and waits until the hub has
+// registered it.
+func (f *chatFixture) connect(t *testing.T, userID uuid.UUID) *testConn {
+	return f.connectWith(t, userID, url.Values{})
+}
+
+// connectWith is connect with further query parameters for the fixture's
+// stand-in authentication.
+func (f *chatFixture) connectWith(t *testing.T, userID uuid.UUID, query url.Values) *testConn {
+	before := f.connections(userID)
+
+	query.Set("user", userID.String())
+	address := "ws" + strings.TrimPrefix(f.server.URL, "http") + "/ws?" + query.Encode()
+	conn, _, err := websocket.DefaultDialer.Dial(address, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	require.Eventually(t, func() bool { return f.connections(userID) == before+1 }, time.Second, 5*time.Millisecond)
+	return &testConn{Conn: conn}
+}
+
+func (c *testConn) next(t *testing.T) string {
+	if len(c.pending) == 0 {
+		c.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, data, err := c.ReadMessage()
+		require.NoError(t, err)
+		c.pending = strings.Split(string(data), "\n")
+	}
+	line := c.pending[0]
+	c.pending = c.pending[1:]
+	return line
+}
+
+func (f *chatFixture) connections(userID uuid.UUID) int {
+	f.hub.mu.RLock()
+	defer f.hub.mu.RUnlock()
+
+	n := 0
+	for _, client := range f.hub.clients {
+		if client.UserID == userID {
+			n++
+		}
+	}
+	return n
+}
+
+type chatMessageEvent struct {
+	Version int              `json:"v"`
+	Type    domain.EventType `json:"type"`
+	SentAt  time.Time        `json:"sent_at"`
+	Payload struct {
+		ID          uuid.UUID            `json:"id"`
+		ChatID      uuid.UUID            `json:"chat_id"`
+		Content     string               `json:"content"`
+		Sender      domain.Participant   `json:"sender"`
+		Attachments []*domain.Attachment `json:"attachments"`
+		Encrypted   bool                 `json:"encrypted"`
+		Ciphertext  []byte               `json:"ciphertext"`
+		Envelopes   []*domain.Envelope   `json:"envelopes"`
+		ExpiresAt   *time.Time           `json:"expires_at"`
+		MessageTTL  int                  `json:"message_ttl"`
+	} `json:"payload"`
+}
+
+func readEvent(t *testing.T, conn *testConn) (chatMessageEvent, string) {
+	raw := conn.next(t)
+
+	var event chatMessageEvent
+	require.NoError(t, json.Unmarshal([]byte(raw), &event))
+	return event, raw
+}
+
+// assertNoEvent checks that nothing was queued for conn by pinging it: the
+// ack has to be the next frame.
+func assertNoEvent(t *testing.T, conn *testConn) {
+	conn.command(t, "sync", CommandPing, nil)
+	raw := conn.next(t)
+
+	var frame replyFrame
+	require.NoError(t, json.Unmarshal([]byte(raw), &frame))
+	assert.Equal(t, domain.EventAck, frame.Type, "expected no event, got %s", raw)
+}
+
+func TestChatMessageIsPushedToEveryRecipientDevice(t *testing.T) {
+	f := newChatFixture(t)
+	phone := f.connect(t, f.claimerID)
+	tablet := f.connect(t, f.claimerID)
+	sender := f.connect(t, f.ownerID)
+
+	message, err := f.chatSvc.SendMessage(context.Background(), f.chatRepo.chat.ID, f.ownerID, "the key is under the mat", nil)
+	require.NoError(t, err)
+
+	for _, conn := range []*testConn{phone, tablet} {
+		event, raw := readEvent(t, conn)
+		assert.Equal(t, domain.EventProtocolVersion, event.Version)
+		assert.Equal(t, domain.EventChatMessage, event.Type)
+		assert.False(t, event.SentAt.IsZero())
+		assert.Equal(t, message.ID, event.Payload.ID)
+		assert.Equal(t, f.chatRepo.chat.ID, event.Payload.ChatID)
+		assert.Equal(t, "the key is under the mat", event.Payload.Content)
+		assert.Equal(t, domain.Participant{Alias: "Quiet Otter"}, event.Payload.Sender)
+
+		// Only the alias identifies the sender
+		assert.NotContains(t, raw, f.ownerID.String())
+	}
+
+	// The sender's own devices already have the message from the response
+	assertNoEvent(t, sender)
+}
+
+func TestChatMessageIsNotPushedAcrossBlocksOrDeletedChats(t *testing.T) {
+	f := newChatFixture(t)
+	recipient := f.connect(t, f.claimerID)
+	ctx := context.Background()
+
+	f.blocks.blocked = true
+	_, err := f.chatSvc.SendMessage(ctx, f.chatRepo.chat.ID, f.ownerID, "hello?", nil)
+	assert.Equal(t, service.ErrChatUnavailable, err)
+
+	// The hub refuses events across a block even if a sender gets that far
+	f.hub.BroadcastToUser(f.claimerID, domain.Event{Type: domain.EventChatMessage, Payload: "hello?", From: f.ownerID})
+	assertNoEvent(t, recipient)
+
+	// A chat the claimer deleted is hidden from them alone: the owner can
+	// still write, but nothing reaches the claimer until they rejoin
+	f.blocks.blocked = false
+	f.chatRepo.chat.DeletedByParticipant = true
+	_, err = f.chatSvc.SendMessage(ctx, f.chatRepo.chat.ID, f.ownerID, "hello?", nil)
+	assert.NoError(t, err)
+	assertNoEvent(t, recipient)
+	assert.Len(t, f.chatRepo.messages, 1)
+
+	_, err = f.chatSvc.SendMessage(ctx, f.chatRepo.chat.ID, f.claimerID, "hi", nil)
+	assert.Equal(t, service.ErrChatDeleted, err)
+	assert.Len(t, f.chatRepo.messages, 1)
+}
+
+func TestDisconnectedDevicesStopReceiving(t *testing.T) {
+	f := newChatFixture(t)
+	recipient := f.connect(t, f.claimerID)
+
+	f.hub.DisconnectUser(f.claimerID)
+	recipient.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := recipient.ReadMessage()
+	assert.Error(t, err)
+
+	_, err = f.chatSvc.SendMessage(context.Background(), f.chatRepo.chat.ID, f.ownerID, "anyone there?", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, f.connections(f.claimerID))
+}
+
+func TestRevokedSessionsLoseTheirConnections(t *testing.T) {
+	f := newChatFixture(t)
+	loggedOut, other := uuid.New(), uuid.New()
+	phone := f.connectWith(t, f.claimerID, url.Values{"session": {loggedOut.String()}})
+	tablet := f.connectWith(t, f.claimerID, url.Values{"session": {other.String()}})
+
+	f.hub.DisconnectSession(loggedOut)
+	phone.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := phone.ReadMessage()
+	assert.Error(t, err)
+	assert.Equal(t, 1, f.connections(f.claimerID))
+
+	// The user's other sessions keep theirs
+	_, err = f.chatSvc.SendMessage(context.Background(), f.chatRepo.chat.ID, f.ownerID, "still there?", nil)
+	require.NoError(t, err)
+	assert.Contains(t, tablet.next(t), "still there?")
+}
+
+func TestConnectionsEndWithTheirAccessToken(t *testing.T) {
+	f := newChatFixture(t)
+	expiresAt := time.Now().Add(200 * time.Millisecond)
+	conn := f.connectWith(t, f.claimerID, url.Values{"expires": {expiresAt.Format(time.RFC3339Nano)}})
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), "got %v", err)
+	assert.False(t, time.Now().Before(expiresAt))
+	assert.Eventually(t, func() bool { return f.connections(f.claimerID) == 0 }, time.Second, 5*time.Millisecond)
+}
+
+func TestReadingClearsUnreadMessages(t *testing.T) {
+	f := newChatFixture(t)
+	sender := f.connect(t, f.ownerID)
+	chatID := f.chatRepo.chat.ID
+	ctx := context.Background()
+
+	first, err := f.chatSvc.SendMessage(ctx, chatID, f.ownerID, "one", nil)
+	require.NoError(t, err)
+	_, err = f.chatSvc.SendMessage(ctx, chatID, f.ownerID, "two", nil)
+	require.NoError(t, err)
+	_, err = f.chatSvc.SendMessage(ctx, chatID, f.claimerID, "my own reply", nil)
+	require.NoError(t, err)
+	readEvent(t, sender)
+
+	unread, err := f.chatSvc.UnreadCount(ctx, f.claimerID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, unread)
+
+	require.NoError(t, f.chatSvc.MarkRead(ctx, chatID, f.claimerID, first.ID))
+	unread, _ = f.chatSvc.UnreadCount(ctx, f.claimerID)
+	assert.Equal(t, 1, unread)
+	event, _ := readEvent(t, sender)
+	assert.Equal(t, domain.EventMessageRead, event.Type)
+
+	// Without a message ID the whole chat is read
+	require.NoError(t, f.chatSvc.MarkRead(ctx, chatID, f.claimerID, uuid.Nil))
+	unread, _ = f.chatSvc.UnreadCount(ctx, f.claimerID)
+	assert.Equal(t, 0, unread)
+	readEvent(t, sender)
+
+	// Reading an older message again changes nothing and tells nobody
+	require.NoError(t, f.chatSvc.MarkRead(ctx, chatID, f.claimerID, first.ID))
+	assertNoEvent(t, sender)
+	assert.Equal(t, service.ErrMessageNotFound, f.chatSvc.MarkRead(ctx, chatID, f.claimerID, uuid.New()))
+
+	// After deleting the chat, reading it all only covers what came since
+	cleared := time.Now()
+	f.chatRepo.mu.Lock()
+	f.chatRepo.chat.ParticipantClearedAt = &cleared
+	f.chatRepo.mu.Unlock()
+	assert.Equal(t, service.ErrMessageNotFound, f.chatSvc.MarkRead(ctx, chatID, f.claimerID, uuid.Nil))
+	assertNoEvent(t, sender)
+
+	time.Sleep(time.Millisecond)
+	later, err := f.chatSvc.SendMessage(ctx, chatID, f.ownerID, "are you back?", nil)
+	require.NoError(t, err)
+	require.NoError(t, f.chatSvc.MarkRead(ctx, chatID, f.claimerID, uuid.Nil))
+	event, raw := readEvent(t, sender)
+	assert.Equal(t, domain.EventMessageRead, event.Type)
+	assert.Contains(t, raw, later.ID.String())
+}
+
+func TestEditsAndUnsendsReachTheRecipient(t *testing.T) {
+	f := newChatFixture(t)
+	recipient := f.connect(t, f.claimerID)
+	chatID := f.chatRepo.chat.ID
+	ctx := context.Background()
+
+	message, err := f.chatSvc.SendMessage(ctx, chatID, f.ownerID, "meet at 5", nil)
+	require.NoError(t, err)
+	readEvent(t, recipient)
+
+	// Only the sender may change a message
+	_, err = f.chatSvc.EditMessage(ctx, chatID, message.ID, f.claimerID, "meet at 6")
+	assert.Equal(t, service.ErrUnauthorized, err)
+
+	edited, err := f.chatSvc.EditMessage(ctx, chatID, message.ID, f.ownerID, "meet at 6")
+	require.NoError(t, err)
+	assert.NotNil(t, edited.EditedAt)
+	event, _ := readEvent(t, recipient)
+	assert.Equal(t, domain.EventMessageEdited, event.Type)
+	assert.Equal(t, "meet at 6", event.Payload.Content)
+
+	unsent, err := f.chatSvc.UnsendMessage(ctx, chatID, message.ID, f.ownerID)
+	require.NoError(t, err)
+	assert.NotNil(t, unsent.DeletedAt)
+	assert.Empty(t, unsent.Content)
+	event, _ = readEvent(t, recipient)
+	assert.Equal(t, domain.EventMessageDeleted, event.Type)
+
+	// A tombstone stays one
+	_, err = f.chatSvc.EditMessage(ctx, chatID, message.ID, f.ownerID, "meet at 7")
+	assert.Equal(t, service.ErrMessageDeleted, err)
+	_, err = f.chatSvc.UnsendMessage(ctx, chatID, message.ID, f.ownerID)
+	assert.Equal(t, service.ErrMessageDeleted, err)
+
+	// Edits close with the window; unsending does not
+	old, err := f.chatSvc.SendMessage(ctx, chatID, f.ownerID, "old news", nil)
+	require.NoError(t, err)
+	readEvent(t, recipient)
+	f.chatRepo.mu.Lock()
+	f.chatRepo.messages[len(f.chatRepo.messages)-1].CreatedAt = time.Now().Add(-2 * time.Minute)
+	f.chatRepo.mu.Unlock()
+	_, err = f.chatSvc.EditMessage(ctx, chatID, old.ID, f.ownerID, "new news")
+	assert.Equal(t, service.ErrEditWindowOver, err)
+	_, err = f.chatSvc.UnsendMessage(ctx, chatID, old.ID, f.ownerID)
+	assert.NoError(t, err)
+	readEvent(t, recipient)
+	assertNoEvent(t, recipient)
+}
+
+func TestAttachmentsReachOnlyTheChat(t *testing.T) {
+	f := newChatFixture(t)
+	recipient := f.connect(t, f.claimerID)
+	chatID := f.chatRepo.chat.ID
+	ctx := context.Background()
+
+	var photo bytes.Buffer
+	require.NoError(t, png.Encode(&photo, image.NewRGBA(image.Rect(0, 0, 4, 3))))
+
+	// Types are sniffed from the content, not taken from the name
+	_, err := f.chatSvc.SendMessage(ctx, chatID, f.ownerID, "", []service.AttachmentUpload{
+		{Filename: "photo.png", Content: bytes.NewReader([]byte{0x4d, 0x5a, 0x90, 0x00})},
+	})
+	assert.Equal(t, service.ErrAttachmentType, err)
+
+	message, err := f.chatSvc.SendMessage(ctx, chatID, f.ownerID, "", []service.AttachmentUpload{
+		{Filename: "door.png", Content: &photo},
+		{Filename: "../../notes.txt", Content: strings.NewReader("second door on the left")},
+	})
+	require.NoError(t, err)
+	require.Len(t, message.Attachments, 2)
+	assert.Equal(t, domain.AttachmentImage, message.Attachments[0].Kind)
+	assert.Equal(t, 4, *message.Attachments[0].Width)
+	assert.Equal(t, domain.AttachmentDocument, message.Attachments[1].Kind)
+	assert.Equal(t, "notes.txt", message.Attachments[1].Filename)
+
+	event, raw := readEvent(t, recipient)
+	require.Len(t, event.Payload.Attachments, 2)
+	assert.NotContains(t, raw, "storage_key")
+
+	document := message.Attachments[1]
+	attachment, file, err := f.chatSvc.OpenAttachment(ctx, chatID, document.ID, f.claimerID)
+	require.NoError(t, err)
+	data, err := io.ReadAll(file)
+	file.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", attachment.MimeType)
+	assert.Equal(t, "second door on the left", string(data))
+
+	// Nobody outside the chat can fetch it, and unsending takes it away
+	_, _, err = f.chatSvc.OpenAttachment(ctx, chatID, document.ID, uuid.New())
+	assert.Equal(t, service.ErrUnauthorized, err)
+	_, _, err = f.chatSvc.OpenAttachment(ctx, chatID, uuid.New(), f.claimerID)
+	assert.Equal(t, service.ErrAttachmentNotFound, err)
+	_, err = f.chatSvc.UnsendMessage(ctx, chatID, message.ID, f.ownerID)
+	require.NoError(t, err)
+	_, _, err = f.chatSvc.OpenAttachment(ctx, chatID, document.ID, f.claimerID)
+	assert.Equal(t, service.ErrAttachmentNotFound, err)
+}
+
+func TestEncryptedMessagesOnlyCarryCiphertext(t *testing.T) {
+	f := newChatFixture(t)
+	recipient := f.connect(t, f.claimerID)
+	chatID := f.chatRepo.chat.ID
+	ctx := context.Background()
+
+	ownerPhone, ownerTablet, claimerPhone := uuid.New(), uuid.New(), uuid.New()
+	f.chatRepo.devices = map[uuid.UUID]uuid.UUID{ownerPhone: f.ownerID, ownerTablet: f.ownerID}
+
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	nonce := make([]byte, gcm.NonceSize())
+	ciphertext := gcm.Seal(nonce, nonce, []byte("the code is 4711"), chatID[:])
+
+	// Envelopes name devices by their alias in the chat
+	alias := func(deviceID uuid.UUID) uuid.UUID { return (&fakeAliases{}).DeviceAlias(chatID, deviceID) }
+	forClaimer := &domain.Envelope{DeviceID: alias(claimerPhone), Payload: []byte("sealed for the claimer")}
+	forTablet := &domain.Envelope{DeviceID: alias(ownerTablet), Payload: []byte("sealed for the tablet")}
+
+	// Nothing to seal for until the recipient has published keys
+	_, err = f.chatSvc.SendEncryptedMessage(ctx, chatID, f.ownerID, ownerPhone, ciphertext, []*domain.Envelope{forTablet})
+	assert.Equal(t, service.ErrNoRecipientKeys, err)
+
+	// Every device but the sending one needs an envelope, and only those
+	f.chatRepo.mu.Lock()
+	f.chatRepo.devices[claimerPhone] = f.claimerID
+	f.chatRepo.mu.Unlock()
+	_, err = f.chatSvc.SendEncryptedMessage(ctx, chatID, f.ownerID, ownerPhone, ciphertext, []*domain.Envelope{forClaimer})
+	assert.Equal(t, service.ErrEnvelopeMismatch, err)
+	_, err = f.chatSvc.SendEncryptedMessage(ctx, chatID, f.ownerID, ownerPhone, ciphertext, []*domain.Envelope{forClaimer, forTablet, {DeviceID: uuid.New(), Payload: []byte("x")}})
+	assert.Equal(t, service.ErrEnvelopeMismatch, err)
+	_, err = f.chatSvc.SendEncryptedMessage(ctx, chatID, f.ownerID, ownerPhone, ciphertext, []*domain.Envelope{
+		{DeviceID: claimerPhone, Payload: forClaimer.Payload},
+		forTablet,
+	})
+	assert.Equal(t, service.ErrEnvelopeMismatch, err)
+
+	message, err := f.chatSvc.SendEncryptedMessage(ctx, chatID, f.ownerID, ownerPhone, ciphertext, []*domain.Envelope{forClaimer, forTablet})
+	require.NoError(t, err)
+	assert.Equal(t, []*domain.Envelope{forTablet}, message.Envelopes)
+
+	// The server keeps no plaintext and each side only sees its own envelopes
+	f.chatRepo.mu.Lock()
+	stored := f.chatRepo.messages[len(f.chatRepo.messages)-1]
+	sealedFor := make([]uuid.UUID, 0, len(f.chatRepo.envelopes))
+	for _, envelope := range f.chatRepo.envelopes {
+		sealedFor = append(sealedFor, envelope.DeviceID)
+	}
+	f.chatRepo.mu.Unlock()
+	assert.ElementsMatch(t, []uuid.UUID{claimerPhone, ownerTablet}, sealedFor)
+	assert.Empty(t, stored.Content)
+	assert.Equal(t, ciphertext, stored.Ciphertext)
+
+	event, raw := readEvent(t, recipient)
+	assert.True(t, event.Payload.Encrypted)
+	assert.Empty(t, event.Payload.Content)
+	assert.Equal(t, ciphertext, event.Payload.Ciphertext)
+	require.Len(t, event.Payload.Envelopes, 1)
+	assert.Equal(t, alias(claimerPhone), event.Payload.Envelopes[0].DeviceID)
+	assert.NotContains(t, raw, ownerTablet.String())
+	assert.NotContains(t, raw, alias(ownerTablet).String())
+	assert.NotContains(t, raw, claimerPhone.String())
+
+	history, err := f.chatSvc.GetMessages(ctx, chatID, f.claimerID, 50, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Len(t, history[0].Envelopes, 1)
+	assert.Equal(t, alias(claimerPhone), history[0].Envelopes[0].DeviceID)
+
+	// With keys on both sides nothing goes in the clear any more
+	_, err = f.chatSvc.SendMessage(ctx, chatID, f.claimerID, "thanks", nil)
+	assert.Equal(t, service.ErrPlaintextRefused, err)
+
+	// Encrypted messages can be unsent but not edited
+	_, err = f.chatSvc.EditMessage(ctx, chatID, message.ID, f.ownerID, "the code is 1234")
+	assert.Equal(t, service.ErrMessageEncrypted, err)
+	unsent, err := f.chatSvc.UnsendMessage(ctx, chatID, message.ID, f.ownerID)
+	require.NoError(t, err)
+	assert.Nil(t, unsent.Ciphertext)
+}
+
+func TestChatTimerAppliesToLaterMessages(t *testing.T) {
+	f := newChatFixture(t)
+	recipient := f.connect(t, f.claimerID)
+	sender := f.connect(t, f.ownerID)
+	chatID := f.chatRepo.chat.ID
+	ctx := context.Background()
+
+	before, err := f.chatSvc.SendMessage(ctx, chatID, f.ownerID, "this one stays", nil)
+	require.NoError(t, err)
+	assert.Nil(t, before.ExpiresAt)
+	readEvent(t, recipient)
+
+	_, err = f.chatSvc.SetTimer(ctx, chatID, f.ownerID, 90)
+	assert.Equal(t, service.ErrInvalidTimer, err)
+	_, err = f.chatSvc.SetTimer(ctx, chatID, uuid.New(), 3600)
+	assert.Equal(t, service.ErrUnauthorized, err)
+
+	// Either side may set it and the other side hears about it
+	chat, err := f.chatSvc.SetTimer(ctx, chatID, f.ownerID, 3600)
+	require.NoError(t, err)
+	assert.Equal(t, 3600, chat.MessageTTL)
+	event, _ := readEvent(t, recipient)
+	assert.Equal(t, domain.EventChatTimer, event.Type)
+	assert.Equal(t, chatID, event.Payload.ChatID)
+	assert.Equal(t, 3600, event.Payload.MessageTTL)
+	assertNoEvent(t, sender)
+
+	// Setting it to what it already is tells nobody
+	_, err = f.chatSvc.SetTimer(ctx, chatID, f.claimerID, 3600)
+	require.NoError(t, err)
+	assertNoEvent(t, sender)
+
+	message, err := f.chatSvc.SendMessage(ctx, chatID, f.claimerID, "this one goes", nil)
+	require.NoError(t, err)
+	require.NotNil(t, message.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *message.ExpiresAt, time.Minute)
+	event, _ = readEvent(t, sender)
+	require.NotNil(t, event.Payload.ExpiresAt)
+
+	// Once expired, a message that has not been purged yet is still gone
+	f.chatRepo.mu.Lock()
+	expired := time.Now().Add(-time.Second)
+	f.chatRepo.messages[len(f.chatRepo.messages)-1].ExpiresAt = &expired
+	f.chatRepo.mu.Unlock()
+	_, err = f.chatSvc.UnsendMessage(ctx, chatID, message.ID, f.claimerID)
+	assert.Equal(t, service.ErrMessageNotFound, err)
+	assert.Equal(t, service.ErrMessageNotFound, f.chatSvc.MarkRead(ctx, chatID, f.ownerID, message.ID))
+}
@@ -0,0 +1,214 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/task-underground/backend/internal/domain"
+	"github.com/task-underground/backend/internal/middleware"
+	"github.com/task-underground/backend/internal/service"
+)
+
+// Commands a client may send. Each is a JSON object
+//
+//	{"v": 1, "id": "42", "type": "send_message", "payload": {"chat_id": "...", "content": "..."}}
+//
+// answered by an ack or error event carrying the same id. Payloads:
+//
+//	ping         none; the ack confirms the connection is alive end to end
+//	send_message {"chat_id", "content"} or, encrypted, {"chat_id",
+//	             "ciphertext", "envelopes"}; the ack carries the stored
+//	             message
+//	typing       {"chat_id"}
+//	mark_read    {"chat_id", "message_id"}; without message_id the whole
+//	             chat is read
+//	subscribe    {"chat_id"}; typing events for the chat
+//	             reach this connection until it unsubscribes
+//	unsubscribe  {"chat_id"}
+const (
+	CommandPing        = "ping"
+	CommandSendMessage = "send_message"
+	CommandTyping      = "typing"
+	CommandMarkRead    = "mark_read"
+	CommandSubscribe   = "subscribe"
+	CommandUnsubscribe = "unsubscribe"
+)
+
+// Error codes of error events.
+const (
+	ErrorBadRequest         = "bad_request"
+	ErrorUnsupportedVersion = "unsupported_version"
+	ErrorUnknownCommand     = "unknown_command"
+	ErrorForbidden          = "forbidden"
+	ErrorNotFound           = "not_found"
+	ErrorUnavailable        = "unavailable"
+	ErrorRateLimited        = "rate_limited"
+	ErrorConflict           = "conflict"
+	ErrorInternal           = "internal"
+)
+
+const commandTimeout = 10 * time.Second
+
+// Command is a frame sent by the client.
+type Command struct {
+	Version int             `json:"v"`
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+type chatCommand struct {
+	ChatID     uuid.UUID          `json:"chat_id"`
+	MessageID  uuid.UUID          `json:"message_id"`
+	Content    string             `json:"content"`
+	Ciphertext []byte             `json:"ciphertext"`
+	Envelopes  []*domain.Envelope `json:"envelopes"`
+}
+
+// commandClasses puts each command in the rate limit budget of the REST
+// routes that do the same.
+var commandClasses = map[string]middleware.RouteClass{
+	CommandSendMessage: middleware.RouteClassMessage,
+	CommandMarkRead:    middleware.RouteClassWrite,
+	CommandTyping:      middleware.RouteClassRead,
+	CommandSubscribe:   middleware.RouteClassRead,
+	CommandUnsubscribe: middleware.RouteClassRead,
+}
+
+var (
+	errUnknownCommand = errors.New("unknown command")
+	errInvalidPayload = errors.New("invalid payload")
+)
+
+func (h *WSHandler) handleCommand(client *Client, data []byte) {
+	var cmd Command
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		h.fail(client, "", &domain.CommandError{Code: ErrorBadRequest, Message: "frame is not a JSON command"})
+		return
+	}
+	if cmd.Version != domain.EventProtocolVersion {
+		h.fail(client, cmd.ID, &domain.CommandError{
+			Code:    ErrorUnsupportedVersion,
+			Message: fmt.Sprintf("protocol version %d is required", domain.EventProtocolVersion),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	if class, ok := commandClasses[cmd.Type]; ok && h.limiter != nil {
+		result, err := h.limiter.TakeUser(ctx, client.UserID, class)
+		if err != nil {
+			// As on the REST routes, a broken store does not block anyone
+			log.Printf("Error checking rate limit for %s: %v", client.UserID, err)
+		} else if !result.Allowed {
+			h.fail(client, cmd.ID, &domain.CommandError{
+				Code:       ErrorRateLimited,
+				Message:    "rate limit exceeded",
+				RetryAfter: int(math.Ceil(result.Reset.Seconds())),
+			})
+			return
+		}
+	}
+
+	payload, err := h.runCommand(ctx, client, cmd)
+	if err != nil {
+		h.fail(client, cmd.ID, commandError(err))
+		return
+	}
+	h.hub.reply(client, domain.Event{Type: domain.EventAck, ID: cmd.ID, Payload: payload})
+}
+
+func (h *WSHandler) runCommand(ctx context.Context, client *Client, cmd Command) (interface{}, error) {
+	var req chatCommand
+	if len(cmd.Payload) > 0 {
+		if err := json.Unmarshal(cmd.Payload, &req); err != nil {
+			return nil, errInvalidPayload
+		}
+	}
+
+	switch cmd.Type {
+	case CommandPing:
+		return nil, nil
+
+	case CommandSendMessage:
+		var message *domain.Message
+		var err error
+		if req.Ciphertext != nil {
+			message, err = h.chatSvc.SendEncryptedMessage(ctx, req.ChatID, client.UserID, client.DeviceID, req.Ciphertext, req.Envelopes)
+		} else {
+			message, err = h.chatSvc.SendMessage(ctx, req.ChatID, client.UserID, req.Content, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+		chat, err := h.chatSvc.GetChat(ctx, message.ChatID, client.UserID)
+		if err != nil {
+			return nil, err
+		}
+		views, err := h.aliasSvc.PresentMessages(ctx, chat, []*domain.Message{message}, client.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return views[0], nil
+
+	case CommandTyping:
+		return nil, h.chatSvc.SendTyping(ctx, req.ChatID, client.UserID)
+
+	case CommandMarkRead:
+		return nil, h.chatSvc.MarkRead(ctx, req.ChatID, client.UserID, req.MessageID)
+
+	case CommandSubscribe:
+		chat, err := h.chatSvc.GetChat(ctx, req.ChatID, client.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !chat.IsVisibleTo(client.UserID) {
+			return nil, service.ErrChatNotFound
+		}
+		client.subscribe(chat.ID)
+		return nil, nil
+
+	case CommandUnsubscribe:
+		client.unsubscribe(req.ChatID)
+		return nil, nil
+	}
+	return nil, errUnknownCommand
+}
+
+func (h *WSHandler) fail(client *Client, id string, cmdErr *domain.CommandError) {
+	h.hub.reply(client, domain.Event{Type: domain.EventError, ID: id, Error: cmdErr})
+}
+
+// commandError maps a service error to the error event the REST routes
+// would answer with a status code.
+func commandError(err error) *domain.CommandError {
+	code := ErrorInternal
+	switch err {
+	case errUnknownCommand:
+		code = ErrorUnknownCommand
+	case errInvalidPayload, service.ErrMessageEmpty, service.ErrInvalidCiphertext, service.ErrChatDeleted:
+		code = ErrorBadRequest
+	case service.ErrEnvelopeMismatch, service.ErrNoRecipientKeys, service.ErrPlaintextRefused:
+		code = ErrorConflict
+	case service.ErrUnauthorized:
+		code = ErrorForbidden
+	case service.ErrChatNotFound, service.ErrMessageNotFound:
+		code = ErrorNotFound
+	case service.ErrChatUnavailable:
+		code = ErrorUnavailable
+	}
+
+	if code == ErrorInternal {
+		log.Printf("Error handling WebSocket command: %v", err)
+		return &domain.CommandError{Code: code, Message: "internal error"}
+	}
+	return &domain.CommandError{Code: code, Message: err.Error()}
+}
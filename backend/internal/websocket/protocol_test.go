@@ -0,0 +1,217 @@
+package websocket
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/task-underground/backend/internal/domain"
+)
+
+type replyFrame struct {
+	Version int                  `json:"v"`
+	Type    domain.EventType     `json:"type"`
+	ID      string               `json:"id"`
+	Payload json.RawMessage      `json:"payload"`
+	Error   *domain.CommandError `json:"error"`
+}
+
+func (c *testConn) command(t *testing.T, id, commandType string, payload interface{}) {
+	data, err := json.Marshal(map[string]interface{}{
+		"v":       domain.EventProtocolVersion,
+		"id":      id,
+		"type":    commandType,
+		"payload": payload,
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.WriteMessage(websocket.TextMessage, data))
+}
+
+func (c *testConn) frame(t *testing.T) replyFrame {
+	var frame replyFrame
+	require.NoError(t, json.Unmarshal([]byte(c.next(t)), &frame))
+	return frame
+}
+
+func (c *testConn) ack(t *testing.T, id string) replyFrame {
+	frame := c.frame(t)
+	require.Equal(t, domain.EventAck, frame.Type, "error: %+v", frame.Error)
+	assert.Equal(t, id, frame.ID)
+	return frame
+}
+
+func (c *testConn) failure(t *testing.T, id, code string) {
+	frame := c.frame(t)
+	require.Equal(t, domain.EventError, frame.Type)
+	assert.Equal(t, id, frame.ID)
+	assert.Equal(t, code, frame.Error.Code)
+}
+
+func TestFrameTypesAreSnakeCase(t *testing.T) {
+	name := regexp.MustCompile(`^[a-z]+(_[a-z]+)*$`)
+	for _, eventType := range []domain.EventType{
+		domain.EventChatMessage,
+		domain.EventMessageEdited,
+		domain.EventMessageDeleted,
+		domain.EventTyping,
+		domain.EventMessageRead,
+		domain.EventChatTimer,
+		domain.EventAck,
+		domain.EventError,
+	} {
+		assert.Regexp(t, name, string(eventType))
+	}
+	for _, command := range []string{CommandPing, CommandSendMessage, CommandTyping, CommandMarkRead, CommandSubscribe, CommandUnsubscribe} {
+		assert.Regexp(t, name, command)
+	}
+}
+
+func TestCommandsAreAnsweredByRequestID(t *testing.T) {
+	f := newChatFixture(t)
+	conn := f.connect(t, f.ownerID)
+
+	conn.command(t, "1", CommandPing, nil)
+	frame := conn.ack(t, "1")
+	assert.Equal(t, domain.EventProtocolVersion, frame.Version)
+
+	conn.command(t, "2", "shout", nil)
+	conn.failure(t, "2", ErrorUnknownCommand)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"v": 2, "id": "3", "type": "ping"}`)))
+	conn.failure(t, "3", ErrorUnsupportedVersion)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`hello`)))
+	conn.failure(t, "", ErrorBadRequest)
+
+	conn.command(t, "4", CommandTyping, "not an object")
+	conn.failure(t, "4", ErrorBadRequest)
+}
+
+func TestSendMessageCommand(t *testing.T) {
+	f := newChatFixture(t)
+	sender := f.connect(t, f.ownerID)
+	recipient := f.connect(t, f.claimerID)
+	chatID := f.chatRepo.chat.ID
+
+	sender.command(t, "a", CommandSendMessage, map[string]interface{}{"chat_id": chatID, "content": "on my way"})
+	var stored domain.MessageView
+	require.NoError(t, json.Unmarshal(sender.ack(t, "a").Payload, &stored))
+	assert.Equal(t, "on my way", stored.Content)
+	assert.True(t, stored.Sender.IsSelf)
+
+	event, _ := readEvent(t, recipient)
+	assert.Equal(t, stored.ID, event.Payload.ID)
+	assert.False(t, event.Payload.Sender.IsSelf)
+
+	sender.command(t, "b", CommandSendMessage, map[string]interface{}{"chat_id": chatID, "content": ""})
+	sender.failure(t, "b", ErrorBadRequest)
+
+	// The same policy as the REST route applies
+	stranger := f.connect(t, uuid.New())
+	stranger.command(t, "c", CommandSendMessage, map[string]interface{}{"chat_id": chatID, "content": "hi"})
+	stranger.failure(t, "c", ErrorForbidden)
+	stranger.command(t, "d", CommandSendMessage, map[string]interface{}{"chat_id": uuid.New(), "content": "hi"})
+	stranger.failure(t, "d", ErrorNotFound)
+
+	f.blocks.mu.Lock()
+	f.blocks.blocked = true
+	f.blocks.mu.Unlock()
+	sender.command(t, "e", CommandSendMessage, map[string]interface{}{"chat_id": chatID, "content": "still there?"})
+	sender.failure(t, "e", ErrorUnavailable)
+	assertNoEvent(t, recipient)
+}
+
+func TestTypingAndReadReceipts(t *testing.T) {
+	f := newChatFixture(t)
+	owner := f.connect(t, f.ownerID)
+	chatScreen := f.connect(t, f.claimerID)
+	inbox := f.connect(t, f.claimerID)
+	chatID := f.chatRepo.chat.ID
+
+	chatScreen.command(t, "s", CommandSubscribe, map[string]interface{}{"chat_id": chatID})
+	chatScreen.ack(t, "s")
+
+	owner.command(t, "1", CommandTyping, map[string]interface{}{"chat_id": chatID})
+	owner.ack(t, "1")
+
+	var activity domain.ChatActivity
+	frame := chatScreen.frame(t)
+	assert.Equal(t, domain.EventTyping, frame.Type)
+	require.NoError(t, json.Unmarshal(frame.Payload, &activity))
+	assert.Equal(t, chatID, activity.ChatID)
+	assertNoEvent(t, inbox)
+
+	// Reading a message tells every one of the sender's devices, once
+	owner.command(t, "2", CommandSendMessage, map[string]interface{}{"chat_id": chatID, "content": "done?"})
+	var message domain.MessageView
+	require.NoError(t, json.Unmarshal(owner.ack(t, "2").Payload, &message))
+	readEvent(t, chatScreen)
+	readEvent(t, inbox)
+
+	chatScreen.command(t, "r", CommandMarkRead, map[string]interface{}{"chat_id": chatID, "message_id": message.ID})
+	chatScreen.ack(t, "r")
+	frame = owner.frame(t)
+	assert.Equal(t, domain.EventMessageRead, frame.Type)
+	require.NoError(t, json.Unmarshal(frame.Payload, &activity))
+	assert.Equal(t, message.ID, *activity.MessageID)
+
+	inbox.command(t, "r1", CommandMarkRead, map[string]interface{}{"chat_id": chatID, "message_id": message.ID})
+	inbox.ack(t, "r1")
+	assertNoEvent(t, owner)
+
+	chatScreen.command(t, "r2", CommandMarkRead, map[string]interface{}{"chat_id": chatID, "message_id": uuid.New()})
+	chatScreen.failure(t, "r2", ErrorNotFound)
+
+	chatScreen.command(t, "u", CommandUnsubscribe, map[string]interface{}{"chat_id": chatID})
+	chatScreen.ack(t, "u")
+	owner.command(t, "4", CommandTyping, map[string]interface{}{"chat_id": chatID})
+	owner.ack(t, "4")
+	assertNoEvent(t, chatScreen)
+
+	// Only participants may watch a chat
+	stranger := f.connect(t, uuid.New())
+	stranger.command(t, "s", CommandSubscribe, map[string]interface{}{"chat_id": chatID})
+	stranger.failure(t, "s", ErrorForbidden)
+}
+
+func TestCommandsShareTheRateLimit(t *testing.T) {
+	f := newChatFixture(t)
+	conn := f.connect(t, f.ownerID)
+	chatID := f.chatRepo.chat.ID
+
+	for i := 0; i < 3; i++ {
+		conn.command(t, "ok", CommandSendMessage, map[string]interface{}{"chat_id": chatID, "content": "spam"})
+		conn.ack(t, "ok")
+	}
+
+	conn.command(t, "no", CommandSendMessage, map[string]interface{}{"chat_id": chatID, "content": "spam"})
+	frame := conn.frame(t)
+	require.Equal(t, domain.EventError, frame.Type)
+	assert.Equal(t, ErrorRateLimited, frame.Error.Code)
+	assert.Greater(t, frame.Error.RetryAfter, 0)
+
+	// Other commands have their own budget
+	conn.command(t, "t", CommandTyping, map[string]interface{}{"chat_id": chatID})
+	conn.ack(t, "t")
+}
+
+func TestOversizedFrameClosesConnection(t *testing.T) {
+	f := newChatFixture(t)
+	conn := f.connect(t, f.ownerID)
+
+	conn.command(t, "big", CommandSendMessage, map[string]interface{}{
+		"chat_id": f.chatRepo.chat.ID,
+		"content": strings.Repeat("x", 2048),
+	})
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseMessageTooBig), "got %v", err)
+	assert.Empty(t, f.chatRepo.messages)
+}
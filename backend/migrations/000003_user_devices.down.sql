@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS device_pairings;
+ALTER TABLE users DROP COLUMN IF EXISTS recovery_secret_hash;
+
+DELETE FROM sessions;
+ALTER TABLE sessions DROP COLUMN IF EXISTS device_id;
+ALTER TABLE sessions ADD COLUMN device_id VARCHAR(255) NOT NULL;
+
+DROP TABLE IF EXISTS user_devices;
@@ -0,0 +1,36 @@
+-- Devices linked to a user; several devices can share one account
+CREATE TABLE user_devices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device_id VARCHAR(255) UNIQUE NOT NULL,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_user_devices_user_id ON user_devices(user_id);
+
+INSERT INTO user_devices (user_id, device_id, created_at)
+SELECT id, device_id, created_at FROM users;
+
+-- Sessions now belong to a device row rather than a raw device string
+DELETE FROM sessions;
+ALTER TABLE sessions DROP COLUMN device_id;
+ALTER TABLE sessions ADD COLUMN device_id UUID NOT NULL REFERENCES user_devices(id) ON DELETE CASCADE;
+CREATE INDEX idx_sessions_device_id ON sessions(device_id);
+
+-- Opt-in recovery secret, stored hashed
+ALTER TABLE users ADD COLUMN recovery_secret_hash VARCHAR(64) UNIQUE;
+
+-- Short-lived pairing codes for linking a new device
+CREATE TABLE device_pairings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_device_pairings_user_id ON device_pairings(user_id);
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS reputation_events;
+
+UPDATE claims SET status = 'rejected' WHERE status = 'disputed';
+ALTER TABLE claims DROP COLUMN IF EXISTS disputed_at;
+ALTER TABLE claims DROP COLUMN IF EXISTS dispute_reason;
+ALTER TABLE claims DROP CONSTRAINT IF EXISTS claims_status_check;
+ALTER TABLE claims ADD CONSTRAINT claims_status_check
+    CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled'));
+
+ALTER TABLE users DROP COLUMN IF EXISTS poster_score;
+ALTER TABLE users DROP COLUMN IF EXISTS worker_score;
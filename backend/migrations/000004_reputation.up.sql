@@ -0,0 +1,43 @@
+-- Separate worker and poster scores, rebuilt from reputation_events
+ALTER TABLE users ADD COLUMN worker_score DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN poster_score DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- Disputes live on the claim
+ALTER TABLE claims DROP CONSTRAINT IF EXISTS claims_status_check;
+ALTER TABLE claims ADD CONSTRAINT claims_status_check
+    CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled', 'disputed'));
+ALTER TABLE claims ADD COLUMN dispute_reason TEXT;
+ALTER TABLE claims ADD COLUMN disputed_at TIMESTAMP WITH TIME ZONE;
+
+-- Reputation events: append-only history
+CREATE TABLE reputation_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('worker', 'poster')),
+    kind VARCHAR(50) NOT NULL CHECK (kind IN (
+        'claim_approved', 'claim_rejected', 'claim_withdrawn', 'claim_abandoned',
+        'rejection_overturned', 'task_completed', 'unjustified_rejection', 'dispute_lost'
+    )),
+    task_id UUID REFERENCES tasks(id) ON DELETE SET NULL,
+    claim_id UUID REFERENCES claims(id) ON DELETE SET NULL,
+    reward_amount DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_reputation_events_user_id ON reputation_events(user_id);
+
+-- Backfill history that was only ever counted as +1
+INSERT INTO reputation_events (user_id, role, kind, task_id, claim_id, reward_amount, created_at)
+SELECT c.claimer_id, 'worker', 'claim_approved', c.task_id, c.id, t.reward_amount, c.updated_at
+FROM claims c JOIN tasks t ON t.id = c.task_id
+WHERE c.status = 'approved';
+
+INSERT INTO reputation_events (user_id, role, kind, task_id, claim_id, reward_amount, created_at)
+SELECT t.owner_id, 'poster', 'task_completed', c.task_id, c.id, t.reward_amount, c.updated_at
+FROM claims c JOIN tasks t ON t.id = c.task_id
+WHERE c.status = 'approved';
+
+INSERT INTO reputation_events (user_id, role, kind, task_id, claim_id, reward_amount, created_at)
+SELECT c.claimer_id, 'worker', 'claim_rejected', c.task_id, c.id, t.reward_amount, c.updated_at
+FROM claims c JOIN tasks t ON t.id = c.task_id
+WHERE c.status = 'rejected';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS reviews;
+ALTER TABLE claims DROP COLUMN IF EXISTS decided_at;
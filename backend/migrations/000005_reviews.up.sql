@@ -0,0 +1,21 @@
+-- When a claim was approved or rejected; opens the review window
+ALTER TABLE claims ADD COLUMN decided_at TIMESTAMP WITH TIME ZONE;
+UPDATE claims SET decided_at = updated_at WHERE status IN ('approved', 'rejected', 'disputed');
+
+-- Reviews: one per side per claim, hidden until both sides submit or the window closes
+CREATE TABLE reviews (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    claim_id UUID NOT NULL REFERENCES claims(id) ON DELETE CASCADE,
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    reviewer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reviewee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reviewer_role VARCHAR(20) NOT NULL CHECK (reviewer_role IN ('worker', 'poster')),
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    comment VARCHAR(280) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(claim_id, reviewer_id)
+);
+
+CREATE INDEX idx_reviews_claim_id ON reviews(claim_id);
+CREATE INDEX idx_reviews_reviewee_id ON reviews(reviewee_id);
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS moderation_actions;
+DROP TABLE IF EXISTS reports;
+
+ALTER TABLE users DROP COLUMN IF EXISTS suspension_reason;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_until;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_at;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS hidden_at;
+ALTER TABLE claims DROP COLUMN IF EXISTS hidden_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS hidden_at;
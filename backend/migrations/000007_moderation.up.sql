@@ -0,0 +1,39 @@
+-- Content hidden by moderation, automatically or by a moderator
+ALTER TABLE tasks ADD COLUMN hidden_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE claims ADD COLUMN hidden_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE messages ADD COLUMN hidden_at TIMESTAMP WITH TIME ZONE;
+
+-- Suspension state; NULL suspended_until with suspended_at set means permanent
+ALTER TABLE users ADD COLUMN suspended_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN suspended_until TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN suspension_reason TEXT;
+
+-- Reports: one per reporter per target
+CREATE TABLE reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reporter_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    target_type VARCHAR(20) NOT NULL CHECK (target_type IN ('task', 'claim', 'message', 'user')),
+    target_id UUID NOT NULL,
+    category VARCHAR(50) NOT NULL CHECK (category IN ('scam', 'illegal', 'harassment', 'spam', 'inappropriate', 'other')),
+    note VARCHAR(1000) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'actioned', 'dismissed')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE(reporter_id, target_type, target_id)
+);
+
+CREATE INDEX idx_reports_target ON reports(target_type, target_id);
+CREATE INDEX idx_reports_status ON reports(status);
+
+-- Moderation decisions
+CREATE TABLE moderation_actions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    target_type VARCHAR(20) NOT NULL CHECK (target_type IN ('task', 'claim', 'message', 'user')),
+    target_id UUID NOT NULL,
+    action VARCHAR(50) NOT NULL CHECK (action IN ('hide', 'suspend_user', 'dismiss')),
+    reason TEXT NOT NULL DEFAULT '',
+    moderator VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_moderation_actions_target ON moderation_actions(target_type, target_id);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_users_suspended;
+
+DELETE FROM moderation_actions WHERE action = 'unsuspend_user';
+ALTER TABLE moderation_actions DROP CONSTRAINT moderation_actions_action_check;
+ALTER TABLE moderation_actions ADD CONSTRAINT moderation_actions_action_check
+    CHECK (action IN ('hide', 'suspend_user', 'dismiss'));
@@ -0,0 +1,6 @@
+-- Lifting a suspension is recorded alongside the other moderation decisions
+ALTER TABLE moderation_actions DROP CONSTRAINT moderation_actions_action_check;
+ALTER TABLE moderation_actions ADD CONSTRAINT moderation_actions_action_check
+    CHECK (action IN ('hide', 'suspend_user', 'unsuspend_user', 'dismiss'));
+
+CREATE INDEX idx_users_suspended ON users(suspended_at) WHERE suspended_at IS NOT NULL;
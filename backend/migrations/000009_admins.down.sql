@@ -0,0 +1,8 @@
+DELETE FROM moderation_actions WHERE action = 'force_cancel';
+ALTER TABLE moderation_actions DROP CONSTRAINT moderation_actions_action_check;
+ALTER TABLE moderation_actions ADD CONSTRAINT moderation_actions_action_check
+    CHECK (action IN ('hide', 'suspend_user', 'unsuspend_user', 'dismiss'));
+
+ALTER TABLE arbitrations DROP COLUMN IF EXISTS admin_arbitrator_id;
+
+DROP TABLE IF EXISTS admins;
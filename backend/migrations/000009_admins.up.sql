@@ -0,0 +1,18 @@
+-- Staff accounts for the admin API, authenticated by their own API key
+CREATE TABLE admins (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) UNIQUE NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('admin', 'moderator', 'arbitrator', 'finance')),
+    key_hash VARCHAR(64) UNIQUE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Disputes are resolved by admins, not by users. arbitrator_id keeps the
+-- users who decided earlier arbitrations
+ALTER TABLE arbitrations ADD COLUMN admin_arbitrator_id UUID REFERENCES admins(id) ON DELETE SET NULL;
+
+-- Force-cancelling a task is recorded alongside the moderation decisions
+ALTER TABLE moderation_actions DROP CONSTRAINT moderation_actions_action_check;
+ALTER TABLE moderation_actions ADD CONSTRAINT moderation_actions_action_check
+    CHECK (action IN ('hide', 'suspend_user', 'unsuspend_user', 'dismiss', 'force_cancel'));
@@ -0,0 +1,3 @@
+-- Erased accounts stay behind as anonymous rows so escrow records and the
+-- other side of their conversations keep a valid reference
+ALTER TABLE users ADD COLUMN erased_at TIMESTAMP WITH TIME ZONE;
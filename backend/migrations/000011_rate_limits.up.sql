@@ -0,0 +1,8 @@
+-- Shared fixed-window rate limit counters, used when RATE_LIMIT_STORE=postgres
+CREATE UNLOGGED TABLE rate_limits (
+    key VARCHAR(200) PRIMARY KEY,
+    window_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    hits INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_rate_limits_window_start ON rate_limits(window_start);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_claims_created_at;
+DROP TABLE IF EXISTS account_creations;
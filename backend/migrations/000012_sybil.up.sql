@@ -0,0 +1,10 @@
+-- Where each account was created from, keyed by a keyed hash of the client
+-- address so the address itself is never stored
+CREATE TABLE account_creations (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    ip_hash VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_account_creations_ip_hash ON account_creations(ip_hash, created_at);
+CREATE INDEX idx_claims_created_at ON claims(created_at);
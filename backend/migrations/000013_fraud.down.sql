@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_claims_decided_at;
+DROP TABLE IF EXISTS fraud_flags;
+DROP TABLE IF EXISTS user_fingerprints;
@@ -0,0 +1,25 @@
+-- Keyed hashes of the networks and devices each user was seen acting from,
+-- so owners and claimers can be matched without storing addresses
+CREATE TABLE user_fingerprints (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('network', 'device')),
+    hash VARCHAR(64) NOT NULL,
+    last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, kind, hash)
+);
+
+CREATE INDEX idx_user_fingerprints_hash ON user_fingerprints(kind, hash);
+
+-- Approved claims held for review as possible self-dealing
+CREATE TABLE fraud_flags (
+    claim_id UUID PRIMARY KEY REFERENCES claims(id) ON DELETE CASCADE,
+    score INTEGER NOT NULL,
+    signals TEXT[] NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'cleared', 'confirmed')),
+    reviewed_by VARCHAR(100),
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_fraud_flags_status ON fraud_flags(status, created_at);
+CREATE INDEX idx_claims_decided_at ON claims(decided_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_messages_chat_id_created_at;
+DROP TABLE IF EXISTS chat_reads;
@@ -0,0 +1,12 @@
+-- How far each participant has read each chat. read_at is the creation time
+-- of the last message read; later messages from the other side are unread.
+CREATE TABLE chat_reads (
+    chat_id UUID NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message_id UUID REFERENCES messages(id) ON DELETE SET NULL,
+    read_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (chat_id, user_id)
+);
+
+CREATE INDEX idx_messages_chat_id_created_at ON messages(chat_id, created_at);
@@ -0,0 +1,15 @@
+-- Senders may edit a message for a while or unsend it. Unsent messages stay
+-- as tombstones; their content and every earlier version, dated from when
+-- it was written, are kept for moderators only.
+ALTER TABLE messages ADD COLUMN edited_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE messages ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE message_edits (
+    id UUID PRIMARY KEY,
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    written_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_message_edits_message_id ON message_edits(message_id, written_at);
@@ -0,0 +1,17 @@
+-- Files sent with chat messages. The bytes live in blob storage under
+-- storage_key; only participants of the chat may download them.
+CREATE TABLE message_attachments (
+    id UUID PRIMARY KEY,
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    chat_id UUID NOT NULL REFERENCES chats(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('image', 'document')),
+    mime_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    width INTEGER,
+    height INTEGER,
+    filename VARCHAR(255) NOT NULL DEFAULT '',
+    storage_key VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_message_attachments_message_id ON message_attachments(message_id);
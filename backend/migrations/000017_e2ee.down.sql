@@ -0,0 +1,5 @@
+ALTER TABLE reports DROP COLUMN IF EXISTS disclosed_content;
+DROP TABLE IF EXISTS message_envelopes;
+ALTER TABLE messages DROP COLUMN IF EXISTS ciphertext;
+DROP TABLE IF EXISTS device_prekeys;
+DROP TABLE IF EXISTS device_keys;
@@ -0,0 +1,36 @@
+-- Public keys each device publishes so others can encrypt chat messages to
+-- it: a long-term identity key, a signed prekey and a pool of one-time
+-- prekeys, each of which is handed out once.
+CREATE TABLE device_keys (
+    device_id UUID PRIMARY KEY REFERENCES user_devices(id) ON DELETE CASCADE,
+    identity_key BYTEA NOT NULL,
+    signed_prekey_id INTEGER NOT NULL,
+    signed_prekey BYTEA NOT NULL,
+    signed_prekey_signature BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE device_prekeys (
+    device_id UUID NOT NULL REFERENCES device_keys(device_id) ON DELETE CASCADE,
+    key_id INTEGER NOT NULL,
+    public_key BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (device_id, key_id)
+);
+
+-- Encrypted messages keep only their ciphertext; content stays empty. The
+-- message key is sealed separately for each device that may read it.
+ALTER TABLE messages ADD COLUMN ciphertext BYTEA;
+
+CREATE TABLE message_envelopes (
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    device_id UUID NOT NULL REFERENCES user_devices(id) ON DELETE CASCADE,
+    payload BYTEA NOT NULL,
+    PRIMARY KEY (message_id, device_id)
+);
+
+CREATE INDEX idx_message_envelopes_device_id ON message_envelopes(device_id);
+
+-- What a reporter decrypted and chose to show moderators
+ALTER TABLE reports ADD COLUMN disclosed_content TEXT;
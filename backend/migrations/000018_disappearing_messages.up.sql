@@ -0,0 +1,9 @@
+-- Disappearing messages: a chat's timer, in seconds, gives each new message
+-- an expiry. Expired messages are hidden at once and deleted by the purge
+-- job, along with messages of tasks that ended longer ago than the
+-- retention period.
+ALTER TABLE chats ADD COLUMN message_ttl_seconds INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE messages ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_messages_expires_at ON messages(expires_at) WHERE expires_at IS NOT NULL;
+CREATE INDEX idx_tasks_status_updated_at ON tasks(status, updated_at);
@@ -0,0 +1,2 @@
+ALTER TABLE chats DROP COLUMN IF EXISTS other_cleared_at;
+ALTER TABLE chats DROP COLUMN IF EXISTS participant_cleared_at;
@@ -0,0 +1,5 @@
+-- Deleting a chat hides it from one side only. Each side's history starts
+-- over from when they last deleted it; once both sides have, the chat and
+-- its messages are purged.
+ALTER TABLE chats ADD COLUMN participant_cleared_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE chats ADD COLUMN other_cleared_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,5 @@
+DELETE FROM account_creations WHERE user_id IS NULL;
+ALTER TABLE account_creations DROP CONSTRAINT account_creations_user_id_key;
+ALTER TABLE account_creations DROP CONSTRAINT account_creations_pkey;
+ALTER TABLE account_creations DROP COLUMN id;
+ALTER TABLE account_creations ADD PRIMARY KEY (user_id);
@@ -0,0 +1,8 @@
+-- An account creation is recorded when the address is admitted, before the
+-- account exists, so concurrent handshakes cannot all slip under the limit.
+-- user_id is filled in once the account has been created.
+ALTER TABLE account_creations DROP CONSTRAINT account_creations_pkey;
+ALTER TABLE account_creations ADD COLUMN id UUID NOT NULL DEFAULT gen_random_uuid();
+ALTER TABLE account_creations ADD PRIMARY KEY (id);
+ALTER TABLE account_creations ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE account_creations ADD CONSTRAINT account_creations_user_id_key UNIQUE (user_id);
@@ -0,0 +1,15 @@
+DELETE FROM device_prekeys;
+DELETE FROM device_keys;
+
+ALTER TABLE device_prekeys DROP CONSTRAINT device_prekeys_device_id_fkey;
+ALTER TABLE device_prekeys DROP CONSTRAINT device_prekeys_pkey;
+ALTER TABLE device_prekeys DROP COLUMN chat_id;
+
+DROP INDEX IF EXISTS idx_device_keys_chat_id;
+ALTER TABLE device_keys DROP CONSTRAINT device_keys_pkey;
+ALTER TABLE device_keys DROP COLUMN chat_id;
+ALTER TABLE device_keys ADD PRIMARY KEY (device_id);
+
+ALTER TABLE device_prekeys ADD PRIMARY KEY (device_id, key_id);
+ALTER TABLE device_prekeys ADD CONSTRAINT device_prekeys_device_id_fkey
+    FOREIGN KEY (device_id) REFERENCES device_keys(device_id) ON DELETE CASCADE;
@@ -0,0 +1,18 @@
+-- Devices publish a separate set of keys for each chat, so the same
+-- identity key never shows up in chats on different tasks. Keys published
+-- for the device as a whole are dropped; clients publish again per chat.
+DELETE FROM device_prekeys;
+DELETE FROM device_keys;
+
+ALTER TABLE device_prekeys DROP CONSTRAINT device_prekeys_device_id_fkey;
+ALTER TABLE device_prekeys DROP CONSTRAINT device_prekeys_pkey;
+ALTER TABLE device_keys DROP CONSTRAINT device_keys_pkey;
+
+ALTER TABLE device_keys ADD COLUMN chat_id UUID NOT NULL REFERENCES chats(id) ON DELETE CASCADE;
+ALTER TABLE device_keys ADD PRIMARY KEY (device_id, chat_id);
+CREATE INDEX idx_device_keys_chat_id ON device_keys(chat_id);
+
+ALTER TABLE device_prekeys ADD COLUMN chat_id UUID NOT NULL;
+ALTER TABLE device_prekeys ADD PRIMARY KEY (device_id, chat_id, key_id);
+ALTER TABLE device_prekeys ADD CONSTRAINT device_prekeys_device_id_fkey
+    FOREIGN KEY (device_id, chat_id) REFERENCES device_keys(device_id, chat_id) ON DELETE CASCADE;
@@ -0,0 +1,4 @@
+UPDATE tasks SET status = 'disputed' WHERE status = 'held';
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_status_check;
+ALTER TABLE tasks ADD CONSTRAINT tasks_status_check
+    CHECK (status IN ('open', 'claimed', 'completed', 'cancelled', 'disputed'));
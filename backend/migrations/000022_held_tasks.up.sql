@@ -0,0 +1,13 @@
+-- Tasks whose approval is held for fraud review get their own status, so
+-- they are not mistaken for disputes
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_status_check;
+ALTER TABLE tasks ADD CONSTRAINT tasks_status_check
+    CHECK (status IN ('open', 'claimed', 'completed', 'cancelled', 'disputed', 'held'));
+
+UPDATE tasks SET status = 'held'
+WHERE status = 'disputed'
+  AND id IN (
+    SELECT c.task_id FROM fraud_flags f
+    JOIN claims c ON c.id = f.claim_id
+    WHERE f.status = 'open'
+  );
@@ -0,0 +1,6 @@
+UPDATE claims SET status = 'cancelled' WHERE status = 'applied';
+ALTER TABLE claims DROP CONSTRAINT IF EXISTS claims_status_check;
+ALTER TABLE claims ADD CONSTRAINT claims_status_check
+    CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled', 'disputed'));
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS application_mode;
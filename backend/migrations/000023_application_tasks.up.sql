@@ -0,0 +1,6 @@
+-- Application-mode tasks: claims start as applications the owner accepts
+ALTER TABLE tasks ADD COLUMN application_mode BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE claims DROP CONSTRAINT IF EXISTS claims_status_check;
+ALTER TABLE claims ADD CONSTRAINT claims_status_check
+    CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled', 'disputed', 'applied'));
@@ -15,14 +15,14 @@ import { Message } from '../../types';
 
 export default function ChatScreen() {
   const route = useRoute();
-  const { taskId, claimerId } = route.params as { taskId: string; claimerId?: string };
+  const { taskId, claimId } = route.params as { taskId: string; claimId?: string };
   const { selectedChat, messages, loading, getOrCreateChat, sendMessage, fetchMessages } =
     useChatStore();
   const [messageText, setMessageText] = useState('');
 
   useEffect(() => {
-    getOrCreateChat(taskId, claimerId);
-  }, [taskId, claimerId]);
+    getOrCreateChat(taskId, claimId);
+  }, [taskId, claimId]);
 
   const handleSend = async () => {
     if (!messageText.trim() || !selectedChat) return;
@@ -10,7 +10,6 @@ import {
 } from 'react-native';
 import { useRoute, useNavigation } from '@react-navigation/native';
 import { useTaskStore } from '../../store/useTaskStore';
-import AsyncStorage from '@react-native-async-storage/async-storage';
 
 export default function TaskDetailScreen() {
   const route = useRoute();
@@ -18,10 +17,8 @@ export default function TaskDetailScreen() {
   const { taskId } = route.params as { taskId: string };
   const { selectedTask, claims, loading, fetchTask, fetchClaims, claimTask, submitCompletion } =
     useTaskStore();
-  const [userId, setUserId] = React.useState<string | null>(null);
 
   useEffect(() => {
-    AsyncStorage.getItem('device_id').then(setUserId);
     fetchTask(taskId);
     fetchClaims(taskId);
   }, [taskId]);
@@ -74,8 +71,8 @@ export default function TaskDetailScreen() {
     );
   }
 
-  const isOwner = selectedTask.owner_id === userId;
-  const userClaim = claims.find((c) => c.claimer_id === userId);
+  const isOwner = selectedTask.owner.is_self;
+  const userClaim = claims.find((c) => c.claimer.is_self);
   const canClaim = !isOwner && !userClaim && selectedTask.status === 'open';
 
   return (
@@ -123,7 +120,7 @@ export default function TaskDetailScreen() {
               <TouchableOpacity
                 style={styles.button}
                 onPress={() =>
-                  navigation.navigate('Chat' as never, { taskId, claimerId: userClaim.claimer_id } as never)
+                  navigation.navigate('Chat' as never, { taskId } as never)
                 }
               >
                 <Text style={styles.buttonText}>Open Chat</Text>
@@ -137,6 +134,9 @@ export default function TaskDetailScreen() {
             <Text style={styles.sectionTitle}>Claims ({claims.length})</Text>
             {claims.map((claim) => (
               <View key={claim.id} style={styles.claimCard}>
+                <Text style={styles.claimText}>
+                  {claim.claimer.alias} ({claim.claimer.reputation_band})
+                </Text>
                 <Text style={styles.claimText}>Status: {claim.status}</Text>
                 {claim.completion_text && (
                   <Text style={styles.completionText}>{claim.completion_text}</Text>
@@ -163,7 +163,7 @@ export default function TaskDetailScreen() {
                       <TouchableOpacity
                         style={styles.button}
                         onPress={() =>
-                          navigation.navigate('Chat' as never, { taskId, claimerId: claim.claimer_id } as never)
+                          navigation.navigate('Chat' as never, { taskId, claimId: claim.id } as never)
                         }
                       >
                         <Text style={styles.buttonText}>Chat</Text>
@@ -1,12 +1,16 @@
 import axios, { AxiosInstance } from 'axios';
 import AsyncStorage from '@react-native-async-storage/async-storage';
-import { Task, Claim, Chat, Message } from '../types';
+import { Task, Claim, Dispute, Chat, InboxPage, Message, TokenPair, AccountChallenge, AttachmentFile, Envelope, KeyBundle, KeyStatus, MessageTTL, Prekey, SignedPrekey } from '../types';
+import { solveChallenge } from './pow';
 
 const DEVICE_ID_KEY = 'device_id';
+const REFRESH_TOKEN_KEY = 'refresh_token';
 
 class ApiService {
   private client: AxiosInstance;
   private baseURL: string;
+  private accessToken: string | null = null;
+  private pendingAuth: Promise<string> | null = null;
 
   constructor() {
     this.baseURL = process.env.EXPO_PUBLIC_API_URL || 'http://localhost:8080';
@@ -18,14 +22,95 @@ class ApiService {
       },
     });
 
-    // Request interceptor to add device ID
+    // Request interceptor to add the access token
     this.client.interceptors.request.use(async (config) => {
-      const deviceId = await this.getDeviceId();
-      if (deviceId) {
-        config.headers['X-Device-ID'] = deviceId;
-      }
+      const token = await this.getAccessToken();
+      config.headers['Authorization'] = `Bearer ${token}`;
       return config;
     });
+
+    // Retry once with fresh tokens when the access token has expired
+    this.client.interceptors.response.use(undefined, async (error) => {
+      const config = error.config;
+      if (error.response?.status === 401 && config && !config._retried) {
+        config._retried = true;
+        this.accessToken = null;
+        return this.client.request(config);
+      }
+      return Promise.reject(error);
+    });
+  }
+
+  async getAccessToken(): Promise<string> {
+    if (this.accessToken) {
+      return this.accessToken;
+    }
+    if (!this.pendingAuth) {
+      this.pendingAuth = this.authenticate().finally(() => {
+        this.pendingAuth = null;
+      });
+    }
+    return this.pendingAuth;
+  }
+
+  private async authenticate(): Promise<string> {
+    const refreshToken = await AsyncStorage.getItem(REFRESH_TOKEN_KEY);
+    let tokens: TokenPair | null = null;
+
+    if (refreshToken) {
+      try {
+        const response = await axios.post<TokenPair>(`${this.baseURL}/api/v1/auth/refresh`, {
+          refresh_token: refreshToken,
+        });
+        tokens = response.data;
+      } catch {
+        tokens = null;
+      }
+    }
+
+    if (!tokens) {
+      tokens = await this.handshake();
+    }
+
+    await AsyncStorage.setItem(REFRESH_TOKEN_KEY, tokens.refresh_token);
+    this.accessToken = tokens.access_token;
+    return tokens.access_token;
+  }
+
+  private async handshake(): Promise<TokenPair> {
+    const deviceId = await this.getDeviceId();
+    try {
+      return await this.postHandshake(deviceId);
+    } catch (error: any) {
+      // This device was revoked remotely; start over as a new device. A
+      // suspended account is also refused with 403 but must not be escaped
+      if (error.response?.status === 403 && error.response?.data?.error === 'device has been revoked') {
+        await AsyncStorage.removeItem(DEVICE_ID_KEY);
+        return this.postHandshake(await this.getDeviceId());
+      }
+      throw error;
+    }
+  }
+
+  // postHandshake signs the device in. A new device is first asked to solve
+  // a proof-of-work challenge, and then retries with the solution.
+  private async postHandshake(deviceId: string | null): Promise<TokenPair> {
+    const url = `${this.baseURL}/api/v1/auth/handshake`;
+    try {
+      const response = await axios.post<TokenPair>(url, { device_id: deviceId });
+      return response.data;
+    } catch (error: any) {
+      const challenge: AccountChallenge | undefined = error.response?.data?.challenge;
+      if (error.response?.status !== 428 || !challenge) {
+        throw error;
+      }
+      const response = await axios.post<TokenPair>(url, {
+        device_id: deviceId,
+        pow_challenge: challenge.challenge,
+        pow_nonce: solveChallenge(challenge),
+      });
+      return response.data;
+    }
   }
 
   private async getDeviceId(): Promise<string | null> {
@@ -42,6 +127,12 @@ class ApiService {
     return `device_${Date.now()}_${Math.random().toString(36).substr(2, 9)}`;
   }
 
+  async logout(): Promise<void> {
+    await this.client.post('/api/v1/auth/logout');
+    this.accessToken = null;
+    await AsyncStorage.removeItem(REFRESH_TOKEN_KEY);
+  }
+
   // Task endpoints
   async createTask(data: {
     title: string;
@@ -50,6 +141,7 @@ class ApiService {
     max_claimants: number;
     claim_deadline: string;
     owner_deadline: string;
+    application_mode?: boolean;
   }): Promise<Task> {
     const response = await this.client.post<Task>('/api/v1/tasks', data);
     return response.data;
@@ -98,6 +190,11 @@ class ApiService {
     return response.data;
   }
 
+  async acceptApplication(claimId: string): Promise<Claim> {
+    const response = await this.client.post<Claim>(`/api/v1/claims/${claimId}/accept`);
+    return response.data;
+  }
+
   async approveClaim(claimId: string): Promise<void> {
     await this.client.post(`/api/v1/claims/${claimId}/approve`);
   }
@@ -106,14 +203,33 @@ class ApiService {
     await this.client.post(`/api/v1/claims/${claimId}/reject`);
   }
 
+  // Arbitration endpoints
+  async getDisputes(limit = 20, offset = 0): Promise<Dispute[]> {
+    const response = await this.client.get<{ disputes: Dispute[] }>('/api/v1/disputes', {
+      params: { limit, offset },
+    });
+    return response.data.disputes;
+  }
+
+  async resolveDispute(claimId: string, decision: 'approve' | 'reject', reason: string): Promise<void> {
+    await this.client.post(`/api/v1/disputes/${claimId}/resolve`, { decision, reason });
+  }
+
   // Chat endpoints
+  async getInbox(cursor?: string, limit = 20): Promise<InboxPage> {
+    const response = await this.client.get<InboxPage>('/api/v1/chats', {
+      params: cursor ? { cursor, limit } : { limit },
+    });
+    return response.data;
+  }
+
   async getChats(taskId: string): Promise<Chat[]> {
     const response = await this.client.get<{ chats: Chat[] }>(`/api/v1/tasks/${taskId}/chats`);
     return response.data.chats;
   }
 
-  async getOrCreateChat(taskId: string, claimerId?: string): Promise<Chat> {
-    const params = claimerId ? { claimer_id: claimerId } : {};
+  async getOrCreateChat(taskId: string, claimId?: string): Promise<Chat> {
+    const params = claimId ? { claim_id: claimId } : {};
     const response = await this.client.post<Chat>(`/api/v1/tasks/${taskId}/chats`, {}, { params });
     return response.data;
   }
@@ -122,13 +238,59 @@ class ApiService {
     await this.client.delete(`/api/v1/chats/${chatId}`);
   }
 
-  async sendMessage(chatId: string, content: string): Promise<Message> {
+  async setChatTimer(chatId: string, messageTtl: MessageTTL): Promise<Chat> {
+    const response = await this.client.put<Chat>(`/api/v1/chats/${chatId}/timer`, {
+      message_ttl: messageTtl,
+    });
+    return response.data;
+  }
+
+  async sendMessage(chatId: string, content: string, attachments: AttachmentFile[] = []): Promise<Message> {
+    if (attachments.length === 0) {
+      const response = await this.client.post<Message>(`/api/v1/chats/${chatId}/messages`, {
+        content,
+      });
+      return response.data;
+    }
+
+    const form = new FormData();
+    form.append('content', content);
+    for (const file of attachments) {
+      form.append('attachments', file as unknown as Blob);
+    }
+    const response = await this.client.post<Message>(`/api/v1/chats/${chatId}/messages`, form, {
+      headers: { 'Content-Type': 'multipart/form-data' },
+      timeout: 60000,
+    });
+    return response.data;
+  }
+
+  async sendEncryptedMessage(chatId: string, ciphertext: string, envelopes: Envelope[]): Promise<Message> {
     const response = await this.client.post<Message>(`/api/v1/chats/${chatId}/messages`, {
-      content,
+      ciphertext,
+      envelopes,
     });
     return response.data;
   }
 
+  // Bundles of the devices a message to the chat must be sealed for. Each
+  // call uses up one-time prekeys and is rate limited, so keep the sessions
+  // it starts.
+  async takeChatKeys(chatId: string): Promise<KeyBundle[]> {
+    const response = await this.client.post<{ devices: KeyBundle[] }>(`/api/v1/chats/${chatId}/keys`);
+    return response.data.devices;
+  }
+
+  // Source for an attachment, for an Image or a download; it needs the
+  // access token like every other request.
+  async getAttachmentSource(chatId: string, attachmentId: string): Promise<{ uri: string; headers: Record<string, string> }> {
+    const token = await this.getAccessToken();
+    return {
+      uri: `${this.baseURL}/api/v1/chats/${chatId}/attachments/${attachmentId}`,
+      headers: { Authorization: `Bearer ${token}` },
+    };
+  }
+
   async getMessages(chatId: string, limit = 50, offset = 0): Promise<Message[]> {
     const response = await this.client.get<{ messages: Message[] }>(
       `/api/v1/chats/${chatId}/messages`,
@@ -138,6 +300,42 @@ class ApiService {
     );
     return response.data.messages;
   }
+
+  async editMessage(chatId: string, messageId: string, content: string): Promise<Message> {
+    const response = await this.client.patch<Message>(`/api/v1/chats/${chatId}/messages/${messageId}`, {
+      content,
+    });
+    return response.data;
+  }
+
+  async unsendMessage(chatId: string, messageId: string): Promise<Message> {
+    const response = await this.client.delete<Message>(`/api/v1/chats/${chatId}/messages/${messageId}`);
+    return response.data;
+  }
+
+  async markChatRead(chatId: string, messageId?: string): Promise<void> {
+    await this.client.post(`/api/v1/chats/${chatId}/read`, messageId ? { message_id: messageId } : undefined);
+  }
+
+  async getUnreadCount(): Promise<number> {
+    const response = await this.client.get<{ unread: number }>('/api/v1/me/unread');
+    return response.data.unread;
+  }
+
+  // Key endpoints
+  async getKeyStatus(chatId: string): Promise<KeyStatus> {
+    const response = await this.client.get<KeyStatus>(`/api/v1/chats/${chatId}/keys/mine`);
+    return response.data;
+  }
+
+  async publishKeys(chatId: string, identityKey: string, signedPrekey: SignedPrekey, oneTimePrekeys: Prekey[] = []): Promise<KeyStatus> {
+    const response = await this.client.put<KeyStatus>(`/api/v1/chats/${chatId}/keys/mine`, {
+      identity_key: identityKey,
+      signed_prekey: signedPrekey,
+      one_time_prekeys: oneTimePrekeys,
+    });
+    return response.data;
+  }
 }
 
 export const apiService = new ApiService();
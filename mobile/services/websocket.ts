@@ -1,33 +1,62 @@
-import { Message } from '../types';
+import { Envelope, Message } from '../types';
 
-export type WSMessageType = 'task_update' | 'chat_message' | 'claim_update' | 'escrow_update';
+export const PROTOCOL_VERSION = 1;
 
+export type WSMessageType =
+  | 'task_update'
+  | 'chat_message'
+  | 'claim_update'
+  | 'escrow_update'
+  | 'typing'
+  | 'message_edited'
+  | 'message_deleted'
+  | 'message_read'
+  | 'chat_timer'
+  | 'ack'
+  | 'error';
+
+export type WSCommandType = 'ping' | 'send_message' | 'typing' | 'mark_read' | 'subscribe' | 'unsubscribe';
+
+export interface WSCommandError {
+  code: string;
+  message: string;
+  retry_after?: number;
+}
+
+// Every event arrives in this envelope; see domain.Event on the server
 export interface WSMessage {
+  v: number;
   type: WSMessageType;
-  payload: any;
+  id?: string;
+  payload?: any;
+  error?: WSCommandError;
+  sent_at: string;
 }
 
+const COMMAND_TIMEOUT_MS = 10000;
+
 export class WebSocketService {
   private ws: WebSocket | null = null;
   private url: string;
-  private deviceId: string;
+  private getToken: () => Promise<string>;
   private reconnectAttempts = 0;
   private maxReconnectAttempts = 5;
   private listeners: Map<WSMessageType, ((data: any) => void)[]> = new Map();
+  private nextRequestId = 1;
+  private pending: Map<string, { resolve: (payload: any) => void; reject: (error: WSCommandError) => void }> =
+    new Map();
 
-  constructor(baseURL: string, deviceId: string) {
+  constructor(baseURL: string, getToken: () => Promise<string>) {
     this.url = baseURL.replace('http://', 'ws://').replace('https://', 'wss://') + '/ws';
-    this.deviceId = deviceId;
+    this.getToken = getToken;
   }
 
-  connect(): Promise<void> {
+  async connect(): Promise<void> {
+    // Browsers cannot set headers on upgrade, so the token rides as a subprotocol
+    const token = await this.getToken();
     return new Promise((resolve, reject) => {
       try {
-        this.ws = new WebSocket(this.url, [], {
-          headers: {
-            'X-Device-ID': this.deviceId,
-          },
-        } as any);
+        this.ws = new WebSocket(this.url, ['bearer', token]);
 
         this.ws.onopen = () => {
           console.log('WebSocket connected');
@@ -36,11 +65,15 @@ export class WebSocketService {
         };
 
         this.ws.onmessage = (event) => {
-          try {
-            const message: WSMessage = JSON.parse(event.data);
-            this.handleMessage(message);
-          } catch (error) {
-            console.error('Error parsing WebSocket message:', error);
+          // Events queued on the server arrive together, one per line
+          for (const line of String(event.data).split('\n')) {
+            if (!line) continue;
+            try {
+              const message: WSMessage = JSON.parse(line);
+              this.handleMessage(message);
+            } catch (error) {
+              console.error('Error parsing WebSocket message:', error);
+            }
           }
         };
 
@@ -70,12 +103,86 @@ export class WebSocketService {
   }
 
   private handleMessage(message: WSMessage) {
+    // Answers to commands go to whoever sent them
+    if ((message.type === 'ack' || message.type === 'error') && message.id) {
+      const request = this.pending.get(message.id);
+      if (request) {
+        this.pending.delete(message.id);
+        if (message.type === 'ack') {
+          request.resolve(message.payload);
+        } else {
+          request.reject(message.error!);
+        }
+        return;
+      }
+    }
+
     const listeners = this.listeners.get(message.type);
     if (listeners) {
       listeners.forEach((listener) => listener(message.payload));
     }
   }
 
+  // request sends a command and resolves with its ack payload, or rejects
+  // with the error the server answered with
+  request<T = any>(type: WSCommandType, payload?: object): Promise<T> {
+    return new Promise((resolve, reject) => {
+      if (!this.ws || this.ws.readyState !== WebSocket.OPEN) {
+        reject({ code: 'disconnected', message: 'WebSocket is not connected' });
+        return;
+      }
+
+      const id = String(this.nextRequestId++);
+      const timer = setTimeout(() => {
+        this.pending.delete(id);
+        reject({ code: 'timeout', message: 'no answer from server' });
+      }, COMMAND_TIMEOUT_MS);
+
+      this.pending.set(id, {
+        resolve: (result) => {
+          clearTimeout(timer);
+          resolve(result);
+        },
+        reject: (error) => {
+          clearTimeout(timer);
+          reject(error);
+        },
+      });
+      this.ws.send(JSON.stringify({ v: PROTOCOL_VERSION, id, type, payload }));
+    });
+  }
+
+  ping(): Promise<void> {
+    return this.request('ping');
+  }
+
+  sendMessage(chatId: string, content: string): Promise<Message> {
+    return this.request<Message>('send_message', { chat_id: chatId, content });
+  }
+
+  sendEncryptedMessage(chatId: string, ciphertext: string, envelopes: Envelope[]): Promise<Message> {
+    return this.request<Message>('send_message', { chat_id: chatId, ciphertext, envelopes });
+  }
+
+  sendTyping(chatId: string): Promise<void> {
+    return this.request('typing', { chat_id: chatId });
+  }
+
+  // markRead without a messageId reads the whole chat
+  markRead(chatId: string, messageId?: string): Promise<void> {
+    return this.request('mark_read', { chat_id: chatId, message_id: messageId });
+  }
+
+  // subscribe receives typing events for the chat on this
+  // connection, e.g. while the chat screen is open
+  subscribe(chatId: string): Promise<void> {
+    return this.request('subscribe', { chat_id: chatId });
+  }
+
+  unsubscribe(chatId: string): Promise<void> {
+    return this.request('unsubscribe', { chat_id: chatId });
+  }
+
   on(type: WSMessageType, callback: (data: any) => void) {
     if (!this.listeners.has(type)) {
       this.listeners.set(type, []);
@@ -94,6 +201,8 @@ export class WebSocketService {
   }
 
   disconnect() {
+    this.pending.forEach((request) => request.reject({ code: 'disconnected', message: 'WebSocket closed' }));
+    this.pending.clear();
     if (this.ws) {
       this.ws.close();
       this.ws = null;
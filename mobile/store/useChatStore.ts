@@ -10,7 +10,7 @@ interface ChatState {
   error: string | null;
 
   fetchChats: (taskId: string) => Promise<void>;
-  getOrCreateChat: (taskId: string, claimerId?: string) => Promise<void>;
+  getOrCreateChat: (taskId: string, claimId?: string) => Promise<void>;
   deleteChat: (chatId: string) => Promise<void>;
   sendMessage: (chatId: string, content: string) => Promise<void>;
   fetchMessages: (chatId: string) => Promise<void>;
@@ -36,10 +36,10 @@ export const useChatStore = create<ChatState>((set, get) => ({
     }
   },
 
-  getOrCreateChat: async (taskId: string, claimerId?: string) => {
+  getOrCreateChat: async (taskId: string, claimId?: string) => {
     set({ loading: true, error: null });
     try {
-      const chat = await apiService.getOrCreateChat(taskId, claimerId);
+      const chat = await apiService.getOrCreateChat(taskId, claimId);
       set({ selectedChat: chat, loading: false });
       await get().fetchMessages(chat.id);
     } catch (error: any) {
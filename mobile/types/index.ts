@@ -1,23 +1,32 @@
 export interface User {
   id: string;
-  device_id: string;
   created_at: string;
   reputation: number;
+  worker_score: number;
+  poster_score: number;
   total_earned: number;
   total_spent: number;
 }
 
+export interface Participant {
+  alias: string;
+  is_self: boolean;
+  reputation_band: 'low' | 'new' | 'rising' | 'established' | 'trusted' | 'veteran';
+}
+
 export interface Task {
   id: string;
-  owner_id: string;
+  owner: Participant;
   title: string;
   description: string;
   reward_amount: number;
   max_claimants: number;
   claim_deadline: string;
   owner_deadline: string;
-  status: 'open' | 'claimed' | 'completed' | 'cancelled' | 'disputed';
+  status: 'open' | 'claimed' | 'completed' | 'cancelled' | 'disputed' | 'held';
   escrow_locked: boolean;
+  application_mode: boolean;
+  hidden_at?: string;
   created_at: string;
   updated_at: string;
 }
@@ -25,11 +34,15 @@ export interface Task {
 export interface Claim {
   id: string;
   task_id: string;
-  claimer_id: string;
-  status: 'pending' | 'approved' | 'rejected' | 'cancelled';
+  claimer: Participant;
+  status: 'applied' | 'pending' | 'approved' | 'rejected' | 'cancelled' | 'disputed';
   submitted_at?: string;
   completion_text?: string;
   completion_image_url?: string;
+  dispute_reason?: string;
+  disputed_at?: string;
+  decided_at?: string;
+  hidden_at?: string;
   created_at: string;
   updated_at: string;
 }
@@ -37,23 +50,191 @@ export interface Claim {
 export interface Chat {
   id: string;
   task_id: string;
-  participant_id: string;
-  other_participant_id: string;
-  deleted_by_participant: boolean;
-  deleted_by_other: boolean;
+  counterpart: Participant;
+  unread_count: number;
+  counterpart_last_read_id?: string;
+  // Disappearing-messages timer in seconds; 0 is off
+  message_ttl: number;
+  task_title?: string;
+  last_message?: Message;
   created_at: string;
   updated_at: string;
 }
 
+export type MessageTTL = 0 | 3600 | 86400 | 604800;
+
+export interface InboxPage {
+  chats: Chat[];
+  next_cursor: string;
+}
+
 export interface Message {
   id: string;
   chat_id: string;
-  sender_id: string;
+  sender: Participant;
   content: string;
   created_at: string;
+  edited_at?: string;
+  // Unsent or hidden messages are tombstones with empty content
+  deleted_at?: string;
+  hidden_at?: string;
+  // Set when the message was sent under a disappearing-messages timer
+  expires_at?: string;
+  attachments?: Attachment[];
+  // Encrypted messages have empty content; the ciphertext is opened with the
+  // key in this device's envelope
+  encrypted?: boolean;
+  ciphertext?: string;
+  envelopes?: Envelope[];
+}
+
+// Binary fields are base64
+export interface Envelope {
+  device_id: string;
+  payload: string;
+}
+
+export interface Prekey {
+  key_id: number;
+  public_key: string;
+}
+
+export interface SignedPrekey extends Prekey {
+  signature: string;
+}
+
+export interface KeyBundle {
+  device_id: string;
+  identity_key: string;
+  signed_prekey: SignedPrekey;
+  one_time_prekey?: Prekey;
+  mine: boolean;
+}
+
+export interface KeyStatus {
+  device_id: string;
+  published: boolean;
+  one_time_prekeys: number;
+}
+
+export type AttachmentKind = 'image' | 'document';
+
+export interface Attachment {
+  id: string;
+  message_id: string;
+  kind: AttachmentKind;
+  mime_type: string;
+  size: number;
+  width?: number;
+  height?: number;
+  filename?: string;
+  created_at: string;
+}
+
+// A local file to upload with a message
+export interface AttachmentFile {
+  uri: string;
+  name: string;
+  type: string;
+}
+
+export interface TokenPair {
+  access_token: string;
+  token_type: string;
+  expires_in: number;
+  refresh_token: string;
+  refresh_expires_at: string;
 }
 
 export interface ApiResponse<T> {
   data?: T;
   error?: string;
 }
+
+export interface Review {
+  id: string;
+  claim_id: string;
+  task_id: string;
+  reviewer_role: 'worker' | 'poster';
+  rating: number;
+  tags: string[];
+  comment?: string;
+  created_at: string;
+}
+
+export interface ReviewThread {
+  claim_id: string;
+  window_closes_at: string;
+  revealed: boolean;
+  counterpart_submitted: boolean;
+  mine: Review | null;
+  theirs: Review | null;
+}
+
+export type ReportTargetType = 'task' | 'claim' | 'message' | 'user';
+
+export type ReportCategory = 'scam' | 'illegal' | 'harassment' | 'spam' | 'inappropriate' | 'other';
+
+export interface Report {
+  id: string;
+  target_type: ReportTargetType;
+  category: ReportCategory;
+  note?: string;
+  disclosed_content?: string;
+  status: 'open' | 'actioned' | 'dismissed';
+  created_at: string;
+  resolved_at?: string;
+}
+
+export interface ExportedMessage {
+  id: string;
+  content: string;
+  mine: boolean;
+  created_at: string;
+}
+
+export interface DataExport {
+  exported_at: string;
+  user: User;
+  devices: { id: string; name: string; created_at: string; last_seen_at: string; revoked_at?: string }[];
+  tasks: Omit<Task, 'owner'>[];
+  claims: Omit<Claim, 'claimer'>[];
+  chats: (Omit<Chat, 'counterpart'> & { messages: ExportedMessage[] })[];
+  escrow_transactions: {
+    id: string;
+    task_id: string;
+    amount: number;
+    transaction_type: string;
+    status: string;
+    created_at: string;
+    completed_at?: string;
+  }[];
+  reviews: Review[];
+}
+
+export interface AccountChallenge {
+  challenge: string;
+  difficulty: number;
+  expires_at: string;
+}
+
+export interface Dispute {
+  task: Task;
+  claim: Claim;
+}
+
+export type TrustTier = 0 | 1 | 2 | 3;
+
+export interface TrustPrivileges {
+  max_task_reward: number;
+  max_active_claims: number;
+  application_tasks: boolean;
+  arbitration: boolean;
+}
+
+export interface TrustRequirement {
+  min_account_days: number;
+  min_reputation: number;
+  min_completed: number;
+  min_approval_rate: number;
+}